@@ -0,0 +1,327 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package async provides a function.EmitErr wrapper decoupling logging calls
+// from slow sinks. Log lines are queued in a bounded queue and emitted by a
+// pool of worker goroutines, so hot paths never wait on network or disk I/O
+// unless explicitly configured to.
+//
+// With a single worker, the default, log lines are emitted in the order they
+// were queued: the log lines of each goroutine are emitted in the order of
+// its logging calls, while those of different goroutines may interleave.
+// Sequence numbers stamped using function.WithSequence are therefore
+// increasing per goroutine, but not necessarily across goroutines. With more
+// than one worker, no order is guaranteed and the sequence numbers are the
+// only way to reconstruct it.
+package async
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// SummaryMessage is the message of the log line reporting the lifetime totals
+// on Close.
+const SummaryMessage = "async emitter closed"
+
+// Default configuration of an Emitter.
+const (
+	DefaultQueueSize = 1024
+	DefaultWorkers   = 1
+)
+
+// Policy determines what happens to a log line emitted while the queue is
+// full.
+type Policy int
+
+// Supported queue full policies.
+const (
+	// DropNewest drops the log line being emitted.
+	DropNewest Policy = iota
+	// DropOldest drops the oldest queued log line to make room.
+	DropOldest
+	// Block waits for room in the queue, applying backpressure to the caller.
+	Block
+)
+
+var (
+	// ErrQueueFull is returned by Emit if the log line is dropped as the
+	// queue is full.
+	ErrQueueFull = errors.New("async queue full, log line dropped")
+	// ErrClosed is returned by Emit if the log line is dropped as the Emitter
+	// is closed.
+	ErrClosed = errors.New("async emitter closed, log line dropped")
+)
+
+type (
+	// Option implements a functional option type for the Emitter.
+	Option func(*options)
+
+	// options holds the configuration of an Emitter.
+	options struct {
+		queueSize     int
+		workers       int
+		policy        Policy
+		errorHandler  func(error)
+		droppedMetric telemetry.Metric
+		summary       bool
+	}
+)
+
+// WithQueueSize sets the number of log lines that can be queued.
+func WithQueueSize(size int) Option {
+	return func(o *options) {
+		o.queueSize = size
+	}
+}
+
+// WithWorkers sets the number of goroutines emitting queued log lines. With
+// more than one worker, log lines may be emitted out of order, even those
+// of a single goroutine.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		o.workers = n
+	}
+}
+
+// WithPolicy sets the policy applied when the queue is full. The default is
+// DropNewest.
+func WithPolicy(p Policy) Option {
+	return func(o *options) {
+		o.policy = p
+	}
+}
+
+// WithErrorHandler sets the function receiving the errors returned by the
+// wrapped EmitErr. By default, these errors are written to os.Stderr.
+func WithErrorHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = fn
+	}
+}
+
+// WithDroppedMetric sets the Metric on which the number of dropped log lines
+// is recorded.
+func WithDroppedMetric(m telemetry.Metric) Option {
+	return func(o *options) {
+		o.droppedMetric = m
+	}
+}
+
+// WithSummary sets whether Close emits a log line with the lifetime totals of
+// emitted, failed and dropped log lines. It is enabled by default.
+func WithSummary(enabled bool) Option {
+	return func(o *options) {
+		o.summary = enabled
+	}
+}
+
+// entry holds a queued log line.
+type entry struct {
+	level  telemetry.Level
+	msg    string
+	err    error
+	values function.Values
+}
+
+// Emitter queues log lines and emits them through the wrapped EmitErr from
+// background workers.
+type Emitter struct {
+	// emitted, failed and dropped hold the lifetime totals of log lines,
+	// kept first for 64-bit alignment of atomic operations.
+	emitted uint64
+	failed  uint64
+	dropped uint64
+
+	emit function.EmitErr
+	opts options
+
+	mtx    sync.RWMutex
+	closed bool
+	queue  chan entry
+
+	// pending counts queued and in-flight log lines, for Flush.
+	pendingMtx sync.Mutex
+	pending    int
+	idle       *sync.Cond
+
+	wg sync.WaitGroup
+}
+
+// New returns an Emitter emitting log lines through the provided EmitErr,
+// use function.AsEmitErr to wrap a function.Emit. Use the Emit method of the
+// Emitter with function.NewLoggerErr, its Flush method with
+// function.WithFlush and Close it on shutdown.
+// Unless disabled using WithSummary, Close emits a log line at Info level
+// with the lifetime totals through the wrapped EmitErr.
+//
+// As log lines are emitted from another goroutine, the wrapped EmitErr must
+// take the call site from Values.Caller instead of walking the stack, as the
+// emitters of this repository do.
+func New(emit function.EmitErr, opts ...Option) *Emitter {
+	o := options{
+		queueSize: DefaultQueueSize,
+		workers:   DefaultWorkers,
+		summary:   true,
+		errorHandler: func(err error) {
+			_, _ = fmt.Fprintf(os.Stderr, "telemetry: %v\n", err)
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.queueSize < 1 {
+		o.queueSize = 1
+	}
+	if o.workers < 1 {
+		o.workers = 1
+	}
+
+	e := &Emitter{
+		emit:  emit,
+		opts:  o,
+		queue: make(chan entry, o.queueSize),
+	}
+	e.idle = sync.NewCond(&e.pendingMtx)
+	e.wg.Add(o.workers)
+	for i := 0; i < o.workers; i++ {
+		go e.work()
+	}
+	return e
+}
+
+// Emit queues the log line. It implements function.EmitErr and returns
+// ErrQueueFull or ErrClosed if the log line is dropped.
+func (e *Emitter) Emit(level telemetry.Level, msg string, err error, values function.Values, _ int) error {
+	// the key-value pairs passed to the logging method may be reused by the
+	// caller once it returns.
+	values.FromMethod = append([]interface{}(nil), values.FromMethod...)
+	en := entry{level: level, msg: msg, err: err, values: values}
+
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	if e.closed {
+		e.drop()
+		return ErrClosed
+	}
+
+	e.add(1)
+	switch e.opts.policy {
+	case Block:
+		e.queue <- en
+		return nil
+	case DropOldest:
+		for {
+			select {
+			case e.queue <- en:
+				return nil
+			default:
+			}
+			select {
+			case <-e.queue:
+				e.done()
+				e.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case e.queue <- en:
+			return nil
+		default:
+			e.done()
+			e.drop()
+			return ErrQueueFull
+		}
+	}
+}
+
+// Flush blocks until the queue is empty and no log line is being emitted. It
+// matches the signature expected by function.WithFlush.
+func (e *Emitter) Flush() {
+	e.pendingMtx.Lock()
+	defer e.pendingMtx.Unlock()
+	for e.pending > 0 {
+		e.idle.Wait()
+	}
+}
+
+// Close stops accepting log lines, emits the queued log lines and stops the
+// workers. It then emits the summary log line, unless disabled using
+// WithSummary. It blocks until done. Subsequent calls do not emit the summary
+// again.
+func (e *Emitter) Close() error {
+	e.mtx.Lock()
+	closing := !e.closed
+	if closing {
+		e.closed = true
+		close(e.queue)
+	}
+	e.mtx.Unlock()
+
+	e.wg.Wait()
+	if !closing || !e.opts.summary {
+		return nil
+	}
+	return e.emit(telemetry.LevelInfo, SummaryMessage, nil, function.Values{
+		FromMethod: []interface{}{
+			"emitted", atomic.LoadUint64(&e.emitted),
+			"failed", atomic.LoadUint64(&e.failed),
+			"dropped", atomic.LoadUint64(&e.dropped),
+		},
+	}, 0)
+}
+
+// work emits queued log lines until the queue is closed.
+func (e *Emitter) work() {
+	defer e.wg.Done()
+	for en := range e.queue {
+		if err := e.emit(en.level, en.msg, en.err, en.values, 0); err != nil {
+			atomic.AddUint64(&e.failed, 1)
+			e.opts.errorHandler(err)
+		} else {
+			atomic.AddUint64(&e.emitted, 1)
+		}
+		e.done()
+	}
+}
+
+func (e *Emitter) add(n int) {
+	e.pendingMtx.Lock()
+	e.pending += n
+	e.pendingMtx.Unlock()
+}
+
+func (e *Emitter) done() {
+	e.pendingMtx.Lock()
+	e.pending--
+	if e.pending == 0 {
+		e.idle.Broadcast()
+	}
+	e.pendingMtx.Unlock()
+}
+
+func (e *Emitter) drop() {
+	atomic.AddUint64(&e.dropped, 1)
+	if e.opts.droppedMetric != nil {
+		e.opts.droppedMetric.Record(1)
+	}
+}
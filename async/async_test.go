@@ -0,0 +1,232 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type mockMetric struct {
+	telemetry.Metric
+	mtx   sync.Mutex
+	count float64
+}
+
+func (m *mockMetric) Record(value float64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.count += value
+}
+
+// recorder captures emitted messages, optionally blocking on the first one
+// until released.
+type recorder struct {
+	mtx     sync.Mutex
+	msgs    []string
+	values  [][]interface{}
+	started chan struct{}
+	release chan struct{}
+}
+
+func newRecorder(blocking bool) *recorder {
+	r := &recorder{}
+	if blocking {
+		r.started = make(chan struct{})
+		r.release = make(chan struct{})
+	}
+	return r
+}
+
+func (r *recorder) emit(_ telemetry.Level, msg string, _ error, values function.Values, _ int) error {
+	r.mtx.Lock()
+	first := len(r.msgs) == 0
+	r.msgs = append(r.msgs, msg)
+	r.values = append(r.values, values.FromMethod)
+	r.mtx.Unlock()
+	if first && r.release != nil {
+		close(r.started)
+		<-r.release
+	}
+	if len(values.FromMethod) > 1 && values.FromMethod[1] == "fail" {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func (r *recorder) result() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]string(nil), r.msgs...)
+}
+
+func TestEmitter(t *testing.T) {
+	var (
+		r    = newRecorder(false)
+		errs []error
+		e    = New(r.emit, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	)
+	logger := function.NewLoggerErr(e.Emit, 0)
+
+	args := []interface{}{"key", "fail"}
+	logger.Info("1", args...)
+	args[1] = "modified"
+	logger.Info("2")
+	e.Flush()
+
+	if want := []string{"1", "2"}; !reflect.DeepEqual(want, r.result()) {
+		t.Fatalf("want: %v\nhave: %v", want, r.result())
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, have %v", errs)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := e.Emit(telemetry.LevelInfo, "3", nil, function.Values{}, 0); err != ErrClosed {
+		t.Fatalf("expected %v to match %v", err, ErrClosed)
+	}
+	_ = e.Close()
+
+	// the summary is emitted once, by the first Close.
+	if want := []string{"1", "2", SummaryMessage}; !reflect.DeepEqual(want, r.result()) {
+		t.Fatalf("want: %v\nhave: %v", want, r.result())
+	}
+	want := []interface{}{"emitted", uint64(1), "failed", uint64(1), "dropped", uint64(0)}
+	if have := r.values[2]; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestEmitterPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		expected []string
+		dropped  float64
+	}{
+		{"drop-newest", DropNewest, []string{"1", "2", "3", SummaryMessage}, 1},
+		{"drop-oldest", DropOldest, []string{"1", "3", "4", SummaryMessage}, 1},
+		{"block", Block, []string{"1", "2", "3", "4", SummaryMessage}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				m = &mockMetric{}
+				r = newRecorder(true)
+				e = New(r.emit, WithQueueSize(2), WithPolicy(tt.policy), WithDroppedMetric(m))
+			)
+
+			// the first log line blocks the worker, the next two fill the
+			// queue.
+			_ = e.Emit(telemetry.LevelInfo, "1", nil, function.Values{}, 0)
+			<-r.started
+			_ = e.Emit(telemetry.LevelInfo, "2", nil, function.Values{}, 0)
+			_ = e.Emit(telemetry.LevelInfo, "3", nil, function.Values{}, 0)
+
+			done := make(chan error)
+			go func() { done <- e.Emit(telemetry.LevelInfo, "4", nil, function.Values{}, 0) }()
+			if tt.policy == Block {
+				close(r.release)
+			}
+			err := <-done
+			if tt.policy != Block {
+				close(r.release)
+			}
+			_ = e.Close()
+
+			if (err == ErrQueueFull) != (tt.policy == DropNewest) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.expected, r.result()) {
+				t.Errorf("want: %v\nhave: %v", tt.expected, r.result())
+			}
+			if m.count != tt.dropped {
+				t.Errorf("expected %v dropped, have %v", tt.dropped, m.count)
+			}
+		})
+	}
+}
+
+func TestEmitterWorkers(t *testing.T) {
+	var (
+		r = newRecorder(false)
+		e = New(r.emit, WithWorkers(4), WithQueueSize(100), WithPolicy(Block), WithSummary(false))
+		l = function.NewLoggerErr(e.Emit, 0)
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Info("msg")
+			}
+		}()
+	}
+	wg.Wait()
+	_ = e.Close()
+
+	if have := len(r.result()); have != 800 {
+		t.Fatalf("expected 800 log lines, have %d", have)
+	}
+}
+
+func TestEmitterOrder(t *testing.T) {
+	var (
+		r = newRecorder(false)
+		e = New(r.emit, WithQueueSize(10), WithPolicy(Block), WithSummary(false))
+		l = function.NewLoggerErr(e.Emit, 0, function.WithSequence("seq"))
+	)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Info("msg", "g", g, "i", i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	_ = e.Close()
+
+	// with a single worker, the log lines of each goroutine are emitted in
+	// order, with increasing sequence numbers.
+	var (
+		next    = make(map[int]int)
+		lastSeq = make(map[int]uint64)
+	)
+	for _, kvs := range r.values {
+		seq, g, i := kvs[1].(uint64), kvs[3].(int), kvs[5].(int)
+		if i != next[g] || seq <= lastSeq[g] {
+			t.Fatalf("goroutine %d: log line %d (seq %d) emitted after log line %d (seq %d)",
+				g, i, seq, next[g]-1, lastSeq[g])
+		}
+		next[g], lastSeq[g] = i+1, seq
+	}
+	if have := len(r.values); have != 800 {
+		t.Fatalf("expected 800 log lines, have %d", have)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit provides a tamper-evident, append-only log for
+// security-sensitive event trails, like authentication and authorization
+// decisions or configuration changes.
+//
+// Each Entry holds a monotonic sequence number and the hash of the previous
+// Entry, chaining all entries together. Modifying, removing, inserting or
+// reordering entries breaks the chain, which is detected by Verify. Keying the
+// hashes using WithKey prevents an attacker with write access to the log from
+// recomputing the chain. As removing trailing entries does not break the
+// chain, compare the last Entry returned by Verify with a copy of the latest
+// Entry stored elsewhere, e.g. published periodically, to detect truncation.
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// ErrTampered is returned by Verify if the log does not hold an intact chain
+// of entries.
+var ErrTampered = errors.New("audit log tampered")
+
+// Entry is a single record of the audit log.
+type Entry struct {
+	// Sequence holds the position of the Entry in the log, starting at 1.
+	Sequence uint64 `json:"seq"`
+	// Time holds the moment the Entry was recorded, in UTC.
+	Time time.Time `json:"time"`
+	// Event holds the name of the recorded event.
+	Event string `json:"event"`
+	// Data holds the key-value pairs of the event as JSON object.
+	Data json.RawMessage `json:"data,omitempty"`
+	// PrevHash holds the Hash of the previous Entry, empty for the first one.
+	PrevHash string `json:"prev_hash"`
+	// Hash holds the hex encoded hash of the Entry, covering all other fields.
+	Hash string `json:"hash"`
+}
+
+type (
+	// Option implements a functional option type for the Log and Verify.
+	Option func(*options)
+
+	// options holds the configuration of the Log and Verify.
+	options struct {
+		key  []byte
+		now  func() time.Time
+		last *Entry
+	}
+)
+
+// WithKey sets the secret key used to compute the entry hashes as HMAC-SHA256
+// instead of plain SHA-256. Verify must be configured with the same key.
+func WithKey(key []byte) Option {
+	return func(o *options) {
+		o.key = append([]byte(nil), key...)
+	}
+}
+
+// WithClock sets the function providing the time of entries. The default is
+// time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithLast continues the chain after the provided Entry, e.g. the last Entry
+// returned by Verify when reopening an existing log. For Verify, it sets the
+// Entry preceding the first verified Entry, allowing verification of a log
+// which was rotated or trimmed.
+func WithLast(e Entry) Option {
+	return func(o *options) {
+		o.last = &e
+	}
+}
+
+// newOptions returns the options with defaults applied.
+func newOptions(opts []Option) options {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Log appends entries to an io.Writer, one JSON object per line.
+type Log struct {
+	mtx  sync.Mutex
+	w    io.Writer
+	opts options
+	seq  uint64
+	prev string
+}
+
+// New returns a Log appending entries to the provided io.Writer, which should
+// be opened in append-only mode.
+func New(w io.Writer, opts ...Option) *Log {
+	o := newOptions(opts)
+	l := &Log{w: w, opts: o}
+	if o.last != nil {
+		l.seq, l.prev = o.last.Sequence, o.last.Hash
+	}
+	return l
+}
+
+// Record appends an Entry for the event with the provided key-value pairs and
+// returns it. Non-string keys are stored in their fmt representation and a
+// missing value is stored as null. If the Entry can not be written, the chain
+// is not advanced and the error is returned.
+func (l *Log) Record(event string, keyValues ...interface{}) (Entry, error) {
+	data, err := encodeData(keyValues)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	e := Entry{
+		Sequence: l.seq + 1,
+		Time:     l.opts.now().UTC(),
+		Event:    event,
+		Data:     data,
+		PrevHash: l.prev,
+	}
+	if e.Hash, err = l.opts.hash(e); err != nil {
+		return Entry{}, err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return Entry{}, err
+	}
+	if _, err = l.w.Write(append(line, '\n')); err != nil {
+		return Entry{}, err
+	}
+	l.seq, l.prev = e.Sequence, e.Hash
+	return e, nil
+}
+
+// Emit records each log line as Entry, using the message as event name and the
+// error, if any, and the key-value pairs of the log line as data. It
+// implements function.EmitErr, so audit events can be logged through a
+// telemetry.Logger created with function.NewLoggerErr.
+func (l *Log) Emit(_ telemetry.Level, msg string, err error, values function.Values, _ int) error {
+	keyValues := make([]interface{}, 0, len(values.FromContext)+len(values.FromLogger)+len(values.FromMethod)+2)
+	if err != nil {
+		keyValues = append(keyValues, "error", err.Error())
+	}
+	keyValues = append(keyValues, values.FromContext...)
+	keyValues = append(keyValues, values.FromLogger...)
+	keyValues = append(keyValues, values.FromMethod...)
+	_, recErr := l.Record(msg, keyValues...)
+	return recErr
+}
+
+// Verify reads the entries written by a Log and checks their sequence numbers
+// and hash chain. It returns the last Entry of the intact chain, together with
+// an error wrapping ErrTampered identifying the first broken Entry, if any.
+func Verify(r io.Reader, opts ...Option) (Entry, error) {
+	o := newOptions(opts)
+
+	var last Entry
+	if o.last != nil {
+		last = *o.last
+	}
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for line := 1; s.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return last, fmt.Errorf("%w: line %d: %v", ErrTampered, line, err)
+		}
+		switch {
+		case e.Sequence != last.Sequence+1:
+			return last, fmt.Errorf("%w: line %d: sequence %d follows %d", ErrTampered, line, e.Sequence, last.Sequence)
+		case e.PrevHash != last.Hash:
+			return last, fmt.Errorf("%w: entry %d: previous hash mismatch", ErrTampered, e.Sequence)
+		}
+		hash, err := o.hash(e)
+		if err != nil {
+			return last, err
+		}
+		if !hmac.Equal([]byte(hash), []byte(e.Hash)) {
+			return last, fmt.Errorf("%w: entry %d: hash mismatch", ErrTampered, e.Sequence)
+		}
+		last = e
+	}
+	return last, s.Err()
+}
+
+// hash returns the hex encoded hash of the Entry, computed over its JSON
+// encoding without Hash.
+func (o options) hash(e Entry) (string, error) {
+	e.Hash = ""
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	var h hash.Hash
+	if o.key != nil {
+		h = hmac.New(sha256.New, o.key)
+	} else {
+		h = sha256.New()
+	}
+	_, _ = h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// encodeData encodes the key-value pairs as JSON object, keeping the last
+// value of repeated keys. Values failing to encode are stored as their fmt
+// representation.
+func encodeData(keyValues []interface{}) (json.RawMessage, error) {
+	if len(keyValues) == 0 {
+		return nil, nil
+	}
+	data := make(map[string]interface{}, (len(keyValues)+1)/2)
+	for i := 0; i < len(keyValues); i += 2 {
+		k, ok := keyValues[i].(string)
+		if !ok {
+			k = fmt.Sprint(keyValues[i])
+		}
+		var v interface{}
+		if i+1 < len(keyValues) {
+			v = keyValues[i+1]
+		}
+		data[k] = v
+	}
+	b, err := json.Marshal(data)
+	if err == nil {
+		return b, nil
+	}
+	for k, v := range data {
+		if _, err = json.Marshal(v); err != nil {
+			data[k] = fmt.Sprintf("%+v", v)
+		}
+	}
+	return json.Marshal(data)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// record writes a log of three entries and returns its lines.
+func record(t *testing.T, opts ...Option) []string {
+	t.Helper()
+	var (
+		out   bytes.Buffer
+		clock = time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	)
+	log := New(&out, append([]Option{WithClock(func() time.Time { return clock })}, opts...)...)
+
+	if _, err := log.Record("login", "user", "alice", "ok", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := log.Record("grant", "user", "alice", "role", "<admin>", "dangling"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger := function.NewLoggerErr(log.Emit, 0)
+	logger.Error("delete", errors.New("denied"), "user", "bob", "ch", make(chan int))
+
+	return strings.SplitAfter(strings.TrimSuffix(out.String(), "\n"), "\n")
+}
+
+func TestVerify(t *testing.T) {
+	key := []byte("secret")
+	lines := record(t, WithKey(key))
+	if len(lines) != 3 {
+		t.Fatalf("unexpected log:\n%s", strings.Join(lines, ""))
+	}
+
+	last, err := Verify(strings.NewReader(strings.Join(lines, "")), WithKey(key))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last.Sequence != 3 || last.Event != "delete" || !last.Time.Equal(time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected last entry: %+v", last)
+	}
+
+	tests := []struct {
+		name  string
+		log   string
+		opts  []Option
+		valid uint64
+	}{
+		{"wrong key", strings.Join(lines, ""), []Option{WithKey([]byte("other"))}, 0},
+		{"unkeyed", strings.Join(lines, ""), nil, 0},
+		{"modified", lines[0] + strings.Replace(lines[1], "admin", "owner", 1) + lines[2], []Option{WithKey(key)}, 1},
+		{"removed", lines[0] + lines[2], []Option{WithKey(key)}, 1},
+		{"reordered", lines[1] + lines[0] + lines[2], []Option{WithKey(key)}, 0},
+		{"head removed", lines[1] + lines[2], []Option{WithKey(key)}, 0},
+		{"garbage", lines[0] + "{", []Option{WithKey(key)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			last, err := Verify(strings.NewReader(tt.log), tt.opts...)
+			if !errors.Is(err, ErrTampered) {
+				t.Fatalf("expected ErrTampered, have %v", err)
+			}
+			if last.Sequence != tt.valid {
+				t.Fatalf("want: %d\nhave: %d", tt.valid, last.Sequence)
+			}
+		})
+	}
+}
+
+func TestResume(t *testing.T) {
+	lines := record(t)
+	first, err := Verify(strings.NewReader(lines[0]))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// verifying a trimmed log requires the last entry before the trimmed part.
+	if _, err = Verify(strings.NewReader(lines[1]+lines[2]), WithLast(first)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	e, err := New(&out, WithLast(first)).Record("logout")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Sequence != 2 || e.PrevHash != first.Hash || e.Data != nil {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	if _, err = Verify(strings.NewReader(lines[0] + out.String())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestRecordWriteError(t *testing.T) {
+	log := New(failingWriter{})
+	if _, err := log.Record("login"); err == nil {
+		t.Fatal("expected error")
+	}
+	if log.seq != 0 || log.prev != "" {
+		t.Fatal("expected chain not to advance")
+	}
+}
@@ -0,0 +1,357 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package batch provides a function.EmitErr implementation queueing log lines
+// and handing them in batches to a Handler, typically shipping them to a
+// remote log service. It takes care of batching, retries with exponential
+// backoff and flushing on shutdown, allowing sinks to focus on encoding and
+// transport.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Default configuration of a Batcher.
+const (
+	DefaultSize        = 512
+	DefaultInterval    = time.Second
+	DefaultQueueSize   = 4096
+	DefaultAttempts    = 5
+	DefaultBackoff     = 100 * time.Millisecond
+	DefaultMaxBackoff  = 10 * time.Second
+	DefaultSendTimeout = 10 * time.Second
+)
+
+var (
+	// ErrQueueFull is returned by Emit if the log line is dropped as the
+	// queue is full.
+	ErrQueueFull = errors.New("batch queue full, log line dropped")
+	// ErrClosed is returned by Emit if the log line is dropped as the Batcher
+	// is closed.
+	ErrClosed = errors.New("batcher closed, log line dropped")
+)
+
+// Record holds a log line queued for deferred handling.
+type Record struct {
+	// Time holds the moment the log line was emitted.
+	Time time.Time
+	// Level holds the level of the log line.
+	Level telemetry.Level
+	// Message holds the log message.
+	Message string
+	// Error holds the error passed to Error or Fatal, if any.
+	Error error
+	// Values holds the key-value pairs and caller of the log line.
+	Values function.Values
+}
+
+// Handler handles a batch of records, e.g. by sending them to a remote log
+// service. Returned errors cause the batch to be retried, unless wrapped using
+// Permanent. The records slice is reused after the Handler returns.
+type Handler func(ctx context.Context, records []Record) error
+
+// permanentError marks an error as not to be retried.
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string { return p.err.Error() }
+func (p *permanentError) Unwrap() error { return p.err }
+
+// Permanent wraps the error returned by a Handler to indicate the batch must
+// not be retried, e.g. because the remote service rejected its contents.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether the error, or any error it wraps, was marked as
+// not to be retried using Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+type (
+	// Option implements a functional option type for the Batcher.
+	Option func(*options)
+
+	// options holds the configuration of a Batcher.
+	options struct {
+		size          int
+		interval      time.Duration
+		queueSize     int
+		attempts      int
+		backoff       time.Duration
+		maxBackoff    time.Duration
+		sendTimeout   time.Duration
+		errorHandler  func(error)
+		droppedMetric telemetry.Metric
+	}
+)
+
+// WithSize sets the maximum number of records handed to the Handler at once.
+func WithSize(size int) Option {
+	return func(o *options) {
+		o.size = size
+	}
+}
+
+// WithInterval sets the maximum time a record is queued before its batch is
+// handed to the Handler.
+func WithInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// WithQueueSize sets the number of records that can be queued. Log lines
+// emitted while the queue is full are dropped.
+func WithQueueSize(size int) Option {
+	return func(o *options) {
+		o.queueSize = size
+	}
+}
+
+// WithRetry sets the maximum number of attempts to handle a batch and the
+// initial and maximum backoff between attempts. The backoff doubles after each
+// failed attempt.
+func WithRetry(attempts int, backoff, maxBackoff time.Duration) Option {
+	return func(o *options) {
+		o.attempts = attempts
+		o.backoff = backoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithSendTimeout sets the timeout of the Context passed to each Handler
+// invocation.
+func WithSendTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.sendTimeout = timeout
+	}
+}
+
+// WithErrorHandler sets the function receiving the error of batches dropped
+// after exhausting their attempts. By default, these errors are written to
+// os.Stderr.
+func WithErrorHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = fn
+	}
+}
+
+// WithDroppedMetric sets the Metric on which the number of dropped records is
+// recorded, whether due to a full queue or due to failed batches.
+func WithDroppedMetric(m telemetry.Metric) Option {
+	return func(o *options) {
+		o.droppedMetric = m
+	}
+}
+
+// Batcher queues log lines and hands them in batches to a Handler from a
+// background goroutine. Batches are handed over once they reach the
+// configured size or the oldest record reaches the configured interval.
+type Batcher struct {
+	handler Handler
+	opts    options
+
+	mtx     sync.RWMutex
+	closed  bool
+	queue   chan Record
+	flushCh chan chan struct{}
+	done    chan struct{}
+}
+
+// New returns a Batcher handing batches of records to the provided Handler.
+// Use its Emit method with function.NewLoggerErr, its Flush method with
+// function.WithFlush and Close it on shutdown.
+func New(h Handler, opts ...Option) *Batcher {
+	o := options{
+		size:        DefaultSize,
+		interval:    DefaultInterval,
+		queueSize:   DefaultQueueSize,
+		attempts:    DefaultAttempts,
+		backoff:     DefaultBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		sendTimeout: DefaultSendTimeout,
+		errorHandler: func(err error) {
+			_, _ = fmt.Fprintf(os.Stderr, "telemetry: %v\n", err)
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.size < 1 {
+		o.size = 1
+	}
+	if o.attempts < 1 {
+		o.attempts = 1
+	}
+
+	b := &Batcher{
+		handler: h,
+		opts:    o,
+		queue:   make(chan Record, o.queueSize),
+		flushCh: make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Emit queues the log line. It implements function.EmitErr and returns
+// ErrQueueFull or ErrClosed if the log line is dropped.
+func (b *Batcher) Emit(level telemetry.Level, msg string, err error, values function.Values, _ int) error {
+	// the key-value pairs passed to the logging method may be reused by the
+	// caller once it returns.
+	values.FromMethod = append([]interface{}(nil), values.FromMethod...)
+	r := Record{Time: time.Now(), Level: level, Message: msg, Error: err, Values: values}
+
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	if b.closed {
+		b.dropped(1)
+		return ErrClosed
+	}
+	select {
+	case b.queue <- r:
+		return nil
+	default:
+		b.dropped(1)
+		return ErrQueueFull
+	}
+}
+
+// Flush blocks until all records queued before the call have been handled. It
+// matches the signature expected by function.WithFlush.
+func (b *Batcher) Flush() {
+	ack := make(chan struct{})
+	select {
+	case b.flushCh <- ack:
+		<-ack
+	case <-b.done:
+	}
+}
+
+// Close stops accepting log lines, hands the queued records to the Handler and
+// stops the background goroutine. It blocks until done.
+func (b *Batcher) Close() error {
+	b.mtx.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mtx.Unlock()
+
+	<-b.done
+	return nil
+}
+
+// run collects records into batches until the queue is closed.
+func (b *Batcher) run() {
+	defer close(b.done)
+
+	var (
+		batch = make([]Record, 0, b.opts.size)
+		timer = time.NewTimer(b.opts.interval)
+	)
+	timer.Stop()
+
+	send := func() {
+		if len(batch) > 0 {
+			b.send(batch)
+			batch = batch[:0]
+		}
+		timer.Stop()
+	}
+
+	for {
+		select {
+		case r, ok := <-b.queue:
+			if !ok {
+				send()
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(b.opts.interval)
+			}
+			batch = append(batch, r)
+			if len(batch) >= b.opts.size {
+				send()
+			}
+		case <-timer.C:
+			send()
+		case ack := <-b.flushCh:
+			// drain the records queued before the flush request.
+			for n := len(b.queue); n > 0; n-- {
+				r, ok := <-b.queue
+				if !ok {
+					break
+				}
+				batch = append(batch, r)
+				if len(batch) >= b.opts.size {
+					send()
+				}
+			}
+			send()
+			close(ack)
+		}
+	}
+}
+
+// send hands the batch to the Handler, retrying failed attempts.
+func (b *Batcher) send(batch []Record) {
+	backoff := b.opts.backoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), b.opts.sendTimeout)
+		err := b.handler(ctx, batch)
+		cancel()
+		if err == nil {
+			return
+		}
+
+		if IsPermanent(err) || attempt >= b.opts.attempts {
+			b.dropped(len(batch))
+			b.opts.errorHandler(fmt.Errorf("dropped batch of %d log lines after %d attempts: %w",
+				len(batch), attempt, err))
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > b.opts.maxBackoff {
+			backoff = b.opts.maxBackoff
+		}
+	}
+}
+
+// dropped records the number of dropped records on the configured Metric.
+func (b *Batcher) dropped(n int) {
+	if b.opts.droppedMetric != nil {
+		b.opts.droppedMetric.Record(float64(n))
+	}
+}
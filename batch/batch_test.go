@@ -0,0 +1,198 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type mockMetric struct {
+	telemetry.Metric
+	mtx   sync.Mutex
+	count float64
+}
+
+func (m *mockMetric) Record(value float64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.count += value
+}
+
+// recorder captures the messages of handled batches.
+type recorder struct {
+	mtx     sync.Mutex
+	batches [][]string
+	fail    func(attempt int) error
+	calls   int
+}
+
+func (r *recorder) handle(_ context.Context, records []Record) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.calls++
+	if r.fail != nil {
+		if err := r.fail(r.calls); err != nil {
+			return err
+		}
+	}
+	msgs := make([]string, 0, len(records))
+	for _, rec := range records {
+		msgs = append(msgs, rec.Message)
+	}
+	r.batches = append(r.batches, msgs)
+	return nil
+}
+
+func (r *recorder) result() [][]string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.batches
+}
+
+func TestBatchSize(t *testing.T) {
+	var r recorder
+	b := New(r.handle, WithSize(2), WithInterval(time.Hour))
+	logger := function.NewLoggerErr(b.Emit, 0)
+
+	for _, msg := range []string{"1", "2", "3", "4", "5"} {
+		logger.Info(msg)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := [][]string{{"1", "2"}, {"3", "4"}, {"5"}}
+	if have := r.result(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+	if err := b.Emit(telemetry.LevelInfo, "6", nil, function.Values{}, 0); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, have %v", err)
+	}
+}
+
+func TestBatchInterval(t *testing.T) {
+	var r recorder
+	b := New(r.handle, WithInterval(10*time.Millisecond))
+	defer func() { _ = b.Close() }()
+
+	_ = b.Emit(telemetry.LevelInfo, "1", nil, function.Values{}, 0)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.result()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("batch not handled after interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBatchFlush(t *testing.T) {
+	var r recorder
+	b := New(r.handle, WithInterval(time.Hour))
+	defer func() { _ = b.Close() }()
+	logger := function.NewLoggerErr(b.Emit, 0, function.WithFlush(b.Flush))
+
+	logger.Info("1")
+	b.Flush()
+
+	want := [][]string{{"1"}}
+	if have := r.result(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestBatchRetry(t *testing.T) {
+	var (
+		r = recorder{fail: func(attempt int) error {
+			if attempt < 3 {
+				return errors.New("unavailable")
+			}
+			return nil
+		}}
+		errs []error
+		m    mockMetric
+	)
+	b := New(r.handle, WithRetry(3, time.Millisecond, time.Millisecond), WithDroppedMetric(&m),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }))
+
+	_ = b.Emit(telemetry.LevelInfo, "1", nil, function.Values{}, 0)
+	b.Flush()
+	if want := [][]string{{"1"}}; !reflect.DeepEqual(want, r.result()) {
+		t.Fatalf("want: %v\nhave: %v", want, r.result())
+	}
+
+	// permanent errors are not retried.
+	r.fail = func(int) error { return Permanent(errors.New("rejected")) }
+	_ = b.Emit(telemetry.LevelInfo, "2", nil, function.Values{}, 0)
+	_ = b.Close()
+
+	if r.calls != 4 {
+		t.Errorf("expected 4 calls, have %d", r.calls)
+	}
+	if len(errs) != 1 || errs[0].Error() != "dropped batch of 1 log lines after 1 attempts: rejected" {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if len(errs) == 1 && !IsPermanent(errs[0]) {
+		t.Errorf("expected %v to be permanent", errs[0])
+	}
+	if m.count != 1 {
+		t.Errorf("expected 1 dropped, have %v", m.count)
+	}
+}
+
+func TestBatchQueueFull(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		handled int32
+		m       mockMetric
+	)
+	b := New(func(context.Context, []Record) error {
+		<-release
+		atomic.AddInt32(&handled, 1)
+		return nil
+	}, WithSize(1), WithQueueSize(1), WithDroppedMetric(&m))
+
+	// the first record is taken by the background goroutine, blocking in the
+	// handler, the second one fills the queue.
+	_ = b.Emit(telemetry.LevelInfo, "1", nil, function.Values{}, 0)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(b.queue) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("record not picked up")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := b.Emit(telemetry.LevelInfo, "2", nil, function.Values{}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.Emit(telemetry.LevelInfo, "3", nil, function.Values{}, 0); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, have %v", err)
+	}
+	close(release)
+	_ = b.Close()
+
+	if handled != 2 || m.count != 1 {
+		t.Fatalf("expected 2 handled and 1 dropped, have %d and %v", handled, m.count)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Encoder encodes a record into the payload of a single log entry.
+type Encoder func(r Record) ([]byte, error)
+
+// JSONEncoder returns an Encoder encoding records as JSON objects using
+// emitter.JSON and the provided options. The time field holds the moment the
+// log line was emitted.
+func JSONEncoder(opts ...emitter.Option) Encoder {
+	return encoder(emitter.JSON, opts)
+}
+
+// LogfmtEncoder returns an Encoder encoding records as logfmt lines using
+// emitter.Logfmt and the provided options. The time field holds the moment
+// the log line was emitted.
+func LogfmtEncoder(opts ...emitter.Option) Encoder {
+	return encoder(emitter.Logfmt, opts)
+}
+
+// encoder adapts an emitter writing newline terminated log lines into an
+// Encoder.
+func encoder(fn func(w io.Writer, opts ...emitter.Option) function.EmitErr, opts []emitter.Option) Encoder {
+	var (
+		mtx sync.Mutex
+		buf bytes.Buffer
+		ts  time.Time
+	)
+	clock := func() time.Time { return ts }
+	enc := fn(&buf, append(opts, emitter.WithClock(clock))...)
+	return func(r Record) ([]byte, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		buf.Reset()
+		ts = r.Time
+		if err := enc(r.Level, r.Message, r.Error, r.Values, 0); err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), bytes.TrimSuffix(buf.Bytes(), []byte("\n"))...), nil
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestEncoder(t *testing.T) {
+	r := Record{
+		Time:    time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Level:   telemetry.LevelError,
+		Message: "failed",
+		Error:   errors.New("boom"),
+		Values:  function.Values{FromLogger: []interface{}{"component", "lib"}},
+	}
+
+	tests := []struct {
+		name     string
+		encoder  Encoder
+		expected string
+	}{
+		{"json", JSONEncoder(), `{"time":"2024-03-01T12:00:00Z","level":"error","msg":"failed","error":"boom","component":"lib"}`},
+		{"json-options", JSONEncoder(emitter.WithTimeKey("")), `{"level":"error","msg":"failed","error":"boom","component":"lib"}`},
+		{"logfmt", LogfmtEncoder(), `time=2024-03-01T12:00:00Z level=error msg=failed error=boom component=lib`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			have, err := tt.encoder(r)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(have) != tt.expected {
+				t.Fatalf("expected %s to match %s", have, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultOverflowValue is the Label value used by LimitCardinality for label
+// value combinations exceeding the cardinality limit if no other value was
+// provided.
+const DefaultOverflowValue = "other"
+
+// LimitCardinality returns a copy of the provided ContextLabels whose Metric
+// method caps the number of distinct label value combinations recorded by each
+// Metric at limit. Once the limit is reached, previously seen combinations are
+// still recorded as is, while measurements with a new combination are recorded
+// with each of the Labels found in Context set to the provided overflow value,
+// or DefaultOverflowValue if empty. This protects metrics backends from an
+// unbounded number of series when Label values are user controlled, e.g. when
+// derived from request data stored in Context. A limit of zero or below
+// disables the cap.
+//
+// The first time a combination is collapsed for a Metric, a warning is logged
+// on the provided Logger, if not nil, without recording its Metric.
+func LimitCardinality(labels *ContextLabels, limit int, overflow string, logger Logger) *ContextLabels {
+	if overflow == "" {
+		overflow = DefaultOverflowValue
+	}
+	c := *labels
+	c.limit, c.overflow, c.logger = limit, overflow, logger
+	return &c
+}
+
+// cardinality tracks the label value combinations recorded by a Metric.
+type cardinality struct {
+	mtx    sync.Mutex
+	seen   map[string]struct{}
+	warned bool
+}
+
+// allow reports if the label value combination can be recorded as is. It also
+// reports if this is the first combination collapsed.
+func (c *cardinality) allow(combination string, limit int) (allowed, first bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, ok := c.seen[combination]; ok {
+		return true, false
+	}
+	if len(c.seen) < limit {
+		c.seen[combination] = struct{}{}
+		return true, false
+	}
+	first, c.warned = !c.warned, true
+	return false, first
+}
+
+// combination returns the key identifying the label value combination of the
+// allowlisted keys found in Context.
+func combination(values []string, found []bool) string {
+	var sb strings.Builder
+	for i, v := range values {
+		if found[i] {
+			sb.WriteByte('=')
+			sb.WriteString(v)
+		}
+		// separate the values by a byte not expected in label values.
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+type countingLogger struct {
+	telemetry.Logger
+	warns int
+}
+
+func (l *countingLogger) Warn(_ string, keyValues ...interface{}) {
+	if _, noMetric := telemetry.ExtractNoMetric(keyValues); noMetric {
+		l.warns++
+	}
+}
+
+func TestLimitCardinality(t *testing.T) {
+	var (
+		recorded [][]telemetry.LabelValue
+		logger   = &countingLogger{Logger: telemetry.NoopLogger()}
+		cl       = telemetry.LimitCardinality(telemetry.NewContextLabels(opSink{}, 0, "tenant", "region"), 2, "", logger)
+		m        = cl.Metric(opMetric{recorded: &recorded})
+		ctx      = func(keyValues ...interface{}) context.Context {
+			return telemetry.KeyValuesToContext(context.Background(), keyValues...)
+		}
+	)
+
+	// combinations are limited, not the values of the individual labels.
+	m.RecordContext(ctx("tenant", "acme", "region", "eu"), 1)
+	m.RecordContext(ctx("tenant", "acme", "region", "us"), 1)
+	m.RecordContext(ctx("tenant", "acme"), 1)
+	m.RecordContext(ctx("tenant", "acme", "region", "eu"), 1)
+	m.With("explicit").RecordContext(ctx("tenant", "globex", "region", "eu"), 1)
+	m.RecordContext(context.Background(), 1)
+
+	// each Metric has its own limit.
+	cl.Metric(opMetric{recorded: &recorded}).RecordContext(ctx("tenant", "acme"), 1)
+
+	want := [][]telemetry.LabelValue{
+		{insertOp{"tenant", "acme"}, insertOp{"region", "eu"}},
+		{insertOp{"tenant", "acme"}, insertOp{"region", "us"}},
+		{upsertOp{"tenant", "other"}},
+		{insertOp{"tenant", "acme"}, insertOp{"region", "eu"}},
+		{"explicit", upsertOp{"tenant", "other"}, upsertOp{"region", "other"}},
+		nil,
+		{insertOp{"tenant", "acme"}},
+	}
+	if !reflect.DeepEqual(want, recorded) {
+		t.Fatalf("want: %v\nhave: %v", want, recorded)
+	}
+	if logger.warns != 1 {
+		t.Errorf("logger.warns=%d, want 1", logger.warns)
+	}
+}
+
+func TestLimitCardinalityOverflowValue(t *testing.T) {
+	var (
+		recorded [][]telemetry.LabelValue
+		cl       = telemetry.LimitCardinality(telemetry.NewContextLabels(opSink{}, 0, "tenant"), 1, "overflow", nil)
+		m        = cl.Metric(opMetric{recorded: &recorded})
+	)
+	m.RecordContext(telemetry.KeyValuesToContext(context.Background(), "tenant", "acme"), 1)
+	m.RecordContext(telemetry.KeyValuesToContext(context.Background(), "tenant", "globex"), 1)
+
+	want := [][]telemetry.LabelValue{
+		{insertOp{"tenant", "acme"}},
+		{upsertOp{"tenant", "overflow"}},
+	}
+	if !reflect.DeepEqual(want, recorded) {
+		t.Fatalf("want: %v\nhave: %v", want, recorded)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cloudwatchlog provides a sink shipping log lines to AWS CloudWatch
+// Logs, allowing Lambda and ECS workloads to ship log lines without a
+// sidecar.
+package cloudwatchlog
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	"github.com/basvanbeek/telemetry/batch"
+	"github.com/basvanbeek/telemetry/emitter"
+)
+
+// CloudWatch Logs PutLogEvents limits.
+const (
+	// MaxBatchEvents is the maximum number of events in a single request.
+	MaxBatchEvents = 10000
+	// MaxBatchBytes is the maximum size of a single request, counting the
+	// message size plus EventOverhead bytes per event.
+	MaxBatchBytes = 1048576
+	// MaxEventBytes is the maximum size of a single event, including
+	// EventOverhead.
+	MaxEventBytes = 262144
+	// EventOverhead is the number of bytes counted for each event on top of
+	// its message size.
+	EventOverhead = 26
+	// MaxBatchSpan is the maximum time span between the events of a single
+	// request.
+	MaxBatchSpan = 24 * time.Hour
+)
+
+// Client holds the CloudWatch Logs operations used by the sink. It is
+// implemented by *cloudwatchlogs.Client.
+type Client interface {
+	PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error)
+	CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error)
+	CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error)
+}
+
+type (
+	// Option implements a functional option type for the CloudWatch sink.
+	Option func(*options)
+
+	// options holds the configuration of the CloudWatch sink.
+	options struct {
+		create    bool
+		encoder   batch.Encoder
+		batchOpts []batch.Option
+	}
+)
+
+// WithAutoCreate sets whether a missing log group and log stream are created.
+// It is enabled by default and requires the logs:CreateLogGroup and
+// logs:CreateLogStream permissions.
+func WithAutoCreate(enabled bool) Option {
+	return func(o *options) {
+		o.create = enabled
+	}
+}
+
+// WithEncoder sets the encoder of the event messages. The default encodes log
+// lines as JSON using batch.JSONEncoder, omitting the time field as each
+// event holds its timestamp.
+func WithEncoder(enc batch.Encoder) Option {
+	return func(o *options) {
+		o.encoder = enc
+	}
+}
+
+// WithBatchOptions sets the options of the underlying batch.Batcher, e.g. to
+// configure batch size, flush interval and retries.
+func WithBatchOptions(opts ...batch.Option) Option {
+	return func(o *options) {
+		o.batchOpts = append(o.batchOpts, opts...)
+	}
+}
+
+// New returns a batch.Batcher shipping log lines to the provided log group
+// and log stream. Use its Emit method with function.NewLoggerErr, its Flush
+// method with function.WithFlush and Close it on shutdown.
+//
+// Batches exceeding the PutLogEvents limits are split into multiple requests
+// and events exceeding MaxEventBytes are truncated. Throttled and otherwise
+// failed requests are retried with backoff by the batch.Batcher. Events
+// CloudWatch Logs rejects for being too old or too far in the future are
+// dropped by the service.
+func New(c Client, group, stream string, opts ...Option) *batch.Batcher {
+	o := options{
+		create:  true,
+		encoder: batch.JSONEncoder(emitter.WithTimeKey("")),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s := &sink{client: c, group: group, stream: stream, opts: o}
+	return batch.New(s.send, o.batchOpts...)
+}
+
+// sink holds the state of the log stream written to.
+type sink struct {
+	mtx    sync.Mutex
+	client Client
+	group  string
+	stream string
+	opts   options
+	token  *string
+}
+
+// send implements batch.Handler.
+func (s *sink) send(ctx context.Context, records []batch.Record) error {
+	events := make([]types.InputLogEvent, 0, len(records))
+	for _, r := range records {
+		msg, err := s.opts.encoder(r)
+		if err != nil {
+			return batch.Permanent(err)
+		}
+		if len(msg) > MaxEventBytes-EventOverhead {
+			msg = msg[:MaxEventBytes-EventOverhead]
+		}
+		events = append(events, types.InputLogEvent{
+			Message:   aws.String(string(msg)),
+			Timestamp: aws.Int64(r.Time.UnixNano() / int64(time.Millisecond)),
+		})
+	}
+	// events of a request must be in chronological order.
+	sort.SliceStable(events, func(i, j int) bool {
+		return *events[i].Timestamp < *events[j].Timestamp
+	})
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for len(events) > 0 {
+		n := split(events)
+		if err := s.put(ctx, events[:n]); err != nil {
+			return err
+		}
+		events = events[n:]
+	}
+	return nil
+}
+
+// split returns the number of leading events fitting a single request.
+func split(events []types.InputLogEvent) int {
+	var (
+		size  int
+		first = *events[0].Timestamp
+		span  = MaxBatchSpan.Milliseconds()
+	)
+	for i, e := range events {
+		size += len(*e.Message) + EventOverhead
+		if i == MaxBatchEvents || size > MaxBatchBytes || *e.Timestamp-first >= span {
+			return i
+		}
+	}
+	return len(events)
+}
+
+// put sends the events, creating the log group and stream and resolving
+// sequence token mismatches as needed.
+func (s *sink) put(ctx context.Context, events []types.InputLogEvent) error {
+	var (
+		created    bool
+		resynced   bool
+		notFound   *types.ResourceNotFoundException
+		invalidSeq *types.InvalidSequenceTokenException
+		accepted   *types.DataAlreadyAcceptedException
+		invalidArg *types.InvalidParameterException
+	)
+	for {
+		out, err := s.client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String(s.group),
+			LogStreamName: aws.String(s.stream),
+			LogEvents:     events,
+			SequenceToken: s.token,
+		})
+		switch {
+		case err == nil:
+			s.token = out.NextSequenceToken
+			return nil
+		case errors.As(err, &notFound) && s.opts.create && !created:
+			if err = s.create(ctx); err != nil {
+				return err
+			}
+			created, s.token = true, nil
+		case errors.As(err, &invalidSeq) && !resynced:
+			resynced, s.token = true, invalidSeq.ExpectedSequenceToken
+		case errors.As(err, &accepted):
+			s.token = accepted.ExpectedSequenceToken
+			return nil
+		case errors.As(err, &invalidArg):
+			return batch.Permanent(err)
+		default:
+			return err
+		}
+	}
+}
+
+// create creates the log group and log stream, ignoring existing ones.
+func (s *sink) create(ctx context.Context) error {
+	var exists *types.ResourceAlreadyExistsException
+	_, err := s.client.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{
+		LogGroupName: aws.String(s.group),
+	})
+	if err != nil && !errors.As(err, &exists) {
+		return err
+	}
+	_, err = s.client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(s.group),
+		LogStreamName: aws.String(s.stream),
+	})
+	if err != nil && !errors.As(err, &exists) {
+		return err
+	}
+	return nil
+}
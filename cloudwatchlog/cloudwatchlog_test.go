@@ -0,0 +1,143 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudwatchlog
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/batch"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type mockClient struct {
+	mtx      sync.Mutex
+	exists   bool
+	token    string
+	requests []*cloudwatchlogs.PutLogEventsInput
+	created  []string
+}
+
+func (c *mockClient) PutLogEvents(_ context.Context, in *cloudwatchlogs.PutLogEventsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !c.exists {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("stream not found")}
+	}
+	if aws.ToString(in.SequenceToken) != c.token {
+		return nil, &types.InvalidSequenceTokenException{ExpectedSequenceToken: aws.String(c.token)}
+	}
+	c.requests = append(c.requests, in)
+	c.token += "x"
+	return &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: aws.String(c.token)}, nil
+}
+
+func (c *mockClient) CreateLogGroup(_ context.Context, in *cloudwatchlogs.CreateLogGroupInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.created = append(c.created, aws.ToString(in.LogGroupName))
+	return nil, &types.ResourceAlreadyExistsException{}
+}
+
+func (c *mockClient) CreateLogStream(_ context.Context, in *cloudwatchlogs.CreateLogStreamInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.created = append(c.created, aws.ToString(in.LogGroupName)+"/"+aws.ToString(in.LogStreamName))
+	c.exists = true
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func TestCloudWatch(t *testing.T) {
+	var (
+		c      = &mockClient{token: "seq"}
+		b      = New(c, "group", "stream")
+		logger = function.NewLoggerErr(b.Emit, 0).With("component", "lib")
+	)
+
+	logger.Info("hello")
+	b.Flush()
+	logger.Warn("again", "attempt", 2)
+	if err := b.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []string{"group", "group/stream"}; strings.Join(c.created, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v to match %v", c.created, expected)
+	}
+	if len(c.requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(c.requests))
+	}
+	for i, expected := range []string{
+		`{"level":"info","msg":"hello","component":"lib"}`,
+		`{"level":"warn","msg":"again","component":"lib","attempt":2}`,
+	} {
+		events := c.requests[i].LogEvents
+		if len(events) != 1 || aws.ToString(events[0].Message) != expected {
+			t.Fatalf("expected %v to hold %s", events, expected)
+		}
+		if aws.ToInt64(events[0].Timestamp) == 0 {
+			t.Fatal("expected timestamp to be set")
+		}
+	}
+}
+
+func TestCloudWatchSplit(t *testing.T) {
+	var (
+		c       = &mockClient{exists: true}
+		s       = &sink{client: c, group: "group", stream: "stream", opts: options{encoder: batch.LogfmtEncoder()}}
+		now     = time.Now()
+		large   = strings.Repeat("a", 300000)
+		records []batch.Record
+	)
+	// out of order records are sorted, records spanning more than a day are
+	// split, large records are truncated and requests are capped by size.
+	records = append(records, batch.Record{Time: now.Add(25 * time.Hour), Level: telemetry.LevelInfo, Message: "late"})
+	for i := 0; i < 5; i++ {
+		records = append(records, batch.Record{Time: now, Level: telemetry.LevelInfo, Message: large})
+	}
+
+	if err := s.send(context.Background(), records); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var counts []int
+	for _, req := range c.requests {
+		counts = append(counts, len(req.LogEvents))
+		size := 0
+		for _, e := range req.LogEvents {
+			if len(*e.Message)+EventOverhead > MaxEventBytes {
+				t.Fatalf("event exceeds %d bytes", MaxEventBytes)
+			}
+			size += len(*e.Message) + EventOverhead
+		}
+		if size > MaxBatchBytes {
+			t.Fatalf("request exceeds %d bytes", MaxBatchBytes)
+		}
+	}
+	if len(counts) != 3 || counts[0] != 4 || counts[1] != 1 || counts[2] != 1 {
+		t.Fatalf("unexpected request sizes %v", counts)
+	}
+	if msg := aws.ToString(c.requests[2].LogEvents[0].Message); !strings.HasSuffix(msg, "msg=late") {
+		t.Fatalf("expected last event to be late, got %s", msg)
+	}
+}
@@ -0,0 +1,20 @@
+module github.com/basvanbeek/telemetry/cloudwatchlog
+
+go 1.21
+
+require (
+	github.com/aws/aws-sdk-go-v2 v1.30.0
+	github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs v1.37.0
+	github.com/basvanbeek/telemetry v0.2.0
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream v1.6.2 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.3.12 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.6.12 // indirect
+	github.com/aws/smithy-go v1.20.2 // indirect
+)
+
+// Work around for maintaining multiple go modules in the same repository
+// until go has better support for this. https://github.com/golang/go/issues/45713
+replace github.com/basvanbeek/telemetry => ../
@@ -0,0 +1,186 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config configures the level and output of the telemetry logging
+// pipeline, including the levels of the registered scopes, without code
+// changes.
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+	"github.com/basvanbeek/telemetry/scope"
+)
+
+// Environment variables read by FromEnv.
+const (
+	EnvLevels = "TELEMETRY_LOG_LEVELS"
+	EnvOutput = "TELEMETRY_LOG_OUTPUT"
+)
+
+// Supported output formats.
+const (
+	OutputConsole = "console"
+	OutputJSON    = "json"
+	OutputLogfmt  = "logfmt"
+)
+
+// Settings holds the level and output configuration of the logging pipeline.
+type Settings struct {
+	// Levels holds the default level and per scope levels in the form
+	// accepted by scope.SetLevels, e.g. "info,grpc*=debug,storage=error". An
+	// entry without scope pattern sets the default level, which is
+	// telemetry.LevelInfo if omitted.
+	Levels string `json:"levels"`
+	// Output holds the output format, one of "console", "json" or "logfmt".
+	// The default is "console".
+	Output string `json:"output"`
+}
+
+// FromEnv returns the Settings found in the TELEMETRY_LOG_LEVELS and
+// TELEMETRY_LOG_OUTPUT environment variables.
+func FromEnv() Settings {
+	return Settings{
+		Levels: os.Getenv(EnvLevels),
+		Output: os.Getenv(EnvOutput),
+	}
+}
+
+// Pipeline holds a telemetry.Logger whose level and output format can be
+// reconfigured at runtime by applying Settings or a Document.
+type Pipeline struct {
+	w      io.Writer
+	opts   []emitter.Option
+	logger telemetry.Logger
+	emit   atomic.Value
+
+	mtx     sync.Mutex
+	closers []io.Closer
+}
+
+// New returns a Pipeline writing log lines to w, using the provided emitter
+// options for all output formats. Its Logger writes console output at
+// telemetry.LevelInfo until Settings are applied.
+func New(w io.Writer, opts ...emitter.Option) *Pipeline {
+	p := &Pipeline{w: w, opts: opts}
+	p.emit.Store(emitter.Console(w, opts...))
+	p.logger = function.NewLoggerErr(func(level telemetry.Level, msg string, err error, values function.Values, callerSkip int) error {
+		return p.emit.Load().(function.EmitErr)(level, msg, err, values, callerSkip+1)
+	}, 0, function.WithCaller())
+	return p
+}
+
+// Init configures the logging pipeline from the environment at startup. It
+// returns a Pipeline writing to os.Stderr with the Settings found by FromEnv
+// applied, and installs its Logger as the logger of the registered scopes
+// using scope.UseLogger.
+func Init() (*Pipeline, error) {
+	p := New(os.Stderr)
+	if err := p.Apply(FromEnv()); err != nil {
+		return nil, err
+	}
+	scope.UseLogger(p.Logger())
+	return p, nil
+}
+
+// Logger returns the Logger of the Pipeline.
+func (p *Pipeline) Logger() telemetry.Logger {
+	return p.logger
+}
+
+// Apply validates and applies the Settings, setting the level of the Logger,
+// the levels of the registered scopes and the output format. Nothing is
+// applied if the Settings are invalid. Outputs of a previously applied
+// Document are closed.
+func (p *Pipeline) Apply(s Settings) error {
+	emit, err := p.output(s.Output, p.w)
+	if err != nil {
+		return err
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return p.apply(s.Levels, emit, nil)
+}
+
+// Close closes the outputs opened by the applied Document, if any. The
+// Pipeline must not be used afterwards.
+func (p *Pipeline) Close() error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	closeAll(p.closers)
+	p.closers = nil
+	return nil
+}
+
+// apply sets the levels and emitter, closing the outputs of the emitter being
+// replaced. The caller must hold the lock.
+func (p *Pipeline) apply(levels string, emit function.EmitErr, closers []io.Closer) error {
+	level, err := defaultLevel(levels)
+	if err != nil {
+		return err
+	}
+	if err = scope.SetLevels(levels); err != nil {
+		return err
+	}
+
+	p.logger.SetLevel(level)
+	scope.SetDefaultLevel(level)
+	p.emit.Store(emit)
+
+	closeAll(p.closers)
+	p.closers = closers
+	return nil
+}
+
+// output returns the emitter for the output format writing to w.
+func (p *Pipeline) output(format string, w io.Writer) (function.EmitErr, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", OutputConsole:
+		return emitter.Console(w, p.opts...), nil
+	case OutputJSON:
+		return emitter.JSON(w, p.opts...), nil
+	case OutputLogfmt:
+		return emitter.Logfmt(w, p.opts...), nil
+	default:
+		return nil, fmt.Errorf("%q is not a valid log output", format)
+	}
+}
+
+// defaultLevel returns the level of the last entry without scope pattern.
+func defaultLevel(levels string) (telemetry.Level, error) {
+	level := telemetry.LevelInfo
+	for _, entry := range strings.Split(levels, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.ContainsAny(entry, "=:") {
+			continue
+		}
+		lvl, err := telemetry.ParseLevel(entry)
+		if err != nil {
+			return telemetry.LevelNone, err
+		}
+		level = lvl
+	}
+	return level, nil
+}
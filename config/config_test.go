@@ -0,0 +1,116 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/scope"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		expected string
+	}{
+		{"json", Settings{Levels: "debug", Output: "JSON"}, `{"level":"debug","msg":"text"}` + "\n"},
+		{"logfmt", Settings{Levels: "debug", Output: "logfmt"}, "level=debug msg=text\n"},
+		{"level", Settings{Output: "logfmt"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := New(&out, emitter.WithTimeKey(""), emitter.WithCallerKey(""))
+			if err := p.Apply(tt.settings); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			p.Logger().Debug("text")
+
+			if have := out.String(); have != tt.expected {
+				t.Fatalf("expected %q to match %q", have, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	sc := scope.Register("config-invalid", "test scope")
+	sc.SetLevel(telemetry.LevelWarn)
+
+	var out bytes.Buffer
+	p := New(&out, emitter.WithTimeKey(""), emitter.WithCallerKey(""), emitter.WithColor(false))
+
+	for _, s := range []Settings{
+		{Levels: "config-invalid=debug", Output: "xml"},
+		{Levels: "verbose,config-invalid=debug"},
+		{Levels: "config-invalid=verbose", Output: "json"},
+	} {
+		if err := p.Apply(s); err == nil {
+			t.Errorf("expected error for %+v", s)
+		}
+	}
+
+	if sc.Level() != telemetry.LevelWarn {
+		t.Errorf("expected scope level %v, have %v", telemetry.LevelWarn, sc.Level())
+	}
+	p.Logger().Info("text")
+	if have := out.String(); !strings.HasSuffix(have, "INFO  text\n") {
+		t.Errorf("expected console output, have %q", have)
+	}
+}
+
+func TestInit(t *testing.T) {
+	t.Setenv(EnvLevels, "error,config-init=debug")
+	t.Setenv(EnvOutput, "json")
+
+	sc := scope.Register("config-init", "test scope")
+	p, err := Init()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Logger().Level() != telemetry.LevelError {
+		t.Errorf("expected logger level %v, have %v", telemetry.LevelError, p.Logger().Level())
+	}
+	if sc.Level() != telemetry.LevelDebug {
+		t.Errorf("expected scope level %v, have %v", telemetry.LevelDebug, sc.Level())
+	}
+}
+
+func TestFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.json")
+	if err := os.WriteFile(path, []byte(`{"levels":"debug","output":"json"}`), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s, err := FromFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (Settings{Levels: "debug", Output: "json"}); s != want {
+		t.Fatalf("want: %+v\nhave: %+v", want, s)
+	}
+	if _, err = FromFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
@@ -0,0 +1,467 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+	"github.com/basvanbeek/telemetry/redact"
+	"github.com/basvanbeek/telemetry/sampling"
+)
+
+// Additional output formats supported by Document outputs.
+const (
+	OutputSyslog   = "syslog"
+	OutputJournald = "journald"
+	OutputGELF     = "gelf"
+)
+
+// Output targets of the console, json and logfmt output formats besides
+// file paths.
+const (
+	TargetStdout = "stdout"
+	TargetStderr = "stderr"
+)
+
+// Document describes a complete logging pipeline. Its field names match the
+// keys of JSON and YAML documents.
+type Document struct {
+	// Level holds the default level, telemetry.LevelInfo if omitted.
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+	// Scopes maps scope name patterns, as accepted by scope.SetLevels, onto
+	// levels. Literal names take precedence over patterns and longer patterns
+	// take precedence over shorter ones.
+	Scopes map[string]string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	// Outputs holds the destinations of the log lines. If omitted, log lines
+	// are written to the Pipeline writer in console format.
+	Outputs []OutputConfig `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+	// Sampling configures head sampling of log lines, if set.
+	Sampling *SamplingConfig `json:"sampling,omitempty" yaml:"sampling,omitempty"`
+	// Redact lists the keys of key-value pairs whose values are replaced by
+	// redact.Redacted. Keys are matched case-insensitively.
+	Redact []string `json:"redact,omitempty" yaml:"redact,omitempty"`
+}
+
+// OutputConfig describes a destination of log lines.
+type OutputConfig struct {
+	// Format holds the output format, one of "console", "json", "logfmt",
+	// "syslog", "journald" or "gelf".
+	Format string `json:"format" yaml:"format"`
+	// Target holds "stdout", "stderr" or a file path for the console, json and
+	// logfmt formats. If omitted, log lines are written to the Pipeline
+	// writer.
+	Target string `json:"target,omitempty" yaml:"target,omitempty"`
+	// Network and Address locate the syslog daemon or GELF input, as
+	// accepted by emitter.DialSyslog and emitter.DialGELF.
+	Network string `json:"network,omitempty" yaml:"network,omitempty"`
+	Address string `json:"address,omitempty" yaml:"address,omitempty"`
+	// Level holds the most verbose level written to the output. If omitted,
+	// all log lines enabled by the Logger are written.
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+	// MaxSize, MaxAge and MaxBackups configure the rotation of file targets,
+	// with MaxAge as accepted by time.ParseDuration.
+	MaxSize    int64  `json:"max_size,omitempty" yaml:"max_size,omitempty"`
+	MaxAge     string `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+	MaxBackups int    `json:"max_backups,omitempty" yaml:"max_backups,omitempty"`
+}
+
+// SamplingConfig configures head sampling of log lines, see the sampling
+// package. Omitted fields use the sampling package defaults, so a zero
+// probability emits all log lines selected by EveryN.
+type SamplingConfig struct {
+	EveryN        int     `json:"every_n,omitempty" yaml:"every_n,omitempty"`
+	Probability   float64 `json:"probability,omitempty" yaml:"probability,omitempty"`
+	ResetInterval string  `json:"reset_interval,omitempty" yaml:"reset_interval,omitempty"`
+	Level         string  `json:"level,omitempty" yaml:"level,omitempty"`
+}
+
+// FieldError reports an invalid Document field.
+type FieldError struct {
+	// Field holds the path of the offending field, e.g. "outputs[1].format".
+	Field string
+	Err   error
+}
+
+// Error implements error.
+func (e *FieldError) Error() string { return e.Field + ": " + e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// fieldError returns a FieldError for the field.
+func fieldError(field string, format string, args ...interface{}) error {
+	return &FieldError{Field: field, Err: fmt.Errorf(format, args...)}
+}
+
+// ParseDocument decodes and validates the JSON Document. Unknown fields are
+// rejected.
+func ParseDocument(b []byte) (Document, error) {
+	var d Document
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&d); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return d, fieldError(jsonField(typeErr.Field), "cannot use %s as %s", typeErr.Value, typeErr.Type)
+		}
+		return d, err
+	}
+	return d, d.Validate()
+}
+
+// jsonField converts the dotted field path of encoding/json, e.g.
+// "outputs.0.format", into the notation of FieldError.
+func jsonField(field string) string {
+	parts := strings.Split(field, ".")
+	var b strings.Builder
+	for i, part := range parts {
+		if _, err := strconv.Atoi(part); err == nil && i > 0 {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// LoadDocument reads, decodes and validates the JSON Document at path.
+func LoadDocument(path string) (Document, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Document{}, err
+	}
+	d, err := ParseDocument(b)
+	if err != nil {
+		return d, fmt.Errorf("invalid logging configuration %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Validate checks the Document, returning a FieldError for the first invalid
+// field.
+func (d Document) Validate() error {
+	if err := validLevel("level", d.Level); err != nil {
+		return err
+	}
+	for _, pattern := range sortedPatterns(d.Scopes) {
+		field := "scopes[" + pattern + "]"
+		if pattern == "" || strings.ContainsAny(pattern, ",=:") {
+			return fieldError(field, "invalid scope pattern")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fieldError(field, "invalid scope pattern: %w", err)
+		}
+		if err := validLevel(field, d.Scopes[pattern]); err != nil {
+			return err
+		}
+	}
+	for i, o := range d.Outputs {
+		if err := o.validate(fmt.Sprintf("outputs[%d]", i)); err != nil {
+			return err
+		}
+	}
+	if s := d.Sampling; s != nil {
+		switch {
+		case s.EveryN < 0:
+			return fieldError("sampling.every_n", "must not be negative")
+		case s.Probability < 0 || s.Probability > 1:
+			return fieldError("sampling.probability", "must be between 0 and 1")
+		}
+		if err := validDuration("sampling.reset_interval", s.ResetInterval); err != nil {
+			return err
+		}
+		if err := validLevel("sampling.level", s.Level); err != nil {
+			return err
+		}
+	}
+	for i, key := range d.Redact {
+		if key == "" {
+			return fieldError(fmt.Sprintf("redact[%d]", i), "must not be empty")
+		}
+	}
+	return nil
+}
+
+// validate checks the output configuration.
+func (o OutputConfig) validate(field string) error {
+	switch o.Format {
+	case OutputConsole, OutputJSON, OutputLogfmt:
+		if o.Network != "" || o.Address != "" {
+			return fieldError(field+".address", "not supported by the %s format", o.Format)
+		}
+	case OutputSyslog, OutputJournald, OutputGELF:
+		if o.Target != "" {
+			return fieldError(field+".target", "not supported by the %s format", o.Format)
+		}
+		if o.Format == OutputJournald && (o.Network != "" || o.Address != "") {
+			return fieldError(field+".address", "not supported by the %s format", o.Format)
+		}
+		if o.Format == OutputGELF && (o.Network == "" || o.Address == "") {
+			return fieldError(field+".address", "network and address are required by the %s format", o.Format)
+		}
+	case "":
+		return fieldError(field+".format", "is required")
+	default:
+		return fieldError(field+".format", "%q is not a valid log output", o.Format)
+	}
+	if err := validLevel(field+".level", o.Level); err != nil {
+		return err
+	}
+	if (o.MaxSize != 0 || o.MaxAge != "" || o.MaxBackups != 0) && !isFile(o) {
+		return fieldError(field+".target", "rotation requires a file target")
+	}
+	switch {
+	case o.MaxSize < 0:
+		return fieldError(field+".max_size", "must not be negative")
+	case o.MaxBackups < 0:
+		return fieldError(field+".max_backups", "must not be negative")
+	}
+	return validDuration(field+".max_age", o.MaxAge)
+}
+
+// isFile reports whether the output writes to a file.
+func isFile(o OutputConfig) bool {
+	switch o.Format {
+	case OutputConsole, OutputJSON, OutputLogfmt:
+		return o.Target != "" && o.Target != TargetStdout && o.Target != TargetStderr
+	default:
+		return false
+	}
+}
+
+func validLevel(field, level string) error {
+	if level == "" {
+		return nil
+	}
+	if _, err := telemetry.ParseLevel(level); err != nil {
+		return &FieldError{Field: field, Err: err}
+	}
+	return nil
+}
+
+func validDuration(field, d string) error {
+	if d == "" {
+		return nil
+	}
+	v, err := time.ParseDuration(d)
+	if err != nil {
+		return &FieldError{Field: field, Err: err}
+	}
+	if v < 0 {
+		return fieldError(field, "must not be negative")
+	}
+	return nil
+}
+
+// parseLevel parses a validated level, returning def if omitted.
+func parseLevel(level string, def telemetry.Level) telemetry.Level {
+	if level == "" {
+		return def
+	}
+	lvl, _ := telemetry.ParseLevel(level)
+	return lvl
+}
+
+// parseDuration parses a validated duration.
+func parseDuration(d string) time.Duration {
+	v, _ := time.ParseDuration(d)
+	return v
+}
+
+// sortedPatterns returns the scope patterns in order of precedence, with the
+// patterns applied last taking precedence: patterns holding wildcards before
+// literal names, shorter before longer.
+func sortedPatterns(scopes map[string]string) []string {
+	patterns := make([]string, 0, len(scopes))
+	for p := range scopes {
+		patterns = append(patterns, p)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		wi, wj := strings.ContainsAny(patterns[i], "*?["), strings.ContainsAny(patterns[j], "*?[")
+		if wi != wj {
+			return wi
+		}
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) < len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	return patterns
+}
+
+// levels returns the levels of the Document in the form accepted by
+// scope.SetLevels.
+func (d Document) levels() string {
+	entries := []string{parseLevel(d.Level, telemetry.LevelInfo).String()}
+	for _, p := range sortedPatterns(d.Scopes) {
+		entries = append(entries, p+"="+d.Scopes[p])
+	}
+	return strings.Join(entries, ",")
+}
+
+// ApplyDocument validates the Document, builds its outputs and applies it,
+// replacing the levels and outputs of the Pipeline. Nothing is applied if the
+// Document is invalid or an output fails to open. Outputs of a previously
+// applied Document are closed.
+func (p *Pipeline) ApplyDocument(d Document) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	emit, closers, err := p.build(d)
+	if err != nil {
+		return err
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if err = p.apply(d.levels(), emit, closers); err != nil {
+		closeAll(closers)
+		return err
+	}
+	return nil
+}
+
+// build returns the emitter of the Document and the outputs to close once it
+// is replaced.
+func (p *Pipeline) build(d Document) (function.EmitErr, []io.Closer, error) {
+	var (
+		emits   []function.EmitErr
+		closers []io.Closer
+	)
+	for i, o := range d.Outputs {
+		emit, c, err := p.buildOutput(o)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, &FieldError{Field: fmt.Sprintf("outputs[%d]", i), Err: err}
+		}
+		if c != nil {
+			closers = append(closers, c)
+		}
+		if o.Level != "" {
+			emit = function.LevelFilterErr(parseLevel(o.Level, telemetry.LevelTrace), emit)
+		}
+		emits = append(emits, emit)
+	}
+
+	var emit function.EmitErr
+	switch len(emits) {
+	case 0:
+		emit = emitter.Console(p.w, p.opts...)
+	case 1:
+		emit = emits[0]
+	default:
+		emit = function.TeeErr(emits...)
+	}
+	if s := d.Sampling; s != nil {
+		opts := []sampling.Option{sampling.WithEveryN(s.EveryN)}
+		if s.Probability > 0 {
+			opts = append(opts, sampling.WithProbability(s.Probability))
+		}
+		if s.ResetInterval != "" {
+			opts = append(opts, sampling.WithResetInterval(parseDuration(s.ResetInterval)))
+		}
+		if s.Level != "" {
+			opts = append(opts, sampling.WithLevel(parseLevel(s.Level, telemetry.LevelTrace)))
+		}
+		sampler := sampling.New(opts...)
+		emit = sampler.EmitErr(emit)
+		// the sampler reports its summary to the outputs, so close it first.
+		closers = append([]io.Closer{sampler}, closers...)
+	}
+	if len(d.Redact) > 0 {
+		emit = function.RedactEmitErr(emit, redact.New(redact.Keys(d.Redact...)))
+	}
+	return emit, closers, nil
+}
+
+// buildOutput opens the output and returns its emitter.
+func (p *Pipeline) buildOutput(o OutputConfig) (function.EmitErr, io.Closer, error) {
+	switch o.Format {
+	case OutputSyslog:
+		w, err := emitter.DialSyslog(o.Network, o.Address)
+		if err != nil {
+			return nil, nil, err
+		}
+		return emitter.Syslog(w, p.opts...), w, nil
+	case OutputJournald:
+		w, err := emitter.DialJournald()
+		if err != nil {
+			return nil, nil, err
+		}
+		return emitter.Journald(w, p.opts...), w, nil
+	case OutputGELF:
+		w, err := emitter.DialGELF(o.Network, o.Address, emitter.GELFCompressGzip)
+		if err != nil {
+			return nil, nil, err
+		}
+		return emitter.GELF(w, p.opts...), w, nil
+	}
+
+	var (
+		w io.Writer
+		c io.Closer
+	)
+	switch o.Target {
+	case "":
+		w = p.w
+	case TargetStdout:
+		w = os.Stdout
+	case TargetStderr:
+		w = os.Stderr
+	default:
+		opts := append([]emitter.Option{
+			emitter.WithMaxSize(o.MaxSize),
+			emitter.WithMaxAge(parseDuration(o.MaxAge)),
+			emitter.WithMaxBackups(o.MaxBackups),
+		}, p.opts...)
+		f, err := emitter.NewRotatingFile(o.Target, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		w, c = f, f
+	}
+	emit, err := p.output(o.Format, w)
+	if err != nil {
+		if c != nil {
+			_ = c.Close()
+		}
+		return nil, nil, err
+	}
+	return emit, c, nil
+}
+
+// closeAll closes the outputs.
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		_ = c.Close()
+	}
+}
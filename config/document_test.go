@@ -0,0 +1,159 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/scope"
+)
+
+func TestParseDocument(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		field string
+	}{
+		{"valid", `{"level":"debug","scopes":{"doc*":"trace"},"outputs":[{"format":"json","level":"info"},` +
+			`{"format":"logfmt","target":"/tmp/app.log","max_size":1024,"max_age":"24h"}],` +
+			`"sampling":{"every_n":10},"redact":["password"]}`, ""},
+		{"level", `{"level":"verbose"}`, "level"},
+		{"scope pattern", `{"scopes":{"a=b":"debug"}}`, "scopes[a=b]"},
+		{"scope level", `{"scopes":{"a":"verbose"}}`, "scopes[a]"},
+		{"format", `{"outputs":[{"format":"json"},{"format":"xml"}]}`, "outputs[1].format"},
+		{"missing format", `{"outputs":[{}]}`, "outputs[0].format"},
+		{"gelf address", `{"outputs":[{"format":"gelf"}]}`, "outputs[0].address"},
+		{"syslog target", `{"outputs":[{"format":"syslog","target":"stdout"}]}`, "outputs[0].target"},
+		{"rotation", `{"outputs":[{"format":"json","target":"stdout","max_size":1}]}`, "outputs[0].target"},
+		{"max age", `{"outputs":[{"format":"json","target":"app.log","max_age":"1 day"}]}`, "outputs[0].max_age"},
+		{"probability", `{"sampling":{"probability":2}}`, "sampling.probability"},
+		{"redact", `{"redact":["a",""]}`, "redact[1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDocument([]byte(tt.doc))
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("expected FieldError, have %v", err)
+			}
+			if fieldErr.Field != tt.field {
+				t.Fatalf("expected %s to match %s", fieldErr.Field, tt.field)
+			}
+		})
+	}
+
+	// the path of type errors depends on the encoding/json version.
+	_, err := ParseDocument([]byte(`{"outputs":[{"format":"json","level":1}]}`))
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || !strings.HasPrefix(fieldErr.Field, "outputs") ||
+		!strings.HasSuffix(fieldErr.Field, ".level") {
+		t.Fatalf("expected FieldError for outputs level, have %v", err)
+	}
+	if _, err = ParseDocument([]byte(`{"unknown":true}`)); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+func TestApplyDocument(t *testing.T) {
+	var (
+		out  bytes.Buffer
+		file = filepath.Join(t.TempDir(), "app.log")
+		p    = New(&out, emitter.WithTimeKey(""), emitter.WithCallerKey(""))
+		sc   = scope.Register("document-a", "test scope")
+	)
+	defer func() { _ = p.Close() }()
+
+	err := p.ApplyDocument(Document{
+		Level:  "debug",
+		Scopes: map[string]string{"document-*": "error", "document-a": "trace"},
+		Outputs: []OutputConfig{
+			{Format: OutputLogfmt, Level: "info"},
+			{Format: OutputJSON, Target: file},
+		},
+		Redact: []string{"password"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sc.Level() != telemetry.LevelTrace {
+		t.Errorf("expected scope level %v, have %v", telemetry.LevelTrace, sc.Level())
+	}
+	p.Logger().Debug("debug")
+	p.Logger().Info("info", "password", "secret")
+
+	if want := "level=info msg=info password=[REDACTED]\n"; out.String() != want {
+		t.Errorf("expected %q to match %q", out.String(), want)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"level":"debug","msg":"debug"}` + "\n" + `{"level":"info","msg":"info","password":"[REDACTED]"}` + "\n"
+	if string(b) != want {
+		t.Errorf("expected %q to match %q", b, want)
+	}
+
+	// invalid documents leave the pipeline untouched.
+	if err = p.ApplyDocument(Document{Level: "verbose"}); err == nil {
+		t.Fatal("expected error for invalid document")
+	}
+	if p.Logger().Level() != telemetry.LevelDebug {
+		t.Errorf("expected level %v, have %v", telemetry.LevelDebug, p.Logger().Level())
+	}
+}
+
+func TestApplyDocumentSampling(t *testing.T) {
+	var out bytes.Buffer
+	p := New(&out, emitter.WithTimeKey(""), emitter.WithCallerKey(""))
+
+	err := p.ApplyDocument(Document{
+		Outputs:  []OutputConfig{{Format: OutputLogfmt}},
+		Sampling: &SamplingConfig{EveryN: 2},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		p.Logger().Info("text")
+	}
+
+	if want := "level=info msg=text\nlevel=info msg=text sample_rate=2\n"; out.String() != want {
+		t.Errorf("expected %q to match %q", out.String(), want)
+	}
+
+	// replacing the document closes the sampler, reporting its summary.
+	out.Reset()
+	if err = p.ApplyDocument(Document{Outputs: []OutputConfig{{Format: OutputLogfmt}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "level=info msg=\"sampler closed\" emitted=2 dropped=2\n"; out.String() != want {
+		t.Errorf("expected %q to match %q", out.String(), want)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// Flag names registered by RegisterFlags.
+const (
+	FlagLevel      = "log-level"
+	FlagScopeLevel = "log-scope-level"
+	FlagOutput     = "log-output"
+)
+
+// NewLevelValue returns a flag.Value setting the telemetry.Level p points to.
+// Its default is the current value of p.
+func NewLevelValue(p *telemetry.Level) flag.Value {
+	return &levelValue{p: p}
+}
+
+// NewScopeLevelsValue returns a flag.Value appending scope level settings in
+// the form "pattern=level" to the slice p points to, as accepted by
+// scope.SetLevels. The flag can be repeated and takes comma separated lists.
+func NewScopeLevelsValue(p *[]string) flag.Value {
+	return &scopeLevelsValue{p: p}
+}
+
+// NewOutputValue returns a flag.Value setting the output format p points to,
+// one of "console", "json" or "logfmt". Its default is the current value of
+// p.
+func NewOutputValue(p *string) flag.Value {
+	return &outputValue{p: p}
+}
+
+// RegisterFlags registers the -log-level, -log-scope-level and -log-output
+// flags on the FlagSet, updating the Settings when parsed. The current
+// Settings, e.g. as returned by FromEnv, serve as defaults, so the flags
+// override them:
+//
+//	s := config.FromEnv()
+//	config.RegisterFlags(flag.CommandLine, &s)
+//	flag.Parse()
+//	err := pipeline.Apply(s)
+func RegisterFlags(fs *flag.FlagSet, s *Settings) {
+	f := &settingsFlags{s: s, level: telemetry.LevelInfo}
+	for _, entry := range strings.Split(s.Levels, ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+		case strings.ContainsAny(entry, "=:"):
+			f.scopes = append(f.scopes, entry)
+		default:
+			if lvl, err := telemetry.ParseLevel(entry); err == nil {
+				f.level = lvl
+			}
+		}
+	}
+
+	fs.Var(&levelValue{p: &f.level, update: f.update}, FlagLevel,
+		"default log level: none, error, warn, info, debug or trace")
+	fs.Var(&scopeLevelsValue{p: &f.scopes, update: f.update}, FlagScopeLevel,
+		"log level of matching scopes as pattern=level, e.g. grpc*=debug; can be repeated")
+	fs.Var(&outputValue{p: &s.Output}, FlagOutput,
+		"log output format: console, json or logfmt")
+}
+
+// settingsFlags composes the Levels of the Settings from the default level
+// and scope level flags, so the default level never overrides scope levels.
+type settingsFlags struct {
+	s      *Settings
+	level  telemetry.Level
+	scopes []string
+}
+
+func (f *settingsFlags) update() {
+	f.s.Levels = strings.Join(append([]string{f.level.String()}, f.scopes...), ",")
+}
+
+type levelValue struct {
+	p      *telemetry.Level
+	update func()
+}
+
+// String implements flag.Value.
+func (v *levelValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return v.p.String()
+}
+
+// Set implements flag.Value.
+func (v *levelValue) Set(s string) error {
+	lvl, err := telemetry.ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	*v.p = lvl
+	if v.update != nil {
+		v.update()
+	}
+	return nil
+}
+
+type scopeLevelsValue struct {
+	p      *[]string
+	update func()
+}
+
+// String implements flag.Value.
+func (v *scopeLevelsValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return strings.Join(*v.p, ",")
+}
+
+// Set implements flag.Value.
+func (v *scopeLevelsValue) Set(s string) error {
+	var entries []string
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		i := strings.IndexAny(entry, "=:")
+		if i < 0 {
+			return fmt.Errorf("%q is not a valid scope level, expected pattern=level", entry)
+		}
+		if _, err := path.Match(entry[:i], ""); err != nil {
+			return fmt.Errorf("invalid scope pattern in %q: %w", entry, err)
+		}
+		if _, err := telemetry.ParseLevel(entry[i+1:]); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	*v.p = append(*v.p, entries...)
+	if v.update != nil {
+		v.update()
+	}
+	return nil
+}
+
+type outputValue struct {
+	p *string
+}
+
+// String implements flag.Value.
+func (v *outputValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+// Set implements flag.Value.
+func (v *outputValue) Set(s string) error {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case OutputConsole, OutputJSON, OutputLogfmt:
+		*v.p = s
+		return nil
+	default:
+		return fmt.Errorf("%q is not a valid log output", s)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		args     []string
+		expected Settings
+	}{
+		{"defaults", Settings{Levels: "debug,a=warn", Output: "json"}, nil,
+			Settings{Levels: "debug,a=warn", Output: "json"}},
+		{"override", Settings{Levels: "debug,a=warn", Output: "json"},
+			[]string{"-log-scope-level", "b*=trace,c:error", "--log-level=error", "-log-output", "LOGFMT"},
+			Settings{Levels: "error,a=warn,b*=trace,c:error", Output: "logfmt"}},
+		{"repeated", Settings{},
+			[]string{"-log-scope-level", "a=debug", "-log-scope-level", "a=error"},
+			Settings{Levels: "info,a=debug,a=error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			s := tt.settings
+			RegisterFlags(fs, &s)
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s != tt.expected {
+				t.Fatalf("want: %+v\nhave: %+v", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestRegisterFlagsInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"-log-level", "verbose"},
+		{"-log-scope-level", "debug"},
+		{"-log-scope-level", "[=debug"},
+		{"-log-scope-level", "a=verbose"},
+		{"-log-output", "xml"},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		var s Settings
+		RegisterFlags(fs, &s)
+
+		if err := fs.Parse(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestLevelValue(t *testing.T) {
+	lvl := telemetry.LevelWarn
+	v := NewLevelValue(&lvl)
+	if v.String() != "warn" {
+		t.Fatalf("expected %s to match warn", v.String())
+	}
+	if err := v.Set(" DEBUG "); err != nil || lvl != telemetry.LevelDebug {
+		t.Fatalf("unexpected result: %v, %v", lvl, err)
+	}
+}
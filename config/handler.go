@@ -0,0 +1,100 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/scope"
+)
+
+// maxLevelRequestBytes limits the size of level change requests.
+const maxLevelRequestBytes = 1 << 20
+
+// ScopeLevel holds the level of a registered scope as listed by LevelHandler.
+type ScopeLevel struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Level       telemetry.Level `json:"level"`
+}
+
+// LevelHandler returns an http.Handler managing the levels of the registered
+// scopes at runtime. GET requests list the scopes with their current level as
+// a JSON array of ScopeLevel objects, sorted by name. PUT and POST requests
+// change the levels of the scopes found in the JSON object body, mapping scope
+// names onto levels, e.g. {"grpc-server":"debug"}, and respond with the
+// updated list. Nothing is changed if the body holds an invalid level or an
+// unknown scope.
+//
+// The handler allows changing the verbosity of a live instance, so only
+// expose it on an administrative endpoint.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut, http.MethodPost:
+			if status, err := setLevels(r); err != nil {
+				http.Error(w, err.Error(), status)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(scopeLevels())
+	})
+}
+
+// setLevels applies the levels found in the request body, returning the HTTP
+// status code to respond with on failure.
+func setLevels(r *http.Request) (int, error) {
+	var levels map[string]telemetry.Level
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxLevelRequestBytes)).Decode(&levels); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	scopes := make(map[string]scope.Scope, len(levels))
+	for name := range levels {
+		sc, ok := scope.Find(name)
+		if !ok {
+			return http.StatusNotFound, fmt.Errorf("%w: %q", scope.ErrUnknownScope, name)
+		}
+		scopes[name] = sc
+	}
+	for name, sc := range scopes {
+		sc.SetLevel(levels[name])
+	}
+	return http.StatusOK, nil
+}
+
+// scopeLevels returns the registered scopes sorted by name.
+func scopeLevels() []ScopeLevel {
+	var (
+		scopes = scope.List()
+		levels = make([]ScopeLevel, 0, len(scopes))
+	)
+	for name, sc := range scopes {
+		levels = append(levels, ScopeLevel{Name: name, Description: sc.Description(), Level: sc.Level()})
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Name < levels[j].Name })
+	return levels
+}
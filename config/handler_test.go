@@ -0,0 +1,85 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/scope"
+)
+
+func TestLevelHandler(t *testing.T) {
+	a := scope.Register("handler-a", "scope a")
+	b := scope.Register("handler-b", "scope b")
+	a.SetLevel(telemetry.LevelInfo)
+	b.SetLevel(telemetry.LevelInfo)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		levelA telemetry.Level
+		levelB telemetry.Level
+	}{
+		{"list", http.MethodGet, "", http.StatusOK, telemetry.LevelInfo, telemetry.LevelInfo},
+		{"put", http.MethodPut, `{"handler-a":"debug"}`, http.StatusOK, telemetry.LevelDebug, telemetry.LevelInfo},
+		{"post", http.MethodPost, `{"handler-a":"warn","Handler-B":"trace"}`, http.StatusOK,
+			telemetry.LevelWarn, telemetry.LevelTrace},
+		{"invalid level", http.MethodPut, `{"handler-a":"verbose"}`, http.StatusBadRequest,
+			telemetry.LevelWarn, telemetry.LevelTrace},
+		{"unknown scope", http.MethodPut, `{"handler-a":"info","unknown":"info"}`, http.StatusNotFound,
+			telemetry.LevelWarn, telemetry.LevelTrace},
+		{"method", http.MethodDelete, "", http.StatusMethodNotAllowed, telemetry.LevelWarn, telemetry.LevelTrace},
+	}
+
+	h := LevelHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, have %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if a.Level() != tt.levelA || b.Level() != tt.levelB {
+				t.Fatalf("want: %v, %v\nhave: %v, %v", tt.levelA, tt.levelB, a.Level(), b.Level())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var levels []ScopeLevel
+			if err := json.Unmarshal(rec.Body.Bytes(), &levels); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var found []ScopeLevel
+			for _, l := range levels {
+				if strings.HasPrefix(l.Name, "handler-") {
+					found = append(found, l)
+				}
+			}
+			want := []ScopeLevel{{"handler-a", "scope a", tt.levelA}, {"handler-b", "scope b", tt.levelB}}
+			if len(found) != 2 || found[0] != want[0] || found[1] != want[1] {
+				t.Fatalf("want: %v\nhave: %v", want, found)
+			}
+		})
+	}
+}
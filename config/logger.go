@@ -0,0 +1,160 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Config declares a complete logging setup, typically decoded from a JSON or
+// YAML document, from which FromConfig builds a Logger. Its field names match
+// the keys of JSON and YAML documents.
+type Config struct {
+	// Level holds the level of the Logger, telemetry.LevelInfo if omitted.
+	// It is decoded from its text representation, e.g. "debug".
+	Level *telemetry.Level `json:"level,omitempty" yaml:"level,omitempty"`
+	// Output holds the destination of the log lines. If its format is
+	// omitted, log lines are written to the FromConfig writer in console
+	// format.
+	Output OutputConfig `json:"output,omitempty" yaml:"output,omitempty"`
+	// Sampling configures head sampling of log lines, if set.
+	Sampling *SamplingConfig `json:"sampling,omitempty" yaml:"sampling,omitempty"`
+	// Redact lists the keys of key-value pairs whose values are replaced by
+	// redact.Redacted. Keys are matched case-insensitively.
+	Redact []string `json:"redact,omitempty" yaml:"redact,omitempty"`
+	// RequiredKeys lists the keys every log line must hold. See FromConfig.
+	RequiredKeys []string `json:"required_keys,omitempty" yaml:"required_keys,omitempty"`
+}
+
+// Validate checks the Config, returning a FieldError for the first invalid
+// field.
+func (c Config) Validate() error {
+	if err := c.document().Validate(); err != nil {
+		var fErr *FieldError
+		if errors.As(err, &fErr) && strings.HasPrefix(fErr.Field, "outputs[0]") {
+			return &FieldError{Field: "output" + strings.TrimPrefix(fErr.Field, "outputs[0]"), Err: fErr.Err}
+		}
+		return err
+	}
+	for i, key := range c.RequiredKeys {
+		if key == "" {
+			return fieldError(fmt.Sprintf("required_keys[%d]", i), "must not be empty")
+		}
+	}
+	return nil
+}
+
+// document returns the Document describing the pipeline of the Config.
+func (c Config) document() Document {
+	d := Document{Sampling: c.Sampling, Redact: c.Redact}
+	if c.Level != nil {
+		d.Level = c.Level.String()
+	}
+	if c.Output != (OutputConfig{}) {
+		d.Outputs = []OutputConfig{c.Output}
+	}
+	return d
+}
+
+// FromConfig validates the Config and returns a Logger writing log lines as
+// configured, together with a Closer flushing the sampler summary and closing
+// the opened output. Log lines are written to w if the Config sets no output
+// target. Unlike a Pipeline, the levels of the registered scopes are left
+// untouched. The emitter options apply to all output formats.
+//
+// Log lines lacking any of the RequiredKeys are written with the missing keys
+// set to "(MISSING)" and reported as failure to emit, see
+// function.NewLoggerErr.
+func FromConfig(cfg Config, w io.Writer, opts ...emitter.Option) (telemetry.Logger, io.Closer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	emit, closers, err := New(w, opts...).build(cfg.document())
+	if err != nil {
+		var fErr *FieldError
+		if errors.As(err, &fErr) {
+			err = &FieldError{Field: "output", Err: fErr.Err}
+		}
+		return nil, nil, err
+	}
+	if len(cfg.RequiredKeys) > 0 {
+		emit = requireKeys(emit, cfg.RequiredKeys)
+	}
+
+	logger := function.NewLoggerErr(emit, 0, function.WithCaller())
+	level := telemetry.LevelInfo
+	if cfg.Level != nil {
+		level = *cfg.Level
+	}
+	logger.SetLevel(level)
+	return logger, closerFunc(func() error {
+		closeAll(closers)
+		return nil
+	}), nil
+}
+
+// requireKeys completes log lines lacking any of the keys, returning an error
+// naming the missing keys.
+func requireKeys(emit function.EmitErr, keys []string) function.EmitErr {
+	return func(level telemetry.Level, msg string, err error, values function.Values, callerSkip int) error {
+		var missing []string
+		for _, key := range keys {
+			if !hasKey(values, key) {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			// the key-value pairs passed to the logging method are owned by
+			// the caller.
+			kvs := append(make([]interface{}, 0, len(values.FromMethod)+2*len(missing)), values.FromMethod...)
+			for _, key := range missing {
+				kvs = append(kvs, key, "(MISSING)")
+			}
+			values.FromMethod = kvs
+		}
+		if emitErr := emit(level, msg, err, values, callerSkip+1); emitErr != nil {
+			return emitErr
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("log line %q lacks required keys: %s", msg, strings.Join(missing, ", "))
+		}
+		return nil
+	}
+}
+
+// hasKey reports whether the log line holds the key.
+func hasKey(values function.Values, key string) bool {
+	for _, kvs := range [][]interface{}{values.FromContext, values.FromLogger, values.FromMethod} {
+		for i := 0; i < len(kvs); i += 2 {
+			if k, ok := kvs[i].(string); ok && k == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// closerFunc adapts a function to io.Closer.
+type closerFunc func() error
+
+// Close implements io.Closer.
+func (f closerFunc) Close() error { return f() }
@@ -0,0 +1,131 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestFromConfig(t *testing.T) {
+	var cfg Config
+	err := json.Unmarshal([]byte(`{"level":"debug","output":{"format":"logfmt"},`+
+		`"sampling":{"every_n":2},"redact":["password"],"required_keys":["service"]}`), &cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	logger, closer, err := FromConfig(cfg, &out, emitter.WithTimeKey(""), emitter.WithCallerKey(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if logger.Level() != telemetry.LevelDebug {
+		t.Fatalf("want: %v\nhave: %v", telemetry.LevelDebug, logger.Level())
+	}
+
+	logger = logger.With("service", "api")
+	logger.Debug("login", "password", "secret")
+	logger.Debug("login", "password", "secret")
+	logger.Debug("login", "password", "secret")
+	if err = closer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "level=debug msg=login service=api password=[REDACTED]\n" +
+		"level=debug msg=login service=api password=[REDACTED] sample_rate=2\n" +
+		"level=info msg=\"sampler closed\" emitted=2 dropped=1\n"
+	if out.String() != want {
+		t.Fatalf("want: %q\nhave: %q", want, out.String())
+	}
+}
+
+func TestFromConfigDefaults(t *testing.T) {
+	var out bytes.Buffer
+	logger, closer, err := FromConfig(Config{}, &out, emitter.WithTimeKey(""), emitter.WithCallerKey(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	logger.Debug("debug")
+	logger.Info("info")
+
+	if want := "INFO  info\n"; out.String() != want {
+		t.Fatalf("want: %q\nhave: %q", want, out.String())
+	}
+}
+
+func TestFromConfigInvalid(t *testing.T) {
+	none := telemetry.LevelNone
+	tests := []struct {
+		name  string
+		cfg   Config
+		field string
+	}{
+		{"format", Config{Output: OutputConfig{Format: "xml"}}, "output.format"},
+		{"target", Config{Output: OutputConfig{Format: OutputGELF}}, "output.address"},
+		{"sampling", Config{Level: &none, Sampling: &SamplingConfig{Probability: 2}}, "sampling.probability"},
+		{"redact", Config{Redact: []string{""}}, "redact[0]"},
+		{"required keys", Config{RequiredKeys: []string{"service", ""}}, "required_keys[1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := FromConfig(tt.cfg, &bytes.Buffer{})
+			var fErr *FieldError
+			if !errors.As(err, &fErr) {
+				t.Fatalf("expected FieldError, have %v", err)
+			}
+			if fErr.Field != tt.field {
+				t.Fatalf("want: %v\nhave: %v", tt.field, fErr.Field)
+			}
+		})
+	}
+}
+
+func TestRequireKeys(t *testing.T) {
+	var have []interface{}
+	emit := requireKeys(func(_ telemetry.Level, _ string, _ error, values function.Values, _ int) error {
+		have = values.FromMethod
+		return nil
+	}, []string{"service", "request"})
+
+	err := emit(telemetry.LevelInfo, "text", nil, function.Values{
+		FromLogger: []interface{}{"service", "api"},
+		FromMethod: []interface{}{"key", "value"},
+	}, 0)
+	if want := `log line "text" lacks required keys: request`; err == nil || err.Error() != want {
+		t.Fatalf("want: %v\nhave: %v", want, err)
+	}
+	if want := []interface{}{"key", "value", "request", "(MISSING)"}; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+
+	err = emit(telemetry.LevelInfo, "text", nil, function.Values{
+		FromContext: []interface{}{"request", "r1"},
+		FromLogger:  []interface{}{"service", "api"},
+	}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
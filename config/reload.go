@@ -0,0 +1,91 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// FromFile returns the Settings found in the JSON document at path, e.g.
+// {"levels":"info,grpc*=debug","output":"json"}.
+func FromFile(path string) (Settings, error) {
+	var s Settings
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err = json.Unmarshal(b, &s); err != nil {
+		return s, fmt.Errorf("invalid logging configuration %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ReloadOnSignal applies the Settings returned by load each time one of the
+// signals is received, syscall.SIGHUP if none are provided. Settings failing
+// to load or validate are reported on the Logger of the Pipeline and leave the
+// current configuration in place. The returned function stops the reloading.
+//
+//	stop := pipeline.ReloadOnSignal(func() (config.Settings, error) {
+//		return config.FromEnv(), nil
+//	})
+//	defer stop()
+func (p *Pipeline) ReloadOnSignal(load func() (Settings, error), sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	var (
+		ch   = make(chan os.Signal, 1)
+		done = make(chan struct{})
+		once sync.Once
+	)
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				p.reload(load)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// reload loads and applies the Settings.
+func (p *Pipeline) reload(load func() (Settings, error)) {
+	s, err := load()
+	if err == nil {
+		err = p.Apply(s)
+	}
+	if err != nil {
+		p.logger.Error("failed to reload logging configuration", err)
+		return
+	}
+	p.logger.Info("reloaded logging configuration", "levels", s.Levels, "output", s.Output)
+}
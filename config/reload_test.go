@@ -0,0 +1,92 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry/emitter"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	var (
+		out  syncBuffer
+		p    = New(&out, emitter.WithTimeKey(""), emitter.WithCallerKey(""))
+		mtx  sync.Mutex
+		next = Settings{Levels: "debug", Output: "logfmt"}
+		err  error
+	)
+	stop := p.ReloadOnSignal(func() (Settings, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return next, err
+	}, syscall.SIGUSR1)
+	defer stop()
+
+	wait := func(substr string) {
+		t.Helper()
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(out.String(), substr) {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %q in output, have %q", substr, out.String())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	wait(`level=info msg="reloaded logging configuration" levels=debug output=logfmt`)
+	if lvl := p.Logger().Level(); lvl.String() != "debug" {
+		t.Fatalf("expected level debug, have %v", lvl)
+	}
+
+	mtx.Lock()
+	next, err = Settings{}, errors.New("unreadable")
+	mtx.Unlock()
+	wait(`level=error msg="failed to reload logging configuration" error=unreadable`)
+	if lvl := p.Logger().Level(); lvl.String() != "debug" {
+		t.Fatalf("expected level debug, have %v", lvl)
+	}
+}
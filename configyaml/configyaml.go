@@ -0,0 +1,55 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package configyaml decodes config.Document YAML documents, describing a
+// complete logging pipeline to apply with config.Pipeline.ApplyDocument.
+package configyaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/basvanbeek/telemetry/config"
+)
+
+// Parse decodes and validates the YAML Document. Unknown fields are rejected.
+// Decoding errors hold the line number of the offending field, validation
+// errors are returned as *config.FieldError.
+func Parse(b []byte) (config.Document, error) {
+	var d config.Document
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&d); err != nil && !errors.Is(err, io.EOF) {
+		return d, err
+	}
+	return d, d.Validate()
+}
+
+// Load reads, decodes and validates the YAML Document at path.
+func Load(path string) (config.Document, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return config.Document{}, err
+	}
+	d, err := Parse(b)
+	if err != nil {
+		return d, fmt.Errorf("invalid logging configuration %s: %w", path, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configyaml
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry/config"
+)
+
+const document = `
+level: debug
+scopes:
+  grpc*: warn
+  storage: error
+outputs:
+  - format: json
+    target: /var/log/app.log
+    max_size: 1048576
+    max_age: 24h
+    max_backups: 3
+  - format: console
+    level: info
+sampling:
+  every_n: 100
+  level: debug
+redact: [password, token]
+`
+
+func TestParse(t *testing.T) {
+	d, err := Parse([]byte(document))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := config.Document{
+		Level:  "debug",
+		Scopes: map[string]string{"grpc*": "warn", "storage": "error"},
+		Outputs: []config.OutputConfig{
+			{Format: "json", Target: "/var/log/app.log", MaxSize: 1 << 20, MaxAge: "24h", MaxBackups: 3},
+			{Format: "console", Level: "info"},
+		},
+		Sampling: &config.SamplingConfig{EveryN: 100, Level: "debug"},
+		Redact:   []string{"password", "token"},
+	}
+	if !reflect.DeepEqual(want, d) {
+		t.Fatalf("want: %+v\nhave: %+v", want, d)
+	}
+
+	if d, err = Parse(nil); err != nil || !reflect.DeepEqual(config.Document{}, d) {
+		t.Fatalf("unexpected result for empty document: %+v, %v", d, err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		expected string
+	}{
+		{"unknown field", "level: info\noutputs:\n  - format: json\n    colour: true\n", "line 4"},
+		{"type", "outputs:\n  - format: json\n    max_size: large\n", "line 3"},
+		{"validation", "outputs:\n  - format: json\n  - format: xml\n", "outputs[1].format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Fatalf("expected %v to contain %s", err, tt.expected)
+			}
+		})
+	}
+
+	var fieldErr *config.FieldError
+	if _, err := Parse([]byte("level: verbose\n")); !errors.As(err, &fieldErr) || fieldErr.Field != "level" {
+		t.Fatalf("expected FieldError for level, have %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.yaml")
+	if err := os.WriteFile(path, []byte("level: warn\n"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d, err := Load(path)
+	if err != nil || d.Level != "warn" {
+		t.Fatalf("unexpected result: %+v, %v", d, err)
+	}
+	if _, err = Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/basvanbeek/telemetry/configyaml
+
+go 1.21
+
+require (
+	github.com/basvanbeek/telemetry v0.2.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+// Work around for maintaining multiple go modules in the same repository
+// until go has better support for this. https://github.com/golang/go/issues/45713
+replace github.com/basvanbeek/telemetry => ../
@@ -0,0 +1,164 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+)
+
+// ContextLabels turns the key-value pairs found in Context with allowlisted
+// keys into metric label values, e.g. to split measurements by tenant or
+// region. It works with any MetricSink, also those ignoring the key-value
+// pairs found in Context when recording.
+type ContextLabels struct {
+	keys   []string
+	labels []Label
+
+	// limit, overflow and logger hold the cardinality limit configured
+	// through LimitCardinality.
+	limit    int
+	overflow string
+	logger   Logger
+}
+
+// NewContextLabels returns ContextLabels creating a Label on the provided
+// MetricSink for each key in the allowlist, using the key as Label name. If
+// limit is positive, each Metric records at most limit distinct combinations of
+// label values, as described by LimitCardinality, protecting the metrics
+// backend from unbounded cardinality when values are request derived.
+func NewContextLabels(ms MetricSink, limit int, keys ...string) *ContextLabels {
+	c := &ContextLabels{
+		keys:   append([]string(nil), keys...),
+		labels: make([]Label, 0, len(keys)),
+	}
+	for _, key := range keys {
+		c.labels = append(c.labels, ms.NewLabel(key))
+	}
+	return LimitCardinality(c, limit, "", nil)
+}
+
+// Labels returns the Labels in allowlist order, to be registered on a Metric
+// using WithLabels.
+func (c *ContextLabels) Labels() []Label {
+	return c.labels
+}
+
+// LabelValues returns Insert operations for the allowlisted key-value pairs
+// found in Context. Values are formatted using fmt.Sprint.
+// The cardinality limit is not applied, as it is tracked per Metric.
+func (c *ContextLabels) LabelValues(ctx context.Context) []LabelValue {
+	values, found, n := c.lookup(ctx)
+	if n == 0 {
+		return nil
+	}
+	labelValues := make([]LabelValue, 0, n)
+	for i, v := range values {
+		if found[i] {
+			labelValues = append(labelValues, c.labels[i].Insert(v))
+		}
+	}
+	return labelValues
+}
+
+// lookup returns the values of the allowlisted keys found in Context, in
+// allowlist order, and the number of keys found.
+func (c *ContextLabels) lookup(ctx context.Context) (values []string, found []bool, n int) {
+	keyValues := ResolveKeyValuesFromContext(ctx)
+	if len(keyValues) == 0 {
+		return nil, nil, 0
+	}
+	values, found = make([]string, len(c.keys)), make([]bool, len(c.keys))
+	for i, key := range c.keys {
+		// the last occurrence of a key wins, as when emitting log lines.
+		for j := len(keyValues) - len(keyValues)%2 - 2; j >= 0; j -= 2 {
+			if k, ok := keyValues[j].(string); ok && k == key {
+				values[i], found[i] = fmt.Sprint(keyValues[j+1]), true
+				n++
+				break
+			}
+		}
+	}
+	return values, found, n
+}
+
+// limitedLabelValues returns the LabelValues of the allowlisted keys found in
+// Context, applying the cardinality limit of the Metric. Collapsed
+// combinations use Upsert operations, so the overflow value also replaces
+// label values the MetricSink derives from Context itself.
+func (c *ContextLabels) limitedLabelValues(ctx context.Context, m Metric, card *cardinality) []LabelValue {
+	if card == nil {
+		return c.LabelValues(ctx)
+	}
+	values, found, n := c.lookup(ctx)
+	if n == 0 {
+		return nil
+	}
+	allowed, first := card.allow(combination(values, found), c.limit)
+	if first && c.logger != nil {
+		c.logger.Warn("metric label cardinality limit reached, collapsing new combinations",
+			"metric", m.Name(), "limit", c.limit, "overflow", c.overflow, NoMetric, true)
+	}
+	labelValues := make([]LabelValue, 0, n)
+	for i, v := range values {
+		switch {
+		case !found[i]:
+		case allowed:
+			labelValues = append(labelValues, c.labels[i].Insert(v))
+		default:
+			labelValues = append(labelValues, c.labels[i].Upsert(c.overflow))
+		}
+	}
+	return labelValues
+}
+
+// Metric returns the provided Metric, which must be created with the Labels
+// registered, adding the LabelValues of the Context passed to RecordContext
+// before recording. As they are Insert operations, LabelValues for the same
+// Labels found in Context or added through With take precedence, except for
+// the overflow values of combinations collapsed by the cardinality limit.
+func (c *ContextLabels) Metric(m Metric) Metric {
+	cm := contextLabelsMetric{Metric: m, labels: c}
+	if c.limit > 0 {
+		cm.cardinality = &cardinality{seen: make(map[string]struct{})}
+	}
+	return cm
+}
+
+type contextLabelsMetric struct {
+	Metric
+	labels      *ContextLabels
+	cardinality *cardinality
+}
+
+func (m contextLabelsMetric) RecordContext(ctx context.Context, value float64) {
+	if values := m.labels.limitedLabelValues(ctx, m.Metric, m.cardinality); len(values) > 0 {
+		m.Metric.With(values...).RecordContext(ctx, value)
+		return
+	}
+	m.Metric.RecordContext(ctx, value)
+}
+
+func (m contextLabelsMetric) RecordLeveled(ctx context.Context, value float64, level Level) {
+	if values := m.labels.limitedLabelValues(ctx, m.Metric, m.cardinality); len(values) > 0 {
+		recordLeveled(ctx, m.Metric.With(values...), value, level)
+		return
+	}
+	recordLeveled(ctx, m.Metric, value, level)
+}
+
+func (m contextLabelsMetric) With(labelValues ...LabelValue) Metric {
+	return contextLabelsMetric{Metric: m.Metric.With(labelValues...), labels: m.labels, cardinality: m.cardinality}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type insertOp struct {
+	name, value string
+}
+
+type upsertOp struct {
+	name, value string
+}
+
+type opLabel string
+
+func (l opLabel) Insert(v string) telemetry.LabelValue { return insertOp{string(l), v} }
+func (l opLabel) Update(v string) telemetry.LabelValue { return nil }
+func (l opLabel) Upsert(v string) telemetry.LabelValue { return upsertOp{string(l), v} }
+func (l opLabel) Delete() telemetry.LabelValue         { return nil }
+
+type opSink struct {
+	telemetry.MetricSink
+}
+
+func (opSink) NewLabel(name string) telemetry.Label { return opLabel(name) }
+
+// opMetric records the LabelValues added through With for each measurement.
+type opMetric struct {
+	telemetry.Metric
+	with     []telemetry.LabelValue
+	recorded *[][]telemetry.LabelValue
+}
+
+func (m opMetric) Name() string { return "op" }
+
+func (m opMetric) RecordContext(_ context.Context, _ float64) {
+	*m.recorded = append(*m.recorded, m.with)
+}
+
+func (m opMetric) With(labelValues ...telemetry.LabelValue) telemetry.Metric {
+	return opMetric{with: append(m.with[:len(m.with):len(m.with)], labelValues...), recorded: m.recorded}
+}
+
+func TestContextLabels(t *testing.T) {
+	var (
+		recorded [][]telemetry.LabelValue
+		cl       = telemetry.NewContextLabels(opSink{}, 2, "tenant", "region")
+		m        = cl.Metric(opMetric{recorded: &recorded})
+	)
+
+	if want, have := 2, len(cl.Labels()); want != have {
+		t.Fatalf("expected %d labels, have %d", want, have)
+	}
+
+	ctx := telemetry.KeyValuesToContext(context.Background(), "tenant", "acme", "user", "bob")
+	m.RecordContext(ctx, 1)
+	m.RecordContext(telemetry.KeyValuesToContext(ctx, "tenant", "globex", "region", 1), 1)
+	m.RecordContext(telemetry.KeyValuesToContext(ctx, "tenant", "initech"), 1)
+	m.RecordContext(context.Background(), 1)
+
+	logger := function.NewLogger(func(telemetry.Level, string, error, function.Values, int) {}, 0)
+	logger.Context(ctx).Metric(m.With("explicit")).Info("counted")
+
+	want := [][]telemetry.LabelValue{
+		{insertOp{"tenant", "acme"}},
+		{insertOp{"tenant", "globex"}, insertOp{"region", "1"}},
+		{upsertOp{"tenant", "other"}},
+		nil,
+		{"explicit", insertOp{"tenant", "acme"}},
+	}
+	if !reflect.DeepEqual(want, recorded) {
+		t.Fatalf("want: %v\nhave: %v", want, recorded)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package correlation generates and propagates correlation identifiers, tying
+// together the log lines and metrics produced while handling a request, both
+// within a service and across service boundaries.
+//
+// The identifier is stored in Context as telemetry key-value pair, so it
+// appears in every log line of Loggers obtained through Logger.Context and in
+// every metric with a matching label recorded through Metric.RecordContext.
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// Defaults for propagating the correlation identifier.
+const (
+	// DefaultHeader is the HTTP header carrying the correlation identifier.
+	DefaultHeader = "X-Request-ID"
+	// DefaultKey is the key of the correlation identifier key-value pair.
+	DefaultKey = "correlation_id"
+	// MaxLength is the maximum length of a received correlation identifier.
+	MaxLength = 128
+)
+
+// ctxKey is the Context key holding the correlation identifier.
+type ctxKey struct{}
+
+type (
+	// Option implements a functional option type for the Middleware and
+	// Transport.
+	Option func(*options)
+
+	// options holds the configuration of the Middleware and Transport.
+	options struct {
+		header    string
+		key       string
+		generator func() string
+	}
+)
+
+// WithHeader sets the HTTP header carrying the correlation identifier. It
+// defaults to DefaultHeader.
+func WithHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithKey sets the key of the key-value pair holding the correlation
+// identifier. It defaults to DefaultKey.
+func WithKey(key string) Option {
+	return func(o *options) {
+		o.key = key
+	}
+}
+
+// WithGenerator sets the function generating correlation identifiers for
+// requests not carrying one. It defaults to NewID.
+func WithGenerator(fn func() string) Option {
+	return func(o *options) {
+		o.generator = fn
+	}
+}
+
+// newOptions returns the configuration with the provided options applied.
+func newOptions(opts []Option) options {
+	o := options{
+		header:    DefaultHeader,
+		key:       DefaultKey,
+		generator: NewID,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// NewID returns a random 128-bit correlation identifier encoded as 32
+// hexadecimal characters.
+func NewID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// ToContext returns a Context holding the correlation identifier, both for
+// retrieval using FromContext and as key-value pair using DefaultKey.
+func ToContext(ctx context.Context, id string) context.Context {
+	return toContext(ctx, DefaultKey, id)
+}
+
+// toContext stores the correlation identifier using the provided key.
+func toContext(ctx context.Context, key, id string) context.Context {
+	ctx = context.WithValue(ctx, ctxKey{}, id)
+	return telemetry.KeyValuesToContext(ctx, key, id)
+}
+
+// FromContext returns the correlation identifier found in Context, or an empty
+// string if none was stored.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Ensure returns the Context unchanged if it holds a correlation identifier.
+// Otherwise, it returns a Context holding a new identifier generated by NewID.
+// It is meant for entry points not driven by HTTP requests, like message
+// consumers and scheduled jobs.
+func Ensure(ctx context.Context) context.Context {
+	if FromContext(ctx) != "" {
+		return ctx
+	}
+	return ToContext(ctx, NewID())
+}
+
+// Middleware returns HTTP middleware taking the correlation identifier from
+// the configured request header, generating one if absent or invalid. The
+// identifier is stored in the request Context and set on the response header,
+// so callers can refer to it when reporting issues.
+//
+// Received identifiers are only accepted if not longer than MaxLength and
+// consisting of printable ASCII characters, preventing clients from injecting
+// content into log lines.
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(o.header)
+			if !valid(id) {
+				id = o.generator()
+			}
+			w.Header().Set(o.header, id)
+			next.ServeHTTP(w, r.WithContext(toContext(r.Context(), o.key, id)))
+		})
+	}
+}
+
+// Transport returns an http.RoundTripper setting the configured request header
+// to the correlation identifier found in the request Context, propagating it
+// to downstream services. Requests already carrying the header or without
+// identifier in Context are passed as is. If base is nil,
+// http.DefaultTransport is used.
+func Transport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, opts: newOptions(opts)}
+}
+
+// transport propagates the correlation identifier on outgoing requests.
+type transport struct {
+	base http.RoundTripper
+	opts options
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if id := FromContext(r.Context()); id != "" && r.Header.Get(t.opts.header) == "" {
+		// a RoundTripper must not modify the provided request.
+		r = r.Clone(r.Context())
+		r.Header.Set(t.opts.header, id)
+	}
+	return t.base.RoundTrip(r)
+}
+
+// valid reports whether the received correlation identifier is acceptable.
+func valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
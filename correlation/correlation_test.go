@@ -0,0 +1,113 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package correlation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		received string
+		expected string
+	}{
+		{"generated", "", "generated"},
+		{"received", "abc-123", "abc-123"},
+		{"control characters", "abc\n123", "generated"},
+		{"too long", strings.Repeat("a", MaxLength+1), "generated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var have []interface{}
+			logger := function.NewLogger(func(_ telemetry.Level, _ string, _ error, values function.Values, _ int) {
+				have = values.FromContext
+			}, 0)
+
+			h := Middleware(WithHeader("X-Correlation-ID"), WithGenerator(func() string { return "generated" }))(
+				http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+					logger.Context(r.Context()).Info("handled")
+				}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.received != "" {
+				r.Header.Set("X-Correlation-ID", tt.received)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if want := []interface{}{DefaultKey, tt.expected}; !reflect.DeepEqual(want, have) {
+				t.Fatalf("want: %v\nhave: %v", want, have)
+			}
+			if id := w.Header().Get("X-Correlation-ID"); id != tt.expected {
+				t.Fatalf("want: %s\nhave: %s", tt.expected, id)
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var have []string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		have = append(have, r.Header.Get(DefaultHeader))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport(nil)}
+	for _, ctx := range []context.Context{
+		context.Background(),
+		ToContext(context.Background(), "abc"),
+	} {
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		res, err := client.Do(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = res.Body.Close()
+		if r.Header.Get(DefaultHeader) != "" {
+			t.Fatal("expected request not to be modified")
+		}
+	}
+
+	if want := []string{"", "abc"}; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestEnsure(t *testing.T) {
+	ctx := Ensure(context.Background())
+	id := FromContext(ctx)
+	if len(id) != 32 {
+		t.Fatalf("unexpected identifier: %q", id)
+	}
+	if have := FromContext(Ensure(ctx)); have != id {
+		t.Fatalf("want: %s\nhave: %s", id, have)
+	}
+	if want, have := []interface{}{DefaultKey, id}, telemetry.KeyValuesFromContext(ctx); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dedup provides a function.Emit wrapper collapsing identical
+// consecutive log lines into a single log line carrying the repeat count,
+// similar to the "message repeated N times" lines of syslog.
+package dedup
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// DefaultCountKey is the default key of the repeat count.
+const DefaultCountKey = "repeat_count"
+
+// SummaryMessage is the message of the log line reporting the lifetime totals
+// on Close.
+const SummaryMessage = "deduper closed"
+
+type (
+	// Option implements a functional option type for the Deduper.
+	Option func(*options)
+
+	// options holds the configuration of a Deduper.
+	options struct {
+		countKey string
+		summary  bool
+	}
+)
+
+// WithCountKey sets the key of the repeat count added to the summarizing log
+// line. The default is DefaultCountKey.
+func WithCountKey(key string) Option {
+	return func(o *options) {
+		o.countKey = key
+	}
+}
+
+// WithSummary sets whether Close emits a log line with the lifetime totals of
+// emitted and held back log lines. It is enabled by default.
+func WithSummary(enabled bool) Option {
+	return func(o *options) {
+		o.summary = enabled
+	}
+}
+
+// entry holds a log line.
+type entry struct {
+	level  telemetry.Level
+	msg    string
+	err    error
+	values function.Values
+}
+
+// same reports whether the log lines are identical, disregarding the call
+// site.
+func (e *entry) same(level telemetry.Level, msg string, err error, values function.Values) bool {
+	if e.level != level || e.msg != msg || (e.err == nil) != (err == nil) {
+		return false
+	}
+	if err != nil && e.err.Error() != err.Error() {
+		return false
+	}
+	return reflect.DeepEqual(e.values.FromContext, values.FromContext) &&
+		reflect.DeepEqual(e.values.FromLogger, values.FromLogger) &&
+		reflect.DeepEqual(e.values.FromMethod, values.FromMethod)
+}
+
+// Deduper collapses identical consecutive log lines emitted within a window.
+type Deduper struct {
+	emit   function.Emit
+	window time.Duration
+	opts   options
+
+	mtx   sync.Mutex
+	last  *entry
+	count int
+	timer *time.Timer
+	gen   uint64
+
+	// emitted and suppressed hold the lifetime totals of emitted and held
+	// back log lines.
+	emitted    uint64
+	suppressed uint64
+	closed     bool
+}
+
+// New returns a Deduper emitting through the provided function. The first
+// occurrence of a log line is emitted immediately, while identical log lines
+// directly following it are held back. Once a different log line is emitted,
+// the window since the first held back log line expires or Flush is called,
+// the last held back log line is emitted with the number of held back log
+// lines added under the count key. Use the Emit method of the Deduper with
+// function.NewLogger and its Flush method with function.WithFlush. Close it on
+// shutdown to emit the held back log lines and, unless disabled using
+// WithSummary, a log line at Info level with the lifetime totals.
+//
+// As summarizing log lines may be emitted from a timer goroutine, the wrapped
+// function must take the call site from Values.Caller instead of walking the
+// stack, as the emitters of this repository do.
+func New(emit function.Emit, window time.Duration, opts ...Option) *Deduper {
+	o := options{countKey: DefaultCountKey, summary: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Deduper{emit: emit, window: window, opts: o}
+}
+
+// Emit implements function.Emit.
+func (d *Deduper) Emit(level telemetry.Level, msg string, err error, values function.Values, callerSkip int) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if d.last != nil && d.last.same(level, msg, err, values) {
+		d.count++
+		d.suppressed++
+		// keep the call site of the most recent occurrence.
+		d.last.values.Caller = values.Caller
+		if d.timer == nil {
+			d.gen++
+			gen := d.gen
+			d.timer = time.AfterFunc(d.window, func() { d.expire(gen) })
+		}
+		return
+	}
+
+	d.flushLocked()
+	d.emitted++
+	d.emit(level, msg, err, values, callerSkip+1)
+
+	// the key-value pairs passed to the logging method may be reused by the
+	// caller once it returns.
+	values.FromMethod = append([]interface{}(nil), values.FromMethod...)
+	d.last = &entry{level: level, msg: msg, err: err, values: values}
+}
+
+// Flush emits the summarizing log line of held back log lines, if any. It
+// matches the signature expected by function.WithFlush.
+func (d *Deduper) Flush() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.flushLocked()
+}
+
+// Close emits the summarizing log line of held back log lines, if any, and the
+// log line with the lifetime totals, unless disabled using WithSummary.
+// Subsequent calls only emit held back log lines.
+func (d *Deduper) Close() error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.flushLocked()
+	if d.closed || !d.opts.summary {
+		return nil
+	}
+	d.closed = true
+	d.emit(telemetry.LevelInfo, SummaryMessage, nil, function.Values{
+		FromMethod: []interface{}{"emitted", d.emitted, "suppressed", d.suppressed},
+	}, 0)
+	return nil
+}
+
+// expire emits the summarizing log line at the end of the window. The next
+// occurrence of the log line is emitted as a new first occurrence.
+func (d *Deduper) expire(gen uint64) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	// ignore timers stopped after they fired.
+	if d.timer == nil || gen != d.gen {
+		return
+	}
+	d.flushLocked()
+	d.last = nil
+}
+
+func (d *Deduper) flushLocked() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.count == 0 {
+		return
+	}
+	e := d.last
+	values := e.values
+	values.FromMethod = append(append(make([]interface{}, 0, len(values.FromMethod)+2),
+		values.FromMethod...), d.opts.countKey, d.count)
+	d.count = 0
+	d.emit(e.level, e.msg, e.err, values, 0)
+}
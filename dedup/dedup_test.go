@@ -0,0 +1,141 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type recorder struct {
+	mtx  sync.Mutex
+	msgs []string
+}
+
+func (r *recorder) emit(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.msgs = append(r.msgs, fmt.Sprintf("%s %s %v %v", level, msg, err, values.FromMethod))
+}
+
+func (r *recorder) result() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]string(nil), r.msgs...)
+}
+
+func TestDedup(t *testing.T) {
+	var (
+		r      = &recorder{}
+		d      = New(r.emit, time.Hour)
+		logger = function.NewLogger(d.Emit, 0, function.WithFlush(d.Flush))
+		err    = errors.New("boom")
+	)
+
+	for i := 0; i < 3; i++ {
+		logger.Error("failed", err, "id", 1)
+	}
+	logger.Error("failed", err, "id", 2)
+	logger.Info("done")
+	logger.Info("done")
+	d.Flush()
+
+	want := []string{
+		"error failed boom [id 1]",
+		"error failed boom [id 1 repeat_count 2]",
+		"error failed boom [id 2]",
+		"info done <nil> []",
+		"info done <nil> [repeat_count 1]",
+	}
+	if have := r.result(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	var (
+		r      = &recorder{}
+		d      = New(r.emit, 10*time.Millisecond, WithCountKey("repeated"))
+		logger = function.NewLogger(d.Emit, 0)
+	)
+
+	logger.Info("tick")
+	logger.Info("tick")
+	logger.Info("tick")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.result()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	// after the window expired, the log line is emitted as a new first
+	// occurrence.
+	logger.Info("tick")
+
+	want := []string{
+		"info tick <nil> []",
+		"info tick <nil> [repeated 2]",
+		"info tick <nil> []",
+	}
+	if have := r.result(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestDedupClose(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{"summary", nil, []string{
+			"info tick <nil> []",
+			"info tick <nil> [repeat_count 2]",
+			"info " + SummaryMessage + " <nil> [emitted 1 suppressed 2]",
+		}},
+		{"no summary", []Option{WithSummary(false)}, []string{
+			"info tick <nil> []",
+			"info tick <nil> [repeat_count 2]",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				r      = &recorder{}
+				d      = New(r.emit, time.Hour, tt.opts...)
+				logger = function.NewLogger(d.Emit, 0)
+			)
+
+			for i := 0; i < 3; i++ {
+				logger.Info("tick")
+			}
+			if err := d.Close(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_ = d.Close()
+
+			if have := r.result(); !reflect.DeepEqual(tt.want, have) {
+				t.Fatalf("want: %v\nhave: %v", tt.want, have)
+			}
+		})
+	}
+}
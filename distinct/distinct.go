@@ -0,0 +1,126 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package distinct provides a telemetry.Logger decorator bounding the number of
+// distinct log messages a component can produce.
+//
+// This catches code building log messages from unique data, like identifiers,
+// instead of passing that data as key-value pairs, which floods log indexes.
+package distinct
+
+import (
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/internal/filter"
+)
+
+// LimitExceededMessage is the message of the log line reporting the limit
+// breach.
+const LimitExceededMessage = "distinct log message limit exceeded, dropping new messages"
+
+// compile time check for compatibility with the filter.Policy interface.
+var _ filter.Policy = (*tracker)(nil)
+
+type (
+	// key identifies a distinct log message.
+	key struct {
+		level telemetry.Level
+		msg   string
+	}
+
+	// tracker holds the distinct log messages seen in the current window.
+	tracker struct {
+		mtx     sync.Mutex
+		limit   int
+		window  time.Duration
+		now     func() time.Time
+		start   time.Time
+		seen    map[key]struct{}
+		dropped int
+	}
+)
+
+// New returns a telemetry.Logger which forwards to the provided Logger as long
+// as no more than limit distinct (level, message) combinations are seen within
+// the provided window. Once the limit is exceeded, log lines with messages not
+// yet seen in the current window are dropped, while known messages continue to
+// be forwarded. The first dropped message of a window triggers a Warn level
+// log line reporting the breach, which does not record an attached Metric.
+// Windows are fixed, the set of known messages is reset at the start of each
+// window. A window of zero or less never resets the set, making the limit
+// apply for the lifetime of the Logger.
+//
+// Loggers derived through With, Context and Metric share the limit with the
+// Logger they were derived from, while Clone returns a Logger with its own
+// limit. Dropped Info, Warn and Error log lines do not record an attached
+// Metric. The stack frame added by the decorator is accounted for in the
+// caller skip of the decorated Logger.
+func New(l telemetry.Logger, limit int, window time.Duration) telemetry.Logger {
+	return filter.New(l, newTracker(limit, window, time.Now))
+}
+
+func newTracker(limit int, window time.Duration, now func() time.Time) *tracker {
+	return &tracker{
+		limit:  limit,
+		window: window,
+		now:    now,
+		start:  now(),
+		seen:   make(map[key]struct{}),
+	}
+}
+
+// allow reports if a log line with the provided level and message can be
+// forwarded. It also reports if this is the first log line dropped in the
+// current window.
+func (t *tracker) allow(level telemetry.Level, msg string) (allowed, first bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.window > 0 {
+		if now := t.now(); now.Sub(t.start) >= t.window {
+			t.start = now
+			t.seen = make(map[key]struct{})
+			t.dropped = 0
+		}
+	}
+
+	k := key{level: level, msg: msg}
+	if _, ok := t.seen[k]; ok {
+		return true, false
+	}
+	if len(t.seen) < t.limit {
+		t.seen[k] = struct{}{}
+		return true, false
+	}
+	t.dropped++
+	return false, t.dropped == 1
+}
+
+// Allow implements filter.Policy. It reports the limit breach on the first log
+// line dropped in a window.
+func (t *tracker) Allow(l telemetry.Logger, level telemetry.Level, msg string) bool {
+	allowed, first := t.allow(level, msg)
+	if first {
+		l.Warn(LimitExceededMessage, "limit", t.limit, "window", t.window.String(),
+			"dropped_msg", msg, telemetry.NoMetric, true)
+	}
+	return allowed
+}
+
+// Clone implements filter.Policy.
+func (t *tracker) Clone() filter.Policy {
+	return newTracker(t.limit, t.window, t.now)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distinct
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+	"github.com/basvanbeek/telemetry/internal/filter"
+)
+
+func TestDistinct(t *testing.T) {
+	var msgs []string
+	inner := function.NewLogger(func(level telemetry.Level, msg string, _ error, _ function.Values, _ int) {
+		msgs = append(msgs, level.String()+" "+msg)
+	}, 0)
+	inner.SetLevel(telemetry.LevelDebug)
+
+	now := time.Unix(0, 0)
+	l := filter.New(inner, newTracker(2, time.Minute, func() time.Time { return now }))
+
+	l.Info("known")
+	l.With("key", "value").Error("failed", nil)
+	l.Context(context.Background()).Info("user 1 logged in")
+	l.Info("user 2 logged in")
+	l.Info("known")
+	l.Debug("debug")
+
+	want := []string{
+		"info known",
+		"error failed",
+		"warn " + LimitExceededMessage,
+		"info known",
+	}
+	if !reflect.DeepEqual(want, msgs) {
+		t.Fatalf("want: %v\nhave: %v", want, msgs)
+	}
+
+	// a new window resets the set of known messages.
+	msgs = nil
+	now = now.Add(time.Minute)
+	l.Info("user 3 logged in")
+	if !reflect.DeepEqual([]string{"info user 3 logged in"}, msgs) {
+		t.Fatalf("want: [info user 3 logged in]\nhave: %v", msgs)
+	}
+
+	// a clone has its own limit.
+	msgs = nil
+	c := l.Clone()
+	c.SetLevel(telemetry.LevelDebug)
+	c.Info("a")
+	c.Info("b")
+	if !reflect.DeepEqual([]string{"info a", "info b"}, msgs) {
+		t.Fatalf("want: [info a info b]\nhave: %v", msgs)
+	}
+}
+
+func TestDistinctNoWindow(t *testing.T) {
+	for _, window := range []time.Duration{0, -time.Minute} {
+		t.Run(window.String(), func(t *testing.T) {
+			var msgs []string
+			inner := function.NewLogger(func(level telemetry.Level, msg string, _ error, _ function.Values, _ int) {
+				msgs = append(msgs, level.String()+" "+msg)
+			}, 0)
+
+			now := time.Unix(0, 0)
+			l := filter.New(inner, newTracker(1, window, func() time.Time { return now }))
+
+			// without a window, the set of known messages is never reset.
+			l.Info("known")
+			l.Info("user 1 logged in")
+			now = now.Add(time.Hour)
+			l.Info("user 2 logged in")
+			l.Info("known")
+
+			want := []string{"info known", "warn " + LimitExceededMessage, "info known"}
+			if !reflect.DeepEqual(want, msgs) {
+				t.Fatalf("want: %v\nhave: %v", want, msgs)
+			}
+		})
+	}
+}
+
+func TestDistinctMetric(t *testing.T) {
+	metric := &mockMetric{}
+	l := New(function.NewLogger(nil, 0), 0, time.Minute).Metric(metric)
+	l.SetLevel(telemetry.LevelNone)
+
+	// disabled log lines are not tracked and still record the Metric.
+	l.Info("user 1 logged in")
+	l.Error("user 2 failed", nil)
+
+	if metric.count != 2 {
+		t.Fatalf("metric.count=%v, want 2", metric.count)
+	}
+
+	// dropped log lines and the log line reporting the limit breach do not
+	// record the Metric.
+	metric.count = 0
+	l.SetLevel(telemetry.LevelInfo)
+	l.Info("known")
+	l.Info("user 3 logged in")
+	if metric.count != 0 {
+		t.Fatalf("metric.count=%v, want 0", metric.count)
+	}
+}
+
+type mockMetric struct {
+	telemetry.Metric
+	count float64
+}
+
+func (m *mockMetric) RecordContext(_ context.Context, value float64) { m.count += value }
@@ -34,6 +34,10 @@ Error: something happened that we can't gracefully recover from.
 This is a log line that should be actionable by an operator and be
 alerted on.
 
+Warn: something happened that does not yet impact application stability
+but is likely to require attention if it persists. E.g. a dependency is
+degraded and operations needed to be retried.
+
 Info: something happened that might be of interest but does not impact
 the application stability. E.g. someone gave the wrong credentials and
 was therefore denied access, parsing error on external input, etc.
@@ -41,9 +45,13 @@ was therefore denied access, parsing error on external input, etc.
 Debug: anything that can help to understand application state during
 development.
 
+Trace: very chatty details, like per-packet or per-iteration state, which
+would drown out Debug logging if enabled together.
+
 More levels get tricky to reason about when writing log lines or establishing
 the right level of verbosity at runtime. By the above explanations fatal folds
-into error, warning folds into info, and trace folds into debug.
+into error: a Fatal log line is logged at Error level, after which the process
+is terminated.
 
 We trust more in partitioning loggers per domain, component, etc. and allow them
 to be individually addressed to required log levels than controlling a single
@@ -0,0 +1,174 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+const (
+	// consoleTimeFormat is the default timestamp layout of the Console emitter.
+	consoleTimeFormat = "15:04:05.000"
+	// consoleCallerWidth is the minimum width of the caller column.
+	consoleCallerWidth = 24
+	// consoleMessageWidth is the minimum width of the message column.
+	consoleMessageWidth = 40
+)
+
+// ANSI escape sequences used by the Console emitter.
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorGray    = "\x1b[90m"
+)
+
+// WithColor forces colorized output of the Console emitter on or off,
+// overriding the detection of a terminal.
+func WithColor(enabled bool) Option {
+	return func(o *options) {
+		o.color = &enabled
+	}
+}
+
+// Console returns a function.EmitErr writing human friendly log lines to w,
+// intended for development. Each log line holds the timestamp, level, caller
+// and message in aligned columns, followed by the key-value pairs found in
+// Context, added to the Logger and passed to the logging method, in that
+// order. The error passed to Error and Fatal is rendered on the lines below
+// using its "%+v" representation, so multi-line errors, e.g. holding stack
+// traces, remain readable. The caller is shown as a short path holding the
+// package directory, file name and line number, and is only available if the
+// function Logger is created with the function.WithCaller option. Values are
+// formatted as with Logfmt, so nil pointers are written as null without
+// calling their methods.
+//
+// The keys of the fixed fields are not written, but configuring an empty key
+// omits the corresponding field. The timestamp defaults to a time of day
+// layout. Levels and keys are colorized if w is a terminal, unless the
+// NO_COLOR environment variable is set. Use WithColor to override.
+func Console(w io.Writer, opts ...Option) function.EmitErr {
+	var (
+		o     = newOptions(append([]Option{WithTimeFormat(consoleTimeFormat)}, opts...))
+		sw    = &syncWriter{w: w}
+		color = isTerminal(w) && os.Getenv("NO_COLOR") == ""
+	)
+	if o.color != nil {
+		color = *o.color
+	}
+	paint := func(buf []byte, code, s string) []byte {
+		if !color {
+			return append(buf, s...)
+		}
+		buf = append(buf, code...)
+		buf = append(buf, s...)
+		return append(buf, colorReset...)
+	}
+
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) error {
+		b := getBuf()
+		defer putBuf(b)
+
+		buf := *b
+		if o.timeKey != "" {
+			buf = paint(buf, colorGray, o.now().Format(o.timeFormat))
+			buf = append(buf, ' ')
+		}
+		if o.levelKey != "" {
+			buf = paint(buf, levelColor(level), fmt.Sprintf("%-5s", strings.ToUpper(level.String())))
+			buf = append(buf, ' ')
+		}
+		if o.callerKey != "" {
+			if c, ok := caller(values); ok {
+				buf = paint(buf, colorGray, fmt.Sprintf("%-*s", consoleCallerWidth, c))
+				buf = append(buf, ' ')
+			}
+		}
+		if o.messageKey != "" {
+			buf = append(buf, msg...)
+		}
+
+		var kvs []byte
+		for _, keyValues := range [][]interface{}{values.FromContext, values.FromLogger, values.FromMethod} {
+			for i := 0; i < len(keyValues); i += 2 {
+				k, v := keyValue(keyValues, i)
+				kvs = append(kvs, ' ')
+				kvs = paint(kvs, colorBlue, string(appendLogfmtKey(nil, k))+"=")
+				kvs = appendLogfmtValue(kvs, v)
+			}
+		}
+		if len(kvs) > 0 {
+			if o.messageKey != "" && len(msg) < consoleMessageWidth {
+				buf = append(buf, strings.Repeat(" ", consoleMessageWidth-len(msg))...)
+			}
+			buf = append(buf, kvs...)
+		}
+
+		if o.errorKey != "" && err != nil {
+			lines := strings.Split(strings.TrimRight(fmt.Sprintf("%+v", err), "\n"), "\n")
+			for i, line := range lines {
+				buf = append(buf, '\n')
+				if i == 0 {
+					buf = paint(buf, colorRed, "    "+o.errorKey+": ")
+				} else {
+					buf = append(buf, strings.Repeat(" ", len(o.errorKey)+6)...)
+				}
+				buf = append(buf, line...)
+			}
+		}
+		buf = append(buf, '\n')
+
+		*b = buf
+		return sw.write(buf)
+	}
+}
+
+// levelColor returns the color used for the level.
+func levelColor(level telemetry.Level) string {
+	switch {
+	case level <= telemetry.LevelError:
+		return colorRed
+	case level <= telemetry.LevelWarn:
+		return colorYellow
+	case level <= telemetry.LevelInfo:
+		return colorGreen
+	case level <= telemetry.LevelDebug:
+		return colorMagenta
+	default:
+		return colorGray
+	}
+}
+
+// isTerminal reports whether w is a character device, like a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestConsole(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"info", nil, func(l telemetry.Logger) { l.Info("text", "component", "lib", "id", 1) },
+			"INFO  text                                     component=lib id=1\n"},
+		{"no-values", nil, func(l telemetry.Logger) { l.Warn("text") },
+			"WARN  text\n"},
+		{"error", nil, func(l telemetry.Logger) { l.Error("text", errors.New("first\nsecond\n"), "component", "lib") },
+			"ERROR text                                     component=lib\n" +
+				"    error: first\n" +
+				"           second\n"},
+		{"nil pointers", nil, func(l telemetry.Logger) { l.Error("text", (*nilError)(nil), "url", (*url.URL)(nil)) },
+			"ERROR text                                     url=null\n" +
+				"    error: <nil>\n"},
+		{"color", []Option{WithColor(true)}, func(l telemetry.Logger) { l.Info("text", "component", "lib") },
+			"\x1b[32mINFO \x1b[0m text                                     \x1b[34mcomponent=\x1b[0mlib\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts := append([]Option{WithTimeKey("")}, tt.opts...)
+			logger := function.NewLoggerErr(Console(&out, opts...), 0)
+
+			tt.logfunc(logger)
+
+			if out.String() != tt.expected {
+				t.Fatalf("expected %q to match %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestConsoleCaller(t *testing.T) {
+	var out bytes.Buffer
+	logger := function.NewLoggerErr(Console(&out, WithTimeKey("")), 0, function.WithCaller())
+
+	logger.Info("text")
+	if !strings.HasPrefix(out.String(), "INFO  emitter/console_test.go:") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	if isTerminal(&bytes.Buffer{}) {
+		t.Error("expected buffer not to be a terminal")
+	}
+	f, err := os.CreateTemp(t.TempDir(), "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	if isTerminal(f) {
+		t.Error("expected regular file not to be a terminal")
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package emitter provides ready-made function.EmitErr implementations
+// encoding log lines for common output formats. Use them with
+// function.NewLoggerErr, which reports failures to write the log lines.
+package emitter
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Default keys used for the fixed fields of a log line.
+const (
+	DefaultTimeKey    = "time"
+	DefaultLevelKey   = "level"
+	DefaultMessageKey = "msg"
+	DefaultErrorKey   = "error"
+	DefaultCallerKey  = "caller"
+)
+
+type (
+	// Option implements a functional option type for the emitters.
+	Option func(*options)
+
+	// options holds the configuration shared by the emitters.
+	options struct {
+		timeKey    string
+		levelKey   string
+		messageKey string
+		errorKey   string
+		callerKey  string
+		timeFormat string
+		now        func() time.Time
+		color      *bool
+
+		syslogFormat SyslogFormat
+		facility     Facility
+		appName      string
+		hostname     string
+		severity     func(telemetry.Level) Severity
+
+		maxSize    int64
+		maxAge     time.Duration
+		maxBackups int
+	}
+)
+
+// WithTimeKey sets the key used for the log line timestamp. An empty key omits
+// the timestamp.
+func WithTimeKey(key string) Option {
+	return func(o *options) {
+		o.timeKey = key
+	}
+}
+
+// WithLevelKey sets the key used for the log line level. An empty key omits
+// the level.
+func WithLevelKey(key string) Option {
+	return func(o *options) {
+		o.levelKey = key
+	}
+}
+
+// WithMessageKey sets the key used for the log line message. An empty key
+// omits the message.
+func WithMessageKey(key string) Option {
+	return func(o *options) {
+		o.messageKey = key
+	}
+}
+
+// WithErrorKey sets the key used for the error passed to Error and Fatal. An
+// empty key omits the error.
+func WithErrorKey(key string) Option {
+	return func(o *options) {
+		o.errorKey = key
+	}
+}
+
+// WithCallerKey sets the key used for the call site of the logging method. An
+// empty key omits the call site. The call site is only available if the
+// function Logger is created with the function.WithCaller option.
+func WithCallerKey(key string) Option {
+	return func(o *options) {
+		o.callerKey = key
+	}
+}
+
+// WithTimeFormat sets the layout used to format the log line timestamp, as
+// accepted by time.Time.Format. The default is time.RFC3339Nano.
+func WithTimeFormat(layout string) Option {
+	return func(o *options) {
+		o.timeFormat = layout
+	}
+}
+
+// WithClock sets the function providing the timestamp of log lines. The
+// default is time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// newOptions returns the options with defaults applied.
+func newOptions(opts []Option) options {
+	o := options{
+		timeKey:    DefaultTimeKey,
+		levelKey:   DefaultLevelKey,
+		messageKey: DefaultMessageKey,
+		errorKey:   DefaultErrorKey,
+		callerKey:  DefaultCallerKey,
+		timeFormat: time.RFC3339Nano,
+		now:        time.Now,
+		facility:   FacilityUser,
+		severity:   DefaultSeverity,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// syncWriter serializes writes of fully encoded log lines to the underlying
+// io.Writer, so concurrent log lines never interleave.
+type syncWriter struct {
+	mtx sync.Mutex
+	w   io.Writer
+}
+
+// write writes the log line in a single Write call.
+func (s *syncWriter) write(p []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	_, err := s.w.Write(p)
+	return err
+}
+
+// bufPool holds the buffers log lines are encoded into.
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+// maxPooledBuf is the capacity above which buffers are not returned to the
+// pool, preventing a single large log line from pinning memory.
+const maxPooledBuf = 64 << 10
+
+func getBuf() *[]byte {
+	return bufPool.Get().(*[]byte)
+}
+
+func putBuf(b *[]byte) {
+	if cap(*b) > maxPooledBuf {
+		return
+	}
+	*b = (*b)[:0]
+	bufPool.Put(b)
+}
+
+// keyValue returns the key-value pair found at index i of keyValues.
+// Non-string keys are formatted using fmt.Sprint and a missing value is set to
+// "(MISSING)".
+func keyValue(keyValues []interface{}, i int) (string, interface{}) {
+	k, ok := keyValues[i].(string)
+	if !ok {
+		k = fmt.Sprint(keyValues[i])
+	}
+	if i+1 < len(keyValues) {
+		return k, keyValues[i+1]
+	}
+	return k, "(MISSING)"
+}
+
+// isNilPointer reports whether v holds a nil pointer. Calling the Error,
+// String or MarshalJSON method of a nil pointer may panic, so like fmt and
+// encoding/json the emitters don't.
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// errorText returns the message of err, or "<nil>" like fmt if err holds a nil
+// pointer.
+func errorText(err error) string {
+	if isNilPointer(err) {
+		return "<nil>"
+	}
+	return err.Error()
+}
+
+// caller returns the call site held by Values as a short path, consisting of
+// the package directory, file name and line number, e.g. "function/logger.go:42".
+func caller(values function.Values) (string, bool) {
+	frame, ok := values.Caller.Resolve()
+	if !ok {
+		return "", false
+	}
+	// runtime reports file paths using forward slashes on all platforms.
+	short := path.Join(path.Base(path.Dir(frame.File)), path.Base(frame.File))
+	return short + ":" + strconv.Itoa(frame.Line), true
+}
+
+// Suffixes of the keys describing the chain of the error passed to Error and
+// Fatal, appended to the configured error key.
+const (
+	CauseSuffix  = ".cause"
+	CausesSuffix = ".causes"
+)
+
+// errorChain returns the key-value pairs describing the chain of wrapped
+// errors held by err, following their Unwrap methods. The "<key>.cause" pair
+// holds the message of the innermost error if err wraps a single chain of
+// errors. If the chain holds a multi-error, like the ones returned by
+// errors.Join, the "<key>.causes" pair holds the messages of its errors
+// instead. If flat is set, the messages of a multi-error are returned as
+// separate "<key>.causes.<index>" pairs, for formats without lists.
+func errorChain(key string, err error, flat bool) []interface{} {
+	var cause error
+	for e := err; !isNilPointer(e); {
+		switch u := e.(type) {
+		case interface{ Unwrap() []error }:
+			var causes []string
+			for _, c := range u.Unwrap() {
+				if c != nil {
+					causes = append(causes, errorText(c))
+				}
+			}
+			if !flat {
+				return []interface{}{key + CausesSuffix, causes}
+			}
+			keyValues := make([]interface{}, 0, 2*len(causes))
+			for i, c := range causes {
+				keyValues = append(keyValues, key+CausesSuffix+"."+strconv.Itoa(i), c)
+			}
+			return keyValues
+		case interface{ Unwrap() error }:
+			if e = u.Unwrap(); e != nil {
+				cause = e
+				continue
+			}
+		}
+		break
+	}
+	if cause == nil {
+		return nil
+	}
+	return []interface{}{key + CauseSuffix, errorText(cause)}
+}
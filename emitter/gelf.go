@@ -0,0 +1,290 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// GELFCompression identifies the compression applied to GELF messages sent
+// over UDP.
+type GELFCompression int
+
+// Supported GELF compression methods.
+const (
+	GELFCompressNone GELFCompression = iota
+	GELFCompressGzip
+	GELFCompressZlib
+)
+
+const (
+	// gelfChunkSize is the maximum size of a UDP datagram sent, leaving
+	// room for IP and UDP headers within common WAN MTUs.
+	gelfChunkSize = 1420
+	// gelfChunkHeader is the size of the header preceding chunk data.
+	gelfChunkHeader = 12
+	// gelfMaxChunks is the maximum number of chunks Graylog reassembles.
+	gelfMaxChunks = 128
+)
+
+// errGELFTooLarge is returned if a GELF message exceeds the maximum number of
+// chunks.
+var errGELFTooLarge = errors.New("gelf message too large")
+
+// GELF returns a function.EmitErr writing each log line as a GELF 1.1 message
+// to w, typically obtained from DialGELF. Each message is written in a single
+// Write call without delimiter, framing is left to the transport.
+//
+// The log message is sent as short_message and the level as the syslog
+// severity configured through WithSeverityFunc. The host name is set through
+// WithHostname. The error, caller and the key-value pairs found in Context,
+// added to the Logger and passed to the logging method are sent as additional
+// fields, with keys prefixed by an underscore and characters not allowed by
+// GELF replaced. Numeric values are sent as numbers, other values as strings.
+// The chain of wrapped errors is sent as with Logfmt. If an error is passed,
+// its "%+v" formatting is sent as full_message. The
+// time, level and message key options do not apply.
+func GELF(w io.Writer, opts ...Option) function.EmitErr {
+	var (
+		o  = newOptions(opts)
+		sw = &syncWriter{w: w}
+	)
+	if o.hostname == "" {
+		o.hostname, _ = os.Hostname()
+	}
+
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) error {
+		b := getBuf()
+		defer putBuf(b)
+
+		now := o.now()
+		buf := append(*b, `{"version":"1.1","host":`...)
+		buf = appendJSONString(buf, o.hostname)
+		buf = append(buf, `,"short_message":`...)
+		buf = appendJSONString(buf, msg)
+		if err != nil {
+			buf = append(buf, `,"full_message":`...)
+			buf = appendJSONString(buf, fmt.Sprintf("%+v", err))
+		}
+		buf = append(buf, `,"timestamp":`...)
+		buf = strconv.AppendInt(buf, now.Unix(), 10)
+		buf = append(buf, '.')
+		buf = appendPadded(buf, now.Nanosecond()/int(time.Millisecond), 3)
+		buf = append(buf, `,"level":`...)
+		buf = strconv.AppendInt(buf, int64(o.severity(level)), 10)
+
+		if o.errorKey != "" && err != nil {
+			buf = appendGELFField(buf, o.errorKey)
+			buf = appendJSONString(buf, err.Error())
+			chain := errorChain(o.errorKey, err, true)
+			for i := 0; i < len(chain); i += 2 {
+				buf = appendGELFField(buf, chain[i].(string))
+				buf = appendJSONString(buf, chain[i+1].(string))
+			}
+		}
+		if o.callerKey != "" {
+			if c, ok := caller(values); ok {
+				buf = appendGELFField(buf, o.callerKey)
+				buf = appendJSONString(buf, c)
+			}
+		}
+		for _, kvs := range [][]interface{}{values.FromContext, values.FromLogger, values.FromMethod} {
+			for i := 0; i < len(kvs); i += 2 {
+				k, v := keyValue(kvs, i)
+				buf = appendGELFField(buf, k)
+				buf = appendGELFValue(buf, v)
+			}
+		}
+		buf = append(buf, '}')
+
+		*b = buf
+		return sw.write(buf)
+	}
+}
+
+// appendPadded appends the non-negative integer zero padded to width digits.
+func appendPadded(buf []byte, i, width int) []byte {
+	s := strconv.Itoa(i)
+	for n := len(s); n < width; n++ {
+		buf = append(buf, '0')
+	}
+	return append(buf, s...)
+}
+
+// appendGELFField appends the name of an additional field, prefixed by a
+// comma. Characters other than letters, digits, underscores, dashes and dots
+// are replaced by underscores. The reserved "id" field is renamed to "id_".
+func appendGELFField(buf []byte, key string) []byte {
+	buf = append(buf, `,"_`...)
+	if key == "" || key == "id" {
+		key += "_"
+	}
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			c == '_', c == '-', c == '.':
+			buf = append(buf, c)
+		default:
+			buf = append(buf, '_')
+		}
+	}
+	return append(buf, `":`...)
+}
+
+// appendGELFValue appends the value of an additional field. GELF only allows
+// strings and numbers, other values are sent in their JSON or string form.
+func appendGELFValue(buf []byte, v interface{}) []byte {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, string:
+		return appendJSONValue(buf, v)
+	}
+	b := appendJSONValue(nil, v)
+	if len(b) > 0 && b[0] == '"' {
+		return append(buf, b...)
+	}
+	return appendJSONString(buf, string(b))
+}
+
+// DialGELF returns a connection to a GELF input, for use with the GELF
+// emitter. Network and address are as accepted by net.Dial, e.g. "udp" and
+// "graylog.example.com:12201".
+//
+// Over UDP, messages are compressed using the provided method and split into
+// GELF chunks if they do not fit a single datagram. Over TCP, messages are
+// terminated by a null byte and the compression method is ignored, as GELF
+// TCP inputs do not support compression. The returned connection reconnects
+// on the next write if a write fails.
+func DialGELF(network, address string, compression GELFCompression) (io.WriteCloser, error) {
+	if network == "" {
+		return nil, errors.New("gelf requires a network")
+	}
+	c := &syslogConn{network: network, address: address}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(network, "udp") {
+		var seed [8]byte
+		_, _ = rand.Read(seed[:])
+		return &gelfUDPConn{conn: c, compression: compression, id: binary.BigEndian.Uint64(seed[:])}, nil
+	}
+	return &gelfTCPConn{conn: c}, nil
+}
+
+// gelfTCPConn frames GELF messages over a stream connection.
+type gelfTCPConn struct {
+	conn io.WriteCloser
+}
+
+// Write implements io.Writer.
+func (c *gelfTCPConn) Write(p []byte) (int, error) {
+	msg := make([]byte, len(p)+1)
+	copy(msg, p)
+	if _, err := c.conn.Write(msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close implements io.Closer.
+func (c *gelfTCPConn) Close() error { return c.conn.Close() }
+
+// gelfUDPConn compresses and chunks GELF messages over a datagram
+// connection.
+type gelfUDPConn struct {
+	mtx         sync.Mutex
+	conn        io.WriteCloser
+	compression GELFCompression
+	id          uint64
+}
+
+// Write implements io.Writer.
+func (c *gelfUDPConn) Write(p []byte) (int, error) {
+	msg, err := c.compress(p)
+	if err != nil {
+		return 0, err
+	}
+	if len(msg) <= gelfChunkSize {
+		if _, err = c.conn.Write(msg); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	const size = gelfChunkSize - gelfChunkHeader
+	count := (len(msg) + size - 1) / size
+	if count > gelfMaxChunks {
+		return 0, errGELFTooLarge
+	}
+
+	chunk := make([]byte, gelfChunkSize)
+	chunk[0], chunk[1] = 0x1e, 0x0f
+	binary.BigEndian.PutUint64(chunk[2:10], atomic.AddUint64(&c.id, 1))
+	chunk[11] = byte(count)
+
+	// chunks of a message must not interleave with those of other messages
+	// sharing the same connection.
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for seq := 0; seq < count; seq++ {
+		chunk[10] = byte(seq)
+		n := copy(chunk[gelfChunkHeader:], msg[seq*size:])
+		if _, err = c.conn.Write(chunk[:gelfChunkHeader+n]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close implements io.Closer.
+func (c *gelfUDPConn) Close() error { return c.conn.Close() }
+
+func (c *gelfUDPConn) compress(p []byte) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		zw  io.WriteCloser
+	)
+	switch c.compression {
+	case GELFCompressGzip:
+		zw = gzip.NewWriter(&buf)
+	case GELFCompressZlib:
+		zw = zlib.NewWriter(&buf)
+	default:
+		return p, nil
+	}
+	if _, err := zw.Write(p); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,169 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestGELF(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"info", nil, func(l telemetry.Logger) { l.Info("text", "id", 1, "ok", true, "user name", "bas") },
+			`{"version":"1.1","host":"host","short_message":"text","timestamp":TS,"level":6,"_component":"lib","_id_":1,"_ok":"true","_user_name":"bas"}`},
+		{"error", nil, func(l telemetry.Logger) { l.Error("text", errors.New("not found")) },
+			`{"version":"1.1","host":"host","short_message":"text","full_message":"not found","timestamp":TS,"level":3,"_error":"not found","_component":"lib"}`},
+		{"severity", []Option{WithSeverityFunc(func(telemetry.Level) Severity { return SeverityNotice })},
+			func(l telemetry.Logger) { l.Warn("text") },
+			`{"version":"1.1","host":"host","short_message":"text","timestamp":TS,"level":5,"_component":"lib"}`},
+	}
+
+	ts := regexp.MustCompile(`"timestamp":\d+\.\d{3}`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts := append([]Option{WithHostname("host")}, tt.opts...)
+			logger := function.NewLoggerErr(GELF(&out, opts...), 0).With("component", "lib")
+
+			tt.logfunc(logger)
+
+			have := ts.ReplaceAllString(out.String(), `"timestamp":TS`)
+			if have != tt.expected {
+				t.Fatalf("expected %q to match %q", have, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDialGELFUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("unable to listen: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	w, err := DialGELF("udp", pc.LocalAddr().String(), GELFCompressZlib)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	read := func() []byte {
+		buf := make([]byte, 2*gelfChunkSize)
+		_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return buf[:n]
+	}
+	inflate := func(p []byte) string {
+		zr, err := zlib.NewReader(bytes.NewReader(p))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b, _ := io.ReadAll(zr)
+		return string(b)
+	}
+
+	// small messages are sent in a single compressed datagram.
+	if _, err = w.Write([]byte(`{"short_message":"hello"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if have := inflate(read()); have != `{"short_message":"hello"}` {
+		t.Fatalf("expected %q to match %q", have, `{"short_message":"hello"}`)
+	}
+
+	// large messages are chunked.
+	// hex encoded random data compresses to roughly half its size.
+	var (
+		large bytes.Buffer
+		rnd   = rand.New(rand.NewSource(1))
+	)
+	for large.Len() < 8*gelfChunkSize {
+		fmt.Fprintf(&large, "%016x", rnd.Uint64())
+	}
+	if _, err = w.Write(large.Bytes()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var (
+		id        []byte
+		count     = -1
+		assembled []byte
+	)
+	for seq := 0; seq != count; seq++ {
+		chunk := read()
+		if chunk[0] != 0x1e || chunk[1] != 0x0f {
+			t.Fatalf("expected chunk magic bytes, got %x", chunk[:2])
+		}
+		if id == nil {
+			id, count = chunk[2:10], int(chunk[11])
+		}
+		if !bytes.Equal(id, chunk[2:10]) || int(chunk[10]) != seq || int(chunk[11]) != count {
+			t.Fatalf("unexpected chunk header %x", chunk[:gelfChunkHeader])
+		}
+		assembled = append(assembled, chunk[gelfChunkHeader:]...)
+	}
+	if count < 2 {
+		t.Fatalf("expected multiple chunks, got %d", count)
+	}
+	if have := inflate(assembled); have != large.String() {
+		t.Fatalf("reassembled message does not match")
+	}
+}
+
+func TestDialGELFTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("unable to listen: %v", err)
+	}
+	defer func() { _ = l.Close() }()
+
+	w, err := DialGELF("tcp", l.Addr().String(), GELFCompressGzip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	_, _ = w.Write([]byte(`{"short_message":"1"}`))
+	_, _ = w.Write([]byte(`{"short_message":"2"}`))
+	_ = w.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	have, _ := io.ReadAll(conn)
+	if expected := "{\"short_message\":\"1\"}\x00{\"short_message\":\"2\"}\x00"; string(have) != expected {
+		t.Fatalf("expected %q to match %q", have, expected)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Journald returns a function.EmitErr writing each log line as an entry using
+// the systemd journal native protocol to w, typically obtained from
+// DialJournald. Each entry is written in a single Write call.
+//
+// Entries hold the MESSAGE, PRIORITY and SYSLOG_IDENTIFIER fields, with the
+// priority mapped from the level and the identifier set to the application
+// name configured through the options. If the function Logger is created with
+// the function.WithCaller option, which honors its caller skip, the
+// CODE_FILE, CODE_LINE and CODE_FUNC fields hold the call site.
+//
+// The error, its chain of wrapped errors as with Logfmt, and the key-value
+// pairs found in Context, added to the Logger and passed to the logging method
+// are added as fields, allowing filtering with
+// journalctl, e.g. journalctl REQUEST_ID=42. Keys are converted to valid field
+// names: upper cased, with characters other than A-Z, 0-9 and underscore
+// replaced by an underscore and leading underscores removed. Keys starting
+// with a digit are prefixed with "F". Values are written as their logfmt
+// representation, without quoting.
+func Journald(w io.Writer, opts ...Option) function.EmitErr {
+	var (
+		o  = newOptions(opts)
+		sw = &syncWriter{w: w}
+	)
+	if o.appName == "" {
+		o.appName = filepath.Base(os.Args[0])
+	}
+
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) error {
+		b := getBuf()
+		defer putBuf(b)
+
+		buf := *b
+		buf = appendJournalField(buf, "MESSAGE", msg)
+		buf = appendJournalField(buf, "PRIORITY", strconv.Itoa(int(o.severity(level))))
+		buf = appendJournalField(buf, "SYSLOG_IDENTIFIER", o.appName)
+		if frame, ok := values.Caller.Resolve(); ok {
+			buf = appendJournalField(buf, "CODE_FILE", frame.File)
+			buf = appendJournalField(buf, "CODE_LINE", strconv.Itoa(frame.Line))
+			buf = appendJournalField(buf, "CODE_FUNC", frame.Function)
+		}
+		if o.errorKey != "" && err != nil {
+			buf = appendJournalField(buf, journalFieldName(o.errorKey), err.Error())
+			chain := errorChain(o.errorKey, err, true)
+			for i := 0; i < len(chain); i += 2 {
+				buf = appendJournalField(buf, journalFieldName(chain[i].(string)), chain[i+1].(string))
+			}
+		}
+		for _, kvs := range [][]interface{}{values.FromContext, values.FromLogger, values.FromMethod} {
+			for i := 0; i < len(kvs); i += 2 {
+				k, v := keyValue(kvs, i)
+				var s string
+				if str, ok := v.(string); ok {
+					s = str
+				} else {
+					s = string(appendLogfmtValue(nil, v))
+				}
+				buf = appendJournalField(buf, journalFieldName(k), s)
+			}
+		}
+
+		*b = buf
+		return sw.write(buf)
+	}
+}
+
+// appendJournalField appends the field in the journal native protocol format.
+// Values holding a newline use the binary safe format.
+func appendJournalField(buf []byte, name, value string) []byte {
+	buf = append(buf, name...)
+	if !strings.ContainsRune(value, '\n') {
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+		return append(buf, '\n')
+	}
+	buf = append(buf, '\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf = append(buf, size[:]...)
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
+
+// journalFieldName converts the key into a valid journal field name.
+func journalFieldName(key string) string {
+	b := make([]byte, 0, len(key)+1)
+	for i := 0; i < len(key) && len(b) < 64; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		default:
+			c = '_'
+		}
+		if c == '_' && len(b) == 0 {
+			// field names starting with an underscore are reserved for
+			// trusted fields added by journald.
+			continue
+		}
+		if len(b) == 0 && c >= '0' && c <= '9' {
+			b = append(b, 'F')
+		}
+		b = append(b, c)
+	}
+	if len(b) == 0 {
+		return "F"
+	}
+	return string(b)
+}
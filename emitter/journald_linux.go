@@ -0,0 +1,93 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// journalSocket is the location of the journal native protocol socket.
+var journalSocket = "/run/systemd/journal/socket"
+
+// DialJournald returns a connection to the systemd journal, for use with the
+// Journald emitter. Entries too large to be sent as a single datagram are
+// passed to the journal using a file descriptor.
+func DialJournald() (io.WriteCloser, error) {
+	addr := &net.UnixAddr{Name: journalSocket, Net: "unixgram"}
+	if _, err := os.Stat(addr.Name); err != nil {
+		return nil, err
+	}
+	// use an unconnected socket, as passing file descriptors requires
+	// addressing the journal explicitly.
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journalConn{conn: conn, addr: addr}, nil
+}
+
+// journalConn is a connection to the systemd journal.
+type journalConn struct {
+	mtx  sync.Mutex
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// Write implements io.Writer.
+func (j *journalConn) Write(p []byte) (int, error) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	n, err := j.conn.WriteToUnix(p, j.addr)
+	if err == nil {
+		return n, nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return n, err
+	}
+	if err = j.writeFD(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeFD writes the entry into an unlinked file on tmpfs and passes its file
+// descriptor to the journal, as documented by the native protocol for entries
+// exceeding the maximum datagram size.
+func (j *journalConn) writeFD(p []byte) error {
+	f, err := os.CreateTemp("/dev/shm", "journal.")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if err = os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err = f.Write(p); err != nil {
+		return err
+	}
+	_, _, err = j.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), j.addr)
+	return err
+}
+
+// Close implements io.Closer.
+func (j *journalConn) Close() error {
+	return j.conn.Close()
+}
@@ -0,0 +1,90 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestDialJournald(t *testing.T) {
+	defer func(s string) { journalSocket = s }(journalSocket)
+	journalSocket = filepath.Join(t.TempDir(), "socket")
+
+	ln, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unable to listen: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	_ = ln.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	w, err := DialJournald()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	logger := function.NewLoggerErr(Journald(w, WithAppName("app")), 0, function.WithErrorHandler(func(err error) {
+		t.Errorf("unexpected error: %v", err)
+	}))
+
+	// small entries are sent as datagram.
+	logger.Info("text")
+	buf := make([]byte, 1024)
+	n, err := ln.Read(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "MESSAGE=text\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\n"; string(buf[:n]) != want {
+		t.Fatalf("expected %q to match %q", buf[:n], want)
+	}
+
+	// large entries are passed using a file descriptor.
+	large := string(bytes.Repeat([]byte("x"), 4<<20))
+	logger.Info(large)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := ln.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("expected a control message: %v", err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("expected a file descriptor: %v", err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "journal")
+	defer func() { _ = f.Close() }()
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "MESSAGE=" + large + "\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\n"; string(entry) != want {
+		t.Fatalf("unexpected entry of %d bytes", len(entry))
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package emitter
+
+import (
+	"errors"
+	"io"
+)
+
+// DialJournald returns a connection to the systemd journal, for use with the
+// Journald emitter. The systemd journal is only available on Linux.
+func DialJournald() (io.WriteCloser, error) {
+	return nil, errors.New("journald is only supported on linux")
+}
@@ -0,0 +1,63 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestJournald(t *testing.T) {
+	tests := []struct {
+		name     string
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"info", func(l telemetry.Logger) { l.Info("text", "request-id", 42, "_hidden", true, "1st", "a") },
+			"MESSAGE=text\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\nCOMPONENT=lib\nREQUEST_ID=42\nHIDDEN=true\nF1ST=a\n"},
+		{"error", func(l telemetry.Logger) { l.Error("text", errors.New("failed")) },
+			"MESSAGE=text\nPRIORITY=3\nSYSLOG_IDENTIFIER=app\nERROR=failed\nCOMPONENT=lib\n"},
+		{"multi-line", func(l telemetry.Logger) { l.Warn("first\nsecond") },
+			"MESSAGE\n\x0c\x00\x00\x00\x00\x00\x00\x00first\nsecond\nPRIORITY=4\nSYSLOG_IDENTIFIER=app\nCOMPONENT=lib\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := function.NewLoggerErr(Journald(&out, WithAppName("app")), 0).With("component", "lib")
+
+			tt.logfunc(logger)
+
+			if out.String() != tt.expected {
+				t.Fatalf("expected %q to match %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestJournaldCaller(t *testing.T) {
+	var out bytes.Buffer
+	logger := function.NewLoggerErr(Journald(&out), 0, function.WithCaller())
+
+	logger.Info("text")
+	if !bytes.Contains(out.Bytes(), []byte("journald_test.go\nCODE_LINE=")) ||
+		!bytes.Contains(out.Bytes(), []byte("CODE_FUNC=github.com/basvanbeek/telemetry/emitter.TestJournaldCaller\n")) {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
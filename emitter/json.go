@@ -0,0 +1,239 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// JSON returns a function.EmitErr writing each log line to w as a single line
+// JSON object. The fixed fields come first, in order of timestamp, level,
+// message, error and caller, using the keys configured through the options.
+// They are followed by the key-value pairs found in Context, added to the
+// Logger and passed to the logging method, in that order. Keys are not
+// deduplicated, so a key-value pair may repeat a key used earlier.
+//
+// If the error wraps other errors, it is followed by a field suffixed with
+// CauseSuffix holding the innermost error, e.g. "error.cause", or if the chain
+// holds a multi-error, like the ones returned by errors.Join, by a field
+// suffixed with CausesSuffix holding the list of its errors.
+//
+// Strings are escaped as defined by RFC 8259, replacing invalid UTF-8 with the
+// Unicode replacement character. Values implementing json.Marshaler are
+// encoded using MarshalJSON, errors and fmt.Stringer implementations as their
+// string representation and other types using encoding/json. Values failing to
+// encode are written as their fmt representation. Nil pointers are written as
+// null, without calling their methods.
+func JSON(w io.Writer, opts ...Option) function.EmitErr {
+	var (
+		o  = newOptions(opts)
+		sw = &syncWriter{w: w}
+	)
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) error {
+		b := getBuf()
+		defer putBuf(b)
+
+		buf := append(*b, '{')
+		first := true
+		field := func(key string) {
+			if !first {
+				buf = append(buf, ',')
+			}
+			first = false
+			buf = appendJSONString(buf, key)
+			buf = append(buf, ':')
+		}
+
+		if o.timeKey != "" {
+			field(o.timeKey)
+			buf = appendJSONString(buf, o.now().Format(o.timeFormat))
+		}
+		if o.levelKey != "" {
+			field(o.levelKey)
+			buf = appendJSONString(buf, level.String())
+		}
+		if o.messageKey != "" {
+			field(o.messageKey)
+			buf = appendJSONString(buf, msg)
+		}
+		if o.errorKey != "" && err != nil {
+			field(o.errorKey)
+			buf = appendJSONString(buf, errorText(err))
+			chain := errorChain(o.errorKey, err, false)
+			for i := 0; i < len(chain); i += 2 {
+				field(chain[i].(string))
+				buf = appendJSONValue(buf, chain[i+1])
+			}
+		}
+		if o.callerKey != "" {
+			if c, ok := caller(values); ok {
+				field(o.callerKey)
+				buf = appendJSONString(buf, c)
+			}
+		}
+		for _, kvs := range [][]interface{}{values.FromContext, values.FromLogger, values.FromMethod} {
+			for i := 0; i < len(kvs); i += 2 {
+				k, v := keyValue(kvs, i)
+				field(k)
+				buf = appendJSONValue(buf, v)
+			}
+		}
+		buf = append(buf, '}', '\n')
+
+		*b = buf
+		return sw.write(buf)
+	}
+}
+
+// appendJSONValue appends the JSON encoding of v to buf.
+func appendJSONValue(buf []byte, v interface{}) []byte {
+	if isNilPointer(v) {
+		return append(buf, "null"...)
+	}
+	switch t := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, t)
+	case bool:
+		return strconv.AppendBool(buf, t)
+	case int:
+		return strconv.AppendInt(buf, int64(t), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(t), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(t), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(t), 10)
+	case int64:
+		return strconv.AppendInt(buf, t, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(t), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(t), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(t), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(t), 10)
+	case uint64:
+		return strconv.AppendUint(buf, t, 10)
+	case float32:
+		return appendJSONFloat(buf, float64(t), 32)
+	case float64:
+		return appendJSONFloat(buf, t, 64)
+	case time.Time:
+		return appendJSONString(buf, t.Format(time.RFC3339Nano))
+	case time.Duration:
+		return appendJSONString(buf, t.String())
+	case json.Marshaler:
+		if b, err := t.MarshalJSON(); err == nil {
+			// compact the output, like encoding/json does, to keep each
+			// record on a single line.
+			n := len(buf)
+			out := bytes.NewBuffer(buf)
+			if err = json.Compact(out, b); err == nil {
+				return out.Bytes()
+			}
+			buf = out.Bytes()[:n]
+		}
+		return appendJSONString(buf, fmt.Sprintf("%+v", v))
+	case error:
+		return appendJSONString(buf, t.Error())
+	case fmt.Stringer:
+		return appendJSONString(buf, t.String())
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return appendJSONString(buf, fmt.Sprintf("%+v", v))
+		}
+		return append(buf, b...)
+	}
+}
+
+// appendJSONFloat appends the float as a JSON number. NaN and infinities,
+// which JSON can not represent as number, are written as string.
+func appendJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(buf, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(buf, `"-Inf"`...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+const hex = "0123456789abcdef"
+
+// appendJSONString appends s as a quoted JSON string, escaping as defined by
+// RFC 8259. Invalid UTF-8 is replaced by U+FFFD, and U+2028 and U+2029 are
+// escaped so the output can be embedded in JavaScript.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
@@ -0,0 +1,154 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"info", nil, func(l telemetry.Logger) { l.Info("text", "id", 1, "ok", true) },
+			`{"level":"info","msg":"text","ctx":"value","lvl":"info","missing":"(MISSING)","id":1,"ok":true}`},
+		{"error", nil, func(l telemetry.Logger) { l.Error("text", errors.New("failed"), 1, 1.5) },
+			`{"level":"error","msg":"text","error":"failed","ctx":"value","lvl":"info","missing":"(MISSING)","1":1.5}`},
+		{"keys", []Option{WithLevelKey("severity"), WithMessageKey("message"), WithErrorKey("err")},
+			func(l telemetry.Logger) { l.Error("text", errors.New("failed")) },
+			`{"severity":"error","message":"text","err":"failed","ctx":"value","lvl":"info","missing":"(MISSING)"}`},
+		{"escaping", nil, func(l telemetry.Logger) { l.Warn("\"quoted\"\n\ttab\\ \x01\xff\u2028 é") },
+			`{"level":"warn","msg":"\"quoted\"\n\ttab\\ \u0001` + "\ufffd" + `\u2028 é","ctx":"value","lvl":"info","missing":"(MISSING)"}`},
+		{"values", nil, func(l telemetry.Logger) {
+			l.Info("text", "nil", nil, "nan", math.NaN(), "dur", time.Second, "map", map[string]int{"a": 1},
+				"raw", json.RawMessage(`{"b":2}`), "bad", json.RawMessage(`{`))
+		}, `{"level":"info","msg":"text","ctx":"value","lvl":"info","missing":"(MISSING)","nil":null,` +
+			`"nan":"NaN","dur":"1s","map":{"a":1},"raw":{"b":2},"bad":"{"}`},
+		{"chain", nil, func(l telemetry.Logger) {
+			l.Error("text", fmt.Errorf("query: %w", fmt.Errorf("dial: %w", errors.New("refused"))))
+		}, `{"level":"error","msg":"text","error":"query: dial: refused","error.cause":"refused",` +
+			`"ctx":"value","lvl":"info","missing":"(MISSING)"}`},
+		{"nil pointers", nil, func(l telemetry.Logger) {
+			l.Error("text", (*nilError)(nil), "url", (*url.URL)(nil), "err", (*nilError)(nil))
+		}, `{"level":"error","msg":"text","error":"<nil>","ctx":"value","lvl":"info","missing":"(MISSING)",` +
+			`"url":null,"err":null}`},
+		{"multi", nil, func(l telemetry.Logger) {
+			l.Error("text", multiError{errors.New("a"), errors.New("b")})
+		}, `{"level":"error","msg":"text","error":"a; b","error.causes":["a","b"],` +
+			`"ctx":"value","lvl":"info","missing":"(MISSING)"}`},
+		{"indented marshaler", nil, func(l telemetry.Logger) {
+			l.Info("text", "doc", indented{}, "raw", json.RawMessage("[\n  1,\n  2\n]"))
+		}, `{"level":"info","msg":"text","ctx":"value","lvl":"info","missing":"(MISSING)",` +
+			`"doc":{"a":1,"b":[true,null]},"raw":[1,2]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts := append([]Option{WithTimeKey("")}, tt.opts...)
+			logger := function.NewLoggerErr(JSON(&out, opts...), 0)
+
+			ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value")
+			l := logger.Context(ctx).With("lvl", telemetry.LevelInfo).With("missing")
+
+			tt.logfunc(l)
+
+			have := out.String()
+			if !json.Valid([]byte(have)) {
+				t.Fatalf("invalid JSON: %s", have)
+			}
+			if have != tt.expected+"\n" {
+				t.Fatalf("expected %s to match %s", have, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJSONFixedFields(t *testing.T) {
+	var out bytes.Buffer
+	logger := function.NewLoggerErr(JSON(&out, WithTimeFormat(time.RFC3339)), 0, function.WithCaller())
+
+	logger.Info("text")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := time.Parse(time.RFC3339, line[DefaultTimeKey].(string)); err != nil {
+		t.Errorf("unexpected timestamp: %v", err)
+	}
+	if c := line[DefaultCallerKey].(string); !strings.HasPrefix(c, "emitter/json_test.go:") {
+		t.Errorf("unexpected caller: %s", c)
+	}
+}
+
+func TestJSONClock(t *testing.T) {
+	var (
+		out   bytes.Buffer
+		clock = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	)
+	logger := function.NewLoggerErr(JSON(&out, WithClock(clock)), 0)
+
+	logger.Info("text")
+
+	if expected := `{"time":"2024-03-01T12:00:00Z","level":"info","msg":"text"}` + "\n"; out.String() != expected {
+		t.Fatalf("expected %q to match %q", out.String(), expected)
+	}
+}
+
+// nilError panics if its Error method is called on a nil pointer.
+type nilError struct {
+	msg string
+}
+
+func (e *nilError) Error() string { return e.msg }
+
+// indented returns multi-line JSON from its MarshalJSON method.
+type indented struct{}
+
+func (indented) MarshalJSON() ([]byte, error) {
+	return []byte("{\n  \"a\": 1,\n  \"b\": [\n    true,\n    null\n  ]\n}\n"), nil
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestJSONWriteError(t *testing.T) {
+	var errs []error
+	logger := function.NewLoggerErr(JSON(failingWriter{}), 0, function.WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	logger.Info("text")
+	if len(errs) != 1 || errs[0].Error() != "write failed" {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
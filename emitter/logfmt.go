@@ -0,0 +1,225 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Logfmt returns a function.EmitErr writing each log line to w in logfmt
+// format, as ingested by e.g. Heroku and the Grafana agent. The fixed fields
+// come first, in order of timestamp, level, message, error and caller, using
+// the keys configured through the options. They are followed by the key-value
+// pairs found in Context, added to the Logger and passed to the logging
+// method, in that order. Keys are not deduplicated; as logfmt consumers
+// typically keep the last occurrence of a repeated key, key-value pairs passed
+// to the logging method take precedence over those added to the Logger, which
+// take precedence over those found in Context.
+//
+// If the error wraps other errors, it is followed by the fields describing its
+// chain as with JSON, with the errors of a multi-error written as separate
+// fields, e.g. "error.causes.0" and "error.causes.1".
+//
+// Values are quoted if empty or holding spaces, equal signs, quotes or
+// non-printable characters. Invalid characters in keys are replaced by an
+// underscore. Errors and fmt.Stringer implementations are written as their
+// string representation, other types using their fmt representation. Nil
+// pointers are written as null, without calling their methods.
+func Logfmt(w io.Writer, opts ...Option) function.EmitErr {
+	var (
+		o  = newOptions(opts)
+		sw = &syncWriter{w: w}
+	)
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) error {
+		b := getBuf()
+		defer putBuf(b)
+
+		buf := *b
+		field := func(key string) {
+			if len(buf) > 0 {
+				buf = append(buf, ' ')
+			}
+			buf = appendLogfmtKey(buf, key)
+			buf = append(buf, '=')
+		}
+
+		if o.timeKey != "" {
+			field(o.timeKey)
+			buf = appendLogfmtString(buf, o.now().Format(o.timeFormat))
+		}
+		if o.levelKey != "" {
+			field(o.levelKey)
+			buf = appendLogfmtString(buf, level.String())
+		}
+		if o.messageKey != "" {
+			field(o.messageKey)
+			buf = appendLogfmtString(buf, msg)
+		}
+		if o.errorKey != "" && err != nil {
+			field(o.errorKey)
+			buf = appendLogfmtString(buf, errorText(err))
+			chain := errorChain(o.errorKey, err, true)
+			for i := 0; i < len(chain); i += 2 {
+				field(chain[i].(string))
+				buf = appendLogfmtString(buf, chain[i+1].(string))
+			}
+		}
+		if o.callerKey != "" {
+			if c, ok := caller(values); ok {
+				field(o.callerKey)
+				buf = appendLogfmtString(buf, c)
+			}
+		}
+		for _, kvs := range [][]interface{}{values.FromContext, values.FromLogger, values.FromMethod} {
+			for i := 0; i < len(kvs); i += 2 {
+				k, v := keyValue(kvs, i)
+				field(k)
+				buf = appendLogfmtValue(buf, v)
+			}
+		}
+		buf = append(buf, '\n')
+
+		*b = buf
+		return sw.write(buf)
+	}
+}
+
+// appendLogfmtKey appends the key, replacing characters not allowed in logfmt
+// keys by an underscore.
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, '_')
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			r = '_'
+		}
+		buf = append(buf, string(r)...)
+	}
+	return buf
+}
+
+// appendLogfmtValue appends the logfmt encoding of v to buf.
+func appendLogfmtValue(buf []byte, v interface{}) []byte {
+	if isNilPointer(v) {
+		return append(buf, "null"...)
+	}
+	switch t := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendLogfmtString(buf, t)
+	case bool:
+		return strconv.AppendBool(buf, t)
+	case int:
+		return strconv.AppendInt(buf, int64(t), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(t), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(t), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(t), 10)
+	case int64:
+		return strconv.AppendInt(buf, t, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(t), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(t), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(t), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(t), 10)
+	case uint64:
+		return strconv.AppendUint(buf, t, 10)
+	case float32:
+		return strconv.AppendFloat(buf, float64(t), 'g', -1, 32)
+	case float64:
+		return strconv.AppendFloat(buf, t, 'g', -1, 64)
+	case time.Time:
+		return appendLogfmtString(buf, t.Format(time.RFC3339Nano))
+	case error:
+		return appendLogfmtString(buf, t.Error())
+	case fmt.Stringer:
+		return appendLogfmtString(buf, t.String())
+	default:
+		return appendLogfmtString(buf, fmt.Sprint(v))
+	}
+}
+
+// appendLogfmtString appends s, quoting and escaping it if needed.
+func appendLogfmtString(buf []byte, s string) []byte {
+	if !needsQuoting(s) {
+		return append(buf, s...)
+	}
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' && c < utf8.RuneSelf {
+			i++
+			continue
+		}
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r != utf8.RuneError || size != 1 {
+				i += size
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i++
+			start = i
+			continue
+		}
+		buf = append(buf, s[start:i]...)
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+		}
+		i++
+		start = i
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+// needsQuoting reports whether the logfmt value needs to be quoted.
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestLogfmt(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"info", nil, func(l telemetry.Logger) { l.Info("text", "id", 1, "ok", true) },
+			`level=info msg=text ctx=value lvl=info missing=(MISSING) id=1 ok=true`},
+		{"error", nil, func(l telemetry.Logger) { l.Error("request failed", errors.New("not found"), 1, 1.5) },
+			`level=error msg="request failed" error="not found" ctx=value lvl=info missing=(MISSING) 1=1.5`},
+		{"keys", []Option{WithLevelKey("severity"), WithMessageKey("message"), WithErrorKey("")},
+			func(l telemetry.Logger) { l.Error("text", errors.New("failed")) },
+			`severity=error message=text ctx=value lvl=info missing=(MISSING)`},
+		{"quoting", nil, func(l telemetry.Logger) {
+			l.Warn("a=b", "empty", "", "quote", `say "hi"`, "lines", "a\nb\x01\xff", "bad key=", "x")
+		}, `level=warn msg="a=b" ctx=value lvl=info missing=(MISSING) empty="" quote="say \"hi\"" ` +
+			`lines="a\nb\u0001` + "\ufffd" + `" bad_key_=x`},
+		{"values", nil, func(l telemetry.Logger) {
+			l.Info("text", "nil", nil, "dur", time.Second, "slice", []int{1, 2})
+		}, `level=info msg=text ctx=value lvl=info missing=(MISSING) nil=null dur=1s slice="[1 2]"`},
+		{"chain", []Option{WithErrorKey("err")}, func(l telemetry.Logger) {
+			l.Error("text", fmt.Errorf("query: %w", fmt.Errorf("dial: %w", errors.New("refused"))))
+		}, `level=error msg=text err="query: dial: refused" err.cause=refused ctx=value lvl=info missing=(MISSING)`},
+		{"nil pointers", nil, func(l telemetry.Logger) {
+			l.Error("text", (*nilError)(nil), "url", (*url.URL)(nil), "err", (*nilError)(nil))
+		}, `level=error msg=text error=<nil> ctx=value lvl=info missing=(MISSING) url=null err=null`},
+		{"multi", nil, func(l telemetry.Logger) {
+			l.Error("text", fmt.Errorf("close: %w", multiError{errors.New("a"), nil, errors.New("b")}))
+		}, `level=error msg=text error="close: a; b" error.causes.0=a error.causes.1=b ctx=value lvl=info missing=(MISSING)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts := append([]Option{WithTimeKey("")}, tt.opts...)
+			logger := function.NewLoggerErr(Logfmt(&out, opts...), 0)
+
+			ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value")
+			l := logger.Context(ctx).With("lvl", telemetry.LevelInfo).With("missing")
+
+			tt.logfunc(l)
+
+			if out.String() != tt.expected+"\n" {
+				t.Fatalf("expected %s to match %s", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+// multiError mimics the multi-errors returned by errors.Join.
+type multiError []error
+
+func (m multiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (m multiError) Unwrap() []error { return m }
@@ -0,0 +1,287 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the layout of the timestamp added to rotated files.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// WithMaxSize sets the size in bytes after which a RotatingFile is rotated.
+// Zero, the default, disables size based rotation.
+func WithMaxSize(size int64) Option {
+	return func(o *options) {
+		o.maxSize = size
+	}
+}
+
+// WithMaxAge sets the age after which a RotatingFile is rotated, measured from
+// the moment the file was opened. Zero, the default, disables age based
+// rotation.
+func WithMaxAge(age time.Duration) Option {
+	return func(o *options) {
+		o.maxAge = age
+	}
+}
+
+// WithMaxBackups sets the number of rotated files a RotatingFile retains, with
+// the oldest removed first. Zero, the default, retains all rotated files.
+func WithMaxBackups(n int) Option {
+	return func(o *options) {
+		o.maxBackups = n
+	}
+}
+
+// compile time check for compatibility with the io.WriteCloser interface.
+var _ io.WriteCloser = (*RotatingFile)(nil)
+
+// RotatingFile is a log file sink rotating based on size and age. It is safe
+// for concurrent use. Combine it with any of the emitters to obtain a
+// function.EmitErr writing to the file, e.g. JSON(f). Pass its Flush method to
+// function.WithFlush to have the file synced before the process exits on
+// Fatal.
+//
+// Rotation renames the file, adding a timestamp between its name and
+// extension, e.g. "app-2024-01-02T15-04-05.000.log" for "app.log", and
+// continues writing to a new file. Rotation happens before a write would
+// exceed the maximum size or once the file exceeds its maximum age, so log
+// lines written in a single Write call are never split over files. If renaming
+// fails, writing continues to the current file and the error is returned by
+// the Write call triggering the rotation, which is retried once the file has
+// grown by another maximum size or reached another maximum age.
+type RotatingFile struct {
+	mtx        sync.Mutex
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	now        func() time.Time
+
+	file   *os.File
+	closed bool
+	// size holds the number of bytes counted towards the maximum size.
+	size   int64
+	opened time.Time
+	// rotated holds the timestamp of the last backup, used to keep backup
+	// names unique when rotating multiple times within a millisecond.
+	rotated time.Time
+}
+
+// NewRotatingFile opens the file at path for appending, creating it and its
+// directory if needed, and returns a RotatingFile configured through the
+// rotation options WithMaxSize, WithMaxAge and WithMaxBackups.
+func NewRotatingFile(path string, opts ...Option) (*RotatingFile, error) {
+	o := newOptions(opts)
+	if o.maxSize < 0 || o.maxAge < 0 || o.maxBackups < 0 {
+		return nil, errors.New("rotation limits can not be negative")
+	}
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    o.maxSize,
+		maxAge:     o.maxAge,
+		maxBackups: o.maxBackups,
+		now:        time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write implements io.Writer.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.file == nil {
+		if f.closed {
+			return 0, os.ErrClosed
+		}
+		// a previous rotation failed to reopen the file.
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	var rotateErr error
+	if f.size > 0 && (f.maxSize > 0 && f.size+int64(len(p)) > f.maxSize ||
+		f.maxAge > 0 && f.now().Sub(f.opened) >= f.maxAge) {
+		if rotateErr = f.rotate(); f.file == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Rotate rotates the file, regardless of its size and age.
+func (f *RotatingFile) Rotate() error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.file == nil {
+		if f.closed {
+			return os.ErrClosed
+		}
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	return f.rotate()
+}
+
+// Sync commits the file contents to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.file.Sync()
+}
+
+// Flush syncs the file, ignoring errors. It matches the signature expected by
+// function.WithFlush.
+func (f *RotatingFile) Flush() {
+	_ = f.Sync()
+}
+
+// Close implements io.Closer.
+func (f *RotatingFile) Close() error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open opens the file for appending.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file, f.size, f.opened = file, info.Size(), f.now()
+	return nil
+}
+
+// rotate renames the current file, opens a new one and removes the backups
+// exceeding the maximum. If the file can't be renamed, it is reopened for
+// appending and the next rotation is postponed.
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err == nil {
+		err = f.rename()
+	}
+	if err != nil {
+		if oErr := f.open(); oErr != nil {
+			return fmt.Errorf("%w; reopening %s: %v", err, f.path, oErr)
+		}
+		f.size = 0
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.prune()
+}
+
+// rename moves the closed file to a new backup name.
+func (f *RotatingFile) rename() error {
+	ts := f.now().Truncate(time.Millisecond)
+	if !ts.After(f.rotated) {
+		ts = f.rotated.Add(time.Millisecond)
+	}
+	f.rotated = ts
+	prefix, ext := f.backupName()
+	backup := prefix + ts.Format(backupTimeFormat) + ext
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("rotating %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// prune removes the oldest backups exceeding the maximum.
+func (f *RotatingFile) prune() error {
+	if f.maxBackups == 0 {
+		return nil
+	}
+	prefix, ext := f.backupName()
+	dir, prefix := filepath.Dir(prefix), filepath.Base(prefix)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if _, err := time.Parse(backupTimeFormat, ts); err == nil {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= f.maxBackups {
+		return nil
+	}
+	// the timestamp format sorts chronologically.
+	sort.Strings(backups)
+	for _, b := range backups[:len(backups)-f.maxBackups] {
+		if err := os.Remove(filepath.Join(dir, b)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backupName returns the parts of the backup file name surrounding the
+// timestamp.
+func (f *RotatingFile) backupName() (string, string) {
+	ext := filepath.Ext(f.path)
+	return strings.TrimSuffix(f.path, ext) + "-", ext
+}
@@ -0,0 +1,203 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// fakeClock returns a clock advancing a millisecond on each reading.
+func fakeClock() func() time.Time {
+	t := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	return func() time.Time {
+		t = t.Add(time.Millisecond)
+		return t
+	}
+}
+
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, e.Name()+":"+string(b))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewRotatingFile(filepath.Join(dir, "logs", "app.log"), WithMaxSize(10), WithMaxBackups(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	f.now = fakeClock()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "oversized line\n", "fifth\n"} {
+		if _, err = f.Write([]byte(line)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	have := files(t, filepath.Join(dir, "logs"))
+	want := []string{
+		"app-2024-01-02T15-04-05.005.log:third\n",
+		"app-2024-01-02T15-04-05.007.log:oversized line\n",
+		"app.log:fifth\n",
+	}
+	if strings.Join(have, "|") != strings.Join(want, "|") {
+		t.Fatalf("want: %q\nhave: %q", want, have)
+	}
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("existing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewRotatingFile(path, WithMaxAge(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	now := time.Now()
+	f.now = func() time.Time { return now }
+
+	_, _ = f.Write([]byte("appended\n"))
+	now = now.Add(time.Hour)
+	_, _ = f.Write([]byte("rotated\n"))
+
+	have := files(t, dir)
+	if len(have) != 2 || !strings.HasSuffix(have[0], ".log:existing\nappended\n") || have[1] != "app.log:rotated\n" {
+		t.Fatalf("unexpected files: %q", have)
+	}
+}
+
+func TestRotatingFileRenameFailure(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewRotatingFile(filepath.Join(dir, "app.log"), WithMaxSize(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	f.now = func() time.Time { return now }
+
+	// a non-empty directory at the backup path makes renaming fail.
+	blocked := filepath.Join(dir, "app-2024-01-02T15-04-05.000.log")
+	if err = os.MkdirAll(filepath.Join(blocked, "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = f.Write([]byte("first\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the failed rotation is reported once, while writing continues.
+	if _, err = f.Write([]byte("second\n")); err == nil {
+		t.Fatal("expected rotation error")
+	}
+	if _, err = f.Write([]byte("3\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// rotation is retried once the file has grown by another maximum size.
+	if err = os.RemoveAll(blocked); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.Write([]byte("fourth\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	have := files(t, dir)
+	want := []string{
+		"app-2024-01-02T15-04-05.001.log:first\nsecond\n3\n",
+		"app.log:fourth\n",
+	}
+	if strings.Join(have, "|") != strings.Join(want, "|") {
+		t.Fatalf("want: %q\nhave: %q", want, have)
+	}
+}
+
+func TestRotatingFileEmit(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewRotatingFile(filepath.Join(dir, "app.log"), WithMaxSize(1024))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger := function.NewLoggerErr(JSON(f, WithTimeKey("")), 0, function.WithFlush(f.Flush),
+		function.WithErrorHandler(func(err error) { t.Errorf("unexpected error: %v", err) }))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.Info("text", "j", j)
+			}
+		}()
+	}
+	wg.Wait()
+	if err = f.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := 0
+	for _, file := range files(t, dir) {
+		content := file[strings.Index(file, ":")+1:]
+		if len(content) > 1024 {
+			t.Errorf("file exceeds maximum size: %d", len(content))
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+			if !strings.HasPrefix(line, `{"level":"info","msg":"text","j":`) {
+				t.Fatalf("corrupted line: %q", line)
+			}
+			lines++
+		}
+	}
+	if lines != 800 {
+		t.Fatalf("expected 800 lines, have %d", lines)
+	}
+
+	if _, err = f.Write([]byte("closed\n")); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("expected os.ErrClosed, have %v", err)
+	}
+}
+
+func TestRotatingFileOptions(t *testing.T) {
+	if _, err := NewRotatingFile(filepath.Join(t.TempDir(), "app.log"), WithMaxBackups(-1)); err == nil {
+		t.Fatal("expected error on negative limit")
+	}
+}
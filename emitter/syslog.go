@@ -0,0 +1,340 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// SyslogFormat identifies the syslog message format.
+type SyslogFormat int
+
+// Supported syslog message formats.
+const (
+	// RFC3164 is the BSD syslog format, understood by most local syslog
+	// daemons.
+	RFC3164 SyslogFormat = iota
+	// RFC5424 is the IETF syslog format, holding a full timestamp and a
+	// structured header.
+	RFC5424
+)
+
+// Facility holds the syslog facility of the emitted messages.
+type Facility int
+
+// Syslog facilities as defined by RFC 5424.
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	_ // NTP subsystem
+	_ // log audit
+	_ // log alert
+	_ // clock daemon
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// Severity holds the syslog severity of an emitted message.
+type Severity int
+
+// Syslog severities as defined by RFC 5424.
+const (
+	SeverityEmergency Severity = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// WithSyslogFormat sets the message format of the Syslog emitter. The default
+// is RFC3164.
+func WithSyslogFormat(format SyslogFormat) Option {
+	return func(o *options) {
+		o.syslogFormat = format
+	}
+}
+
+// WithFacility sets the facility of the Syslog emitter. The default is
+// FacilityUser.
+func WithFacility(facility Facility) Option {
+	return func(o *options) {
+		o.facility = facility
+	}
+}
+
+// WithAppName sets the application name, or tag, of the Syslog emitter. If
+// not set, the base name of the executable is used.
+func WithAppName(name string) Option {
+	return func(o *options) {
+		o.appName = name
+	}
+}
+
+// WithHostname sets the host name of the Syslog emitter. If not set, the host
+// name reported by the kernel is used.
+func WithHostname(name string) Option {
+	return func(o *options) {
+		o.hostname = name
+	}
+}
+
+// WithSeverityFunc sets the function mapping log levels onto syslog
+// severities for the Syslog emitter. See DefaultSeverity for the default.
+func WithSeverityFunc(fn func(telemetry.Level) Severity) Option {
+	return func(o *options) {
+		o.severity = fn
+	}
+}
+
+// DefaultSeverity maps Error onto SeverityError, Warn onto SeverityWarning,
+// Info onto SeverityInfo and Debug and Trace onto SeverityDebug.
+func DefaultSeverity(level telemetry.Level) Severity {
+	switch {
+	case level <= telemetry.LevelError:
+		return SeverityError
+	case level <= telemetry.LevelWarn:
+		return SeverityWarning
+	case level <= telemetry.LevelInfo:
+		return SeverityInfo
+	default:
+		return SeverityDebug
+	}
+}
+
+// Syslog returns a function.EmitErr writing each log line as a syslog message
+// to w, typically obtained from DialSyslog. The message format, facility,
+// application name, host name and level to severity mapping are configured
+// through the options. Each message is written in a single Write call and
+// terminated by a newline, allowing both datagram and stream transports.
+//
+// The message body holds the log message, followed by the error, caller and
+// the key-value pairs found in Context, added to the Logger and passed to the
+// logging method, encoded as logfmt. The chain of wrapped errors is encoded as
+// with Logfmt. The time and level options do not apply,
+// as the syslog header holds the timestamp and severity.
+func Syslog(w io.Writer, opts ...Option) function.EmitErr {
+	var (
+		o   = newOptions(opts)
+		sw  = &syncWriter{w: w}
+		pid = strconv.Itoa(os.Getpid())
+	)
+	if o.appName == "" {
+		o.appName = filepath.Base(os.Args[0])
+	}
+	if o.hostname == "" {
+		o.hostname, _ = os.Hostname()
+	}
+	o.appName = syslogHeaderField(o.appName, 48)
+	o.hostname = syslogHeaderField(o.hostname, 255)
+
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) error {
+		b := getBuf()
+		defer putBuf(b)
+
+		now := o.now()
+		pri := int(o.facility)*8 + int(o.severity(level))
+
+		buf := append(*b, '<')
+		buf = strconv.AppendInt(buf, int64(pri), 10)
+		buf = append(buf, '>')
+		switch o.syslogFormat {
+		case RFC5424:
+			buf = append(buf, "1 "...)
+			buf = now.AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+			buf = append(buf, ' ')
+			buf = append(buf, o.hostname...)
+			buf = append(buf, ' ')
+			buf = append(buf, o.appName...)
+			buf = append(buf, ' ')
+			buf = append(buf, pid...)
+			buf = append(buf, " - - "...)
+		default:
+			buf = now.AppendFormat(buf, time.Stamp)
+			buf = append(buf, ' ')
+			buf = append(buf, o.hostname...)
+			buf = append(buf, ' ')
+			buf = append(buf, o.appName...)
+			buf = append(buf, '[')
+			buf = append(buf, pid...)
+			buf = append(buf, "]: "...)
+		}
+
+		buf = append(buf, msg...)
+		if o.errorKey != "" && err != nil {
+			buf = append(buf, ' ')
+			buf = appendLogfmtKey(buf, o.errorKey)
+			buf = append(buf, '=')
+			buf = appendLogfmtString(buf, err.Error())
+			chain := errorChain(o.errorKey, err, true)
+			for i := 0; i < len(chain); i += 2 {
+				buf = append(buf, ' ')
+				buf = appendLogfmtKey(buf, chain[i].(string))
+				buf = append(buf, '=')
+				buf = appendLogfmtString(buf, chain[i+1].(string))
+			}
+		}
+		if o.callerKey != "" {
+			if c, ok := caller(values); ok {
+				buf = append(buf, ' ')
+				buf = appendLogfmtKey(buf, o.callerKey)
+				buf = append(buf, '=')
+				buf = appendLogfmtString(buf, c)
+			}
+		}
+		for _, kvs := range [][]interface{}{values.FromContext, values.FromLogger, values.FromMethod} {
+			for i := 0; i < len(kvs); i += 2 {
+				k, v := keyValue(kvs, i)
+				buf = append(buf, ' ')
+				buf = appendLogfmtKey(buf, k)
+				buf = append(buf, '=')
+				buf = appendLogfmtValue(buf, v)
+			}
+		}
+		buf = append(buf, '\n')
+
+		*b = buf
+		return sw.write(buf)
+	}
+}
+
+// syslogHeaderField returns the value restricted to the printable US-ASCII
+// characters and maximum length allowed in syslog header fields. An empty
+// value is replaced by the nil value "-".
+func syslogHeaderField(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// localSyslogPaths holds the well known locations of the local syslog socket.
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// errNoLocalSyslog is returned if no local syslog socket could be found.
+var errNoLocalSyslog = errors.New("no local syslog socket found")
+
+// DialSyslog returns a connection to a syslog daemon, for use with the Syslog
+// emitter. An empty network connects to the local syslog daemon through its
+// unix socket. Otherwise, network and address are as accepted by net.Dial,
+// e.g. "udp" and "logs.example.com:514". The returned connection reconnects
+// on the next write if a write fails.
+func DialSyslog(network, address string) (io.WriteCloser, error) {
+	c := &syslogConn{network: network, address: address}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// syslogConn is a connection to a syslog daemon, reconnecting after failures.
+type syslogConn struct {
+	mtx     sync.Mutex
+	network string
+	address string
+	conn    net.Conn
+}
+
+// Write implements io.Writer.
+func (c *syslogConn) Write(p []byte) (int, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.conn != nil {
+		if n, err := c.conn.Write(p); err == nil {
+			return n, nil
+		}
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+	if err := c.connectLocked(); err != nil {
+		return 0, err
+	}
+	return c.conn.Write(p)
+}
+
+// Close implements io.Closer.
+func (c *syslogConn) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *syslogConn) connect() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.connectLocked()
+}
+
+func (c *syslogConn) connectLocked() error {
+	if c.network != "" {
+		conn, err := net.Dial(c.network, c.address)
+		if err != nil {
+			return err
+		}
+		c.conn = conn
+		return nil
+	}
+	for _, path := range localSyslogPaths {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				c.conn = conn
+				return nil
+			}
+		}
+	}
+	return errNoLocalSyslog
+}
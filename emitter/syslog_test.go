@@ -0,0 +1,97 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package emitter
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestSyslog(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		name     string
+		opts     []Option
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"rfc3164", nil, func(l telemetry.Logger) { l.Info("text", "id", 1) },
+			`<14>TIMESTAMP host app[` + pid + `]: text component=lib id=1`},
+		{"rfc3164-error", []Option{WithFacility(FacilityLocal3)},
+			func(l telemetry.Logger) { l.Error("text", errors.New("not found")) },
+			`<155>TIMESTAMP host app[` + pid + `]: text error="not found" component=lib`},
+		{"rfc5424", []Option{WithSyslogFormat(RFC5424), WithFacility(FacilityDaemon)},
+			func(l telemetry.Logger) { l.Warn("text") },
+			`<28>1 TIMESTAMP host app ` + pid + ` - - text component=lib`},
+		{"severity", []Option{WithSeverityFunc(func(telemetry.Level) Severity { return SeverityNotice })},
+			func(l telemetry.Logger) { l.Info("text") },
+			`<13>TIMESTAMP host app[` + pid + `]: text component=lib`},
+		{"header-fields", []Option{WithAppName("my app"), WithHostname("\x01")},
+			func(l telemetry.Logger) { l.Info("text") },
+			`<14>TIMESTAMP - myapp[` + pid + `]: text component=lib`},
+	}
+
+	stamp := regexp.MustCompile(`^(<\d+>(?:1 )?)(\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}(?:Z|[+-]\d\d:\d\d)|[A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d)`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts := append([]Option{WithHostname("host"), WithAppName("app")}, tt.opts...)
+			logger := function.NewLoggerErr(Syslog(&out, opts...), 0).With("component", "lib")
+
+			tt.logfunc(logger)
+
+			have := stamp.ReplaceAllString(out.String(), "${1}TIMESTAMP")
+			if have != tt.expected+"\n" {
+				t.Fatalf("expected %q to match %q", have, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDialSyslog(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("unable to listen: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	w, err := DialSyslog("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	logger := function.NewLoggerErr(Syslog(w, WithSyslogFormat(RFC5424)), 0)
+	logger.Info("text")
+
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(buf[:n], []byte("<14>1 ")) || !bytes.HasSuffix(buf[:n], []byte(" - - text\n")) {
+		t.Fatalf("unexpected message: %q", buf[:n])
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eventlog provides a function.EmitErr writing log lines to the
+// Windows Event Log.
+package eventlog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// EventType holds the type of an Event Log entry.
+type EventType int
+
+// Supported Event Log entry types.
+const (
+	Info EventType = iota
+	Warning
+	Error
+)
+
+// DefaultEventID is the event identifier used if not configured otherwise.
+const DefaultEventID = 1
+
+type (
+	// EventLog writes entries to an event source. It is implemented by the
+	// Log type of golang.org/x/sys/windows/svc/eventlog.
+	EventLog interface {
+		Info(eid uint32, msg string) error
+		Warning(eid uint32, msg string) error
+		Error(eid uint32, msg string) error
+	}
+
+	// Log is an EventLog handle as returned by Open.
+	Log interface {
+		EventLog
+		Close() error
+	}
+)
+
+type (
+	// Option implements a functional option type for the Event Log emitter.
+	Option func(*options)
+
+	// options holds the configuration of the Event Log emitter.
+	options struct {
+		eventID   uint32
+		eventType func(telemetry.Level) EventType
+	}
+)
+
+// WithEventID sets the event identifier of the written entries. The default is
+// DefaultEventID.
+func WithEventID(eid uint32) Option {
+	return func(o *options) {
+		o.eventID = eid
+	}
+}
+
+// WithEventTypeFunc sets the function mapping log levels onto entry types.
+// See DefaultEventType for the default.
+func WithEventTypeFunc(fn func(telemetry.Level) EventType) Option {
+	return func(o *options) {
+		o.eventType = fn
+	}
+}
+
+// DefaultEventType maps Error onto Error, Warn onto Warning and Info, Debug and
+// Trace onto Info entries.
+func DefaultEventType(level telemetry.Level) EventType {
+	switch {
+	case level <= telemetry.LevelError:
+		return Error
+	case level <= telemetry.LevelWarn:
+		return Warning
+	default:
+		return Info
+	}
+}
+
+// Emit returns a function.EmitErr writing each log line as an entry to the
+// provided EventLog. The entry holds the log message on its first line,
+// followed by a line holding the error, caller and the key-value pairs found in
+// Context, added to the Logger and passed to the logging method, encoded as
+// logfmt. The entry type is determined by the level, see WithEventTypeFunc.
+func Emit(l EventLog, opts ...Option) function.EmitErr {
+	o := options{
+		eventID:   DefaultEventID,
+		eventType: DefaultEventType,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var (
+		mtx    sync.Mutex
+		fields bytes.Buffer
+		encode = emitter.Logfmt(&fields,
+			emitter.WithTimeKey(""), emitter.WithLevelKey(""), emitter.WithMessageKey(""))
+	)
+	return func(level telemetry.Level, msg string, err error, values function.Values, callerSkip int) error {
+		mtx.Lock()
+		fields.Reset()
+		_ = encode(level, msg, err, values, callerSkip+1)
+		if f := strings.TrimSuffix(fields.String(), "\n"); f != "" {
+			msg += "\n" + f
+		}
+		mtx.Unlock()
+
+		switch o.eventType(level) {
+		case Error:
+			return l.Error(o.eventID, msg)
+		case Warning:
+			return l.Warning(o.eventID, msg)
+		default:
+			return l.Info(o.eventID, msg)
+		}
+	}
+}
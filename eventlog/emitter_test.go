@@ -0,0 +1,85 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventlog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type mockEventLog struct {
+	entries []string
+}
+
+func (m *mockEventLog) Info(eid uint32, msg string) error {
+	m.entries = append(m.entries, fmt.Sprintf("info:%d:%s", eid, msg))
+	return nil
+}
+
+func (m *mockEventLog) Warning(eid uint32, msg string) error {
+	m.entries = append(m.entries, fmt.Sprintf("warning:%d:%s", eid, msg))
+	return nil
+}
+
+func (m *mockEventLog) Error(eid uint32, msg string) error {
+	m.entries = append(m.entries, fmt.Sprintf("error:%d:%s", eid, msg))
+	return errors.New("event log full")
+}
+
+func TestEmit(t *testing.T) {
+	var (
+		el   mockEventLog
+		errs []error
+	)
+	logger := function.NewLoggerErr(Emit(&el), 0, function.WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+	logger.SetLevel(telemetry.LevelDebug)
+
+	logger.Debug("debug")
+	logger.With("component", "lib").Info("info", "id", 1)
+	logger.Warn("warn")
+	logger.Error("error", errors.New("not found"))
+
+	want := []string{
+		"info:1:debug",
+		"info:1:info\ncomponent=lib id=1",
+		"warning:1:warn",
+		"error:1:error\nerror=\"not found\"",
+	}
+	if !reflect.DeepEqual(want, el.entries) {
+		t.Fatalf("want: %q\nhave: %q", want, el.entries)
+	}
+	if len(errs) != 1 || errs[0].Error() != "event log full" {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+func TestEmitOptions(t *testing.T) {
+	var el mockEventLog
+	logger := function.NewLoggerErr(Emit(&el, WithEventID(42), WithEventTypeFunc(func(telemetry.Level) EventType {
+		return Warning
+	})), 0)
+
+	logger.Info("info")
+	if want := []string{"warning:42:info"}; !reflect.DeepEqual(want, el.entries) {
+		t.Fatalf("want: %q\nhave: %q", want, el.entries)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package eventlog
+
+import "errors"
+
+// errNotSupported is returned on platforms without the Windows Event Log.
+var errNotSupported = errors.New("the event log is only supported on windows")
+
+// Install registers the event source in the registry. The Windows Event Log is
+// only available on Windows.
+func Install(string) error {
+	return errNotSupported
+}
+
+// Remove deletes the registration of the event source. The Windows Event Log
+// is only available on Windows.
+func Remove(string) error {
+	return errNotSupported
+}
+
+// Open returns a handle to the registered event source, for use with Emit. The
+// Windows Event Log is only available on Windows.
+func Open(string) (Log, error) {
+	return nil, errNotSupported
+}
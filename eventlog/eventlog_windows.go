@@ -0,0 +1,40 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package eventlog
+
+import (
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// Install registers the event source in the registry, using the EventCreate
+// message file supporting all entry types. Registering requires administrative
+// privileges and is typically done by an installer. It fails if the source is
+// already registered.
+func Install(source string) error {
+	return eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info)
+}
+
+// Remove deletes the registration of the event source.
+func Remove(source string) error {
+	return eventlog.Remove(source)
+}
+
+// Open returns a handle to the registered event source, for use with Emit.
+func Open(source string) (Log, error) {
+	return eventlog.Open(source)
+}
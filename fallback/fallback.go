@@ -0,0 +1,214 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fallback provides a function.EmitErr wrapper routing log lines to a
+// secondary destination, like a local file, while the primary destination
+// fails, replaying them to the primary destination once it recovers.
+package fallback
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// DefaultReplaySize is the default number of failed log lines held for
+// replay.
+const DefaultReplaySize = 1024
+
+type (
+	// Option implements a functional option type for the Fallback.
+	Option func(*options)
+
+	// options holds the configuration of a Fallback.
+	options struct {
+		replaySize    int
+		retryInterval time.Duration
+		droppedMetric telemetry.Metric
+		now           func() time.Time
+	}
+)
+
+// WithReplaySize sets the number of failed log lines held for replay. Once
+// full, the oldest log lines are dropped from the replay buffer. A size of 0
+// disables replay.
+func WithReplaySize(size int) Option {
+	return func(o *options) {
+		o.replaySize = size
+	}
+}
+
+// WithRetryInterval sets the interval at which the primary destination is
+// retried after a failure. In between, log lines go to the secondary
+// destination directly. By default the primary destination is tried for each
+// log line.
+func WithRetryInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.retryInterval = d
+	}
+}
+
+// WithDroppedMetric sets the Metric on which the number of log lines dropped
+// from the replay buffer is recorded.
+func WithDroppedMetric(m telemetry.Metric) Option {
+	return func(o *options) {
+		o.droppedMetric = m
+	}
+}
+
+// entry holds a log line awaiting replay.
+type entry struct {
+	level  telemetry.Level
+	msg    string
+	err    error
+	values function.Values
+}
+
+// Fallback routes log lines to the secondary destination while the primary
+// destination fails.
+type Fallback struct {
+	primary   function.EmitErr
+	secondary function.EmitErr
+	opts      options
+
+	mtx    sync.Mutex
+	replay []entry
+	retry  time.Time
+}
+
+// New returns a Fallback emitting log lines through the primary EmitErr. Log
+// lines the primary fails to emit, including those dropped by a full queue of
+// a batch.Batcher or async.Emitter, are emitted through the secondary EmitErr
+// and held for replay. Once the primary emits successfully again, the held log
+// lines are replayed to it in order, ahead of new log lines. Replayed log
+// lines thus appear in both destinations. Use the Emit method of the Fallback
+// with function.NewLoggerErr.
+func New(primary, secondary function.EmitErr, opts ...Option) *Fallback {
+	o := options{
+		replaySize: DefaultReplaySize,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Fallback{primary: primary, secondary: secondary, opts: o}
+}
+
+// Emit implements function.EmitErr. It only returns an error if both the
+// primary and secondary destination fail.
+func (f *Fallback) Emit(level telemetry.Level, msg string, err error, values function.Values, callerSkip int) error {
+	f.mtx.Lock()
+	healthy := len(f.replay) == 0 && f.retry.IsZero()
+	f.mtx.Unlock()
+
+	if healthy {
+		pErr := f.primary(level, msg, err, values, callerSkip+1)
+		if pErr == nil {
+			return nil
+		}
+		return f.fail(pErr, level, msg, err, values, callerSkip)
+	}
+
+	f.mtx.Lock()
+	recovered := f.recover(false)
+	f.mtx.Unlock()
+
+	if recovered {
+		if pErr := f.primary(level, msg, err, values, callerSkip+1); pErr != nil {
+			return f.fail(pErr, level, msg, err, values, callerSkip)
+		}
+		return nil
+	}
+	return f.fail(nil, level, msg, err, values, callerSkip)
+}
+
+// Replay replays the held log lines to the primary destination, regardless of
+// the retry interval. It reports whether all held log lines were replayed,
+// allowing it to be called periodically to recover while no log lines are
+// emitted.
+func (f *Fallback) Replay() bool {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.recover(true)
+}
+
+// Pending returns the number of log lines held for replay.
+func (f *Fallback) Pending() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return len(f.replay)
+}
+
+// recover retries the primary destination, if due or forced, by replaying the
+// held log lines. It reports whether all held log lines were replayed.
+func (f *Fallback) recover(force bool) bool {
+	if !force && !f.retry.IsZero() && f.opts.now().Before(f.retry) {
+		return false
+	}
+	for len(f.replay) > 0 {
+		e := f.replay[0]
+		if f.primary(e.level, e.msg, e.err, e.values, 0) != nil {
+			f.backoff()
+			return false
+		}
+		f.replay[0] = entry{}
+		f.replay = f.replay[1:]
+	}
+	f.replay = nil
+	f.retry = time.Time{}
+	return true
+}
+
+// fail emits the log line through the secondary destination and holds it for
+// replay.
+func (f *Fallback) fail(pErr error, level telemetry.Level, msg string, err error, values function.Values, callerSkip int) error {
+	sErr := f.secondary(level, msg, err, values, callerSkip+2)
+
+	f.mtx.Lock()
+	if pErr != nil {
+		f.backoff()
+	}
+	if f.opts.replaySize > 0 {
+		if len(f.replay) >= f.opts.replaySize {
+			f.replay[0] = entry{}
+			f.replay = f.replay[1:]
+			if f.opts.droppedMetric != nil {
+				f.opts.droppedMetric.Record(1)
+			}
+		}
+		// the key-value pairs passed to the logging method may be reused by
+		// the caller once it returns.
+		values.FromMethod = append([]interface{}(nil), values.FromMethod...)
+		f.replay = append(f.replay, entry{level: level, msg: msg, err: err, values: values})
+	}
+	f.mtx.Unlock()
+
+	if sErr != nil {
+		if pErr == nil {
+			return fmt.Errorf("secondary emit failed: %w", sErr)
+		}
+		return fmt.Errorf("primary emit failed: %v, secondary emit failed: %w", pErr, sErr)
+	}
+	return nil
+}
+
+// backoff schedules the next attempt of the primary destination.
+func (f *Fallback) backoff() {
+	if f.opts.retryInterval > 0 {
+		f.retry = f.opts.now().Add(f.opts.retryInterval)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fallback
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type mockMetric struct {
+	telemetry.Metric
+	mtx   sync.Mutex
+	count float64
+}
+
+func (m *mockMetric) Record(value float64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.count += value
+}
+
+// destination captures the messages of emitted log lines and fails while
+// down is set.
+type destination struct {
+	down bool
+	msgs []string
+}
+
+func (d *destination) emit(_ telemetry.Level, msg string, _ error, _ function.Values, _ int) error {
+	if d.down {
+		return errors.New("unavailable")
+	}
+	d.msgs = append(d.msgs, msg)
+	return nil
+}
+
+func TestFallback(t *testing.T) {
+	var primary, secondary destination
+	f := New(primary.emit, secondary.emit)
+	logger := function.NewLoggerErr(f.Emit, 0)
+
+	logger.Info("1")
+	primary.down = true
+	logger.Info("2")
+	logger.Info("3")
+	if f.Pending() != 2 {
+		t.Fatalf("expected 2 pending, have %d", f.Pending())
+	}
+	primary.down = false
+	logger.Info("4")
+
+	if want := []string{"1", "2", "3", "4"}; !reflect.DeepEqual(want, primary.msgs) {
+		t.Errorf("want: %v\nhave: %v", want, primary.msgs)
+	}
+	if want := []string{"2", "3"}; !reflect.DeepEqual(want, secondary.msgs) {
+		t.Errorf("want: %v\nhave: %v", want, secondary.msgs)
+	}
+	if f.Pending() != 0 {
+		t.Errorf("expected 0 pending, have %d", f.Pending())
+	}
+}
+
+func TestFallbackKeyValues(t *testing.T) {
+	var (
+		primary = destination{down: true}
+		values  []function.Values
+	)
+	f := New(primary.emit, func(_ telemetry.Level, _ string, _ error, v function.Values, _ int) error { return nil })
+
+	kvs := []interface{}{"key", "value"}
+	_ = f.Emit(telemetry.LevelInfo, "1", nil, function.Values{FromMethod: kvs}, 0)
+	kvs[1] = "reused"
+
+	f.primary = func(_ telemetry.Level, _ string, _ error, v function.Values, _ int) error {
+		values = append(values, v)
+		return nil
+	}
+	if !f.Replay() {
+		t.Fatal("expected replay to succeed")
+	}
+	if len(values) != 1 || !reflect.DeepEqual(values[0].FromMethod, []interface{}{"key", "value"}) {
+		t.Fatalf("unexpected replayed values: %v", values)
+	}
+}
+
+func TestFallbackReplaySize(t *testing.T) {
+	var (
+		primary   = destination{down: true}
+		secondary destination
+		m         mockMetric
+	)
+	f := New(primary.emit, secondary.emit, WithReplaySize(2), WithDroppedMetric(&m))
+
+	for _, msg := range []string{"1", "2", "3"} {
+		_ = f.Emit(telemetry.LevelInfo, msg, nil, function.Values{}, 0)
+	}
+	primary.down = false
+	if !f.Replay() {
+		t.Fatal("expected replay to succeed")
+	}
+
+	if want := []string{"2", "3"}; !reflect.DeepEqual(want, primary.msgs) {
+		t.Errorf("want: %v\nhave: %v", want, primary.msgs)
+	}
+	if m.count != 1 {
+		t.Errorf("expected 1 dropped, have %v", m.count)
+	}
+}
+
+func TestFallbackRetryInterval(t *testing.T) {
+	var (
+		primary   = destination{down: true}
+		secondary destination
+		now       = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	)
+	f := New(primary.emit, secondary.emit, WithRetryInterval(time.Minute))
+	f.opts.now = func() time.Time { return now }
+
+	_ = f.Emit(telemetry.LevelInfo, "1", nil, function.Values{}, 0)
+	primary.down = false
+	_ = f.Emit(telemetry.LevelInfo, "2", nil, function.Values{}, 0)
+	if len(primary.msgs) != 0 {
+		t.Fatalf("expected primary not to be retried, have %v", primary.msgs)
+	}
+
+	now = now.Add(time.Minute)
+	_ = f.Emit(telemetry.LevelInfo, "3", nil, function.Values{}, 0)
+	if want := []string{"1", "2", "3"}; !reflect.DeepEqual(want, primary.msgs) {
+		t.Errorf("want: %v\nhave: %v", want, primary.msgs)
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(want, secondary.msgs) {
+		t.Errorf("want: %v\nhave: %v", want, secondary.msgs)
+	}
+}
+
+func TestFallbackErrors(t *testing.T) {
+	var (
+		primary   = destination{down: true}
+		secondary = destination{down: true}
+	)
+	f := New(primary.emit, secondary.emit)
+
+	err := f.Emit(telemetry.LevelInfo, "1", nil, function.Values{}, 0)
+	if want := "primary emit failed: unavailable, secondary emit failed: unavailable"; err == nil || err.Error() != want {
+		t.Fatalf("expected %v to match %s", err, want)
+	}
+	err = f.Emit(telemetry.LevelInfo, "2", nil, function.Values{}, 0)
+	if want := "secondary emit failed: unavailable"; err == nil || err.Error() != want {
+		t.Fatalf("expected %v to match %s", err, want)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import "context"
+
+// compile time check for compatibility with the MetricSink interfaces.
+var (
+	_ MetricSink        = fanoutSink(nil)
+	_ DerivedMetricSink = fanoutSink(nil)
+)
+
+// FanoutMetricSink returns a MetricSink forwarding each operation to all
+// provided MetricSinks in order, e.g. to expose metrics for scraping while
+// pushing them to another backend during a migration. Metrics and Labels it
+// creates hold one instance per MetricSink, and the LabelValues of its Labels
+// hold one operation per MetricSink, each handed to the MetricSink that
+// created it. Other LabelValues are handed to all MetricSinks as is.
+//
+// Derived gauges are only created on the MetricSinks implementing
+// DerivedMetricSink. Nil MetricSinks are ignored and a single MetricSink is
+// returned as is.
+func FanoutMetricSink(sinks ...MetricSink) MetricSink {
+	filtered := make(fanoutSink, 0, len(sinks))
+	for _, ms := range sinks {
+		if ms != nil {
+			filtered = append(filtered, ms)
+		}
+	}
+	if len(filtered) == 1 {
+		return filtered[0]
+	}
+	return filtered
+}
+
+type fanoutSink []MetricSink
+
+// NewSum implements MetricSink.
+func (f fanoutSink) NewSum(name, description string, opts ...MetricOption) Metric {
+	m := make(fanoutMetric, 0, len(f))
+	for i, ms := range f {
+		m = append(m, ms.NewSum(name, description, fanoutOptions(i, opts)...))
+	}
+	return m
+}
+
+// NewGauge implements MetricSink.
+func (f fanoutSink) NewGauge(name, description string, opts ...MetricOption) Metric {
+	m := make(fanoutMetric, 0, len(f))
+	for i, ms := range f {
+		m = append(m, ms.NewGauge(name, description, fanoutOptions(i, opts)...))
+	}
+	return m
+}
+
+// NewDistribution implements MetricSink.
+func (f fanoutSink) NewDistribution(name, description string, bounds []float64, opts ...MetricOption) Metric {
+	m := make(fanoutMetric, 0, len(f))
+	for i, ms := range f {
+		m = append(m, ms.NewDistribution(name, description, bounds, fanoutOptions(i, opts)...))
+	}
+	return m
+}
+
+// NewLabel implements MetricSink.
+func (f fanoutSink) NewLabel(name string) Label {
+	l := make(fanoutLabel, 0, len(f))
+	for _, ms := range f {
+		l = append(l, ms.NewLabel(name))
+	}
+	return l
+}
+
+// ContextWithLabels implements MetricSink. Each MetricSink adds its LabelValues
+// to the Context in turn. If a MetricSink returns an error, its LabelValues are
+// skipped and the first error is returned along with the Context holding the
+// LabelValues of the other MetricSinks.
+func (f fanoutSink) ContextWithLabels(ctx context.Context, values ...LabelValue) (context.Context, error) {
+	var firstErr error
+	for i, ms := range f {
+		c, err := ms.ContextWithLabels(ctx, fanoutValues(i, values)...)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		ctx = c
+	}
+	return ctx, firstErr
+}
+
+// NewDerivedGauge implements DerivedMetricSink.
+func (f fanoutSink) NewDerivedGauge(name, description string) DerivedMetric {
+	var d fanoutDerived
+	for _, ms := range f {
+		if dms, ok := ms.(DerivedMetricSink); ok {
+			d = append(d, dms.NewDerivedGauge(name, description))
+		}
+	}
+	return d
+}
+
+// fanoutOptions returns the MetricOptions for the MetricSink at index i,
+// replacing the fanout Labels by the Labels created by that MetricSink.
+func fanoutOptions(i int, opts []MetricOption) []MetricOption {
+	if len(opts) == 0 {
+		return opts
+	}
+	var o MetricOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(o.Labels) == 0 {
+		return opts
+	}
+	labels := make([]Label, 0, len(o.Labels))
+	for _, l := range o.Labels {
+		if fl, ok := l.(fanoutLabel); ok {
+			l = fl[i]
+		}
+		labels = append(labels, l)
+	}
+	return append(opts[:len(opts):len(opts)], WithLabels(labels...))
+}
+
+// fanoutValues returns the LabelValues for the MetricSink at index i.
+func fanoutValues(i int, values []LabelValue) []LabelValue {
+	out := make([]LabelValue, 0, len(values))
+	for _, v := range values {
+		if fv, ok := v.(fanoutValue); ok {
+			v = fv[i]
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+type fanoutLabel []Label
+
+type fanoutValue []LabelValue
+
+func (l fanoutLabel) Insert(value string) LabelValue {
+	v := make(fanoutValue, 0, len(l))
+	for _, label := range l {
+		v = append(v, label.Insert(value))
+	}
+	return v
+}
+
+func (l fanoutLabel) Update(value string) LabelValue {
+	v := make(fanoutValue, 0, len(l))
+	for _, label := range l {
+		v = append(v, label.Update(value))
+	}
+	return v
+}
+
+func (l fanoutLabel) Upsert(value string) LabelValue {
+	v := make(fanoutValue, 0, len(l))
+	for _, label := range l {
+		v = append(v, label.Upsert(value))
+	}
+	return v
+}
+
+func (l fanoutLabel) Delete() LabelValue {
+	v := make(fanoutValue, 0, len(l))
+	for _, label := range l {
+		v = append(v, label.Delete())
+	}
+	return v
+}
+
+type fanoutMetric []Metric
+
+func (f fanoutMetric) Increment() {
+	for _, m := range f {
+		m.Increment()
+	}
+}
+
+func (f fanoutMetric) Decrement() {
+	for _, m := range f {
+		m.Decrement()
+	}
+}
+
+func (f fanoutMetric) Name() string {
+	if len(f) == 0 {
+		return ""
+	}
+	return f[0].Name()
+}
+
+func (f fanoutMetric) Record(value float64) {
+	for _, m := range f {
+		m.Record(value)
+	}
+}
+
+func (f fanoutMetric) RecordContext(ctx context.Context, value float64) {
+	for _, m := range f {
+		m.RecordContext(ctx, value)
+	}
+}
+
+func (f fanoutMetric) RecordLeveled(ctx context.Context, value float64, level Level) {
+	for _, m := range f {
+		recordLeveled(ctx, m, value, level)
+	}
+}
+
+func (f fanoutMetric) With(labelValues ...LabelValue) Metric {
+	out := make(fanoutMetric, 0, len(f))
+	for i, m := range f {
+		out = append(out, m.With(fanoutValues(i, labelValues)...))
+	}
+	return out
+}
+
+type fanoutDerived []DerivedMetric
+
+func (f fanoutDerived) Name() string {
+	if len(f) == 0 {
+		return ""
+	}
+	return f[0].Name()
+}
+
+func (f fanoutDerived) ValueFrom(valueFn func() float64, labelValues ...LabelValue) DerivedMetric {
+	out := make(fanoutDerived, 0, len(f))
+	for i, d := range f {
+		out = append(out, d.ValueFrom(valueFn, fanoutValues(i, labelValues)...))
+	}
+	return out
+}
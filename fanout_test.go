@@ -0,0 +1,139 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+type sinkOp struct {
+	sink, label, value string
+}
+
+type sinkLabel struct {
+	sink, name string
+}
+
+func (l sinkLabel) Insert(v string) telemetry.LabelValue { return sinkOp{l.sink, l.name, v} }
+func (l sinkLabel) Update(v string) telemetry.LabelValue { return sinkOp{l.sink, l.name, v} }
+func (l sinkLabel) Upsert(v string) telemetry.LabelValue { return sinkOp{l.sink, l.name, v} }
+func (l sinkLabel) Delete() telemetry.LabelValue         { return sinkOp{l.sink, l.name, ""} }
+
+type sinkCtx string
+
+// namedSink records the measurements of its Metrics, prefixed by its name.
+type namedSink struct {
+	name     string
+	log      *[]string
+	labels   []telemetry.Label
+	ctxCalls int
+}
+
+type namedMetric struct {
+	telemetry.Metric
+	sink *namedSink
+	name string
+	with []telemetry.LabelValue
+}
+
+func (m *namedMetric) Increment() { m.Record(1) }
+
+func (m *namedMetric) Name() string { return m.name }
+
+func (m *namedMetric) Record(value float64) {
+	*m.sink.log = append(*m.sink.log, m.sink.name+":"+m.name)
+	for _, v := range m.with {
+		if op, ok := v.(sinkOp); !ok || op.sink != m.sink.name {
+			*m.sink.log = append(*m.sink.log, m.sink.name+":foreign")
+		}
+	}
+}
+
+func (m *namedMetric) RecordContext(ctx context.Context, value float64) {
+	if ctx.Value(sinkCtx(m.sink.name)) == nil {
+		*m.sink.log = append(*m.sink.log, m.sink.name+":missing-context")
+	}
+	m.Record(value)
+}
+
+func (m *namedMetric) With(labelValues ...telemetry.LabelValue) telemetry.Metric {
+	return &namedMetric{sink: m.sink, name: m.name, with: append(m.with, labelValues...)}
+}
+
+func (s *namedSink) NewSum(name, _ string, opts ...telemetry.MetricOption) telemetry.Metric {
+	var o telemetry.MetricOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s.labels = o.Labels
+	return &namedMetric{sink: s, name: name}
+}
+
+func (s *namedSink) NewLabel(name string) telemetry.Label { return sinkLabel{s.name, name} }
+
+func (s *namedSink) NewGauge(name, _ string, _ ...telemetry.MetricOption) telemetry.Metric {
+	return &namedMetric{sink: s, name: name}
+}
+
+func (s *namedSink) NewDistribution(name, _ string, _ []float64, _ ...telemetry.MetricOption) telemetry.Metric {
+	return &namedMetric{sink: s, name: name}
+}
+
+func (s *namedSink) ContextWithLabels(ctx context.Context, values ...telemetry.LabelValue) (context.Context, error) {
+	for _, v := range values {
+		if op, ok := v.(sinkOp); !ok || op.sink != s.name {
+			*s.log = append(*s.log, s.name+":foreign")
+		}
+	}
+	return context.WithValue(ctx, sinkCtx(s.name), values), nil
+}
+
+func TestFanoutMetricSink(t *testing.T) {
+	var (
+		log []string
+		a   = &namedSink{name: "a", log: &log}
+		b   = &namedSink{name: "b", log: &log}
+		ms  = telemetry.FanoutMetricSink(a, nil, b)
+	)
+
+	tenant := ms.NewLabel("tenant")
+	sum := ms.NewSum("requests", "", telemetry.WithUnit(telemetry.None), telemetry.WithLabels(tenant))
+	if want, have := []telemetry.Label{sinkLabel{"a", "tenant"}}, a.labels; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+	if have := sum.Name(); have != "requests" {
+		t.Errorf("expected requests, have %s", have)
+	}
+
+	ctx, err := ms.ContextWithLabels(context.Background(), tenant.Upsert("acme"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sum.With(tenant.Insert("globex")).RecordContext(ctx, 1)
+	ms.NewGauge("connections", "").Increment()
+
+	want := []string{"a:requests", "b:requests", "a:connections", "b:connections"}
+	if !reflect.DeepEqual(want, log) {
+		t.Fatalf("want: %v\nhave: %v", want, log)
+	}
+
+	if have := telemetry.FanoutMetricSink(a); have != a {
+		t.Errorf("expected a single MetricSink to be returned as is")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"os"
+	"sync"
+)
+
+// OnFatalFn holds a function signature which can be used to register hooks
+// that need to run after a Logger handled a Fatal log line, but before the
+// process exits.
+type OnFatalFn func()
+
+var (
+	fatalMtx   sync.Mutex
+	fatalHooks []OnFatalFn
+	exitFunc   = os.Exit
+)
+
+// OnFatal registers a hook to run when Exit is called, typically by a Logger
+// handling a Fatal log line. Hooks run in reverse order of registration, like
+// deferred function calls.
+func OnFatal(hook OnFatalFn) {
+	fatalMtx.Lock()
+	defer fatalMtx.Unlock()
+
+	fatalHooks = append(fatalHooks, hook)
+}
+
+// SetExitFunc configures the function Exit uses to terminate the process. By
+// default os.Exit is used. Setting a nil function restores the default.
+func SetExitFunc(fn func(code int)) {
+	fatalMtx.Lock()
+	defer fatalMtx.Unlock()
+
+	if fn == nil {
+		fn = os.Exit
+	}
+	exitFunc = fn
+}
+
+// Exit runs all registered OnFatal hooks and calls the configured exit function
+// with the provided code. Logger implementations must call Exit(1) at the end
+// of Fatal, after having emitted the log line and flushed their sinks.
+func Exit(code int) {
+	fatalMtx.Lock()
+	hooks := fatalHooks
+	exit := exitFunc
+	fatalMtx.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+	exit(code)
+}
@@ -0,0 +1,44 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExit(t *testing.T) {
+	var calls []string
+	t.Cleanup(func() {
+		fatalHooks = nil
+		SetExitFunc(nil)
+	})
+
+	OnFatal(func() { calls = append(calls, "hook1") })
+	OnFatal(func() { calls = append(calls, "hook2") })
+	SetExitFunc(func(code int) {
+		if code != 1 {
+			t.Errorf("exit code=%d, want 1", code)
+		}
+		calls = append(calls, "exit")
+	})
+
+	NoopLogger().Fatal("text", nil)
+
+	want := []string{"hook2", "hook1", "exit"}
+	if !reflect.DeepEqual(want, calls) {
+		t.Fatalf("want: %v\nhave: %v", want, calls)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fluent provides a sink shipping log lines to Fluentd or Fluent Bit
+// using the Forward protocol over TCP or a unix socket, removing the need for
+// a local file tail.
+package fluent
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry/batch"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Keys used for the fixed fields of a forwarded record.
+const (
+	LevelKey   = "level"
+	MessageKey = "message"
+	ErrorKey   = "error"
+	CallerKey  = "caller"
+)
+
+// DefaultDialTimeout is the default timeout for connecting to the Fluent
+// daemon.
+const DefaultDialTimeout = 5 * time.Second
+
+type (
+	// Option implements a functional option type for the Forwarder.
+	Option func(*options)
+
+	// options holds the configuration of a Forwarder.
+	options struct {
+		ack         bool
+		dialTimeout time.Duration
+		batchOpts   []batch.Option
+	}
+)
+
+// WithAck enables at-least-once delivery. Each batch is sent with a chunk
+// identifier and only considered delivered once the daemon acknowledges it,
+// otherwise it is retried. The daemon input must have require_ack_response
+// enabled.
+func WithAck() Option {
+	return func(o *options) {
+		o.ack = true
+	}
+}
+
+// WithDialTimeout sets the timeout for connecting to the Fluent daemon.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = timeout
+	}
+}
+
+// WithBatchOptions sets the options of the underlying batch.Batcher, e.g. to
+// configure batch size, flush interval and retries.
+func WithBatchOptions(opts ...batch.Option) Option {
+	return func(o *options) {
+		o.batchOpts = append(o.batchOpts, opts...)
+	}
+}
+
+// Forwarder ships log lines to a Fluent daemon. Use its Emit method with
+// function.NewLoggerErr, its Flush method with function.WithFlush and Close it
+// on shutdown.
+type Forwarder struct {
+	*batch.Batcher
+	client *client
+}
+
+// New returns a Forwarder sending log lines tagged with tag to the Fluent
+// daemon listening at the provided address. Network is "tcp" or "unix". The
+// connection is established on the first batch sent and re-established after
+// failures, with the failed batch retried by the batch.Batcher.
+//
+// Each log line is sent in Forward mode as a record holding the level,
+// message, error, caller and the key-value pairs found in Context, added to
+// the Logger and passed to the logging method. The caller is only available
+// if the function Logger is created with the function.WithCaller option.
+func New(network, address, tag string, opts ...Option) *Forwarder {
+	o := options{dialTimeout: DefaultDialTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := &client{network: network, address: address, tag: tag, opts: o}
+	return &Forwarder{
+		Batcher: batch.New(c.send, o.batchOpts...),
+		client:  c,
+	}
+}
+
+// Close flushes the pending log lines and closes the connection.
+func (f *Forwarder) Close() error {
+	err := f.Batcher.Close()
+	if cErr := f.client.close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+// client holds the connection to the Fluent daemon.
+type client struct {
+	mtx     sync.Mutex
+	network string
+	address string
+	tag     string
+	opts    options
+	conn    net.Conn
+	reader  *bufio.Reader
+	buf     []byte
+}
+
+// send implements batch.Handler.
+func (c *client) send(ctx context.Context, records []batch.Record) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var chunk string
+	if c.opts.ack {
+		var id [16]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return err
+		}
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+	}
+	c.buf = c.encode(c.buf[:0], records, chunk)
+
+	if err := c.roundTrip(ctx, chunk); err != nil {
+		if c.conn != nil {
+			_ = c.conn.Close()
+			c.conn = nil
+		}
+		return err
+	}
+	return nil
+}
+
+// roundTrip writes the encoded message and awaits the acknowledgement if
+// requested.
+func (c *client) roundTrip(ctx context.Context, chunk string) error {
+	if c.conn == nil {
+		d := net.Dialer{Timeout: c.opts.dialTimeout}
+		conn, err := d.DialContext(ctx, c.network, c.address)
+		if err != nil {
+			return err
+		}
+		c.conn = conn
+		c.reader = bufio.NewReader(conn)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.conn.SetDeadline(deadline)
+	}
+	if _, err := c.conn.Write(c.buf); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+
+	res, err := decode(c.reader)
+	if err != nil {
+		return fmt.Errorf("fluent ack: %w", err)
+	}
+	if m, ok := res.(map[string]interface{}); !ok || m["ack"] != chunk {
+		return fmt.Errorf("fluent ack: unexpected response %v", res)
+	}
+	return nil
+}
+
+func (c *client) close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// encode appends the records as Forward mode message:
+// [tag, [[time, record], ...], {"size": n, "chunk": id}].
+func (c *client) encode(buf []byte, records []batch.Record, chunk string) []byte {
+	buf = appendArrayHeader(buf, 3)
+	buf = appendString(buf, c.tag)
+	buf = appendArrayHeader(buf, len(records))
+	for _, r := range records {
+		buf = appendArrayHeader(buf, 2)
+		buf = appendEventTime(buf, r.Time)
+		buf = appendRecord(buf, r)
+	}
+	if chunk == "" {
+		buf = appendMapHeader(buf, 1)
+	} else {
+		buf = appendMapHeader(buf, 2)
+		buf = appendString(buf, "chunk")
+		buf = appendString(buf, chunk)
+	}
+	buf = appendString(buf, "size")
+	return appendInt(buf, int64(len(records)))
+}
+
+// appendRecord appends the log line as a msgpack map. Non-string keys are
+// formatted using fmt.Sprint and a missing value is set to "(MISSING)".
+func appendRecord(buf []byte, r batch.Record) []byte {
+	var (
+		kvs       = [][]interface{}{r.Values.FromContext, r.Values.FromLogger, r.Values.FromMethod}
+		n         = 2
+		file, ok  = caller(r.Values)
+		keyValues = 0
+	)
+	for _, kv := range kvs {
+		keyValues += (len(kv) + 1) / 2
+	}
+	if r.Error != nil {
+		n++
+	}
+	if ok {
+		n++
+	}
+
+	buf = appendMapHeader(buf, n+keyValues)
+	buf = appendString(buf, LevelKey)
+	buf = appendString(buf, r.Level.String())
+	buf = appendString(buf, MessageKey)
+	buf = appendString(buf, r.Message)
+	if r.Error != nil {
+		buf = appendString(buf, ErrorKey)
+		buf = appendString(buf, r.Error.Error())
+	}
+	if ok {
+		buf = appendString(buf, CallerKey)
+		buf = appendString(buf, file)
+	}
+	for _, kv := range kvs {
+		for i := 0; i < len(kv); i += 2 {
+			k, isString := kv[i].(string)
+			if !isString {
+				k = fmt.Sprint(kv[i])
+			}
+			buf = appendString(buf, k)
+			if i+1 < len(kv) {
+				buf = appendValue(buf, kv[i+1])
+			} else {
+				buf = appendString(buf, "(MISSING)")
+			}
+		}
+	}
+	return buf
+}
+
+// caller returns the call site of the log line as short "dir/file.go:line".
+func caller(values function.Values) (string, bool) {
+	frame, ok := values.Caller.Resolve()
+	if !ok {
+		return "", false
+	}
+	short := path.Join(path.Base(path.Dir(frame.File)), path.Base(frame.File))
+	return short + ":" + strconv.Itoa(frame.Line), true
+}
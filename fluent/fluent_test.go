@@ -0,0 +1,156 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluent
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry/batch"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// server is a minimal Forward protocol input recording received messages.
+type server struct {
+	l        net.Listener
+	mtx      sync.Mutex
+	messages [][]interface{}
+	// dropFirst closes the first connection without reading from it.
+	dropFirst bool
+}
+
+func newServer(t *testing.T, dropFirst bool) *server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("unable to listen: %v", err)
+	}
+	s := &server{l: l, dropFirst: dropFirst}
+	go s.serve()
+	return s
+}
+
+func (s *server) serve() {
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		s.mtx.Lock()
+		drop := s.dropFirst
+		s.dropFirst = false
+		s.mtx.Unlock()
+		if drop {
+			_ = conn.Close()
+			continue
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	for {
+		v, err := decode(r)
+		if err != nil {
+			return
+		}
+		msg := v.([]interface{})
+		s.mtx.Lock()
+		s.messages = append(s.messages, msg)
+		s.mtx.Unlock()
+		if chunk, ok := msg[2].(map[string]interface{})["chunk"]; ok {
+			_, _ = conn.Write(appendString(appendString(appendMapHeader(nil, 1), "ack"), chunk.(string)))
+		}
+	}
+}
+
+func (s *server) received() [][]interface{} {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.messages
+}
+
+func TestForwarder(t *testing.T) {
+	s := newServer(t, false)
+	defer func() { _ = s.l.Close() }()
+
+	f := New("tcp", s.l.Addr().String(), "app.logs", WithAck())
+	logger := function.NewLoggerErr(f.Emit, 0, function.WithCaller()).With("component", "lib")
+	logger.Info("hello", "attempt", 1)
+	logger.Error("failed", errors.New("boom"), "dangling")
+	if err := f.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msgs := s.received()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+	if msgs[0][0] != "app.logs" {
+		t.Fatalf("expected %v to match app.logs", msgs[0][0])
+	}
+	if opts := msgs[0][2].(map[string]interface{}); opts["size"] != int64(2) || opts["chunk"] == nil {
+		t.Fatalf("unexpected options %v", opts)
+	}
+
+	entries := msgs[0][1].([]interface{})
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	first := entries[0].([]interface{})
+	if _, ok := first[0].(time.Time); !ok {
+		t.Fatalf("expected event time, got %T", first[0])
+	}
+	record := first[1].(map[string]interface{})
+	if record["level"] != "info" || record["message"] != "hello" || record["component"] != "lib" || record["attempt"] != int64(1) {
+		t.Fatalf("unexpected record %v", record)
+	}
+	if c, _ := record["caller"].(string); !strings.HasPrefix(c, "fluent/fluent_test.go:") {
+		t.Fatalf("unexpected caller %v", record["caller"])
+	}
+	record = entries[1].([]interface{})[1].(map[string]interface{})
+	if record["level"] != "error" || record["error"] != "boom" || record["dangling"] != "(MISSING)" {
+		t.Fatalf("unexpected record %v", record)
+	}
+}
+
+func TestForwarderReconnect(t *testing.T) {
+	s := newServer(t, true)
+	defer func() { _ = s.l.Close() }()
+
+	var errs []error
+	f := New("tcp", s.l.Addr().String(), "app", WithAck(), WithBatchOptions(
+		batch.WithRetry(3, time.Millisecond, time.Millisecond),
+		batch.WithErrorHandler(func(err error) { errs = append(errs, err) }),
+	))
+	logger := function.NewLoggerErr(f.Emit, 0)
+	logger.Info("hello")
+	if err := f.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if msgs := s.received(); len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluent
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// errMsgpack is returned when decoding an unsupported or malformed msgpack
+// value.
+var errMsgpack = errors.New("invalid or unsupported msgpack value")
+
+// eventTimeExt is the msgpack extension type of the Fluent EventTime.
+const eventTimeExt = 0
+
+func appendNil(buf []byte) []byte { return append(buf, 0xc0) }
+
+func appendBool(buf []byte, b bool) []byte {
+	if b {
+		return append(buf, 0xc3)
+	}
+	return append(buf, 0xc2)
+}
+
+func appendInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendUint(buf, uint64(i))
+	case i >= -32:
+		return append(buf, byte(i))
+	case i >= math.MinInt8:
+		return append(buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return append(buf, 0xd1, byte(i>>8), byte(i))
+	case i >= math.MinInt32:
+		return appendUint32(append(buf, 0xd2), uint32(i))
+	default:
+		return appendUint64(append(buf, 0xd3), uint64(i))
+	}
+}
+
+func appendUint(buf []byte, u uint64) []byte {
+	switch {
+	case u < 128:
+		return append(buf, byte(u))
+	case u <= math.MaxUint8:
+		return append(buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return append(buf, 0xcd, byte(u>>8), byte(u))
+	case u <= math.MaxUint32:
+		return appendUint32(append(buf, 0xce), uint32(u))
+	default:
+		return appendUint64(append(buf, 0xcf), u)
+	}
+}
+
+func appendFloat(buf []byte, f float64) []byte {
+	return appendUint64(append(buf, 0xcb), math.Float64bits(f))
+}
+
+func appendString(buf []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, 0xda, byte(n>>8), byte(n))
+	default:
+		buf = appendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+func appendBinary(buf []byte, b []byte) []byte {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, 0xc5, byte(n>>8), byte(n))
+	default:
+		buf = appendUint32(append(buf, 0xc6), uint32(n))
+	}
+	return append(buf, b...)
+}
+
+func appendArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return append(buf, 0xdc, byte(n>>8), byte(n))
+	default:
+		return appendUint32(append(buf, 0xdd), uint32(n))
+	}
+}
+
+func appendMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return append(buf, 0xde, byte(n>>8), byte(n))
+	default:
+		return appendUint32(append(buf, 0xdf), uint32(n))
+	}
+}
+
+// appendEventTime appends the timestamp as Fluent EventTime extension,
+// holding seconds and nanoseconds since the Unix epoch.
+func appendEventTime(buf []byte, t time.Time) []byte {
+	buf = append(buf, 0xd7, eventTimeExt)
+	buf = appendUint32(buf, uint32(t.Unix()))
+	return appendUint32(buf, uint32(t.Nanosecond()))
+}
+
+// appendValue appends the value using the closest msgpack type. Values
+// without a msgpack counterpart are appended as strings.
+func appendValue(buf []byte, v interface{}) []byte {
+	switch t := v.(type) {
+	case nil:
+		return appendNil(buf)
+	case string:
+		return appendString(buf, t)
+	case bool:
+		return appendBool(buf, t)
+	case int:
+		return appendInt(buf, int64(t))
+	case int8:
+		return appendInt(buf, int64(t))
+	case int16:
+		return appendInt(buf, int64(t))
+	case int32:
+		return appendInt(buf, int64(t))
+	case int64:
+		return appendInt(buf, t)
+	case uint:
+		return appendUint(buf, uint64(t))
+	case uint8:
+		return appendUint(buf, uint64(t))
+	case uint16:
+		return appendUint(buf, uint64(t))
+	case uint32:
+		return appendUint(buf, uint64(t))
+	case uint64:
+		return appendUint(buf, t)
+	case float32:
+		return appendFloat(buf, float64(t))
+	case float64:
+		return appendFloat(buf, t)
+	case []byte:
+		return appendBinary(buf, t)
+	case time.Time:
+		return appendString(buf, t.Format(time.RFC3339Nano))
+	case time.Duration:
+		return appendString(buf, t.String())
+	case error:
+		return appendString(buf, t.Error())
+	case fmt.Stringer:
+		return appendString(buf, t.String())
+	default:
+		return appendString(buf, fmt.Sprintf("%+v", v))
+	}
+}
+
+// decode reads a single msgpack value. Maps are decoded into
+// map[string]interface{}, integers into int64 or uint64 and extension values
+// other than EventTime are not supported.
+func decode(r *bufio.Reader) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c < 0x80:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return decodeMap(r, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return decodeArray(r, int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		return decodeString(r, int(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readLength(r, c-0xc4)
+		if err != nil {
+			return nil, err
+		}
+		return readN(r, n)
+	case 0xcb:
+		b, err := readN(r, 8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		b, err := readN(r, 1<<(c-0xcc))
+		if err != nil {
+			return nil, err
+		}
+		var u uint64
+		for _, d := range b {
+			u = u<<8 | uint64(d)
+		}
+		return u, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		b, err := readN(r, 1<<(c-0xd0))
+		if err != nil {
+			return nil, err
+		}
+		i := int64(int8(b[0]))
+		for _, d := range b[1:] {
+			i = i<<8 | int64(d)
+		}
+		return i, nil
+	case 0xd7:
+		b, err := readN(r, 9)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != eventTimeExt {
+			return nil, errMsgpack
+		}
+		return time.Unix(int64(binary.BigEndian.Uint32(b[1:5])), int64(binary.BigEndian.Uint32(b[5:]))), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := readLength(r, c-0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return decodeString(r, n)
+	case 0xdc, 0xdd:
+		n, err := readLength(r, c-0xdc+1)
+		if err != nil {
+			return nil, err
+		}
+		return decodeArray(r, n)
+	case 0xde, 0xdf:
+		n, err := readLength(r, c-0xde+1)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMap(r, n)
+	}
+	return nil, errMsgpack
+}
+
+// readLength reads a big endian length of 1, 2 or 4 bytes for size class 0,
+// 1 and 2 respectively.
+func readLength(r *bufio.Reader, class byte) (int, error) {
+	b, err := readN(r, 1<<class)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, d := range b {
+		n = n<<8 | int(d)
+	}
+	return n, nil
+}
+
+func readN(r *bufio.Reader, n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func decodeString(r *bufio.Reader, n int) (string, error) {
+	b, err := readN(r, n)
+	return string(b), err
+}
+
+func decodeArray(r *bufio.Reader, n int) ([]interface{}, error) {
+	a := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func decodeMap(r *bufio.Reader, n int) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, errMsgpack
+		}
+		if m[key], err = decode(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func appendUint32(buf []byte, u uint32) []byte {
+	return append(buf, byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
+
+func appendUint64(buf []byte, u uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(u>>32)), uint32(u))
+}
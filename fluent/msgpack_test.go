@@ -0,0 +1,95 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluent
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"nil", nil, nil},
+		{"true", true, true},
+		{"false", false, false},
+		{"fixint", 127, int64(127)},
+		{"negative-fixint", -32, int64(-32)},
+		{"uint8", 255, uint64(255)},
+		{"uint16", 65535, uint64(65535)},
+		{"uint32", uint32(math.MaxUint32), uint64(math.MaxUint32)},
+		{"uint64", uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{"int8", -128, int64(-128)},
+		{"int16", -32768, int64(-32768)},
+		{"int32", math.MinInt32, int64(math.MinInt32)},
+		{"int64", int64(math.MinInt64), int64(math.MinInt64)},
+		{"float", 1.5, 1.5},
+		{"fixstr", "hello", "hello"},
+		{"str8", strings.Repeat("a", 200), strings.Repeat("a", 200)},
+		{"str16", strings.Repeat("a", 70000)[:65535], strings.Repeat("a", 65535)},
+		{"str32", strings.Repeat("a", 70000), strings.Repeat("a", 70000)},
+		{"binary", []byte{1, 2, 3}, []byte{1, 2, 3}},
+		{"duration", time.Second, "1s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			have, err := decode(bufio.NewReader(bytes.NewReader(appendValue(nil, tt.value))))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(have, tt.expected) {
+				t.Fatalf("expected %v to match %v", have, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMsgpackContainers(t *testing.T) {
+	var buf []byte
+	buf = appendArrayHeader(buf, 20)
+	for i := 0; i < 20; i++ {
+		buf = appendInt(buf, int64(i))
+	}
+	buf = appendMapHeader(buf, 20)
+	for i := 0; i < 20; i++ {
+		buf = appendString(buf, strings.Repeat("k", i+1))
+		buf = appendBool(buf, i%2 == 0)
+	}
+	ts := time.Unix(1700000000, 123456789)
+	buf = appendEventTime(buf, ts)
+
+	r := bufio.NewReader(bytes.NewReader(buf))
+	a, err := decode(r)
+	if err != nil || len(a.([]interface{})) != 20 || a.([]interface{})[19] != int64(19) {
+		t.Fatalf("unexpected array %v: %v", a, err)
+	}
+	m, err := decode(r)
+	if err != nil || len(m.(map[string]interface{})) != 20 || m.(map[string]interface{})["kkk"] != true {
+		t.Fatalf("unexpected map %v: %v", m, err)
+	}
+	et, err := decode(r)
+	if err != nil || !ts.Equal(et.(time.Time)) {
+		t.Fatalf("expected %v to match %v: %v", et, ts, err)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+// DuplicateKeys defines how key-value pairs sharing a key are handled before
+// Values are handed to the Emit function. Keys are compared across
+// Values.FromContext, Values.FromLogger and Values.FromMethod, in that order,
+// so with KeepLast the key-value pairs passed to the logging method override
+// those added to the Logger, which override those found in Context. Only
+// string keys are compared.
+type DuplicateKeys int
+
+// Available DuplicateKeys policies.
+const (
+	// KeepLast only retains the last key-value pair of a key. It is the
+	// default.
+	KeepLast DuplicateKeys = iota
+	// KeepFirst only retains the first key-value pair of a key.
+	KeepFirst
+	// KeepAll retains all key-value pairs, leaving duplicates to the Emit
+	// function.
+	KeepAll
+)
+
+// WithDuplicateKeys configures how key-value pairs sharing a key are handled
+// before emitting. The default is KeepLast.
+func WithDuplicateKeys(policy DuplicateKeys) Option {
+	return func(o *options) {
+		o.duplicateKeys = policy
+	}
+}
+
+// dedupMapThreshold holds the number of key-value pairs above which duplicate
+// keys are detected using a map instead of comparing all pairs.
+const dedupMapThreshold = 16
+
+// dedup removes the key-value pairs sharing a key according to the policy.
+// The slices of values are only copied if they hold key-value pairs to remove.
+func dedup(values Values, policy DuplicateKeys) Values {
+	if policy == KeepAll {
+		return values
+	}
+	lists := [3][]interface{}{values.FromContext, values.FromLogger, values.FromMethod}
+
+	var (
+		buf  [dedupMapThreshold]string
+		keys = buf[:0]
+	)
+	for _, kvs := range lists {
+		for i := 0; i+1 < len(kvs); i += 2 {
+			if k, ok := kvs[i].(string); ok {
+				keys = append(keys, k)
+			}
+		}
+	}
+	if !hasDuplicates(keys) {
+		return values
+	}
+
+	// walk the key-value pairs in the order of precedence, marking the keys
+	// seen so others are dropped.
+	var (
+		seen  = make(map[string]struct{}, len(keys))
+		drop  [3]map[int]bool
+		order = [3]int{0, 1, 2}
+	)
+	if policy == KeepLast {
+		order = [3]int{2, 1, 0}
+	}
+	for _, l := range order {
+		kvs := lists[l]
+		n := len(kvs) - len(kvs)%2
+		for j := 0; j < n; j += 2 {
+			i := j
+			if policy == KeepLast {
+				i = n - 2 - j
+			}
+			k, ok := kvs[i].(string)
+			if !ok {
+				continue
+			}
+			if _, dup := seen[k]; dup {
+				if drop[l] == nil {
+					drop[l] = make(map[int]bool)
+				}
+				drop[l][i] = true
+				continue
+			}
+			seen[k] = struct{}{}
+		}
+	}
+
+	for l, d := range drop {
+		if d == nil {
+			continue
+		}
+		kvs := make([]interface{}, 0, len(lists[l])-2*len(d))
+		for i := 0; i < len(lists[l]); i += 2 {
+			if d[i] {
+				continue
+			}
+			end := i + 2
+			if end > len(lists[l]) {
+				end = len(lists[l])
+			}
+			kvs = append(kvs, lists[l][i:end]...)
+		}
+		lists[l] = kvs
+	}
+	values.FromContext, values.FromLogger, values.FromMethod = lists[0], lists[1], lists[2]
+	return values
+}
+
+// hasDuplicates reports whether the keys hold duplicates.
+func hasDuplicates(keys []string) bool {
+	if len(keys) > dedupMapThreshold {
+		seen := make(map[string]struct{}, len(keys))
+		for _, k := range keys {
+			if _, ok := seen[k]; ok {
+				return true
+			}
+			seen[k] = struct{}{}
+		}
+		return false
+	}
+	for i := 1; i < len(keys); i++ {
+		for j := 0; j < i; j++ {
+			if keys[i] == keys[j] {
+				return true
+			}
+		}
+	}
+	return false
+}
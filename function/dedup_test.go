@@ -0,0 +1,94 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   DuplicateKeys
+		expected Values
+	}{
+		{"last", KeepLast, Values{
+			FromContext: []interface{}{"ctx", "value"},
+			FromLogger:  []interface{}{"logger", "value"},
+			FromMethod:  []interface{}{1, "a", "key", "method", 1, "b", "missing"},
+		}},
+		{"first", KeepFirst, Values{
+			FromContext: []interface{}{"ctx", "value", "key", "context"},
+			FromLogger:  []interface{}{"logger", "value"},
+			FromMethod:  []interface{}{1, "a", 1, "b", "missing"},
+		}},
+		{"all", KeepAll, Values{
+			FromContext: []interface{}{"ctx", "value", "key", "context"},
+			FromLogger:  []interface{}{"key", "logger", "logger", "value", "key", "logger2"},
+			FromMethod:  []interface{}{1, "a", "key", "method", 1, "b", "missing"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var have Values
+			logger := NewLogger(func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+				have = values
+			}, 0, WithDuplicateKeys(tt.policy))
+
+			ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value", "key", "context")
+			l := logger.Context(ctx).With("key", "logger").With("logger", "value", "key", "logger2")
+			l.Info("text", 1, "a", "key", "method", 1, "b", "missing")
+
+			if !reflect.DeepEqual(tt.expected, have) {
+				t.Fatalf("want: %v\nhave: %v", tt.expected, have)
+			}
+		})
+	}
+}
+
+func TestDuplicateKeysUnmodified(t *testing.T) {
+	var (
+		have Values
+		kvs  = []interface{}{"key", "logger"}
+	)
+	logger := NewLogger(func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+		have = values
+	}, 0).With(kvs...)
+
+	method := []interface{}{"key", "method"}
+	logger.Info("text", method...)
+	if len(have.FromLogger) != 0 || !reflect.DeepEqual(method, have.FromMethod) {
+		t.Fatalf("unexpected values: %v", have)
+	}
+	logger.Info("text", "other", "method")
+	if !reflect.DeepEqual(kvs, have.FromLogger) {
+		t.Fatalf("want: %v\nhave: %v", kvs, have.FromLogger)
+	}
+
+	// many keys are compared using a map.
+	many := make([]interface{}, 0, 2*dedupMapThreshold+2)
+	for i := 0; i <= dedupMapThreshold; i++ {
+		many = append(many, string(rune('a'+i)), i)
+	}
+	logger.Info("text", append(many, "a", "last")...)
+	if len(have.FromMethod) != len(many) || have.FromMethod[len(many)-1] != "last" {
+		t.Fatalf("unexpected values: %v", have.FromMethod)
+	}
+}
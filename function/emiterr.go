@@ -0,0 +1,130 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+const (
+	// minErrorInterval is the initial interval in which the default error
+	// handler reports at most one failure to emit.
+	minErrorInterval = time.Second
+	// maxErrorInterval is the maximum interval in which the default error
+	// handler reports at most one failure to emit.
+	maxErrorInterval = time.Minute
+)
+
+// EmitErr is like Emit but allows the function to report a failure to write
+// the log message. See NewLoggerErr for how returned errors are handled.
+type EmitErr func(level telemetry.Level, msg string, err error, values Values, callerSkip int) error
+
+// AsEmitErr adapts an Emit function to the EmitErr signature. The returned
+// function never returns an error.
+func AsEmitErr(emitFunc Emit) EmitErr {
+	if emitFunc == nil {
+		return nil
+	}
+	return func(level telemetry.Level, msg string, err error, values Values, callerSkip int) error {
+		emitFunc(level, msg, err, values, callerSkip+1)
+		return nil
+	}
+}
+
+// NewLoggerErr creates a new function Logger that uses the given EmitErr
+// function to write log messages. Errors returned by the function are passed
+// to the handler configured with WithErrorHandler. If no handler is configured,
+// errors are written to os.Stderr, backing off exponentially up to one report
+// per minute to prevent flooding. The backoff starts over once failures stop
+// for a full interval. If an error Metric is configured using
+// WithErrorMetric, it is incremented for each returned error.
+// Loggers are configured at telemetry.LevelInfo level by default.
+func NewLoggerErr(emitFunc EmitErr, callerSkip int, opts ...Option) telemetry.Logger {
+	if emitFunc == nil {
+		return NewLogger(nil, callerSkip, opts...)
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	handler := o.errorHandler
+	if handler == nil {
+		handler = (&backoffErrorHandler{}).handle
+	}
+
+	return NewLogger(func(level telemetry.Level, msg string, err error, values Values, callerSkip int) {
+		// account for this function in the caller skip.
+		if emitErr := emitFunc(level, msg, err, values, callerSkip+1); emitErr != nil {
+			if o.errorMetric != nil {
+				o.errorMetric.Increment()
+			}
+			handler(emitErr)
+		}
+	}, callerSkip, opts...)
+}
+
+// backoffErrorHandler writes errors to the fallback writer, limiting the
+// number of reports using an exponential backoff. The backoff is reset once a
+// full interval passes without failures.
+type backoffErrorHandler struct {
+	mtx        sync.Mutex
+	now        func() time.Time
+	last       time.Time
+	next       time.Time
+	interval   time.Duration
+	suppressed int
+}
+
+// handle reports the provided error unless suppressed by the backoff.
+func (h *backoffErrorHandler) handle(err error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	now := time.Now()
+	if h.now != nil {
+		now = h.now()
+	}
+	// emits succeeded for a full interval, start over at the minimum interval.
+	if !h.last.IsZero() && now.Sub(h.last) > h.interval {
+		h.interval = 0
+	}
+	h.last = now
+	if now.Before(h.next) {
+		h.suppressed++
+		return
+	}
+
+	if h.suppressed > 0 {
+		_, _ = fmt.Fprintf(fallback, "telemetry: failed to emit log line: %v (%d more failures suppressed)\n",
+			err, h.suppressed)
+	} else {
+		_, _ = fmt.Fprintf(fallback, "telemetry: failed to emit log line: %v\n", err)
+	}
+
+	h.interval *= 2
+	if h.interval < minErrorInterval {
+		h.interval = minErrorInterval
+	}
+	if h.interval > maxErrorInterval {
+		h.interval = maxErrorInterval
+	}
+	h.next = now.Add(h.interval)
+	h.suppressed = 0
+}
@@ -0,0 +1,146 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestNewLoggerErr(t *testing.T) {
+	errSink := errors.New("sink unavailable")
+	var (
+		handled []error
+		emitted int
+	)
+	emitter := func(telemetry.Level, string, error, Values, int) error {
+		emitted++
+		if emitted%2 == 0 {
+			return errSink
+		}
+		return nil
+	}
+
+	metric := &mockIncrementMetric{}
+	logger := NewLoggerErr(emitter, 0,
+		WithErrorHandler(func(err error) { handled = append(handled, err) }),
+		WithErrorMetric(metric),
+	)
+
+	for i := 0; i < 4; i++ {
+		logger.Info("text")
+	}
+
+	if emitted != 4 {
+		t.Fatalf("emitted=%d, want 4", emitted)
+	}
+	if len(handled) != 2 || handled[0] != errSink {
+		t.Fatalf("handled=%v, want 2x %v", handled, errSink)
+	}
+	if metric.count != 2 {
+		t.Fatalf("metric.count=%v, want 2", metric.count)
+	}
+}
+
+func TestNewLoggerErrDefaultHandler(t *testing.T) {
+	var out bytes.Buffer
+	fallback = &out
+	t.Cleanup(func() { fallback = os.Stderr })
+
+	logger := NewLoggerErr(func(telemetry.Level, string, error, Values, int) error {
+		return errors.New("sink unavailable")
+	}, 0)
+
+	for i := 0; i < 3; i++ {
+		logger.Info("text")
+	}
+
+	// subsequent failures are suppressed by the backoff.
+	want := "telemetry: failed to emit log line: sink unavailable\n"
+	if out.String() != want {
+		t.Fatalf("expected %q to match %q", out.String(), want)
+	}
+}
+
+func TestAsEmitErr(t *testing.T) {
+	var msgs []string
+	logger := NewLoggerErr(AsEmitErr(func(_ telemetry.Level, msg string, _ error, _ Values, _ int) {
+		msgs = append(msgs, msg)
+	}), 0)
+
+	logger.Info("first")
+	logger.Info("second")
+
+	if strings.Join(msgs, ",") != "first,second" {
+		t.Fatalf("msgs=%v, want [first second]", msgs)
+	}
+	if AsEmitErr(nil) != nil {
+		t.Fatal("expected nil EmitErr for nil Emit")
+	}
+}
+
+type mockIncrementMetric struct {
+	telemetry.Metric
+	count float64
+}
+
+func (m *mockIncrementMetric) Increment() { m.count++ }
+
+func TestBackoffErrorHandlerReset(t *testing.T) {
+	var out bytes.Buffer
+	fallback = &out
+	t.Cleanup(func() { fallback = os.Stderr })
+
+	var (
+		clock   = time.Unix(0, 0)
+		handler = &backoffErrorHandler{now: func() time.Time { return clock }}
+		errSink = errors.New("sink unavailable")
+		reports = func() int { return strings.Count(out.String(), "\n") }
+	)
+
+	// a burst of failures grows the interval to 4s: reports at 0s, 1s and 3s.
+	for i := 0; i < 8; i++ {
+		handler.handle(errSink)
+		clock = clock.Add(500 * time.Millisecond)
+	}
+	if reports() != 3 || handler.interval != 4*time.Second {
+		t.Fatalf("expected 3 reports and a 4s interval, have %d and %v", reports(), handler.interval)
+	}
+
+	// failures keep occurring within the interval, so the backoff holds.
+	clock = clock.Add(3 * time.Second)
+	handler.handle(errSink)
+	if handler.interval != 8*time.Second {
+		t.Fatalf("want: %v\nhave: %v", 8*time.Second, handler.interval)
+	}
+
+	// a full interval without failures resets the backoff.
+	clock = clock.Add(9 * time.Second)
+	handler.handle(errSink)
+	clock = clock.Add(minErrorInterval)
+	handler.handle(errSink)
+	if handler.interval != 2*time.Second {
+		t.Fatalf("want: %v\nhave: %v", 2*time.Second, handler.interval)
+	}
+	if reports() != 6 {
+		t.Fatalf("expected 6 reports, have %d", reports())
+	}
+}
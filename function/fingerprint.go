@@ -0,0 +1,91 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"hash/fnv"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// ErrorFingerprintKey is the key of the error fingerprint key/value pair added
+// to Error and Fatal log lines by Loggers created with WithErrorFingerprint.
+const ErrorFingerprintKey = "error_fingerprint"
+
+// WithErrorFingerprint configures the Logger to add a fingerprint of the error
+// passed to Error and Fatal to the key/value pairs passed to the logging
+// method, using ErrorFingerprintKey. The fingerprint is computed by
+// ErrorFingerprint using the function holding the call site, taking the
+// configured caller skip into account. Downstream systems can group recurring
+// errors by it.
+func WithErrorFingerprint() Option {
+	return func(o *options) {
+		o.fingerprint = true
+	}
+}
+
+// Patterns replaced when normalizing error messages, in order of application.
+var (
+	quotedPattern = regexp.MustCompile(`"[^"]*"|'[^']*'|` + "`[^`]*`")
+	hexPattern    = regexp.MustCompile(`\b(0[xX][0-9a-fA-F]+|[0-9a-fA-F]{8,}(-[0-9a-fA-F]{4,})*)\b`)
+	numberPattern = regexp.MustCompile(`[0-9]+`)
+)
+
+// NormalizeErrorMessage returns the error message with variable parts
+// replaced, so messages of recurring errors are equal: quoted strings are
+// replaced by "?", hexadecimal values, like identifiers and addresses, by "*"
+// and numbers by "#".
+func NormalizeErrorMessage(msg string) string {
+	msg = quotedPattern.ReplaceAllString(msg, `"?"`)
+	msg = hexPattern.ReplaceAllString(msg, "*")
+	return numberPattern.ReplaceAllString(msg, "#")
+}
+
+// ErrorFingerprint returns a stable fingerprint of the error raised in the
+// provided function, as 16 hexadecimal characters. It hashes the type of the
+// innermost error found by following Unwrap, the normalized message of the
+// error, see NormalizeErrorMessage, and the function name. Line numbers are
+// left out, so fingerprints survive unrelated code changes.
+func ErrorFingerprint(err error, function string) string {
+	if err == nil {
+		return ""
+	}
+	cause := err
+	for {
+		u, ok := cause.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		next := u.Unwrap()
+		if next == nil {
+			break
+		}
+		cause = next
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(reflect.TypeOf(cause).String()))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(NormalizeErrorMessage(err.Error())))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(function))
+
+	fp := strconv.FormatUint(h.Sum64(), 16)
+	for len(fp) < 16 {
+		fp = "0" + fp
+	}
+	return fp
+}
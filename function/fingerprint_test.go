@@ -0,0 +1,84 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"user 42 not found", "user # not found"},
+		{`open "/tmp/x.txt": no such file`, `open "?": no such file`},
+		{"request 4bf92f35-77b3-4da6-a3ce-929d0e0e4736 failed", "request * failed"},
+		{"dial tcp 10.0.0.1:5432: connection refused", "dial tcp #.#.#.#:#: connection refused"},
+		{"bad pointer 0xc000123456", "bad pointer *"},
+	}
+
+	for _, tt := range tests {
+		if have := NormalizeErrorMessage(tt.msg); have != tt.want {
+			t.Errorf("want: %s\nhave: %s", tt.want, have)
+		}
+	}
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	fp := ErrorFingerprint(fmt.Errorf("load user 1: %w", os.ErrNotExist), "pkg.Load")
+	if len(fp) != 16 {
+		t.Fatalf("unexpected fingerprint: %s", fp)
+	}
+	if have := ErrorFingerprint(fmt.Errorf("load user 2: %w", os.ErrNotExist), "pkg.Load"); have != fp {
+		t.Fatalf("want: %s\nhave: %s", fp, have)
+	}
+	for _, other := range []string{
+		ErrorFingerprint(fmt.Errorf("load user 1: %w", os.ErrNotExist), "pkg.Save"),
+		ErrorFingerprint(fmt.Errorf("load user 1: %w", &os.PathError{Op: "open", Path: "x", Err: os.ErrNotExist}), "pkg.Load"),
+		ErrorFingerprint(fmt.Errorf("load group 1: %w", os.ErrNotExist), "pkg.Load"),
+	} {
+		if other == fp {
+			t.Fatalf("expected fingerprints to differ from %s", fp)
+		}
+	}
+	if have := ErrorFingerprint(nil, "pkg.Load"); have != "" {
+		t.Fatalf("unexpected fingerprint: %s", have)
+	}
+}
+
+func TestWithErrorFingerprint(t *testing.T) {
+	var have []interface{}
+	logger := NewLogger(func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+		have = values.FromMethod
+	}, 0, WithErrorFingerprint())
+
+	logger.Error("text", errors.New("failed 1"), "key", "value")
+	first := have
+	logger.Error("text", errors.New("failed 2"), "key", "value")
+	if len(have) != 4 || have[2] != ErrorFingerprintKey || have[3] != first[3] {
+		t.Fatalf("unexpected key/value pairs: %v and %v", first, have)
+	}
+
+	logger.Error("text", nil)
+	if len(have) != 0 {
+		t.Fatalf("unexpected key/value pairs: %v", have)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// guardPageSize holds the number of stack frames retrieved at a time while
+// searching for an Emit call in progress.
+const guardPageSize = 64
+
+var (
+	// fallback is where log lines are written to that are created from within
+	// an Emit call.
+	fallback io.Writer = os.Stderr
+
+	// emitStart and emitEnd delimit the program counters of the function
+	// handing log lines to the hooks and Emit function.
+	emitStart, emitEnd uintptr
+)
+
+func init() {
+	emitStart = reflect.ValueOf((*Logger).emit).Pointer()
+	if f := runtime.FuncForPC(emitStart); f != nil {
+		emitStart = f.Entry()
+	}
+	emitEnd = funcEnd(emitStart)
+}
+
+// funcEnd returns the first program counter past the function starting at
+// entry, found by binary search as the runtime does not expose it.
+func funcEnd(entry uintptr) uintptr {
+	inside := func(pc uintptr) bool {
+		f := runtime.FuncForPC(pc)
+		return f != nil && f.Entry() == entry
+	}
+	size := uintptr(1)
+	for inside(entry + size) {
+		size *= 2
+	}
+	lo, hi := entry+size/2, entry+size
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		if inside(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
+
+// enterEmit marks the Loggers sharing the root of the Logger as emitting. It
+// returns false if the log line is created from within an Emit call, in which
+// case it must not be handed to an Emit function again. Otherwise leaveEmit
+// must be called once the Emit call has returned.
+//
+// As long as the Loggers are not emitting another log line, entering costs a
+// single atomic increment. Otherwise the call stack of the goroutine is
+// searched for an Emit call in progress, to tell recursion from concurrent use.
+func (l *Logger) enterEmit() bool {
+	if atomic.AddInt32(l.emitting, 1) > 1 && insideEmit() {
+		atomic.AddInt32(l.emitting, -1)
+		return false
+	}
+	return true
+}
+
+// leaveEmit marks the end of an Emit call started with enterEmit.
+func (l *Logger) leaveEmit() {
+	atomic.AddInt32(l.emitting, -1)
+}
+
+// insideEmit reports whether the call stack of the current goroutine holds an
+// Emit call in progress, besides the one of the log line at hand. The program
+// counters are compared against the bounds of the emit function without
+// resolving symbols, searching the entire call stack.
+func insideEmit() bool {
+	var pcs [guardPageSize]uintptr
+	// skip runtime.Callers, insideEmit, enterEmit and the emit call of the log
+	// line at hand.
+	for skip := 4; ; skip += len(pcs) {
+		n := runtime.Callers(skip, pcs[:])
+		for _, pc := range pcs[:n] {
+			// return addresses point past the call instruction.
+			if pc > emitStart && pc <= emitEnd {
+				return true
+			}
+		}
+		if n < len(pcs) {
+			return false
+		}
+	}
+}
+
+// emitFallback writes a log line created from within an Emit call to the
+// fallback writer in a plain key=value format.
+func emitFallback(level telemetry.Level, msg string, err error, values Values) {
+	var buf bytes.Buffer
+	buf.WriteString("telemetry: recursive log: ")
+	formatLine(&buf, level, msg, err, values)
+	_, _ = fallback.Write(buf.Bytes())
+}
+
+// formatLine appends the log line to the buffer in a plain key=value format.
+func formatLine(buf *bytes.Buffer, level telemetry.Level, msg string, err error, values Values) {
+	_, _ = fmt.Fprintf(buf, "level=%v msg=%q", level, msg)
+	if err != nil {
+		_, _ = fmt.Fprintf(buf, " error=%q", err.Error())
+	}
+	for _, kvs := range [][]interface{}{values.FromContext, values.FromLogger, values.FromMethod} {
+		for i := 0; i < len(kvs); i += 2 {
+			_, _ = fmt.Fprintf(buf, " %v=", kvs[i])
+			if i+1 < len(kvs) {
+				_, _ = fmt.Fprintf(buf, "%v", kvs[i+1])
+			}
+		}
+	}
+	buf.WriteByte('\n')
+}
@@ -0,0 +1,232 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestRecursiveEmit(t *testing.T) {
+	var out bytes.Buffer
+	fallback = &out
+	t.Cleanup(func() { fallback = os.Stderr })
+
+	var (
+		logger telemetry.Logger
+		calls  int
+	)
+	logger = NewLogger(func(_ telemetry.Level, msg string, _ error, _ Values, _ int) {
+		calls++
+		// an emitter reporting its own failure through the same logger.
+		logger.With("key", "value").Error("failed to deliver", errors.New("unavailable"), "msg", msg)
+	}, 0)
+
+	logger.Info("text")
+
+	if calls != 1 {
+		t.Fatalf("calls=%d, want 1", calls)
+	}
+	want := `telemetry: recursive log: level=error msg="failed to deliver" error="unavailable" key=value msg=text` + "\n"
+	if out.String() != want {
+		t.Fatalf("expected %s to match %s", out.String(), want)
+	}
+
+	// after returning from Emit, logging must reach the Emit function again.
+	logger.Info("text")
+	if calls != 2 {
+		t.Fatalf("calls=%d, want 2", calls)
+	}
+}
+
+func TestConcurrentEmit(t *testing.T) {
+	var (
+		mtx   sync.Mutex
+		calls int
+	)
+	logger := NewLogger(func(telemetry.Level, string, error, Values, int) {
+		mtx.Lock()
+		calls++
+		mtx.Unlock()
+	}, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Info("text")
+		}()
+	}
+	wg.Wait()
+
+	if calls != 50 {
+		t.Fatalf("calls=%d, want 50", calls)
+	}
+}
+
+func TestEmitWhileEmitting(t *testing.T) {
+	var out bytes.Buffer
+	fallback = &out
+	t.Cleanup(func() { fallback = os.Stderr })
+
+	var (
+		entered = make(chan struct{})
+		release = make(chan struct{})
+		mtx     sync.Mutex
+		msgs    []string
+	)
+	logger := NewLogger(func(_ telemetry.Level, msg string, _ error, _ Values, _ int) {
+		if msg == "blocking" {
+			close(entered)
+			<-release
+		}
+		mtx.Lock()
+		msgs = append(msgs, msg)
+		mtx.Unlock()
+	}, 0)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Info("blocking")
+	}()
+	<-entered
+
+	// a log line of another goroutine while an Emit call is in progress is no
+	// recursion.
+	logger.With("key", "value").Info("concurrent")
+	close(release)
+	<-done
+
+	if out.Len() != 0 {
+		t.Fatalf("unexpected fallback output: %s", out.String())
+	}
+	if want, have := "concurrent blocking", strings.Join(msgs, " "); want != have {
+		t.Fatalf("want: %s\nhave: %s", want, have)
+	}
+}
+
+func TestRecursiveEmitAcrossLoggers(t *testing.T) {
+	var out bytes.Buffer
+	fallback = &out
+	t.Cleanup(func() { fallback = os.Stderr })
+
+	var (
+		a, b   telemetry.Logger
+		aCalls int
+		bCalls int
+	)
+	a = NewLogger(func(telemetry.Level, string, error, Values, int) {
+		aCalls++
+		b.Info("from a")
+	}, 0)
+	b = NewLogger(func(telemetry.Level, string, error, Values, int) {
+		bCalls++
+		a.Info("from b")
+	}, 0)
+
+	a.Info("text")
+
+	if aCalls != 1 || bCalls != 1 {
+		t.Fatalf("expected 1 call of each Emit function, have %d and %d", aCalls, bCalls)
+	}
+	if want := "telemetry: recursive log: level=info msg=\"from b\"\n"; out.String() != want {
+		t.Fatalf("expected %s to match %s", out.String(), want)
+	}
+}
+
+func TestRecursiveEmitDeepStack(t *testing.T) {
+	var out bytes.Buffer
+	fallback = &out
+	t.Cleanup(func() { fallback = os.Stderr })
+
+	var (
+		logger telemetry.Logger
+		calls  int
+		deep   func(depth int)
+	)
+	deep = func(depth int) {
+		if depth == 0 {
+			logger.Info("deep")
+			return
+		}
+		deep(depth - 1)
+	}
+	logger = NewLogger(func(telemetry.Level, string, error, Values, int) {
+		calls++
+		// log from far below the Emit call.
+		deep(1000)
+	}, 0)
+
+	logger.Info("text")
+
+	if calls != 1 {
+		t.Fatalf("calls=%d, want 1", calls)
+	}
+	if want := "telemetry: recursive log: level=info msg=\"deep\"\n"; out.String() != want {
+		t.Fatalf("expected %s to match %s", out.String(), want)
+	}
+}
+
+func BenchmarkEmit(b *testing.B) {
+	logger := NewLogger(func(telemetry.Level, string, error, Values, int) {}, 0).With("component", "store")
+
+	b.Run("serial", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Info("text", "key", "value")
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info("text", "key", "value")
+			}
+		})
+	})
+	b.Run("contended", func(b *testing.B) {
+		// an Emit call in progress on another goroutine makes each log line
+		// search the call stack for recursion.
+		var (
+			entered = make(chan struct{})
+			release = make(chan struct{})
+		)
+		blocking := NewLogger(func(_ telemetry.Level, msg string, _ error, _ Values, _ int) {
+			if msg == "blocking" {
+				close(entered)
+				<-release
+			}
+		}, 0)
+		go blocking.Info("blocking")
+		<-entered
+		defer close(release)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				blocking.Info("text", "key", "value")
+			}
+		})
+	})
+}
@@ -19,6 +19,7 @@ package function
 
 import (
 	"context"
+	"runtime"
 	"sync/atomic"
 
 	"github.com/basvanbeek/telemetry"
@@ -32,9 +33,18 @@ type (
 	// Similarly, the keyValues parameter presented in this method will already contain al the key/value pairs
 	// that need to be logged.
 	// The function will only be called when the log actually needs to be emitted.
+	// The key/value pairs found in the Logger Context are extracted at the time of the logging call; the
+	// Context itself is not handed to the function. Implementations processing Values asynchronously, e.g.
+	// on a background goroutine, therefore observe the Context as it was when the log line was created.
+	// Implementations may log through a telemetry.Logger themselves, e.g. to report a failure to deliver.
+	// Log lines created from within an Emit call on the same goroutine, through a function Logger sharing its
+	// root with the emitting Logger, are written to os.Stderr instead of being handed to an Emit function,
+	// which protects against infinite recursion.
 	Emit func(level telemetry.Level, msg string, err error, values Values, callerSkip int)
 
 	// Values contains all the key/value pairs to be included when emitting logs.
+	// Key-value pairs sharing a key are removed before handing Values to the
+	// Emit function, as configured using WithDuplicateKeys.
 	Values struct {
 		// FromContext has all the key/value pairs that have been added to the Logger Context
 		FromContext []interface{}
@@ -42,24 +52,45 @@ type (
 		FromLogger []interface{}
 		// FromMethod has the key/value pairs that were passed to the logging method.
 		FromMethod []interface{}
+		// Caller holds the call site of the logging method. It is only populated if the Logger was created
+		// with the WithCaller option. Emit implementations needing file and line information can resolve it
+		// lazily, while others pay nothing.
+		Caller Caller
+	}
+
+	// Caller holds the call site of a logging method. The zero value indicates caller information was not
+	// captured.
+	Caller struct {
+		// PC holds the program counter of the call site as returned by runtime.Callers.
+		PC uintptr
 	}
 
 	// Logger is an implementation of the telemetry.Logger that allows configuring named
 	// loggers that can be configured independently and referenced by name.
+	// Logging at a disabled level does not allocate when called on a *Logger, as
+	// the key/value pairs passed to its methods do not escape. Called through the
+	// telemetry.Logger interface, the caller allocates the key/value pairs
+	// regardless of the level; guard such calls using telemetry.Enabled on hot
+	// paths.
 	Logger struct {
 		// ctx holds the Context to extract key-value pairs from to be added to each
 		// log line.
 		ctx context.Context
 		// args holds the key-value pairs to be added to each log line.
 		args []interface{}
-		// metric holds the Metric to increment each time Info() or Error() is called.
+		// metric holds the Metric to increment each time Info(), Warn() or Error() is called.
 		metric telemetry.Metric
 		// level holds the configured log level.
 		level *int32
+		// emitting holds the number of log lines being emitted by the Loggers
+		// sharing the same root, used to guard against recursion.
+		emitting *int32
 		// emitFunc is the function that will be used to actually emit the logs
 		emitFunc Emit
 		// callerSkip is the number of stack frames to skip when adding file and line.
 		callerSkip int32
+		// opts holds the optional configuration as provided to NewLogger.
+		opts options
 	}
 )
 
@@ -68,14 +99,19 @@ var _ telemetry.Logger = (*Logger)(nil)
 
 // NewLogger creates a new function Logger that uses the given Emit function to write log messages.
 // Loggers are configured at telemetry.LevelInfo level by default.
-func NewLogger(emitFunc Emit, callerSkip int) telemetry.Logger {
+func NewLogger(emitFunc Emit, callerSkip int, opts ...Option) telemetry.Logger {
 	lvl := int32(telemetry.LevelInfo)
-	return &Logger{
+	l := &Logger{
 		ctx:        context.Background(),
 		level:      &lvl,
+		emitting:   new(int32),
 		emitFunc:   emitFunc,
 		callerSkip: int32(callerSkip),
 	}
+	for _, opt := range opts {
+		opt(&l.opts)
+	}
+	return l
 }
 
 func (l *Logger) CSIncrease() {
@@ -86,20 +122,42 @@ func (l *Logger) CSDecrease() {
 	atomic.AddInt32(&l.callerSkip, -1)
 }
 
+// Trace emits a log message at trace level with the given key value pairs.
+func (l *Logger) Trace(msg string, keyValues ...interface{}) {
+	if !l.enabled(telemetry.LevelTrace) {
+		return
+	}
+	keyValues, _ = telemetry.ExtractNoMetric(keyValues)
+	l.emit(telemetry.LevelTrace, msg, nil, keyValues)
+}
+
 // Debug emits a log message at debug level with the given key value pairs.
 func (l *Logger) Debug(msg string, keyValues ...interface{}) {
 	if !l.enabled(telemetry.LevelDebug) {
 		return
 	}
+	keyValues, _ = telemetry.ExtractNoMetric(keyValues)
 	l.emit(telemetry.LevelDebug, msg, nil, keyValues)
 }
 
 // Info emits a log message at info level with the given key value pairs.
+// For Loggers obtained through V with a verbosity above 0, the log message is
+// emitted at the level matching the verbosity instead.
 func (l *Logger) Info(msg string, keyValues ...interface{}) {
+	if l.opts.verbosity > 0 {
+		level := telemetry.VerbosityToLevel(l.opts.verbosity)
+		if !l.enabled(level) {
+			return
+		}
+		keyValues, _ = telemetry.ExtractNoMetric(keyValues)
+		l.emit(level, msg, nil, keyValues)
+		return
+	}
 	// even if we don't output the log line due to the level configuration,
-	// we always emit the Metric if it is set.
-	if l.metric != nil {
-		l.metric.RecordContext(l.ctx, 1)
+	// we always emit the Metric if it is set, unless explicitly skipped.
+	keyValues, noMetric := telemetry.ExtractNoMetric(keyValues)
+	if !noMetric {
+		l.record(telemetry.LevelInfo, keyValues)
 	}
 	if !l.enabled(telemetry.LevelInfo) {
 		return
@@ -107,14 +165,29 @@ func (l *Logger) Info(msg string, keyValues ...interface{}) {
 	l.emit(telemetry.LevelInfo, msg, nil, keyValues)
 }
 
+// Warn emits a log message at warn level with the given key value pairs.
+func (l *Logger) Warn(msg string, keyValues ...interface{}) {
+	// even if we don't output the log line due to the level configuration,
+	// we always emit the Metric if it is set, unless explicitly skipped.
+	keyValues, noMetric := telemetry.ExtractNoMetric(keyValues)
+	if !noMetric {
+		l.record(telemetry.LevelWarn, keyValues)
+	}
+	if !l.enabled(telemetry.LevelWarn) {
+		return
+	}
+	l.emit(telemetry.LevelWarn, msg, nil, keyValues)
+}
+
 // Error emits a log message at error level with the given key value pairs.
 // The given error will be used as the last parameter in the message format
 // string.
 func (l *Logger) Error(msg string, err error, keyValues ...interface{}) {
 	// even if we don't output the log line due to the level configuration,
-	// we always emit the Metric if it is set.
-	if l.metric != nil {
-		l.metric.RecordContext(l.ctx, 1)
+	// we always emit the Metric if it is set, unless explicitly skipped.
+	keyValues, noMetric := telemetry.ExtractNoMetric(keyValues)
+	if !noMetric {
+		l.record(telemetry.LevelError, keyValues)
 	}
 
 	if !l.enabled(telemetry.LevelError) {
@@ -124,16 +197,104 @@ func (l *Logger) Error(msg string, err error, keyValues ...interface{}) {
 	l.emit(telemetry.LevelError, msg, err, keyValues)
 }
 
+// Fatal emits a log message at error level with the given key value pairs,
+// after which the Emit function is flushed, if configured with WithFlush, and
+// telemetry.Exit(1) is called to terminate the process.
+func (l *Logger) Fatal(msg string, err error, keyValues ...interface{}) {
+	keyValues, noMetric := telemetry.ExtractNoMetric(keyValues)
+	if !noMetric {
+		l.record(telemetry.LevelError, keyValues)
+	}
+
+	if l.enabled(telemetry.LevelError) {
+		l.emit(telemetry.LevelError, msg, err, keyValues)
+	}
+	if l.opts.flush != nil {
+		l.opts.flush()
+	}
+
+	telemetry.Exit(1)
+}
+
+// record emits a measurement for the attached Metric, if any. If the Metric
+// implements telemetry.LeveledMetric, the provided level is passed along.
+func (l *Logger) record(level telemetry.Level, keyValues []interface{}) {
+	if l.metric == nil {
+		return
+	}
+	value := l.opts.metricValue(keyValues)
+	if lm, ok := l.metric.(telemetry.LeveledMetric); ok {
+		lm.RecordLeveled(l.ctx, value, level)
+		return
+	}
+	l.metric.RecordContext(l.ctx, value)
+}
+
 // emit the given log with all the key/values that have been accumulated.
 func (l *Logger) emit(level telemetry.Level, msg string, err error, keyValues []interface{}) {
 	// Note that here we don't ensure an even number of arguments in the keyValues slice.
 	// We let that to the emit function implementation with the idea of being able to accommodate
 	// unstructured loggers that don't use arguments as key/value pairs.
-	l.emitFunc(level, msg, err, Values{
-		FromContext: telemetry.KeyValuesFromContext(l.ctx),
+	values := Values{
+		FromContext: l.opts.provide(l.ctx, telemetry.ResolveKeyValuesFromContext(l.ctx)),
 		FromLogger:  l.args,
-		FromMethod:  keyValues,
-	}, int(l.callerSkip))
+		FromMethod:  l.opts.stamp(keyValues),
+	}
+	if l.opts.stackDepth > 0 && level == telemetry.LevelError {
+		// skip emit and the logging method.
+		values.FromMethod = withKeyValue(values.FromMethod, StackTraceKey,
+			stackTrace(2+int(l.callerSkip), l.opts.stackDepth))
+	}
+	if l.opts.fingerprint && err != nil && level == telemetry.LevelError {
+		// skip emit and the logging method.
+		frame, _ := callerAt(2 + int(l.callerSkip)).Resolve()
+		values.FromMethod = withKeyValue(values.FromMethod, ErrorFingerprintKey,
+			ErrorFingerprint(err, frame.Function))
+	}
+	values = dedup(values, l.opts.duplicateKeys)
+	if l.opts.logVolume {
+		recordVolume(level, values.FromLogger)
+	}
+	if l.opts.caller {
+		// skip emit and the logging method.
+		values.Caller = callerAt(2 + int(l.callerSkip))
+	}
+
+	values = l.opts.redact(values)
+
+	// Guard against Emit functions logging through a Logger ending up in the
+	// same Emit function again.
+	if !l.enterEmit() {
+		emitFallback(level, msg, err, values)
+		return
+	}
+	defer l.leaveEmit()
+
+	for _, hook := range l.opts.hooks {
+		hook(l.ctx, level, msg, err, values)
+	}
+	l.emitFunc(level, msg, err, values, int(l.callerSkip))
+}
+
+// Resolve returns the symbolic information of the call site. It returns false
+// if caller information was not captured.
+func (c Caller) Resolve() (runtime.Frame, bool) {
+	if c.PC == 0 {
+		return runtime.Frame{}, false
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{c.PC}).Next()
+	return frame, true
+}
+
+// callerAt returns the Caller found at the provided number of stack frames
+// above the function calling callerAt, with 0 identifying that function.
+func callerAt(skip int) Caller {
+	var pcs [1]uintptr
+	// skip runtime.Callers and callerAt.
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return Caller{}
+	}
+	return Caller{PC: pcs[0]}
 }
 
 // Level returns the logging level configured for this Logger.
@@ -144,12 +305,16 @@ func (l *Logger) SetLevel(level telemetry.Level) {
 	switch {
 	case level < telemetry.LevelError:
 		level = telemetry.LevelNone
-	case level < telemetry.LevelInfo:
+	case level < telemetry.LevelWarn:
 		level = telemetry.LevelError
+	case level < telemetry.LevelInfo:
+		level = telemetry.LevelWarn
 	case level < telemetry.LevelDebug:
 		level = telemetry.LevelInfo
-	default:
+	case level < telemetry.LevelTrace:
 		level = telemetry.LevelDebug
+	default:
+		level = telemetry.LevelTrace
 	}
 
 	atomic.StoreInt32(l.level, int32(level))
@@ -159,6 +324,11 @@ func (l *Logger) SetLevel(level telemetry.Level) {
 // logging level.
 func (l *Logger) enabled(level telemetry.Level) bool { return l.emitFunc != nil && level <= l.Level() }
 
+// Enabled reports whether the Logger emits log lines at the provided level,
+// which is never the case without Emit function. It is used by
+// telemetry.Enabled.
+func (l *Logger) Enabled(level telemetry.Level) bool { return l.enabled(level) }
+
 // With returns Logger with provided key value pairs attached.
 func (l *Logger) With(keyValues ...interface{}) telemetry.Logger {
 	if len(keyValues) == 0 {
@@ -170,7 +340,7 @@ func (l *Logger) With(keyValues ...interface{}) telemetry.Logger {
 
 	// We don't call Clone() here as we don't want to deference the level pointer;
 	// we just want to add the given args.
-	newLogger := newLoggerWithValues(l.ctx, l.metric, l.level, l.emitFunc, l.args, l.callerSkip)
+	newLogger := newLoggerWithValues(l.ctx, l.metric, l.level, l.emitting, l.emitFunc, l.args, l.callerSkip, l.opts)
 
 	for i := 0; i < len(keyValues); i += 2 {
 		if k, ok := keyValues[i].(string); ok {
@@ -186,16 +356,47 @@ func (l *Logger) With(keyValues ...interface{}) telemetry.Logger {
 func (l *Logger) Context(ctx context.Context) telemetry.Logger {
 	// We don't call Clone() here as we don't want to deference the level pointer;
 	// we just want to set the context.
-	return newLoggerWithValues(ctx, l.metric, l.level, l.emitFunc, l.args, l.callerSkip)
+	return newLoggerWithValues(ctx, l.metric, l.level, l.emitting, l.emitFunc, l.args, l.callerSkip, l.opts)
 }
 
 // Metric attaches provided Metric to the Logger allowing this metric to
-// record each invocation of Info and Error log lines. If context is available
+// record each invocation of Info, Warn and Error log lines. If context is available
 // in the Logger, it can be used for Metrics labels.
 func (l *Logger) Metric(m telemetry.Metric) telemetry.Logger {
 	// We don't call Clone() here as we don't want to deference the level pointer;
 	// we just want to set the metric.
-	return newLoggerWithValues(l.ctx, m, l.level, l.emitFunc, l.args, l.callerSkip)
+	return newLoggerWithValues(l.ctx, m, l.level, l.emitting, l.emitFunc, l.args, l.callerSkip, l.opts)
+}
+
+// V returns a Logger for klog/logr style verbosity levels, allowing code like
+// log.V(4).Info(...) to be ported as is. Info log lines of the returned Logger
+// are emitted at the level returned by telemetry.VerbosityToLevel, so they are
+// only output if the shared level of the Logger allows for it. Info log lines
+// with a verbosity above 0 do not record an attached Metric, just like Debug
+// and Trace. Other logging methods are not affected by the verbosity.
+func (l *Logger) V(verbosity int) telemetry.Logger {
+	opts := l.opts
+	opts.verbosity = verbosity
+	return newLoggerWithValues(l.ctx, l.metric, l.level, l.emitting, l.emitFunc, l.args, l.callerSkip, opts)
+}
+
+// WithCallerSkip returns a Logger sharing the level of the current Logger,
+// skipping the provided number of additional stack frames when capturing the
+// call site. Use it for helpers wrapping the logging methods, so the call site
+// of the helper is reported.
+func (l *Logger) WithCallerSkip(skip int) telemetry.Logger {
+	cs := atomic.LoadInt32(&l.callerSkip) + int32(skip)
+	return newLoggerWithValues(l.ctx, l.metric, l.level, l.emitting, l.emitFunc, l.args, cs, l.opts)
+}
+
+// WithEmit returns a Logger which keeps the key value pairs, Context, Metric
+// and caller skip of the current Logger but uses the provided Emit function to
+// write log messages. Like the other With methods, the returned Logger shares
+// its level with the current Logger.
+func (l *Logger) WithEmit(emitFunc Emit) telemetry.Logger {
+	// We don't call Clone() here as we don't want to deference the level pointer;
+	// we just want to set the emit function.
+	return newLoggerWithValues(l.ctx, l.metric, l.level, l.emitting, emitFunc, l.args, l.callerSkip, l.opts)
 }
 
 // Clone the current Logger and return it
@@ -203,18 +404,20 @@ func (l *Logger) Clone() telemetry.Logger {
 	// When cloning the logger, we don't want both logger to share a level.
 	// We need to dereference the pointer and set the level properly.
 	lvl := *l.level
-	return newLoggerWithValues(l.ctx, l.metric, &lvl, l.emitFunc, l.args, l.callerSkip)
+	return newLoggerWithValues(l.ctx, l.metric, &lvl, l.emitting, l.emitFunc, l.args, l.callerSkip, l.opts)
 }
 
 // newLoggerWithValues creates a new instance of a logger with the given data.
-func newLoggerWithValues(ctx context.Context, m telemetry.Metric, l *int32, f Emit, args []interface{}, cs int32) *Logger {
+func newLoggerWithValues(ctx context.Context, m telemetry.Metric, l, e *int32, f Emit, args []interface{}, cs int32, o options) *Logger {
 	newLogger := &Logger{
 		args:       make([]interface{}, len(args)),
 		ctx:        ctx,
 		metric:     m,
 		level:      l,
+		emitting:   e,
 		emitFunc:   f,
 		callerSkip: cs,
+		opts:       o,
 	}
 	copy(newLogger.args, args)
 	return newLogger
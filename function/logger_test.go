@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/basvanbeek/telemetry"
@@ -49,12 +50,18 @@ func TestLogger(t *testing.T) {
 	}{
 		{"none", telemetry.LevelNone, func(l telemetry.Logger) { l.Error("text", errors.New("error")) }, "", 1},
 		{"disabled-info", telemetry.LevelNone, func(l telemetry.Logger) { l.Info("text") }, "", 1},
+		{"disabled-warn", telemetry.LevelNone, func(l telemetry.Logger) { l.Warn("text") }, "", 1},
 		{"disabled-debug", telemetry.LevelNone, func(l telemetry.Logger) { l.Debug("text") }, "", 0},
+		{"disabled-trace", telemetry.LevelDebug, func(l telemetry.Logger) { l.Trace("text") }, "", 0},
 		{"disabled-error", telemetry.LevelNone, func(l telemetry.Logger) { l.Error("text", errors.New("error")) }, "", 1},
 		{"info", telemetry.LevelInfo, func(l telemetry.Logger) { l.Info("text") },
 			`level=info msg="text" [ctx value lvl info missing (MISSING)]`, 1},
 		{"info-with-values", telemetry.LevelInfo, func(l telemetry.Logger) { l.Info("text", "where", "there", 1, "1") },
 			`level=info msg="text" [ctx value lvl info missing (MISSING) where there 1 1]`, 1},
+		{"warn", telemetry.LevelWarn, func(l telemetry.Logger) { l.Warn("text") },
+			`level=warn msg="text" [ctx value lvl info missing (MISSING)]`, 1},
+		{"warn-with-values", telemetry.LevelWarn, func(l telemetry.Logger) { l.Warn("text", "where", "there", 1, "1") },
+			`level=warn msg="text" [ctx value lvl info missing (MISSING) where there 1 1]`, 1},
 		{"error", telemetry.LevelInfo, func(l telemetry.Logger) { l.Error("text", errors.New("error")) },
 			`level=error msg="text" err=error [ctx value lvl info missing (MISSING)]`, 1},
 		{"error-with-values", telemetry.LevelInfo, func(l telemetry.Logger) { l.Error("text", errors.New("error"), "where", "there", 1, "1") },
@@ -63,6 +70,8 @@ func TestLogger(t *testing.T) {
 			`level=debug msg="text" [ctx value lvl info missing (MISSING)]`, 0},
 		{"debug-with-values", telemetry.LevelDebug, func(l telemetry.Logger) { l.Debug("text", "where", "there", 1, "1") },
 			`level=debug msg="text" [ctx value lvl info missing (MISSING) where there 1 1]`, 0},
+		{"trace", telemetry.LevelTrace, func(l telemetry.Logger) { l.Trace("text", "where", "there") },
+			`level=trace msg="text" [ctx value lvl info missing (MISSING) where there]`, 0},
 	}
 
 	for _, tt := range tests {
@@ -95,11 +104,17 @@ func TestLogger(t *testing.T) {
 func TestSetUnexpectedLevel(t *testing.T) {
 	logger := NewLogger(nil, 0)
 	withvalues := logger.With("key", "value")
-	logger.SetLevel(telemetry.LevelInfo - 1)
+	logger.SetLevel(telemetry.LevelWarn - 1)
 
 	if withvalues.Level() != telemetry.LevelError {
 		t.Fatalf("Logger.Level()=%v, want: %v", withvalues.Level(), telemetry.LevelError)
 	}
+
+	logger.SetLevel(telemetry.LevelInfo - 1)
+
+	if withvalues.Level() != telemetry.LevelWarn {
+		t.Fatalf("Logger.Level()=%v, want: %v", withvalues.Level(), telemetry.LevelWarn)
+	}
 }
 
 func TestClone(t *testing.T) {
@@ -151,9 +166,259 @@ func TestClone(t *testing.T) {
 	}
 }
 
+func TestV(t *testing.T) {
+	var out bytes.Buffer
+	metric := mockMetric{}
+	logger := NewLogger(func(level telemetry.Level, msg string, _ error, _ Values, _ int) {
+		_, _ = fmt.Fprintf(&out, "%v:%s;", level, msg)
+	}, 0).Metric(&metric).(*Logger)
+
+	logger.V(0).Info("v0")
+	logger.V(1).Info("v1")
+	logger.V(4).With("key", "value").Info("v4")
+	logger.V(5).Error("v5", nil)
+
+	logger.SetLevel(telemetry.LevelDebug)
+	logger.V(4).Info("v4")
+	logger.V(5).Info("v5")
+
+	logger.SetLevel(telemetry.LevelTrace)
+	logger.V(5).Info("v5")
+
+	want := "info:v0;error:v5;debug:v4;trace:v5;"
+	if out.String() != want {
+		t.Fatalf("expected %s to match %s", out.String(), want)
+	}
+	if metric.count != 2 {
+		t.Fatalf("metric.count=%v, want 2", metric.count)
+	}
+}
+
+func TestWithEmit(t *testing.T) {
+	var original, migrated bytes.Buffer
+	emitter := func(w io.Writer) Emit {
+		return func(level telemetry.Level, msg string, _ error, values Values, _ int) {
+			all := append(values.FromContext, values.FromLogger...)
+			_, _ = fmt.Fprintf(w, "level=%v msg=%q %v", level, msg, all)
+		}
+	}
+
+	ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value")
+	logger := NewLogger(emitter(&original), 0).Context(ctx).With("key", "value")
+	derived := logger.(*Logger).WithEmit(emitter(&migrated))
+
+	derived.Info("text")
+	if original.Len() != 0 {
+		t.Fatalf("unexpected output on original emitter: %s", original.String())
+	}
+	want := `level=info msg="text" [ctx value key value]`
+	if migrated.String() != want {
+		t.Fatalf("expected %s to match %s", migrated.String(), want)
+	}
+
+	logger.SetLevel(telemetry.LevelDebug)
+	if derived.Level() != telemetry.LevelDebug {
+		t.Fatalf("derived.Level()=%v, want: %v", derived.Level(), telemetry.LevelDebug)
+	}
+}
+
+func TestFatal(t *testing.T) {
+	var calls []string
+	t.Cleanup(func() { telemetry.SetExitFunc(nil) })
+	telemetry.SetExitFunc(func(code int) { calls = append(calls, fmt.Sprintf("exit %d", code)) })
+
+	metric := mockMetric{}
+	logger := NewLogger(func(level telemetry.Level, msg string, err error, _ Values, _ int) {
+		calls = append(calls, fmt.Sprintf("emit %v %s %v", level, msg, err))
+	}, 0, WithFlush(func() { calls = append(calls, "flush") })).Metric(&metric)
+
+	logger.Fatal("text", errors.New("error"))
+
+	want := []string{"emit error text error", "flush", "exit 1"}
+	if strings.Join(want, ",") != strings.Join(calls, ",") {
+		t.Fatalf("want: %v\nhave: %v", want, calls)
+	}
+	if metric.count != 1 {
+		t.Fatalf("metric.count=%v, want 1", metric.count)
+	}
+}
+
+func TestLeveledMetric(t *testing.T) {
+	metric := mockLeveledMetric{counts: make(map[telemetry.Level]float64)}
+	logger := NewLogger(nil, 0).Metric(&metric)
+
+	logger.Info("text")
+	logger.Info("text")
+	logger.Warn("text")
+	logger.Error("text", errors.New("error"))
+	logger.Debug("text")
+
+	if metric.counts[telemetry.LevelInfo] != 2 {
+		t.Fatalf("metric.counts[info]=%v, want 2", metric.counts[telemetry.LevelInfo])
+	}
+	if metric.counts[telemetry.LevelWarn] != 1 {
+		t.Fatalf("metric.counts[warn]=%v, want 1", metric.counts[telemetry.LevelWarn])
+	}
+	if metric.counts[telemetry.LevelError] != 1 {
+		t.Fatalf("metric.counts[error]=%v, want 1", metric.counts[telemetry.LevelError])
+	}
+	if metric.count != 0 {
+		t.Fatalf("metric.count=%v, want 0", metric.count)
+	}
+}
+
+func TestNoMetric(t *testing.T) {
+	var out bytes.Buffer
+	metric := mockMetric{}
+	logger := NewLogger(func(_ telemetry.Level, msg string, _ error, values Values, _ int) {
+		_, _ = fmt.Fprintf(&out, "%s %v;", msg, values.FromMethod)
+	}, 0).Metric(&metric)
+	logger.SetLevel(telemetry.LevelDebug)
+
+	logger.Info("info", "key", "value")
+	logger.Info("info", telemetry.NoMetric, true, "key", "value")
+	logger.Error("error", nil, "key", "value", telemetry.NoMetric, true)
+	logger.Debug("debug", telemetry.NoMetric, true)
+
+	if metric.count != 1 {
+		t.Fatalf("metric.count=%v, want 1", metric.count)
+	}
+	want := "info [key value];info [key value];error [key value];debug [];"
+	if out.String() != want {
+		t.Fatalf("expected %s to match %s", out.String(), want)
+	}
+}
+
+func TestContextSnapshot(t *testing.T) {
+	var captured []Values
+	logger := NewLogger(func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+		captured = append(captured, values)
+	}, 0)
+
+	ctx := telemetry.KeyValuesToContext(context.Background(), "key1", "val1", "key2", "val2", "key3", "val3")
+	ctx = telemetry.KeyValuesToContext(ctx, "key4", "val4")
+
+	logger.Context(telemetry.KeyValuesToContext(ctx, "req", "1")).Info("text")
+	logger.Context(telemetry.KeyValuesToContext(ctx, "req", "2")).Info("text")
+
+	if len(captured) != 2 {
+		t.Fatalf("len(captured)=%d, want 2", len(captured))
+	}
+	for i, want := range []string{"1", "2"} {
+		fromContext := captured[i].FromContext
+		if have := fromContext[len(fromContext)-1]; have != want {
+			t.Fatalf("captured[%d] req=%v, want %v", i, have, want)
+		}
+	}
+}
+
 type mockMetric struct {
 	telemetry.Metric
 	count float64
 }
 
 func (m *mockMetric) RecordContext(_ context.Context, value float64) { m.count += value }
+
+type mockLeveledMetric struct {
+	mockMetric
+	counts map[telemetry.Level]float64
+}
+
+func (m *mockLeveledMetric) RecordLeveled(_ context.Context, value float64, level telemetry.Level) {
+	m.counts[level] += value
+}
+
+func TestDisabledAllocs(t *testing.T) {
+	var (
+		ctx    = telemetry.KeyValuesToContext(context.Background(), "request", "r1")
+		logger = NewLogger(func(telemetry.Level, string, error, Values, int) {}, 0,
+			WithCaller(), WithSequence("seq")).Context(ctx).With("component", "store").Metric(&mockMetric{}).(*Logger)
+		verbose = logger.V(4).(*Logger)
+		iface   = opaque(logger)
+		err     = errors.New("failed")
+		item    = &struct{ id int }{id: 1}
+	)
+	logger.SetLevel(telemetry.LevelInfo)
+
+	tests := []struct {
+		name string
+		log  func()
+	}{
+		{"trace", func() { logger.Trace("text", "key", "value", "n", 1, "item", item, "err", err) }},
+		{"debug", func() { logger.Debug("text", "key", "value", "n", 1, "item", item, "err", err) }},
+		{"debug unstructured", func() { logger.Debug("text") }},
+		{"info verbosity", func() { verbose.Info("text", "key", "value", "n", 1, "item", item) }},
+		{"interface guarded", func() {
+			if telemetry.Enabled(iface, telemetry.LevelDebug) {
+				iface.Debug("text", "key", "value", "n", 1, "item", item)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allocs := testing.AllocsPerRun(100, tt.log); allocs != 0 {
+				t.Fatalf("expected 0 allocs, have %v", allocs)
+			}
+		})
+	}
+
+	logger.SetLevel(telemetry.LevelError)
+	t.Run("info", func(t *testing.T) {
+		if allocs := testing.AllocsPerRun(100, func() { logger.Info("text", "key", "value", "item", item) }); allocs != 0 {
+			t.Fatalf("expected 0 allocs, have %v", allocs)
+		}
+	})
+}
+
+func TestEnabled(t *testing.T) {
+	logger := NewLogger(func(telemetry.Level, string, error, Values, int) {}, 0)
+	if !telemetry.Enabled(logger, telemetry.LevelInfo) || telemetry.Enabled(logger, telemetry.LevelDebug) {
+		t.Fatal("expected only info level and up to be enabled")
+	}
+	if telemetry.Enabled(NewLogger(nil, 0), telemetry.LevelError) {
+		t.Fatal("expected no level to be enabled without Emit function")
+	}
+}
+
+// opaque hides the concrete type of the Logger from the compiler, so calls on
+// the returned Logger are not devirtualized.
+//
+//go:noinline
+func opaque(l telemetry.Logger) telemetry.Logger { return l }
+
+func BenchmarkLogger(b *testing.B) {
+	ctx := telemetry.KeyValuesToContext(context.Background(), "request", "r1")
+	logger := NewLogger(func(telemetry.Level, string, error, Values, int) {}, 0).
+		Context(ctx).With("component", "store").(*Logger)
+	logger.SetLevel(telemetry.LevelInfo)
+	iface := opaque(logger)
+
+	b.Run("disabled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Debug("text", "key", "value", "n", 1)
+		}
+	})
+	b.Run("disabled interface", func(b *testing.B) {
+		// includes the allocation of the variadic slice by the caller
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			iface.Debug("text", "key", "value", "n", 1)
+		}
+	})
+	b.Run("disabled interface guarded", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if telemetry.Enabled(iface, telemetry.LevelDebug) {
+				iface.Debug("text", "key", "value", "n", 1)
+			}
+		}
+	})
+	b.Run("enabled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Info("text", "key", "value", "n", 1)
+		}
+	})
+}
@@ -0,0 +1,245 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+type (
+	// Option implements a functional option type for the function Logger.
+	Option func(*options)
+
+	// options holds the optional configuration of a function Logger. Loggers
+	// derived through With, Context, Metric and Clone inherit the options.
+	options struct {
+		// metricValueKey holds the key of the method key/value pair to take the
+		// Metric measurement value from.
+		metricValueKey string
+		// sequenceKey holds the key used to stamp emitted log lines with a
+		// sequence number.
+		sequenceKey string
+		// sequence holds the last handed out sequence number.
+		sequence *uint64
+		// caller indicates if the call site of logging methods is captured.
+		caller bool
+		// errorHandler holds the function to pass EmitErr failures to.
+		errorHandler func(error)
+		// errorMetric holds the Metric to increment on EmitErr failures.
+		errorMetric telemetry.Metric
+		// flush holds the function flushing the Emit function's sink.
+		flush func()
+		// verbosity holds the verbosity set through Logger.V.
+		verbosity int
+		// duplicateKeys holds the policy for key-value pairs sharing a key.
+		duplicateKeys DuplicateKeys
+		// providers holds the functions lazily providing key-value pairs.
+		providers []telemetry.KeyValuesProvider
+		// logVolume indicates if emitted log lines are counted on the log
+		// volume Metrics.
+		logVolume bool
+		// hooks holds the functions called for each emitted log line.
+		hooks []Hook
+		// stackDepth holds the maximum number of stack frames to capture for
+		// Error and Fatal log lines, 0 disables stack trace capture.
+		stackDepth int
+		// fingerprint indicates if Error and Fatal log lines get an error
+		// fingerprint added.
+		fingerprint bool
+		// redactors holds the Redactors applied to each emitted log line.
+		redactors []Redactor
+	}
+
+	// Hook is a function called for each log line emitted by a Logger, right
+	// before it is handed to the Emit function. Unlike Emit functions, it
+	// receives the Context of the Logger, allowing it to act on values not
+	// represented as key-value pairs, e.g. the active span of a tracing
+	// library. Hooks must not retain or modify Values.
+	Hook func(ctx context.Context, level telemetry.Level, msg string, err error, values Values)
+)
+
+// WithMetricValueFromField configures the Logger to record the value of the
+// key/value pair with the provided key, as passed to Info, Warn or Error, on the
+// attached Metric instead of a constant 1. This turns log lines like
+// Info("queue depth", "depth", 42) into measurements without a separate
+// instrumentation call.
+//
+// Values of type int, int8, int16, int32, int64, uint, uint8, uint16, uint32,
+// uint64, float32 and float64 are converted to float64. If the key is absent
+// or its value is not numeric, a value of 1 is recorded.
+func WithMetricValueFromField(key string) Option {
+	return func(o *options) {
+		o.metricValueKey = key
+	}
+}
+
+// WithSequence configures the Logger to stamp each emitted log line with a
+// monotonically increasing sequence number, using the provided key. The number
+// is taken at the time of the logging call, so consumers of Emit functions
+// processing log lines out of band, e.g. asynchronously, can reconstruct the
+// order in which they were created. Loggers derived through With, Context,
+// Metric and Clone share the sequence of the Logger they were created from.
+// The key/value pair is added in front of the key/value pairs passed to the
+// logging method.
+func WithSequence(key string) Option {
+	return func(o *options) {
+		o.sequenceKey = key
+		o.sequence = new(uint64)
+	}
+}
+
+// WithCaller configures the Logger to capture the call site of each emitted
+// log line in Values.Caller, taking the configured caller skip into account.
+// Capturing only records the program counter, symbolization is left to Emit
+// implementations calling Caller.Resolve.
+func WithCaller() Option {
+	return func(o *options) {
+		o.caller = true
+	}
+}
+
+// WithErrorHandler configures the function to call when the EmitErr function
+// of a Logger created with NewLoggerErr returns an error. The handler may log
+// through a telemetry.Logger, see Emit for how recursion is handled.
+func WithErrorHandler(handler func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = handler
+	}
+}
+
+// WithErrorMetric configures a Metric to increment each time the EmitErr
+// function of a Logger created with NewLoggerErr returns an error.
+func WithErrorMetric(m telemetry.Metric) Option {
+	return func(o *options) {
+		o.errorMetric = m
+	}
+}
+
+// WithFlush configures a function which flushes any buffered log lines of the
+// Emit function's sink. It is called by Fatal before the process terminates.
+func WithFlush(flush func()) Option {
+	return func(o *options) {
+		o.flush = flush
+	}
+}
+
+// WithKeyValuesProvider configures a function providing key-value pairs
+// derived from the Context of the Logger, e.g. trace identifiers, to add to
+// each emitted log line. It is only invoked for log lines enabled by the
+// Logger level and its key-value pairs are merged into Values.FromContext,
+// after those found in the Context. Providers stored in the Context using
+// telemetry.KeyValuesProviderToContext are supported without configuration.
+func WithKeyValuesProvider(provider telemetry.KeyValuesProvider) Option {
+	return func(o *options) {
+		if provider != nil {
+			o.providers = append(o.providers[:len(o.providers):len(o.providers)], provider)
+		}
+	}
+}
+
+// WithHook configures a Hook to call for each emitted log line. Hooks are
+// called in order of configuration. Log lines created through a function
+// Logger from within a Hook are handled as when created from within an Emit
+// function.
+func WithHook(hook Hook) Option {
+	return func(o *options) {
+		if hook != nil {
+			o.hooks = append(o.hooks[:len(o.hooks):len(o.hooks)], hook)
+		}
+	}
+}
+
+// provide returns the key-value pairs found in Context extended with those
+// returned by the configured providers.
+func (o options) provide(ctx context.Context, keyValues []interface{}) []interface{} {
+	for _, provider := range o.providers {
+		if kvs := provider(ctx); len(kvs) > 0 {
+			keyValues = telemetry.MergeKeyValues(keyValues, kvs...)
+		}
+	}
+	return keyValues
+}
+
+// stamp returns a copy of the provided key/value pairs, preceded by the next
+// sequence number if configured to do so. Copying keeps the variadic slice of
+// the logging methods from escaping, so the compiler can allocate it on the
+// stack of the caller.
+func (o options) stamp(keyValues []interface{}) []interface{} {
+	n := len(keyValues)
+	if o.sequence != nil {
+		n += 2
+	}
+	if n == 0 {
+		return nil
+	}
+	kvs := make([]interface{}, 0, n)
+	if o.sequence != nil {
+		kvs = append(kvs, o.sequenceKey, atomic.AddUint64(o.sequence, 1))
+	}
+	return append(kvs, keyValues...)
+}
+
+// metricValue returns the value to record on the attached Metric for the
+// provided method key/value pairs.
+func (o options) metricValue(keyValues []interface{}) float64 {
+	if o.metricValueKey == "" {
+		return 1
+	}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		if k, ok := keyValues[i].(string); !ok || k != o.metricValueKey {
+			continue
+		}
+		if v, ok := toFloat64(keyValues[i+1]); ok {
+			return v
+		}
+		return 1
+	}
+	return 1
+}
+
+// toFloat64 converts numeric values to float64.
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
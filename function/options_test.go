@@ -0,0 +1,213 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestWithMetricValueFromField(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyValues []interface{}
+		want      float64
+	}{
+		{"absent", []interface{}{"other", 42}, 1},
+		{"int", []interface{}{"depth", 42}, 42},
+		{"int64", []interface{}{"depth", int64(-3)}, -3},
+		{"uint8", []interface{}{"depth", uint8(7)}, 7},
+		{"float64", []interface{}{"depth", 0.5}, 0.5},
+		{"non-numeric", []interface{}{"depth", "42"}, 1},
+		{"dangling", []interface{}{"depth"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric := mockMetric{}
+			logger := NewLogger(nil, 0, WithMetricValueFromField("depth")).
+				With("depth", 1000).
+				Metric(&metric)
+
+			logger.Info("queue depth", tt.keyValues...)
+			if metric.count != tt.want {
+				t.Fatalf("info metric.count=%v, want %v", metric.count, tt.want)
+			}
+
+			metric.count = 0
+			logger.Error("queue depth", errors.New("error"), tt.keyValues...)
+			if metric.count != tt.want {
+				t.Fatalf("error metric.count=%v, want %v", metric.count, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSequence(t *testing.T) {
+	var (
+		mtx   sync.Mutex
+		lines [][]interface{}
+	)
+	logger := NewLogger(func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+		mtx.Lock()
+		lines = append(lines, values.FromMethod)
+		mtx.Unlock()
+	}, 0, WithSequence("seq"))
+
+	logger.Info("first", "key", "value")
+	logger.Debug("suppressed")
+	logger.With("key", "value").Info("second")
+	logger.Context(context.Background()).Clone().Error("third", nil)
+
+	want := [][]interface{}{
+		{"seq", uint64(1), "key", "value"},
+		{"seq", uint64(2)},
+		{"seq", uint64(3)},
+	}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+
+	// sequence numbers are unique under concurrent use.
+	lines = nil
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Info("concurrent")
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[uint64]bool)
+	for _, kvs := range lines {
+		seen[kvs[1].(uint64)] = true
+	}
+	if len(seen) != 10 {
+		t.Fatalf("unique sequence numbers=%d, want 10", len(seen))
+	}
+}
+
+func TestWithCaller(t *testing.T) {
+	var callers []Caller
+	emitter := func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+		callers = append(callers, values.Caller)
+	}
+
+	NewLogger(emitter, 0).Info("text")
+	if _, ok := callers[0].Resolve(); ok {
+		t.Fatalf("expected caller not to be captured, got: %v", callers[0])
+	}
+
+	callers = nil
+	logger := NewLogger(emitter, 0, WithCaller())
+	logger.Info("text")
+	logger.With("key", "value").Error("text", nil)
+
+	for _, caller := range callers {
+		frame, ok := caller.Resolve()
+		if !ok {
+			t.Fatal("expected caller to be captured")
+		}
+		if !strings.HasSuffix(frame.Function, ".TestWithCaller") {
+			t.Errorf("frame.Function=%s, want: TestWithCaller", frame.Function)
+		}
+		if !strings.HasSuffix(frame.File, "options_test.go") {
+			t.Errorf("frame.File=%s, want: options_test.go", frame.File)
+		}
+	}
+}
+
+func TestWithKeyValuesProvider(t *testing.T) {
+	var (
+		calls int
+		have  Values
+	)
+	provider := func(ctx context.Context) []interface{} {
+		calls++
+		return []interface{}{"user", telemetry.KeyValuesFromContext(ctx)[1]}
+	}
+	logger := NewLogger(func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+		have = values
+	}, 0, WithKeyValuesProvider(provider))
+
+	ctx := telemetry.KeyValuesToContext(context.Background(), "subject", "alice")
+	ctx = telemetry.KeyValuesProviderToContext(ctx, func(context.Context) []interface{} {
+		calls++
+		return []interface{}{"tenant", "acme"}
+	})
+	l := logger.Context(ctx).With("key", "value")
+
+	l.Debug("suppressed")
+	if calls != 0 {
+		t.Fatalf("expected providers not to be invoked, have %d calls", calls)
+	}
+
+	l.Info("text")
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, have %d", calls)
+	}
+	want := Values{
+		FromContext: []interface{}{"subject", "alice", "tenant", "acme", "user", "alice"},
+		FromLogger:  []interface{}{"key", "value"},
+	}
+	if !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestWithHook(t *testing.T) {
+	type line struct {
+		ctx   interface{}
+		level telemetry.Level
+		msg   string
+		err   error
+	}
+	var (
+		hooked  []line
+		emitted int
+		failed  = errors.New("failed")
+	)
+	logger := NewLogger(func(telemetry.Level, string, error, Values, int) {
+		emitted++
+	}, 0, WithHook(func(ctx context.Context, level telemetry.Level, msg string, err error, _ Values) {
+		hooked = append(hooked, line{ctx.Value(hookKey{}), level, msg, err})
+	}), WithHook(nil))
+
+	ctx := context.WithValue(context.Background(), hookKey{}, "value")
+	logger.Context(ctx).Error("text", failed)
+	logger.Debug("suppressed")
+	logger.Info("info")
+
+	want := []line{
+		{"value", telemetry.LevelError, "text", failed},
+		{nil, telemetry.LevelInfo, "info", nil},
+	}
+	if !reflect.DeepEqual(want, hooked) {
+		t.Fatalf("want: %v\nhave: %v", want, hooked)
+	}
+	if emitted != 2 {
+		t.Fatalf("expected 2 emitted log lines, have %d", emitted)
+	}
+}
+
+type hookKey struct{}
@@ -0,0 +1,80 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"sync/atomic"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// Redactor rewrites the key/value pairs of a log line before it is handed to
+// Hooks and Emit functions, e.g. to scrub personal or payment data. It must
+// not modify the slices held by the provided Values, but return Values with
+// copies of the slices it changes. See the redact package for a rule based
+// implementation.
+type Redactor func(values Values) Values
+
+// globalRedactor holds the Redactor applied by all Loggers.
+var globalRedactor atomic.Value
+
+type redactorHolder struct {
+	redactor Redactor
+}
+
+// SetGlobalRedactor configures a Redactor applied to the log lines of all
+// Loggers, before the Redactors configured per Logger using WithRedactor. A
+// nil Redactor removes the global Redactor.
+func SetGlobalRedactor(r Redactor) {
+	globalRedactor.Store(redactorHolder{redactor: r})
+}
+
+// WithRedactor configures a Redactor to apply to the log lines of the Logger.
+// Redactors are applied in order of configuration, after the global Redactor.
+func WithRedactor(r Redactor) Option {
+	return func(o *options) {
+		if r != nil {
+			o.redactors = append(o.redactors[:len(o.redactors):len(o.redactors)], r)
+		}
+	}
+}
+
+// redact applies the global and configured Redactors to the Values.
+func (o options) redact(values Values) Values {
+	if h, ok := globalRedactor.Load().(redactorHolder); ok && h.redactor != nil {
+		values = h.redactor(values)
+	}
+	for _, r := range o.redactors {
+		values = r(values)
+	}
+	return values
+}
+
+// RedactEmit returns an Emit function applying the Redactor to each log line
+// before handing it to the provided Emit function, allowing redaction per
+// destination, e.g. when combined using Tee.
+func RedactEmit(emit Emit, r Redactor) Emit {
+	return func(level telemetry.Level, msg string, err error, values Values, callerSkip int) {
+		emit(level, msg, err, r(values), callerSkip+1)
+	}
+}
+
+// RedactEmitErr returns an EmitErr function applying the Redactor to each log
+// line before handing it to the provided EmitErr function.
+func RedactEmitErr(emit EmitErr, r Redactor) EmitErr {
+	return func(level telemetry.Level, msg string, err error, values Values, callerSkip int) error {
+		return emit(level, msg, err, r(values), callerSkip+1)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// replace returns a Redactor replacing the values of the provided key.
+func replace(key string, value interface{}) Redactor {
+	return func(values Values) Values {
+		kvs := append([]interface{}(nil), values.FromMethod...)
+		for i := 0; i+1 < len(kvs); i += 2 {
+			if kvs[i] == key {
+				kvs[i+1] = value
+			}
+		}
+		values.FromMethod = kvs
+		return values
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	var have, sink []interface{}
+	t.Cleanup(func() { SetGlobalRedactor(nil) })
+	SetGlobalRedactor(replace("password", "global"))
+
+	logger := NewLogger(Tee(
+		func(_ telemetry.Level, _ string, _ error, values Values, _ int) { have = values.FromMethod },
+		RedactEmit(func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+			sink = values.FromMethod
+		}, replace("card", "sink")),
+	), 0, WithRedactor(replace("password", "logger")), WithRedactor(nil))
+
+	kvs := []interface{}{"password", "secret", "card", "4111"}
+	logger.Info("text", kvs...)
+
+	if want := []interface{}{"password", "logger", "card", "4111"}; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+	if want := []interface{}{"password", "logger", "card", "sink"}; !reflect.DeepEqual(want, sink) {
+		t.Fatalf("want: %v\nhave: %v", want, sink)
+	}
+	if kvs[1] != "secret" {
+		t.Fatal("expected key/value pairs not to be modified")
+	}
+
+	SetGlobalRedactor(nil)
+	NewLogger(func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+		have = values.FromMethod
+	}, 0).Info("text", kvs...)
+	if !reflect.DeepEqual(kvs, have) {
+		t.Fatalf("want: %v\nhave: %v", kvs, have)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// StackTraceKey is the key of the stack trace key/value pair added to Error
+// and Fatal log lines by Loggers created with WithStackTrace.
+const StackTraceKey = "stacktrace"
+
+// DefaultStackDepth is the maximum number of stack frames captured by
+// WithStackTrace if no positive depth is provided.
+const DefaultStackDepth = 32
+
+// WithStackTrace configures the Logger to capture the stack trace of each
+// Error and Fatal log line, adding it as formatted string to the key/value
+// pairs passed to the logging method using StackTraceKey. The stack starts at
+// the call site, taking the configured caller skip into account, and holds at
+// most depth frames, or DefaultStackDepth frames if depth is not positive.
+// Truncated stack traces end with a line holding "...".
+//
+// Each frame is formatted as in panics, the function name on one line followed
+// by a tab indented line holding the file and line number.
+func WithStackTrace(depth int) Option {
+	return func(o *options) {
+		if depth <= 0 {
+			depth = DefaultStackDepth
+		}
+		o.stackDepth = depth
+	}
+}
+
+// stackTrace returns the formatted stack trace found at the provided number of
+// stack frames above the function calling stackTrace, holding at most depth
+// frames.
+func stackTrace(skip, depth int) string {
+	// capture one extra frame to detect truncation.
+	pcs := make([]uintptr, depth+1)
+	// skip runtime.Callers and stackTrace.
+	n := runtime.Callers(skip+2, pcs)
+
+	var (
+		sb     strings.Builder
+		frames = runtime.CallersFrames(pcs[:n])
+	)
+	for i := 0; ; i++ {
+		frame, more := frames.Next()
+		if i == depth {
+			sb.WriteString("...\n")
+			break
+		}
+		sb.WriteString(frame.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(frame.File)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(frame.Line))
+		sb.WriteByte('\n')
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// withKeyValue returns a copy of the key/value pairs with the provided
+// key/value pair added.
+func withKeyValue(keyValues []interface{}, key string, value interface{}) []interface{} {
+	kvs := make([]interface{}, 0, len(keyValues)+2)
+	if len(keyValues)%2 == 1 {
+		// keep the dangling key of unstructured log lines last.
+		kvs = append(kvs, key, value)
+		return append(kvs, keyValues...)
+	}
+	kvs = append(kvs, keyValues...)
+	return append(kvs, key, value)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// logError logs through the provided Logger, for verifying caller skip
+// handling.
+func logError(l telemetry.Logger, keyValues ...interface{}) {
+	l.Error("text", errors.New("failed"), keyValues...)
+}
+
+func TestWithStackTrace(t *testing.T) {
+	var have []interface{}
+	emit := func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+		have = values.FromMethod
+	}
+
+	tests := []struct {
+		name      string
+		logger    telemetry.Logger
+		logfunc   func(telemetry.Logger)
+		keyValues int
+		frames    []string
+	}{
+		{"error", NewLogger(emit, 0, WithStackTrace(2)),
+			func(l telemetry.Logger) { l.Error("text", nil, "key", "value") },
+			4, []string{"function.TestWithStackTrace.func", "function.TestWithStackTrace.func", "..."}},
+		{"caller skip", NewLogger(emit, 1, WithStackTrace(1)),
+			func(l telemetry.Logger) { logError(l, "key") },
+			3, []string{"function.TestWithStackTrace.func", "..."}},
+		{"info", NewLogger(emit, 0, WithStackTrace(0)),
+			func(l telemetry.Logger) { l.Info("text", "key", "value") },
+			2, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			have = nil
+			tt.logfunc(tt.logger)
+			if len(have) != tt.keyValues {
+				t.Fatalf("unexpected key/value pairs: %v", have)
+			}
+			if tt.frames == nil {
+				return
+			}
+
+			var stack string
+			for i := 0; i+1 < len(have); i += 2 {
+				if have[i] == StackTraceKey {
+					stack = have[i+1].(string)
+				}
+			}
+			var frames []string
+			for _, line := range strings.Split(stack, "\n") {
+				if !strings.HasPrefix(line, "\t") {
+					frames = append(frames, line)
+				}
+			}
+			if len(frames) != len(tt.frames) {
+				t.Fatalf("unexpected stack trace:\n%s", stack)
+			}
+			for i, frame := range tt.frames {
+				if !strings.Contains(frames[i], frame) {
+					t.Fatalf("unexpected stack trace:\n%s", stack)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// stderr is where NewWithStderrForErrors duplicates log lines to.
+var stderr io.Writer = os.Stderr
+
+// Tee returns an Emit function handing each log line to all provided Emit
+// functions in order, e.g. to write to stdout and a file while shipping to a
+// remote service. A destination panicking does not prevent the log line from
+// reaching the other destinations, the panic is reported on os.Stderr. Nil
+// functions are ignored.
+func Tee(emits ...Emit) Emit {
+	errEmits := make([]EmitErr, 0, len(emits))
+	for _, emit := range emits {
+		if emit != nil {
+			errEmits = append(errEmits, AsEmitErr(emit))
+		}
+	}
+	tee := TeeErr(errEmits...)
+	return func(level telemetry.Level, msg string, err error, values Values, callerSkip int) {
+		// Emit functions do not fail, so errors can only stem from panics.
+		if pErr := tee(level, msg, err, values, callerSkip+1); pErr != nil {
+			_, _ = fmt.Fprintf(fallback, "telemetry: %v\n", pErr)
+		}
+	}
+}
+
+// TeeErr returns an EmitErr function handing each log line to all provided
+// EmitErr functions in order. A destination failing or panicking does not
+// prevent the log line from reaching the other destinations. The errors of
+// all failed destinations are returned combined. Nil functions are ignored.
+func TeeErr(emits ...EmitErr) EmitErr {
+	filtered := make([]EmitErr, 0, len(emits))
+	for _, emit := range emits {
+		if emit != nil {
+			filtered = append(filtered, emit)
+		}
+	}
+	return func(level telemetry.Level, msg string, err error, values Values, callerSkip int) error {
+		var errs multiError
+		for _, emit := range filtered {
+			if emitErr := teeCall(emit, level, msg, err, values, callerSkip); emitErr != nil {
+				errs = append(errs, emitErr)
+			}
+		}
+		switch len(errs) {
+		case 0:
+			return nil
+		case 1:
+			return errs[0]
+		default:
+			return errs
+		}
+	}
+}
+
+// teeCall calls the destination, converting a panic into an error.
+func teeCall(emit EmitErr, level telemetry.Level, msg string, err error, values Values, callerSkip int) (emitErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			emitErr = fmt.Errorf("emit panicked: %v", r)
+		}
+	}()
+	// account for the Tee function and this function in the caller skip.
+	return emit(level, msg, err, values, callerSkip+2)
+}
+
+// multiError combines the errors of multiple destinations.
+type multiError []error
+
+func (m multiError) Error() string {
+	s := make([]string, 0, len(m))
+	for _, err := range m {
+		s = append(s, err.Error())
+	}
+	return strings.Join(s, "; ")
+}
+
+// Unwrap returns the combined errors, for use with errors.Is and errors.As.
+func (m multiError) Unwrap() []error { return m }
+
+// LevelFilter returns an Emit function handing only log lines of the provided
+// level or more severe to emitFunc, e.g. to send Warn and Error lines to a
+// secondary destination of a Tee. Returns nil if emitFunc is nil.
+func LevelFilter(level telemetry.Level, emitFunc Emit) Emit {
+	if emitFunc == nil {
+		return nil
+	}
+	return func(lvl telemetry.Level, msg string, err error, values Values, callerSkip int) {
+		if lvl > level {
+			return
+		}
+		// account for this function in the caller skip.
+		emitFunc(lvl, msg, err, values, callerSkip+1)
+	}
+}
+
+// LevelFilterErr is like LevelFilter for EmitErr functions. Log lines more
+// verbose than the level are dropped without error.
+func LevelFilterErr(level telemetry.Level, emitFunc EmitErr) EmitErr {
+	if emitFunc == nil {
+		return nil
+	}
+	return func(lvl telemetry.Level, msg string, err error, values Values, callerSkip int) error {
+		if lvl > level {
+			return nil
+		}
+		// account for this function in the caller skip.
+		return emitFunc(lvl, msg, err, values, callerSkip+1)
+	}
+}
+
+// NewWithStderrForErrors returns an Emit function handing all log lines to
+// primary while duplicating Warn and Error lines to os.Stderr in a plain
+// key=value format. As the duplication happens below the Logger, a Metric
+// attached to the Logger is recorded once per log line, not once per
+// destination.
+func NewWithStderrForErrors(primary Emit) Emit {
+	return Tee(primary, LevelFilter(telemetry.LevelWarn, emitStderr))
+}
+
+// emitStderr writes the log line to stderr in a plain key=value format.
+func emitStderr(level telemetry.Level, msg string, err error, values Values, _ int) {
+	var buf bytes.Buffer
+	formatLine(&buf, level, msg, err, values)
+	_, _ = stderr.Write(buf.Bytes())
+}
@@ -0,0 +1,180 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestTee(t *testing.T) {
+	var out bytes.Buffer
+	fallback = &out
+	t.Cleanup(func() { fallback = os.Stderr })
+
+	var msgs []string
+	record := func(name string) Emit {
+		return func(_ telemetry.Level, msg string, _ error, _ Values, _ int) {
+			msgs = append(msgs, name+":"+msg)
+		}
+	}
+	panicking := func(telemetry.Level, string, error, Values, int) { panic("broken sink") }
+
+	logger := NewLogger(Tee(record("a"), nil, panicking, record("b")), 0)
+	logger.Info("hello")
+
+	if want := []string{"a:hello", "b:hello"}; !reflect.DeepEqual(want, msgs) {
+		t.Fatalf("want: %v\nhave: %v", want, msgs)
+	}
+	if !strings.Contains(out.String(), "emit panicked: broken sink") {
+		t.Fatalf("expected panic to be reported, have %q", out.String())
+	}
+}
+
+func TestTeeErr(t *testing.T) {
+	var (
+		errA    = errors.New("a failed")
+		errB    = errors.New("b failed")
+		handled []error
+		calls   int
+	)
+	failing := func(err error) EmitErr {
+		return func(telemetry.Level, string, error, Values, int) error {
+			calls++
+			return err
+		}
+	}
+
+	logger := NewLoggerErr(TeeErr(failing(nil), failing(errA), nil, failing(errB)), 0,
+		WithErrorHandler(func(err error) { handled = append(handled, err) }))
+	logger.Info("hello")
+
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, have %d", calls)
+	}
+	if len(handled) != 1 || handled[0].Error() != "a failed; b failed" {
+		t.Fatalf("unexpected errors: %v", handled)
+	}
+	if u, ok := handled[0].(interface{ Unwrap() []error }); !ok || !reflect.DeepEqual(u.Unwrap(), []error{errA, errB}) {
+		t.Fatalf("expected combined error to unwrap into %v", []error{errA, errB})
+	}
+
+	// a single failure is returned as is.
+	handled = nil
+	logger = NewLoggerErr(TeeErr(failing(errA), failing(nil)), 0,
+		WithErrorHandler(func(err error) { handled = append(handled, err) }))
+	logger.Info("hello")
+	if len(handled) != 1 || handled[0] != errA {
+		t.Fatalf("unexpected errors: %v", handled)
+	}
+}
+
+func TestTeeCaller(t *testing.T) {
+	var files []string
+	record := func(_ telemetry.Level, _ string, _ error, values Values, _ int) {
+		frame, _ := values.Caller.Resolve()
+		files = append(files, frame.Function)
+	}
+
+	logger := NewLogger(Tee(record, record), 0, WithCaller())
+	logger.Info("hello")
+
+	if len(files) != 2 {
+		t.Fatalf("expected 2 log lines, have %d", len(files))
+	}
+	for _, fn := range files {
+		if !strings.HasSuffix(fn, "TestTeeCaller") {
+			t.Fatalf("expected caller to be TestTeeCaller, have %s", fn)
+		}
+	}
+}
+
+func TestLevelFilter(t *testing.T) {
+	var levels []telemetry.Level
+	record := func(level telemetry.Level, _ string, _ error, _ Values, _ int) {
+		levels = append(levels, level)
+	}
+
+	logger := NewLogger(LevelFilter(telemetry.LevelWarn, record), 0)
+	logger.SetLevel(telemetry.LevelDebug)
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error", errors.New("failed"))
+
+	if want := []telemetry.Level{telemetry.LevelWarn, telemetry.LevelError}; !reflect.DeepEqual(want, levels) {
+		t.Fatalf("want: %v\nhave: %v", want, levels)
+	}
+	if LevelFilter(telemetry.LevelWarn, nil) != nil {
+		t.Fatal("expected nil Emit function")
+	}
+}
+
+func TestLevelFilterErr(t *testing.T) {
+	var levels []telemetry.Level
+	record := func(level telemetry.Level, _ string, _ error, _ Values, _ int) error {
+		levels = append(levels, level)
+		return errors.New("failed")
+	}
+
+	var handled int
+	logger := NewLoggerErr(LevelFilterErr(telemetry.LevelWarn, record), 0,
+		WithErrorHandler(func(error) { handled++ }))
+	logger.Info("info")
+	logger.Warn("warn")
+
+	if want := []telemetry.Level{telemetry.LevelWarn}; !reflect.DeepEqual(want, levels) {
+		t.Fatalf("want: %v\nhave: %v", want, levels)
+	}
+	if handled != 1 {
+		t.Fatalf("expected 1 error for the emitted log line, have %d", handled)
+	}
+	if LevelFilterErr(telemetry.LevelWarn, nil) != nil {
+		t.Fatal("expected nil EmitErr function")
+	}
+}
+
+func TestNewWithStderrForErrors(t *testing.T) {
+	var out bytes.Buffer
+	stderr = &out
+	t.Cleanup(func() { stderr = os.Stderr })
+
+	var msgs []string
+	primary := func(_ telemetry.Level, msg string, _ error, _ Values, _ int) {
+		msgs = append(msgs, msg)
+	}
+	metric := &mockMetric{}
+
+	logger := NewLogger(NewWithStderrForErrors(primary), 0).Metric(metric)
+	logger.Info("info", "key", "value")
+	logger.Warn("warn", "key", "value")
+	logger.Error("error", errors.New("failed"))
+
+	if want := []string{"info", "warn", "error"}; !reflect.DeepEqual(want, msgs) {
+		t.Fatalf("want: %v\nhave: %v", want, msgs)
+	}
+	if want := "level=warn msg=\"warn\" key=value\nlevel=error msg=\"error\" error=\"failed\"\n"; out.String() != want {
+		t.Fatalf("want: %q\nhave: %q", want, out.String())
+	}
+	if metric.count != 3 {
+		t.Fatalf("expected Metric to be recorded once per log line, have %v", metric.count)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// Names of the Metrics maintained by Loggers created with the
+// WithLogVolumeMetrics option.
+const (
+	LogMessagesMetric = "log_messages_total"
+	LogErrorsMetric   = "log_errors_total"
+)
+
+// Labels of the log volume Metrics. The ScopeLabel value is taken from the
+// key-value pair with the "scope" key added to the Logger, as done by the
+// scope package, and is empty for Loggers outside of a scope.
+const (
+	ScopeLabel = "scope"
+	LevelLabel = "level"
+)
+
+// WithLogVolumeMetrics configures the Logger to count its emitted log lines in
+// a log_messages_total Sum with scope and level labels, and its Error and
+// Fatal log lines in a log_errors_total Sum with a scope label. The Metrics are
+// created on the global MetricSink once it is set using
+// telemetry.SetGlobalMetricSink, log lines emitted before are not counted.
+// They are shared by all Loggers configured with this option, so enabling it
+// on the Logger passed to scope.UseLogger covers all scopes.
+func WithLogVolumeMetrics() Option {
+	return func(o *options) {
+		o.logVolume = true
+		volumeOnce.Do(func() {
+			telemetry.ToGlobalMetricSink(func(ms telemetry.MetricSink) {
+				v := &logVolume{scope: ms.NewLabel(ScopeLabel), level: ms.NewLabel(LevelLabel)}
+				v.messages = ms.NewSum(LogMessagesMetric, "Number of emitted log lines.",
+					telemetry.WithLabels(v.scope, v.level))
+				v.errors = ms.NewSum(LogErrorsMetric, "Number of emitted Error and Fatal log lines.",
+					telemetry.WithLabels(v.scope))
+				volume.Store(v)
+			})
+		})
+	}
+}
+
+var (
+	volumeOnce sync.Once
+	volume     atomic.Value
+)
+
+// logVolume holds the log volume Metrics.
+type logVolume struct {
+	messages telemetry.Metric
+	errors   telemetry.Metric
+	scope    telemetry.Label
+	level    telemetry.Label
+}
+
+// recordVolume counts an emitted log line on the log volume Metrics, if
+// created.
+func recordVolume(level telemetry.Level, fromLogger []interface{}) {
+	v, ok := volume.Load().(*logVolume)
+	if !ok {
+		return
+	}
+	var scope string
+	for i := len(fromLogger) - len(fromLogger)%2 - 2; i >= 0; i -= 2 {
+		if k, ok := fromLogger[i].(string); ok && k == ScopeLabel {
+			scope, _ = fromLogger[i+1].(string)
+			break
+		}
+	}
+	v.messages.With(v.scope.Upsert(scope), v.level.Upsert(level.String())).Increment()
+	if level == telemetry.LevelError {
+		v.errors.With(v.scope.Upsert(scope)).Increment()
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+type volumeLabel string
+
+func (l volumeLabel) Insert(v string) telemetry.LabelValue { return string(l) + "=" + v }
+func (l volumeLabel) Update(v string) telemetry.LabelValue { return string(l) + "=" + v }
+func (l volumeLabel) Upsert(v string) telemetry.LabelValue { return string(l) + "=" + v }
+func (l volumeLabel) Delete() telemetry.LabelValue         { return string(l) + "=" }
+
+// volumeSink counts measurements by metric name and label values.
+type volumeSink struct {
+	telemetry.MetricSink
+	mtx    sync.Mutex
+	counts map[string]float64
+}
+
+type volumeMetric struct {
+	telemetry.Metric
+	sink   *volumeSink
+	name   string
+	labels []string
+}
+
+func (m *volumeMetric) Increment() {
+	m.sink.mtx.Lock()
+	defer m.sink.mtx.Unlock()
+	m.sink.counts[m.name+"{"+strings.Join(m.labels, ",")+"}"]++
+}
+
+func (m *volumeMetric) With(labelValues ...telemetry.LabelValue) telemetry.Metric {
+	mc := *m
+	for _, lv := range labelValues {
+		mc.labels = append(mc.labels, lv.(string))
+	}
+	return &mc
+}
+
+func (s *volumeSink) NewSum(name, _ string, _ ...telemetry.MetricOption) telemetry.Metric {
+	return &volumeMetric{sink: s, name: name}
+}
+
+func (s *volumeSink) NewLabel(name string) telemetry.Label { return volumeLabel(name) }
+
+func TestLogVolumeMetrics(t *testing.T) {
+	sink := &volumeSink{counts: make(map[string]float64)}
+	telemetry.SetGlobalMetricSink(sink)
+
+	logger := NewLogger(func(telemetry.Level, string, error, Values, int) {}, 0, WithLogVolumeMetrics())
+	logger.SetLevel(telemetry.LevelDebug)
+	scoped := logger.With("scope", "db")
+
+	logger.Info("info")
+	logger.Trace("disabled")
+	scoped.Debug("debug")
+	scoped.Context(context.Background()).Error("error", nil)
+	scoped.Warn("warn")
+	scoped.Warn("warn")
+	NewLogger(func(telemetry.Level, string, error, Values, int) {}, 0).Info("not counted")
+
+	want := map[string]float64{
+		"log_messages_total{scope=,level=info}":    1,
+		"log_messages_total{scope=db,level=debug}": 1,
+		"log_messages_total{scope=db,level=error}": 1,
+		"log_messages_total{scope=db,level=warn}":  2,
+		"log_errors_total{scope=db}":               1,
+	}
+	if !reflect.DeepEqual(want, sink.counts) {
+		t.Fatalf("want: %v\nhave: %v", want, sink.counts)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// compile time check for compatibility with the io.Writer interface.
+var _ io.Writer = (*levelWriter)(nil)
+
+// LevelWriter returns an io.Writer that turns each written line into a log
+// line emitted at the provided level on the given telemetry.Logger. Partial
+// lines are buffered until a newline is written. Empty lines are dropped.
+// This allows capturing the output of libraries that only accept an io.Writer.
+func LevelWriter(l telemetry.Logger, level telemetry.Level) io.Writer {
+	return &levelWriter{logger: l, level: level}
+}
+
+// NewStdLogger returns a standard library *log.Logger writing into the given
+// telemetry.Logger at the provided level, e.g. for use as http.Server ErrorLog
+// or with libraries that only accept a *log.Logger. To log into a particular
+// scope, pass the scope's Logger. The returned *log.Logger is created without
+// prefix and flags, as timestamps and prefixes are left to the
+// telemetry.Logger. Output spanning multiple lines results in a log line per
+// line, see LevelWriter.
+func NewStdLogger(l telemetry.Logger, level telemetry.Level) *log.Logger {
+	return log.New(LevelWriter(l, level), "", 0)
+}
+
+type levelWriter struct {
+	mtx    sync.Mutex
+	logger telemetry.Logger
+	level  telemetry.Level
+	buf    []byte
+}
+
+// Write implements io.Writer.
+func (w *levelWriter) Write(p []byte) (int, error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		w.log(string(bytes.TrimRight(w.buf[:idx], "\r")))
+		w.buf = w.buf[idx+1:]
+	}
+	if len(w.buf) == 0 {
+		// release the underlying array once all lines have been consumed.
+		w.buf = nil
+	}
+
+	return len(p), nil
+}
+
+// log emits the provided line at the configured level.
+func (w *levelWriter) log(line string) {
+	if line == "" {
+		return
+	}
+	switch {
+	case w.level <= telemetry.LevelError:
+		w.logger.Error(line, nil)
+	case w.level <= telemetry.LevelWarn:
+		w.logger.Warn(line)
+	case w.level <= telemetry.LevelInfo:
+		w.logger.Info(line)
+	case w.level <= telemetry.LevelDebug:
+		w.logger.Debug(line)
+	default:
+		w.logger.Trace(line)
+	}
+}
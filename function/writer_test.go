@@ -0,0 +1,77 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestLevelWriter(t *testing.T) {
+	var lines []string
+	logger := NewLogger(func(level telemetry.Level, msg string, _ error, _ Values, _ int) {
+		lines = append(lines, fmt.Sprintf("%v:%s", level, msg))
+	}, 0)
+	logger.SetLevel(telemetry.LevelTrace)
+
+	tests := []struct {
+		level telemetry.Level
+		want  string
+	}{
+		{telemetry.LevelError, "error:line"},
+		{telemetry.LevelWarn, "warn:line"},
+		{telemetry.LevelInfo, "info:line"},
+		{telemetry.LevelDebug, "debug:line"},
+		{telemetry.LevelTrace, "trace:line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			lines = nil
+			w := LevelWriter(logger, tt.level)
+
+			_, _ = w.Write([]byte("li"))
+			if len(lines) != 0 {
+				t.Fatalf("partial line emitted: %v", lines)
+			}
+			_, _ = w.Write([]byte("ne\r\n\nline\nli"))
+			_, _ = w.Write([]byte("ne\n"))
+
+			want := []string{tt.want, tt.want, tt.want}
+			if !reflect.DeepEqual(want, lines) {
+				t.Fatalf("want: %v\nhave: %v", want, lines)
+			}
+		})
+	}
+}
+
+func TestNewStdLogger(t *testing.T) {
+	var lines []string
+	logger := NewLogger(func(level telemetry.Level, msg string, _ error, _ Values, _ int) {
+		lines = append(lines, fmt.Sprintf("%v:%s", level, msg))
+	}, 0)
+
+	l := NewStdLogger(logger.With("component", "http"), telemetry.LevelWarn)
+	l.Printf("http: TLS handshake error from %s", "127.0.0.1")
+	l.Print("first\nsecond")
+
+	want := []string{"warn:http: TLS handshake error from 127.0.0.1", "warn:first", "warn:second"}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcplog provides sinks for Google Cloud Logging, either through the
+// Cloud Logging API or as structured JSON written to stdout, which the
+// logging agents of GKE, Cloud Run and Cloud Functions forward. Both map the
+// severity, trace, span and labels onto their dedicated LogEntry fields.
+package gcplog
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/logging"
+	logpb "cloud.google.com/go/logging/apiv2/loggingpb"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Default keys of the key-value pairs mapped onto LogEntry fields.
+const (
+	DefaultTraceKey = "trace_id"
+	DefaultSpanKey  = "span_id"
+)
+
+// Special fields recognized by the logging agents in structured JSON.
+const (
+	TraceField          = "logging.googleapis.com/trace"
+	SpanField           = "logging.googleapis.com/spanId"
+	LabelsField         = "logging.googleapis.com/labels"
+	SourceLocationField = "logging.googleapis.com/sourceLocation"
+)
+
+// Logger holds the Cloud Logging operation used by Emit. It is implemented by
+// *logging.Logger.
+type Logger interface {
+	Log(e logging.Entry)
+}
+
+type (
+	// Option implements a functional option type for the Cloud Logging sinks.
+	Option func(*options)
+
+	// options holds the configuration of the Cloud Logging sinks.
+	options struct {
+		projectID string
+		traceKey  string
+		spanKey   string
+		labelKeys map[string]bool
+	}
+)
+
+// WithProjectID sets the Google Cloud project ID used to qualify trace IDs as
+// "projects/PROJECT_ID/traces/TRACE_ID", as required for Cloud Logging to
+// correlate log entries with Cloud Trace.
+func WithProjectID(id string) Option {
+	return func(o *options) {
+		o.projectID = id
+	}
+}
+
+// WithTraceKey sets the key of the key-value pair holding the trace ID. The
+// default is DefaultTraceKey.
+func WithTraceKey(key string) Option {
+	return func(o *options) {
+		o.traceKey = key
+	}
+}
+
+// WithSpanKey sets the key of the key-value pair holding the span ID. The
+// default is DefaultSpanKey.
+func WithSpanKey(key string) Option {
+	return func(o *options) {
+		o.spanKey = key
+	}
+}
+
+// WithLabelKeys sets the keys of the key-value pairs sent as LogEntry labels
+// instead of payload fields. Labels are indexed and can be used in log based
+// metrics and sinks.
+func WithLabelKeys(keys ...string) Option {
+	return func(o *options) {
+		for _, key := range keys {
+			o.labelKeys[key] = true
+		}
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		traceKey:  DefaultTraceKey,
+		spanKey:   DefaultSpanKey,
+		labelKeys: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// entry holds a log line split into the LogEntry fields.
+type entry struct {
+	trace     string
+	spanID    string
+	labels    map[string]string
+	keyValues []interface{}
+}
+
+// split extracts the trace, span and labels from the key-value pairs found in
+// Context, added to the Logger and passed to the logging method. Non-string
+// keys are formatted using fmt.Sprint and a missing value is set to
+// "(MISSING)".
+func (o options) split(values function.Values) entry {
+	var e entry
+	for _, kvs := range [][]interface{}{values.FromContext, values.FromLogger, values.FromMethod} {
+		for i := 0; i < len(kvs); i += 2 {
+			k, ok := kvs[i].(string)
+			if !ok {
+				k = fmt.Sprint(kvs[i])
+			}
+			var v interface{} = "(MISSING)"
+			if i+1 < len(kvs) {
+				v = kvs[i+1]
+			}
+			switch {
+			case k == o.traceKey:
+				e.trace = o.qualifyTrace(fmt.Sprint(v))
+			case k == o.spanKey:
+				e.spanID = fmt.Sprint(v)
+			case o.labelKeys[k]:
+				if e.labels == nil {
+					e.labels = make(map[string]string)
+				}
+				e.labels[k] = fmt.Sprint(v)
+			default:
+				e.keyValues = append(e.keyValues, k, v)
+			}
+		}
+	}
+	return e
+}
+
+func (o options) qualifyTrace(trace string) string {
+	if o.projectID == "" || trace == "" || strings.HasPrefix(trace, "projects/") {
+		return trace
+	}
+	return "projects/" + o.projectID + "/traces/" + trace
+}
+
+// Emit returns a function.EmitErr logging through the provided Cloud Logging
+// Logger, which buffers and sends entries in the background. Use the Flush
+// method of the Logger with function.WithFlush. Errors sending entries are
+// reported to the OnError function of the logging.Client.
+//
+// The payload holds the message, the error and the remaining key-value
+// pairs. The caller is sent as source location and is only available if the
+// function Logger is created with the function.WithCaller option.
+func Emit(l Logger, opts ...Option) function.EmitErr {
+	o := newOptions(opts)
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) error {
+		e := o.split(values)
+
+		payload := make(map[string]interface{}, 2+len(e.keyValues)/2)
+		payload["message"] = msg
+		if err != nil {
+			payload["error"] = err.Error()
+		}
+		for i := 0; i < len(e.keyValues); i += 2 {
+			payload[e.keyValues[i].(string)] = payloadValue(e.keyValues[i+1])
+		}
+
+		entry := logging.Entry{
+			Timestamp: time.Now(),
+			Severity:  ToSeverity(level),
+			Payload:   payload,
+			Labels:    e.labels,
+			Trace:     e.trace,
+			SpanID:    e.spanID,
+		}
+		if frame, ok := values.Caller.Resolve(); ok {
+			entry.SourceLocation = &logpb.LogEntrySourceLocation{
+				File:     frame.File,
+				Line:     int64(frame.Line),
+				Function: path.Base(frame.Function),
+			}
+		}
+		l.Log(entry)
+		return nil
+	}
+}
+
+// payloadValue converts values the JSON payload conversion would lose, such
+// as errors and fmt.Stringers, into strings.
+func payloadValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case time.Duration:
+		return t.String()
+	case error:
+		return t.Error()
+	case fmt.Stringer:
+		return t.String()
+	default:
+		return v
+	}
+}
+
+// sourceLocation is the structured JSON form of the LogEntry source location.
+type sourceLocation struct {
+	File     string `json:"file"`
+	Line     string `json:"line"`
+	Function string `json:"function,omitempty"`
+}
+
+// JSON returns a function.EmitErr writing structured JSON log lines to w,
+// typically os.Stdout, as recognized by the logging agents of GKE, Cloud Run
+// and Cloud Functions. The severity, trace, span, labels and source location
+// are written using their special fields, followed by the time, message,
+// error and remaining key-value pairs.
+func JSON(w io.Writer, opts ...Option) function.EmitErr {
+	o := newOptions(opts)
+	enc := emitter.JSON(w,
+		emitter.WithLevelKey(""),
+		emitter.WithMessageKey("message"),
+		emitter.WithCallerKey(""),
+	)
+	return func(level telemetry.Level, msg string, err error, values function.Values, callerSkip int) error {
+		e := o.split(values)
+
+		special := []interface{}{"severity", strings.ToUpper(ToSeverity(level).String())}
+		if e.trace != "" {
+			special = append(special, TraceField, e.trace)
+		}
+		if e.spanID != "" {
+			special = append(special, SpanField, e.spanID)
+		}
+		if e.labels != nil {
+			special = append(special, LabelsField, e.labels)
+		}
+		if frame, ok := values.Caller.Resolve(); ok {
+			special = append(special, SourceLocationField, sourceLocation{
+				File:     frame.File,
+				Line:     fmt.Sprint(frame.Line),
+				Function: path.Base(frame.Function),
+			})
+		}
+
+		return enc(level, msg, err, function.Values{
+			FromContext: special,
+			FromMethod:  e.keyValues,
+		}, callerSkip)
+	}
+}
+
+// ToSeverity maps a telemetry.Level onto the corresponding Cloud Logging
+// severity.
+func ToSeverity(level telemetry.Level) logging.Severity {
+	switch {
+	case level <= telemetry.LevelNone:
+		return logging.Default
+	case level <= telemetry.LevelError:
+		return logging.Error
+	case level <= telemetry.LevelWarn:
+		return logging.Warning
+	case level <= telemetry.LevelInfo:
+		return logging.Info
+	default:
+		return logging.Debug
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcplog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/logging"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type mockLogger struct {
+	entries []logging.Entry
+}
+
+func (m *mockLogger) Log(e logging.Entry) { m.entries = append(m.entries, e) }
+
+func TestEmit(t *testing.T) {
+	var (
+		m      = &mockLogger{}
+		logger = function.NewLoggerErr(Emit(m, WithProjectID("proj"), WithLabelKeys("tenant")), 0, function.WithCaller())
+		ctx    = telemetry.KeyValuesToContext(context.Background(), "trace_id", "abc", "span_id", "def")
+	)
+
+	logger.Context(ctx).With("tenant", "acme").Error("failed", errors.New("boom"), "attempt", 2, "dangling")
+
+	if len(m.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(m.entries))
+	}
+	e := m.entries[0]
+	if e.Severity != logging.Error {
+		t.Errorf("expected %v to match %v", e.Severity, logging.Error)
+	}
+	if e.Trace != "projects/proj/traces/abc" || e.SpanID != "def" {
+		t.Errorf("unexpected trace %q and span %q", e.Trace, e.SpanID)
+	}
+	if expected := map[string]string{"tenant": "acme"}; !reflect.DeepEqual(e.Labels, expected) {
+		t.Errorf("expected %v to match %v", e.Labels, expected)
+	}
+	expected := map[string]interface{}{"message": "failed", "error": "boom", "attempt": 2, "dangling": "(MISSING)"}
+	if !reflect.DeepEqual(e.Payload, expected) {
+		t.Errorf("expected %v to match %v", e.Payload, expected)
+	}
+	if e.SourceLocation == nil || !strings.HasSuffix(e.SourceLocation.File, "gcplog_test.go") ||
+		e.SourceLocation.Function != "gcplog.TestEmit" {
+		t.Errorf("unexpected source location %v", e.SourceLocation)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var (
+		out    bytes.Buffer
+		logger = function.NewLoggerErr(JSON(&out, WithLabelKeys("tenant")), 0, function.WithCaller())
+	)
+
+	logger.With("tenant", "acme", "trace_id", "projects/p/traces/abc").Warn("slow", "ms", 300)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loc, _ := line[SourceLocationField].(map[string]interface{})
+	if loc == nil || !strings.HasSuffix(loc["file"].(string), "gcplog_test.go") {
+		t.Errorf("unexpected source location %v", line[SourceLocationField])
+	}
+	delete(line, SourceLocationField)
+	delete(line, "time")
+
+	expected := map[string]interface{}{
+		"severity":  "WARNING",
+		"message":   "slow",
+		TraceField:  "projects/p/traces/abc",
+		LabelsField: map[string]interface{}{"tenant": "acme"},
+		"ms":        float64(300),
+	}
+	if !reflect.DeepEqual(line, expected) {
+		t.Fatalf("expected %v to match %v", line, expected)
+	}
+	if !strings.HasPrefix(out.String(), `{"time":`) {
+		t.Fatalf("expected time field first, got %s", out.String())
+	}
+}
+
+func TestToSeverity(t *testing.T) {
+	tests := []struct {
+		level    telemetry.Level
+		expected logging.Severity
+	}{
+		{telemetry.LevelNone, logging.Default},
+		{telemetry.LevelError, logging.Error},
+		{telemetry.LevelWarn, logging.Warning},
+		{telemetry.LevelInfo, logging.Info},
+		{telemetry.LevelDebug, logging.Debug},
+		{telemetry.LevelTrace, logging.Debug},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			if have := ToSeverity(tt.level); have != tt.expected {
+				t.Fatalf("expected %s to match %s", have, tt.expected)
+			}
+		})
+	}
+}
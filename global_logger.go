@@ -0,0 +1,102 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"sync/atomic"
+)
+
+// callerSkipper is implemented by Loggers capturing the call site, like the
+// function Logger, allowing the package level logging functions to report the
+// code calling them.
+type callerSkipper interface {
+	WithCallerSkip(skip int) Logger
+}
+
+// globalLoggers holds the global Logger and the Logger used by the package
+// level logging functions.
+type globalLoggers struct {
+	logger  Logger
+	wrapped Logger
+}
+
+var globalLogger atomic.Value
+
+// SetGlobalLogger atomically replaces the global Logger used by the package
+// level logging functions. Passing nil restores the default NoopLogger.
+func SetGlobalLogger(l Logger) {
+	if l == nil {
+		l = NoopLogger()
+	}
+	g := globalLoggers{logger: l, wrapped: l}
+	if cs, ok := l.(callerSkipper); ok {
+		g.wrapped = cs.WithCallerSkip(1)
+	}
+	globalLogger.Store(g)
+}
+
+// GlobalLogger returns the global Logger, which is a NoopLogger unless set
+// using SetGlobalLogger.
+func GlobalLogger() Logger {
+	return global().logger
+}
+
+func global() globalLoggers {
+	if g, ok := globalLogger.Load().(globalLoggers); ok {
+		return g
+	}
+	return globalLoggers{logger: noop, wrapped: noop}
+}
+
+// noop is the global Logger used until SetGlobalLogger is called.
+var noop = NoopLogger()
+
+// Trace logs through the global Logger at trace level.
+func Trace(msg string, keyValuePairs ...interface{}) {
+	global().wrapped.Trace(msg, keyValuePairs...)
+}
+
+// Debug logs through the global Logger at debug level.
+func Debug(msg string, keyValuePairs ...interface{}) {
+	global().wrapped.Debug(msg, keyValuePairs...)
+}
+
+// Info logs through the global Logger at info level.
+func Info(msg string, keyValuePairs ...interface{}) {
+	global().wrapped.Info(msg, keyValuePairs...)
+}
+
+// Warn logs through the global Logger at warn level.
+func Warn(msg string, keyValuePairs ...interface{}) {
+	global().wrapped.Warn(msg, keyValuePairs...)
+}
+
+// Error logs through the global Logger at error level.
+func Error(msg string, err error, keyValuePairs ...interface{}) {
+	global().wrapped.Error(msg, err, keyValuePairs...)
+}
+
+// Fatal logs through the global Logger at error level, after which the
+// process is terminated.
+func Fatal(msg string, err error, keyValuePairs ...interface{}) {
+	global().wrapped.Fatal(msg, err, keyValuePairs...)
+}
+
+// With returns a Logger derived from the current global Logger, decorated
+// with the provided key-value pairs. The returned Logger is not affected by
+// later calls to SetGlobalLogger.
+func With(keyValuePairs ...interface{}) Logger {
+	return global().logger.With(keyValuePairs...)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestGlobalLogger(t *testing.T) {
+	t.Cleanup(func() { telemetry.SetGlobalLogger(nil) })
+
+	// the package level functions are safe to use without a global Logger.
+	telemetry.Info("dropped")
+	telemetry.With("key", "value").Info("dropped")
+
+	var out bytes.Buffer
+	logger := function.NewLogger(func(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+		frame, _ := values.Caller.Resolve()
+		_, _ = fmt.Fprintf(&out, "%v %s %v %v %s:%d\n", level, msg, err, values.FromLogger,
+			filepath.Base(frame.File), frame.Line)
+	}, 0, function.WithCaller())
+	telemetry.SetGlobalLogger(logger)
+	telemetry.GlobalLogger().SetLevel(telemetry.LevelDebug)
+
+	_, _, line, _ := runtime.Caller(0)
+	telemetry.Debug("debug")
+	telemetry.With("key", "value").Info("with")
+	telemetry.Trace("trace")
+
+	want := fmt.Sprintf("debug debug <nil> [] global_logger_test.go:%d\n", line+1) +
+		fmt.Sprintf("info with <nil> [key value] global_logger_test.go:%d\n", line+2)
+	if out.String() != want {
+		t.Fatalf("want: %s\nhave: %s", want, out.String())
+	}
+	if telemetry.GlobalLogger() != logger {
+		t.Fatal("expected the global Logger to be returned")
+	}
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	t.Cleanup(func() { telemetry.SetGlobalLogger(nil) })
+
+	ctx := context.Background()
+	if l := telemetry.LoggerFromContext(ctx); l != telemetry.GlobalLogger() {
+		t.Fatalf("expected the global Logger, have %v", l)
+	}
+
+	global := telemetry.NoopLogger()
+	telemetry.SetGlobalLogger(global)
+	if l := telemetry.LoggerFromContext(ctx); l != global {
+		t.Fatalf("expected the global Logger, have %v", l)
+	}
+
+	logger := telemetry.NoopLogger().With("request_id", 42)
+	ctx = telemetry.WithLogger(ctx, logger)
+	if l := telemetry.LoggerFromContext(context.WithValue(ctx, struct{}{}, "value")); l != logger {
+		t.Fatalf("expected the Context Logger, have %v", l)
+	}
+	if l := telemetry.LoggerFromContext(telemetry.WithLogger(ctx, nil)); l != global {
+		t.Fatalf("expected the global Logger, have %v", l)
+	}
+}
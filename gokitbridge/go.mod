@@ -0,0 +1,14 @@
+module github.com/basvanbeek/telemetry/gokitbridge
+
+go 1.21
+
+require (
+	github.com/basvanbeek/telemetry v0.2.0
+	github.com/go-kit/log v0.2.1
+)
+
+require github.com/go-logfmt/logfmt v0.5.1 // indirect
+
+// Work around for maintaining multiple go modules in the same repository
+// until go has better support for this. https://github.com/golang/go/issues/45713
+replace github.com/basvanbeek/telemetry => ../
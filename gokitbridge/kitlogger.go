@@ -0,0 +1,123 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gokitbridge
+
+import (
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// compile time check for compatibility with the go-kit log.Logger interface.
+var _ log.Logger = (*kitLogger)(nil)
+
+type kitLogger struct {
+	logger telemetry.Logger
+}
+
+// NewKitLogger returns a go-kit log.Logger forwarding into the provided
+// telemetry.Logger, allowing services using go-kit middleware to migrate
+// incrementally.
+//
+// The level of each log line is taken from the go-kit level key-value pair,
+// holding either a level.Value or a string accepted by telemetry.ParseLevel.
+// Log lines without a recognized level are logged at Info level. The value of
+// the MessageKey key-value pair is used as the message. For error level log
+// lines, an error found under ErrorKey is passed as the error argument of
+// Error. All other key-value pairs are passed as is, with a missing value set
+// to "(MISSING)".
+func NewKitLogger(l telemetry.Logger) log.Logger {
+	return &kitLogger{logger: l}
+}
+
+// Log implements log.Logger.
+func (k *kitLogger) Log(keyvals ...interface{}) error {
+	var (
+		lvl = telemetry.LevelInfo
+		msg string
+		kvs = make([]interface{}, 0, len(keyvals))
+	)
+	for i := 0; i < len(keyvals); i += 2 {
+		var v interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		switch keyvals[i] {
+		case level.Key():
+			if l, ok := parseLevel(v); ok {
+				lvl = l
+				continue
+			}
+		case MessageKey:
+			if s, ok := v.(string); ok && msg == "" {
+				msg = s
+				continue
+			}
+		}
+		kvs = append(kvs, keyvals[i], v)
+	}
+
+	switch lvl {
+	case telemetry.LevelError:
+		kvs, err := extractError(kvs)
+		k.logger.Error(msg, err, kvs...)
+	case telemetry.LevelWarn:
+		k.logger.Warn(msg, kvs...)
+	case telemetry.LevelInfo:
+		k.logger.Info(msg, kvs...)
+	case telemetry.LevelDebug:
+		k.logger.Debug(msg, kvs...)
+	default:
+		k.logger.Trace(msg, kvs...)
+	}
+	return nil
+}
+
+// parseLevel returns the telemetry.Level for a go-kit level value.
+func parseLevel(v interface{}) (telemetry.Level, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return 0, false
+	}
+	lvl, err := telemetry.ParseLevel(s)
+	if err != nil || lvl == telemetry.LevelNone {
+		return 0, false
+	}
+	return lvl, true
+}
+
+// extractError removes the first ErrorKey key-value pair holding an error value
+// and returns it.
+func extractError(keyvals []interface{}) ([]interface{}, error) {
+	for i := 0; i < len(keyvals); i += 2 {
+		if keyvals[i] != ErrorKey {
+			continue
+		}
+		if err, ok := keyvals[i+1].(error); ok {
+			kvs := make([]interface{}, 0, len(keyvals)-2)
+			kvs = append(kvs, keyvals[:i]...)
+			return append(kvs, keyvals[i+2:]...), err
+		}
+	}
+	return keyvals, nil
+}
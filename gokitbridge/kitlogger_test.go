@@ -0,0 +1,72 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gokitbridge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestKitLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := function.NewLogger(func(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+		_, _ = fmt.Fprintf(&out, "level=%v msg=%q", level, msg)
+		if err != nil {
+			_, _ = fmt.Fprintf(&out, " err=%v", err)
+		}
+		all := append(values.FromLogger, values.FromMethod...)
+		_, _ = fmt.Fprintf(&out, " %v", all)
+	}, 0)
+	logger.SetLevel(telemetry.LevelDebug)
+
+	l := log.With(NewKitLogger(logger), "component", "lib")
+	failed := errors.New("failed")
+
+	tests := []struct {
+		name     string
+		logfunc  func()
+		expected string
+	}{
+		{"no-level", func() { _ = l.Log("msg", "text", "id", 1) },
+			`level=info msg="text" [component lib id 1]`},
+		{"debug", func() { _ = level.Debug(l).Log("msg", "text") },
+			`level=debug msg="text" [component lib]`},
+		{"trace", func() { _ = l.Log("level", "trace", "msg", "text") }, ""},
+		{"warn", func() { _ = level.Warn(l).Log("msg", "text", "err", failed) },
+			`level=warn msg="text" [component lib err failed]`},
+		{"error", func() { _ = level.Error(l).Log("msg", "text", "err", failed, "dangling") },
+			`level=error msg="text" err=failed [component lib dangling (MISSING)]`},
+		{"unknown-level", func() { _ = l.Log("level", "fatal", "msg", "text") },
+			`level=info msg="text" [component lib level fatal]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			tt.logfunc()
+			if out.String() != tt.expected {
+				t.Fatalf("expected %s to match %s", out.String(), tt.expected)
+			}
+		})
+	}
+}
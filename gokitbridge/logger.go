@@ -0,0 +1,92 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gokitbridge provides a telemetry.Logger implementation emitting
+// through a go-kit log.Logger, and a go-kit log.Logger forwarding into a
+// telemetry.Logger.
+package gokitbridge
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Keys used for the message, error and caller of a log line, following the
+// go-kit conventions.
+const (
+	MessageKey = "msg"
+	ErrorKey   = "err"
+	CallerKey  = "caller"
+)
+
+// NewLogger returns a telemetry.Logger emitting through the provided go-kit
+// log.Logger. Each log line starts with the go-kit level key-value pair,
+// followed by the MessageKey, ErrorKey and CallerKey key-value pairs and the
+// key-value pairs found in Context, added to the Logger and passed to the
+// logging method, in that order. As go-kit has no trace level, Trace log lines
+// are logged at debug level.
+//
+// The returned Logger is a function Logger and supports the same options.
+// Its level is initialized at telemetry.LevelInfo. Level filtering done by the
+// go-kit log.Logger, e.g. using level.NewFilter, still applies.
+func NewLogger(l log.Logger, opts ...function.Option) telemetry.Logger {
+	opts = append([]function.Option{function.WithCaller()}, opts...)
+	return function.NewLogger(Emit(l), 0, opts...)
+}
+
+// Emit returns a function.Emit writing log lines to the provided go-kit
+// log.Logger. The CallerKey key-value pair is taken from Values.Caller, which
+// is only populated if the function Logger is created with the
+// function.WithCaller option, and formatted like log.DefaultCaller.
+func Emit(l log.Logger) function.Emit {
+	return func(lvl telemetry.Level, msg string, err error, values function.Values, _ int) {
+		n := len(values.FromContext) + len(values.FromLogger) + len(values.FromMethod)
+		kvs := make([]interface{}, 0, n+9)
+		kvs = append(kvs, level.Key(), ToKitLevel(lvl), MessageKey, msg)
+		if err != nil {
+			kvs = append(kvs, ErrorKey, err)
+		}
+		if frame, ok := values.Caller.Resolve(); ok {
+			kvs = append(kvs, CallerKey, filepath.Base(frame.File)+":"+strconv.Itoa(frame.Line))
+		}
+		kvs = append(kvs, values.FromContext...)
+		kvs = append(kvs, values.FromLogger...)
+		kvs = append(kvs, values.FromMethod...)
+		if len(kvs)%2 != 0 {
+			kvs = append(kvs, "(MISSING)")
+		}
+
+		_ = l.Log(kvs...)
+	}
+}
+
+// ToKitLevel maps a telemetry.Level onto the corresponding go-kit level.Value.
+func ToKitLevel(lvl telemetry.Level) level.Value {
+	switch {
+	case lvl <= telemetry.LevelError:
+		return level.ErrorValue()
+	case lvl <= telemetry.LevelWarn:
+		return level.WarnValue()
+	case lvl <= telemetry.LevelInfo:
+		return level.InfoValue()
+	default:
+		return level.DebugValue()
+	}
+}
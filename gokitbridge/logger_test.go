@@ -0,0 +1,77 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gokitbridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    telemetry.Level
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"disabled", telemetry.LevelInfo, func(l telemetry.Logger) { l.Debug("text") }, ""},
+		{"trace", telemetry.LevelTrace, func(l telemetry.Logger) { l.Trace("text", "where", "there") },
+			`level=debug msg=text caller=logger_test.go:NN ctx=value lvl=info missing=(MISSING) where=there` + "\n"},
+		{"debug", telemetry.LevelDebug, func(l telemetry.Logger) { l.Debug("text", 1, "1") },
+			`level=debug msg=text caller=logger_test.go:NN ctx=value lvl=info missing=(MISSING) 1=1` + "\n"},
+		{"info", telemetry.LevelInfo, func(l telemetry.Logger) { l.Info("text") },
+			`level=info msg=text caller=logger_test.go:NN ctx=value lvl=info missing=(MISSING)` + "\n"},
+		{"warn", telemetry.LevelInfo, func(l telemetry.Logger) { l.Warn("text", "dangling") },
+			`level=warn msg=text caller=logger_test.go:NN ctx=value lvl=info missing=(MISSING) dangling=(MISSING)` + "\n"},
+		{"error", telemetry.LevelInfo, func(l telemetry.Logger) { l.Error("text", errors.New("failed")) },
+			`level=error msg=text err=failed caller=logger_test.go:NN ctx=value lvl=info missing=(MISSING)` + "\n"},
+	}
+
+	line := regexp.MustCompile(`:[0-9]+ `)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := NewLogger(log.NewLogfmtLogger(&out))
+			logger.SetLevel(tt.level)
+
+			ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value")
+			l := logger.Context(ctx).With("lvl", telemetry.LevelInfo).With("missing")
+
+			tt.logfunc(l)
+
+			if have := line.ReplaceAllString(out.String(), ":NN "); have != tt.expected {
+				t.Fatalf("expected %q to match %q", have, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKitLevelFilter(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewLogger(level.NewFilter(log.NewLogfmtLogger(&out), level.AllowWarn()))
+
+	logger.Info("text")
+	if out.Len() != 0 {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
@@ -17,3 +17,8 @@ require (
 // Work around for maintaining multiple go modules in the same repository
 // until go has better support for this. https://github.com/golang/go/issues/45713
 replace github.com/basvanbeek/telemetry => ../
+
+// run v0.1.1 does not compile against the current telemetry.Logger interface,
+// which added the Trace, Warn and Fatal methods. Use a copy of run v0.1.1 with
+// its fallback Logger completed until an upstream release catches up.
+replace github.com/basvanbeek/run => ./third_party/run
@@ -69,7 +69,7 @@ func (s *service) FlagSet() *run.FlagSet {
 			"where scope can be one of [%s] and default_level or level can be "+
 			"one of [%s]",
 		strings.Join(scope.Names(), ", "),
-		strings.Join([]string{"debug", "info", "error", "none"}, ", "),
+		strings.Join([]string{"trace", "debug", "info", "warn", "error", "none"}, ", "),
 	))
 
 	return fs
@@ -0,0 +1,12 @@
+module github.com/basvanbeek/run
+
+go 1.17
+
+require (
+	github.com/basvanbeek/multierror v0.1.0
+	github.com/basvanbeek/telemetry v0.1.0
+	github.com/logrusorgru/aurora v2.0.3+incompatible
+	github.com/spf13/pflag v1.0.5
+)
+
+require github.com/hashicorp/errwrap v1.1.0 // indirect
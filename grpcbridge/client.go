@@ -0,0 +1,130 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcbridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor instrumenting
+// outbound unary RPCs. See StreamClientInterceptor for details.
+func UnaryClientInterceptor(l telemetry.Logger, opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(DefaultClientMessage, DefaultClientLevel, opts)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if o.filter != nil && !o.filter(method) {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		start := time.Now()
+		ctx = o.clientContext(ctx)
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		o.done(l, rpcContext(ctx, method, cc), status.Code(err), err, start)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a grpc.StreamClientInterceptor instrumenting
+// outbound streaming RPCs.
+//
+// On completion, the RPC is logged with its method, target, status code and
+// duration, together with the key-value pairs found in the RPC Context, at
+// the level returned by the level function, and the configured metrics are
+// recorded. Streaming RPCs complete once receiving a message fails, which
+// includes the io.EOF marking successful completion. The key-value pairs
+// selected using WithPropagatedKeys are sent as outgoing metadata.
+func StreamClientInterceptor(l telemetry.Logger, opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(DefaultClientMessage, DefaultClientLevel, opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if o.filter != nil && !o.filter(method) {
+			return streamer(ctx, desc, cc, method, callOpts...)
+		}
+
+		start := time.Now()
+		ctx = o.clientContext(ctx)
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			o.done(l, rpcContext(ctx, method, cc), status.Code(err), err, start)
+			return nil, err
+		}
+		return &clientStream{ClientStream: cs, done: func(err error) {
+			o.done(l, rpcContext(ctx, method, cc), status.Code(err), err, start)
+		}}, nil
+	}
+}
+
+// clientContext returns the Context with the propagated key-value pairs added
+// as outgoing metadata.
+func (o options) clientContext(ctx context.Context) context.Context {
+	if len(o.propagate) == 0 {
+		return ctx
+	}
+	keyValues := telemetry.ResolveKeyValuesFromContext(ctx)
+	var pairs []string
+	for _, key := range o.propagate {
+		// the last occurrence of a key holds its current value.
+		for i := len(keyValues) - 2; i >= 0; i -= 2 {
+			if k, ok := keyValues[i].(string); ok && k == key {
+				pairs = append(pairs, key, fmt.Sprint(keyValues[i+1]))
+				break
+			}
+		}
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// clientKeyValues returns the Context holding the RPC key-value pairs.
+func rpcContext(ctx context.Context, method string, cc *grpc.ClientConn) context.Context {
+	keyValues := []interface{}{MethodKey, method}
+	if cc != nil {
+		keyValues = append(keyValues, TargetKey, cc.Target())
+	}
+	return telemetry.KeyValuesToContext(ctx, keyValues...)
+}
+
+// clientStream reports the completion of a grpc.ClientStream.
+type clientStream struct {
+	grpc.ClientStream
+	once sync.Once
+	done func(err error)
+}
+
+// RecvMsg implements grpc.ClientStream.
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() {
+			if errors.Is(err, io.EOF) {
+				s.done(nil)
+				return
+			}
+			s.done(err)
+		})
+	}
+	return err
+}
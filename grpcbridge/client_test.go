@@ -0,0 +1,118 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcbridge
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	var (
+		lines  []line
+		metric mockMetric
+		md     metadata.MD
+	)
+	interceptor := UnaryClientInterceptor(capture(&lines), WithLatencyMetric(&metric),
+		WithPropagatedKeys("tenant", "missing"))
+
+	ctx := telemetry.KeyValuesToContext(context.Background(), "tenant", "acme", "user", 42)
+	err := interceptor(ctx, "/pkg.Service/Method", nil, nil, nil,
+		func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return status.Error(codes.Unavailable, "down")
+		})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := (metadata.MD{"tenant": {"acme"}}); !reflect.DeepEqual(want, md) {
+		t.Fatalf("want: %v\nhave: %v", want, md)
+	}
+	want := []line{{telemetry.LevelWarn, DefaultClientMessage, []interface{}{
+		"tenant", "acme", "user", 42, MethodKey, "/pkg.Service/Method",
+		CodeKey, "Unavailable", DurationKey, nil, "error", "rpc error: code = Unavailable desc = down",
+	}}}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+	wantMetric := [][]interface{}{{"tenant", "acme", "user", 42, MethodKey, "/pkg.Service/Method", CodeKey, "Unavailable"}}
+	if !reflect.DeepEqual(wantMetric, metric.contexts) {
+		t.Fatalf("want: %v\nhave: %v", wantMetric, metric.contexts)
+	}
+
+	// the server interceptors pick up the propagated key-value pairs.
+	var have []interface{}
+	_, _ = UnaryServerInterceptor(capture(&lines), WithPropagatedKeys("tenant"))(
+		metadata.NewIncomingContext(context.Background(), md), nil,
+		&grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Method"},
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			have = telemetry.KeyValuesFromContext(ctx)
+			return nil, nil
+		})
+	if want := []interface{}{"tenant", "acme", MethodKey, "/pkg.Service/Method"}; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+type mockClientStream struct {
+	grpc.ClientStream
+	msgs int
+}
+
+func (m *mockClientStream) RecvMsg(interface{}) error {
+	if m.msgs == 0 {
+		return io.EOF
+	}
+	m.msgs--
+	return nil
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	var lines []line
+	logger := capture(&lines)
+	logger.SetLevel(telemetry.LevelDebug)
+	interceptor := StreamClientInterceptor(logger)
+
+	cs, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/pkg.Service/Stream",
+		func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+			return &mockClientStream{msgs: 2}, nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for cs.RecvMsg(nil) == nil {
+		if len(lines) != 0 {
+			t.Fatal("expected stream completion to be logged after the last message")
+		}
+	}
+	_ = cs.RecvMsg(nil)
+
+	want := []line{{telemetry.LevelDebug, DefaultClientMessage, []interface{}{
+		MethodKey, "/pkg.Service/Stream", CodeKey, "OK", DurationKey, nil,
+	}}}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcbridge
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// Keys of the key-value pairs describing an RPC.
+const (
+	MethodKey   = "method"
+	PeerKey     = "peer"
+	DeadlineKey = "deadline"
+	CodeKey     = "code"
+	DurationKey = "duration"
+	PanicKey    = "panic"
+	StackKey    = "stack"
+	TargetKey   = "target"
+)
+
+// Default messages of the RPC log lines.
+const (
+	DefaultServerMessage = "grpc request"
+	DefaultClientMessage = "grpc client request"
+)
+
+type (
+	// Option implements a functional option type for the interceptors.
+	Option func(*options)
+
+	// options holds the configuration of the interceptors.
+	options struct {
+		message        string
+		levelFunc      func(codes.Code) telemetry.Level
+		requestsMetric telemetry.Metric
+		latencyMetric  telemetry.Metric
+		filter         func(fullMethod string) bool
+		propagate      []string
+	}
+)
+
+// WithMessage sets the message of the RPC log lines. It defaults to
+// DefaultServerMessage for the server and DefaultClientMessage for the client
+// interceptors.
+func WithMessage(msg string) Option {
+	return func(o *options) {
+		o.message = msg
+	}
+}
+
+// WithLevelFunc sets the function returning the level at which an RPC is
+// logged based on its status code. It defaults to DefaultServerLevel for the
+// server and DefaultClientLevel for the client interceptors. Return
+// telemetry.LevelNone to not log the RPC.
+func WithLevelFunc(fn func(codes.Code) telemetry.Level) Option {
+	return func(o *options) {
+		o.levelFunc = fn
+	}
+}
+
+// WithRequestsMetric sets the Metric, typically a Sum, incremented for each
+// completed RPC. It is recorded using the RPC Context holding the RPC
+// key-value pairs, so labels named after the MethodKey and CodeKey constants
+// are set to the corresponding values.
+func WithRequestsMetric(m telemetry.Metric) Option {
+	return func(o *options) {
+		o.requestsMetric = m
+	}
+}
+
+// WithLatencyMetric sets the Metric, typically a Distribution, on which the RPC
+// duration is recorded in seconds. Labels are handled as with
+// WithRequestsMetric.
+func WithLatencyMetric(m telemetry.Metric) Option {
+	return func(o *options) {
+		o.latencyMetric = m
+	}
+}
+
+// WithFilter sets a function deciding if an RPC is instrumented based on its
+// full method name, e.g. to exclude health checks.
+func WithFilter(fn func(fullMethod string) bool) Option {
+	return func(o *options) {
+		o.filter = fn
+	}
+}
+
+// WithPropagatedKeys sets the keys of the Context key-value pairs propagated
+// across RPCs. The client interceptors add the key-value pairs found in the
+// RPC Context as outgoing metadata, while the server interceptors add the
+// received metadata as key-value pairs to the RPC Context. Keys must be valid
+// metadata keys, i.e. lower case. Values are propagated in their string
+// representation, e.g.:
+//
+//	opts := grpcbridge.WithPropagatedKeys(correlation.DefaultKey, "tenant")
+func WithPropagatedKeys(keys ...string) Option {
+	return func(o *options) {
+		o.propagate = append(o.propagate[:len(o.propagate):len(o.propagate)], keys...)
+	}
+}
+
+// newOptions returns the configuration with the provided options applied.
+func newOptions(message string, levelFunc func(codes.Code) telemetry.Level, opts []Option) options {
+	o := options{message: message, levelFunc: levelFunc}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// DefaultServerLevel returns the level at which the server interceptors log an
+// RPC. Codes caused by the client or by normal operation map to
+// telemetry.LevelInfo, codes indicating a degraded service map to
+// telemetry.LevelWarn and codes indicating a server fault map to
+// telemetry.LevelError.
+func DefaultServerLevel(code codes.Code) telemetry.Level {
+	switch code {
+	case codes.DeadlineExceeded, codes.ResourceExhausted, codes.Unavailable,
+		codes.Aborted, codes.FailedPrecondition, codes.OutOfRange:
+		return telemetry.LevelWarn
+	case codes.Unknown, codes.Unimplemented, codes.Internal, codes.DataLoss:
+		return telemetry.LevelError
+	default:
+		return telemetry.LevelInfo
+	}
+}
+
+// DefaultClientLevel returns the level at which the client interceptors log an
+// RPC. Successful RPCs map to telemetry.LevelDebug, other codes are mapped as
+// by DefaultServerLevel.
+func DefaultClientLevel(code codes.Code) telemetry.Level {
+	if code == codes.OK {
+		return telemetry.LevelDebug
+	}
+	return DefaultServerLevel(code)
+}
+
+// done records the metrics and logs the completion of an RPC.
+func (o options) done(l telemetry.Logger, ctx context.Context, code codes.Code, err error, start time.Time, keyValues ...interface{}) {
+	duration := time.Since(start)
+	mctx := telemetry.KeyValuesToContext(ctx, CodeKey, code.String())
+	if o.requestsMetric != nil {
+		o.requestsMetric.RecordContext(mctx, 1)
+	}
+	if o.latencyMetric != nil {
+		o.latencyMetric.RecordContext(mctx, duration.Seconds())
+	}
+
+	l = l.Context(ctx)
+	keyValues = append([]interface{}{CodeKey, code.String(), DurationKey, duration}, keyValues...)
+	switch level := o.levelFunc(code); level {
+	case telemetry.LevelNone:
+	case telemetry.LevelError:
+		l.Error(o.message, err, keyValues...)
+	case telemetry.LevelWarn:
+		l.Warn(o.message, append(keyValues, errorKeyValue(err)...)...)
+	case telemetry.LevelInfo:
+		l.Info(o.message, append(keyValues, errorKeyValue(err)...)...)
+	case telemetry.LevelDebug:
+		l.Debug(o.message, append(keyValues, errorKeyValue(err)...)...)
+	default:
+		l.Trace(o.message, append(keyValues, errorKeyValue(err)...)...)
+	}
+}
+
+// errorKeyValue returns the error as key-value pair for log levels without
+// error argument.
+func errorKeyValue(err error) []interface{} {
+	if err == nil {
+		return nil
+	}
+	return []interface{}{"error", err.Error()}
+}
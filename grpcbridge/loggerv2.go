@@ -0,0 +1,177 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpcbridge integrates gRPC with this package, e.g. routing the gRPC
+// internal logs into a telemetry.Logger and instrumenting RPCs using
+// interceptors.
+package grpcbridge
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/grpclog"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// SystemKey is the key added to all log lines emitted by the gRPC logger,
+// having "grpc" as value.
+const SystemKey = "system"
+
+// depthOffset holds the number of frames between the code logging through
+// grpclog and the LoggerV2 methods: the grpclog function and its LoggerV2 or
+// DepthLoggerV2 method.
+const depthOffset = 2
+
+// compile time check for compatibility with the grpclog interfaces.
+var _ grpclog.DepthLoggerV2 = (*loggerV2)(nil)
+
+// callerSkip is implemented by Loggers supporting caller skip adjustments,
+// like scope.CallerSkip.
+type callerSkip interface {
+	CSIncrease()
+	CSDecrease()
+}
+
+type loggerV2 struct {
+	logger telemetry.Logger
+	// depths caches the Loggers with adjusted caller skip per call depth.
+	depths sync.Map
+}
+
+// NewLoggerV2 returns a grpclog.LoggerV2, also implementing
+// grpclog.DepthLoggerV2, forwarding the gRPC internal logs into the provided
+// telemetry.Logger. Install it with grpclog.SetLoggerV2 before calling any
+// gRPC functions.
+//
+// The gRPC Info, Warning, Error and Fatal severities map onto the Info, Warn,
+// Error and Fatal methods. Verbosity checks done by gRPC using V are mapped
+// onto the level of the telemetry.Logger using telemetry.VerbosityToLevel.
+// Each log line has the SystemKey key-value pair added. If the
+// telemetry.Logger supports caller skip adjustments, like the function Logger
+// does, the reported caller is the gRPC code calling grpclog.
+func NewLoggerV2(l telemetry.Logger) grpclog.LoggerV2 {
+	return &loggerV2{logger: l}
+}
+
+// Info implements grpclog.LoggerV2.
+func (g *loggerV2) Info(args ...interface{}) {
+	g.at(0).Info(fmt.Sprint(args...))
+}
+
+// Infoln implements grpclog.LoggerV2.
+func (g *loggerV2) Infoln(args ...interface{}) {
+	g.at(0).Info(sprintln(args))
+}
+
+// Infof implements grpclog.LoggerV2.
+func (g *loggerV2) Infof(format string, args ...interface{}) {
+	g.at(0).Info(fmt.Sprintf(format, args...))
+}
+
+// Warning implements grpclog.LoggerV2.
+func (g *loggerV2) Warning(args ...interface{}) {
+	g.at(0).Warn(fmt.Sprint(args...))
+}
+
+// Warningln implements grpclog.LoggerV2.
+func (g *loggerV2) Warningln(args ...interface{}) {
+	g.at(0).Warn(sprintln(args))
+}
+
+// Warningf implements grpclog.LoggerV2.
+func (g *loggerV2) Warningf(format string, args ...interface{}) {
+	g.at(0).Warn(fmt.Sprintf(format, args...))
+}
+
+// Error implements grpclog.LoggerV2.
+func (g *loggerV2) Error(args ...interface{}) {
+	g.at(0).Error(fmt.Sprint(args...), nil)
+}
+
+// Errorln implements grpclog.LoggerV2.
+func (g *loggerV2) Errorln(args ...interface{}) {
+	g.at(0).Error(sprintln(args), nil)
+}
+
+// Errorf implements grpclog.LoggerV2.
+func (g *loggerV2) Errorf(format string, args ...interface{}) {
+	g.at(0).Error(fmt.Sprintf(format, args...), nil)
+}
+
+// Fatal implements grpclog.LoggerV2.
+func (g *loggerV2) Fatal(args ...interface{}) {
+	g.at(0).Fatal(fmt.Sprint(args...), nil)
+}
+
+// Fatalln implements grpclog.LoggerV2.
+func (g *loggerV2) Fatalln(args ...interface{}) {
+	g.at(0).Fatal(sprintln(args), nil)
+}
+
+// Fatalf implements grpclog.LoggerV2.
+func (g *loggerV2) Fatalf(format string, args ...interface{}) {
+	g.at(0).Fatal(fmt.Sprintf(format, args...), nil)
+}
+
+// V implements grpclog.LoggerV2.
+func (g *loggerV2) V(l int) bool {
+	return telemetry.VerbosityToLevel(l) <= g.logger.Level()
+}
+
+// InfoDepth implements grpclog.DepthLoggerV2.
+func (g *loggerV2) InfoDepth(depth int, args ...interface{}) {
+	g.at(depth).Info(sprintln(args))
+}
+
+// WarningDepth implements grpclog.DepthLoggerV2.
+func (g *loggerV2) WarningDepth(depth int, args ...interface{}) {
+	g.at(depth).Warn(sprintln(args))
+}
+
+// ErrorDepth implements grpclog.DepthLoggerV2.
+func (g *loggerV2) ErrorDepth(depth int, args ...interface{}) {
+	g.at(depth).Error(sprintln(args), nil)
+}
+
+// FatalDepth implements grpclog.DepthLoggerV2.
+func (g *loggerV2) FatalDepth(depth int, args ...interface{}) {
+	g.at(depth).Fatal(sprintln(args), nil)
+}
+
+// at returns the Logger reporting the caller found depth frames above the code
+// calling grpclog.
+func (g *loggerV2) at(depth int) telemetry.Logger {
+	if l, ok := g.depths.Load(depth); ok {
+		return l.(telemetry.Logger)
+	}
+	// With returns a new Logger, so we never adjust the caller skip of the
+	// Logger we were handed.
+	l := g.logger.With(SystemKey, "grpc")
+	if cs, ok := l.(callerSkip); ok {
+		for i := 0; i < depth+depthOffset; i++ {
+			cs.CSIncrease()
+		}
+	}
+	actual, _ := g.depths.LoadOrStore(depth, l)
+	return actual.(telemetry.Logger)
+}
+
+// sprintln formats the arguments in the manner of fmt.Println, without the
+// trailing newline.
+func sprintln(args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
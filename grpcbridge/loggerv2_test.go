@@ -0,0 +1,94 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcbridge
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/grpclog"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestLoggerV2(t *testing.T) {
+	var out bytes.Buffer
+	logger := function.NewLogger(func(level telemetry.Level, msg string, _ error, values function.Values, _ int) {
+		frame, _ := values.Caller.Resolve()
+		_, _ = fmt.Fprintf(&out, "level=%v msg=%q source=%s %v",
+			level, msg, filepath.Base(frame.File), values.FromLogger)
+	}, 0, function.WithCaller())
+	logger.SetLevel(telemetry.LevelDebug)
+
+	grpclog.SetLoggerV2(NewLoggerV2(logger))
+	component := grpclog.Component("core")
+
+	tests := []struct {
+		name     string
+		logfunc  func()
+		expected string
+	}{
+		{"info", func() { grpclog.Info("text ", 1) },
+			`level=info msg="text 1" source=loggerv2_test.go [system grpc]`},
+		{"infoln", func() { grpclog.Infoln("text", 1) },
+			`level=info msg="text 1" source=loggerv2_test.go [system grpc]`},
+		{"warningf", func() { grpclog.Warningf("text %d", 1) },
+			`level=warn msg="text 1" source=loggerv2_test.go [system grpc]`},
+		{"error", func() { grpclog.Error("text") },
+			`level=error msg="text" source=loggerv2_test.go [system grpc]`},
+		{"component-info", func() { component.Infof("text %d", 1) },
+			`level=info msg="[core] text 1" source=loggerv2_test.go [system grpc]`},
+		{"component-warning", func() { component.Warning("text") },
+			`level=warn msg="[core] text" source=loggerv2_test.go [system grpc]`},
+		{"component-error", func() { component.Errorf("text") },
+			`level=error msg="[core] text" source=loggerv2_test.go [system grpc]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			tt.logfunc()
+			if out.String() != tt.expected {
+				t.Fatalf("expected %s to match %s", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestV(t *testing.T) {
+	logger := function.NewLogger(func(telemetry.Level, string, error, function.Values, int) {}, 0)
+	g := NewLoggerV2(logger)
+
+	tests := []struct {
+		level     telemetry.Level
+		verbosity int
+		want      bool
+	}{
+		{telemetry.LevelInfo, 0, true},
+		{telemetry.LevelInfo, 2, false},
+		{telemetry.LevelDebug, 2, true},
+		{telemetry.LevelWarn, 0, false},
+	}
+
+	for _, tt := range tests {
+		logger.SetLevel(tt.level)
+		if have := g.V(tt.verbosity); have != tt.want {
+			t.Errorf("V(%d) at %s=%v, want %v", tt.verbosity, tt.level, have, tt.want)
+		}
+	}
+}
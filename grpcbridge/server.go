@@ -0,0 +1,121 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcbridge
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor instrumenting
+// unary RPCs. See StreamServerInterceptor for details.
+func UnaryServerInterceptor(l telemetry.Logger, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(DefaultServerMessage, DefaultServerLevel, opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		if o.filter != nil && !o.filter(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		ctx = o.serverContext(ctx, l, info.FullMethod)
+		defer func() {
+			o.serverDone(l, ctx, recover(), &err, start)
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor instrumenting
+// streaming RPCs.
+//
+// The method, peer address and deadline, if any, of the RPC are added as
+// key-value pairs to the Context handed to the handler, which also holds a
+// request scoped Logger retrievable using telemetry.LoggerFromContext, as well
+// as the key-value pairs received as metadata, see WithPropagatedKeys. On
+// completion, the RPC is logged with its status code and duration at the level
+// returned by the level function, and the configured metrics are recorded.
+// Panics in the handler are recovered, logged as an RPC with Internal status
+// code holding the panic value and stack trace, and returned as an Internal
+// status to the client.
+func StreamServerInterceptor(l telemetry.Logger, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(DefaultServerMessage, DefaultServerLevel, opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		if o.filter != nil && !o.filter(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		start := time.Now()
+		ctx := o.serverContext(ss.Context(), l, info.FullMethod)
+		defer func() {
+			o.serverDone(l, ctx, recover(), &err, start)
+		}()
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverContext returns the Context holding the RPC key-value pairs and the
+// request scoped Logger.
+func (o options) serverContext(ctx context.Context, l telemetry.Logger, method string) context.Context {
+	var keyValues []interface{}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, key := range o.propagate {
+			if values := md.Get(key); len(values) > 0 {
+				keyValues = append(keyValues, key, values[0])
+			}
+		}
+	}
+	keyValues = append(keyValues, MethodKey, method)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		keyValues = append(keyValues, PeerKey, p.Addr.String())
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		keyValues = append(keyValues, DeadlineKey, deadline)
+	}
+	ctx = telemetry.KeyValuesToContext(ctx, keyValues...)
+	return telemetry.WithLogger(ctx, l.Context(ctx))
+}
+
+// serverDone handles the completion of a server RPC, turning a recovered panic
+// into an Internal status error.
+func (o options) serverDone(l telemetry.Logger, ctx context.Context, recovered interface{}, err *error, start time.Time) {
+	if recovered != nil {
+		*err = status.Error(codes.Internal, "internal error")
+		o.done(l, ctx, codes.Internal, fmt.Errorf("panic: %v", recovered), start,
+			PanicKey, fmt.Sprint(recovered), StackKey, string(debug.Stack()))
+		return
+	}
+	o.done(l, ctx, status.Code(*err), *err, start)
+}
+
+// serverStream overrides the Context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context implements grpc.ServerStream.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
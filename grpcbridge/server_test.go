@@ -0,0 +1,154 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcbridge
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type line struct {
+	level     telemetry.Level
+	msg       string
+	keyValues []interface{}
+}
+
+// capture returns a Logger recording the emitted log lines, dropping the
+// values varying between runs.
+func capture(lines *[]line) telemetry.Logger {
+	return function.NewLogger(func(level telemetry.Level, msg string, _ error, values function.Values, _ int) {
+		kvs := append(append([]interface{}(nil), values.FromContext...), values.FromMethod...)
+		for i := 0; i+1 < len(kvs); i += 2 {
+			switch kvs[i] {
+			case DurationKey, DeadlineKey, StackKey:
+				kvs[i+1] = nil
+			}
+		}
+		*lines = append(*lines, line{level, msg, kvs})
+	}, 0)
+}
+
+type mockMetric struct {
+	telemetry.Metric
+	contexts [][]interface{}
+}
+
+func (m *mockMetric) RecordContext(ctx context.Context, _ float64) {
+	m.contexts = append(m.contexts, telemetry.KeyValuesFromContext(ctx))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var (
+		lines   []line
+		metric  mockMetric
+		scoped  []interface{}
+		logger  = capture(&lines)
+		addr    = &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 1234}
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			scoped = telemetry.KeyValuesFromContext(ctx)
+			if len(scoped) > 0 && telemetry.LoggerFromContext(ctx) == telemetry.GlobalLogger() {
+				t.Error("expected request scoped Logger")
+			}
+			switch req {
+			case "fail":
+				return nil, status.Error(codes.NotFound, "no such item")
+			case "panic":
+				panic("boom")
+			}
+			return "ok", nil
+		}
+	)
+	interceptor := UnaryServerInterceptor(logger, WithRequestsMetric(&metric),
+		WithFilter(func(method string) bool { return !strings.HasPrefix(method, "/grpc.health") }))
+
+	ctx, cancel := context.WithTimeout(peer.NewContext(context.Background(), &peer.Peer{Addr: addr}), time.Minute)
+	defer cancel()
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Method"}
+
+	if resp, err := interceptor(ctx, "ok", info, handler); resp != "ok" || err != nil {
+		t.Fatalf("unexpected result: %v, %v", resp, err)
+	}
+	if want := []interface{}{MethodKey, "/pkg.Service/Method", PeerKey, "192.0.2.1:1234"}; !reflect.DeepEqual(want, scoped[:4]) {
+		t.Fatalf("want: %v\nhave: %v", want, scoped)
+	}
+	if _, err := interceptor(ctx, "fail", info, handler); status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := interceptor(ctx, "panic", info, handler); status.Code(err) != codes.Internal {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := interceptor(ctx, "ok", &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kvs := func(code string, extra ...interface{}) []interface{} {
+		return append([]interface{}{MethodKey, "/pkg.Service/Method", PeerKey, "192.0.2.1:1234", DeadlineKey, nil,
+			CodeKey, code, DurationKey, nil}, extra...)
+	}
+	want := []line{
+		{telemetry.LevelInfo, DefaultServerMessage, kvs("OK")},
+		{telemetry.LevelInfo, DefaultServerMessage, kvs("NotFound", "error", "rpc error: code = NotFound desc = no such item")},
+		{telemetry.LevelError, DefaultServerMessage, kvs("Internal", PanicKey, "boom", StackKey, nil)},
+	}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+	if len(metric.contexts) != 3 || !reflect.DeepEqual(metric.contexts[1][6:], []interface{}{CodeKey, "NotFound"}) {
+		t.Fatalf("unexpected metric contexts: %v", metric.contexts)
+	}
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (m mockServerStream) Context() context.Context { return m.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	var lines []line
+	interceptor := StreamServerInterceptor(capture(&lines), WithMessage("stream"),
+		WithLevelFunc(func(codes.Code) telemetry.Level { return telemetry.LevelWarn }))
+
+	err := interceptor(nil, mockServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/pkg.Service/Stream"},
+		func(_ interface{}, ss grpc.ServerStream) error {
+			if method := telemetry.KeyValuesFromContext(ss.Context())[1]; method != "/pkg.Service/Stream" {
+				t.Errorf("unexpected method: %v", method)
+			}
+			return errors.New("failed")
+		})
+	if status.Code(err) != codes.Unknown {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []line{{telemetry.LevelWarn, "stream", []interface{}{MethodKey, "/pkg.Service/Stream",
+		CodeKey, "Unknown", DurationKey, nil, "error", "failed"}}}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+}
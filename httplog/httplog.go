@@ -0,0 +1,249 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httplog provides net/http instrumentation producing structured
+// access logs and latency metrics through the telemetry interfaces, both for
+// handled requests and for outbound calls.
+package httplog
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// compile time check for compatibility with the http.Flusher interface.
+var _ http.Flusher = (*responseWriter)(nil)
+
+// Keys of the key-value pairs describing a request.
+const (
+	MethodKey     = "method"
+	PathKey       = "path"
+	StatusKey     = "status"
+	BytesKey      = "bytes"
+	LatencyKey    = "latency"
+	RemoteAddrKey = "remote_addr"
+	UserAgentKey  = "user_agent"
+	HostKey       = "host"
+	RetriesKey    = "retries"
+)
+
+// Default messages of the log lines.
+const (
+	DefaultMessage       = "http request"
+	DefaultClientMessage = "http client request"
+)
+
+type (
+	// Option implements a functional option type for the Middleware and
+	// Transport.
+	Option func(*options)
+
+	// options holds the configuration of the Middleware and Transport.
+	options struct {
+		message           string
+		successLevel      telemetry.Level
+		clientLevel       telemetry.Level
+		serverLevel       telemetry.Level
+		latencyMetric     telemetry.Metric
+		filter            func(*http.Request) bool
+		attempts          int
+		backoff           time.Duration
+		correlationHeader string
+		traceContext      bool
+	}
+)
+
+// WithMessage sets the message of the log lines. It defaults to
+// DefaultMessage for the Middleware and DefaultClientMessage for the
+// Transport.
+func WithMessage(msg string) Option {
+	return func(o *options) {
+		o.message = msg
+	}
+}
+
+// WithLevels sets the levels at which requests are logged, based on their
+// response status: below 400, from 400 to 499 and from 500 up. For the
+// Middleware they default to telemetry.LevelInfo, telemetry.LevelInfo and
+// telemetry.LevelError, for the Transport to telemetry.LevelDebug,
+// telemetry.LevelInfo and telemetry.LevelError. Outbound calls failing without
+// response are logged at the serverError level. Use telemetry.LevelNone to
+// not log requests of a status class.
+func WithLevels(success, clientError, serverError telemetry.Level) Option {
+	return func(o *options) {
+		o.successLevel = success
+		o.clientLevel = clientError
+		o.serverLevel = serverError
+	}
+}
+
+// WithLatencyMetric sets the Metric, typically a Distribution, on which the
+// request latency is recorded in seconds. It is recorded using the request
+// Context holding the request key-value pairs, so labels named after the
+// MethodKey, PathKey, HostKey and StatusKey constants are set to the
+// corresponding values. The Middleware sets the method, path and status, the
+// Transport the method, host and status, with a status of 0 for calls failing
+// without response. Beware of the cardinality of the path, e.g. by wrapping
+// the Metric using the Metric method of telemetry.ContextLabels configured
+// through telemetry.LimitCardinality.
+func WithLatencyMetric(m telemetry.Metric) Option {
+	return func(o *options) {
+		o.latencyMetric = m
+	}
+}
+
+// WithFilter sets a function deciding if a request is instrumented, e.g. to
+// exclude health checks. Requests for which it returns false are passed to
+// the next Handler as is.
+func WithFilter(fn func(*http.Request) bool) Option {
+	return func(o *options) {
+		o.filter = fn
+	}
+}
+
+// Middleware returns HTTP middleware logging an access log line through the
+// provided Logger for each handled request. The log line holds the method,
+// path, response status, response bytes, latency, remote address and user
+// agent of the request.
+//
+// The method and path key-value pairs are added to the request Context before
+// calling the next Handler, so log lines of Loggers obtained through
+// Logger.Context in the handler include them as well. Install it after
+// middleware adding other request-scoped key-values, like the correlation
+// Middleware, to include those in the access log line.
+func Middleware(l telemetry.Logger, opts ...Option) func(http.Handler) http.Handler {
+	o := options{
+		message:      DefaultMessage,
+		successLevel: telemetry.LevelInfo,
+		clientLevel:  telemetry.LevelInfo,
+		serverLevel:  telemetry.LevelError,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if o.filter != nil && !o.filter(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			ctx := telemetry.KeyValuesToContext(r.Context(), MethodKey, r.Method, PathKey, r.URL.Path)
+			rw := &responseWriter{ResponseWriter: w}
+
+			defer func() {
+				latency := time.Since(start)
+				status := rw.status
+				if p := recover(); p != nil {
+					// log the request before passing on the panic, the
+					// http.Server aborts the response of panicking handlers.
+					defer panic(p)
+					if status == 0 {
+						status = http.StatusInternalServerError
+					}
+				} else if status == 0 {
+					status = http.StatusOK
+				}
+
+				if o.latencyMetric != nil {
+					o.latencyMetric.RecordContext(telemetry.KeyValuesToContext(ctx, StatusKey, status),
+						latency.Seconds())
+				}
+				logAt(l.Context(ctx), o.level(status), o.message,
+					StatusKey, status,
+					BytesKey, rw.bytes,
+					LatencyKey, latency,
+					RemoteAddrKey, r.RemoteAddr,
+					UserAgentKey, r.UserAgent(),
+				)
+			}()
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
+
+// level returns the log level for the response status.
+func (o options) level(status int) telemetry.Level {
+	switch {
+	case status >= 500:
+		return o.serverLevel
+	case status >= 400:
+		return o.clientLevel
+	default:
+		return o.successLevel
+	}
+}
+
+// logAt logs the message at the provided level.
+func logAt(l telemetry.Logger, level telemetry.Level, msg string, keyValues ...interface{}) {
+	switch level {
+	case telemetry.LevelNone:
+	case telemetry.LevelError:
+		l.Error(msg, nil, keyValues...)
+	case telemetry.LevelWarn:
+		l.Warn(msg, keyValues...)
+	case telemetry.LevelInfo:
+		l.Info(msg, keyValues...)
+	case telemetry.LevelDebug:
+		l.Debug(msg, keyValues...)
+	default:
+		l.Trace(msg, keyValues...)
+	}
+}
+
+// responseWriter captures the status and size of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher, if supported by the wrapped ResponseWriter.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped ResponseWriter, allowing http.ResponseController
+// to access its optional interfaces.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,163 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type line struct {
+	level     telemetry.Level
+	msg       string
+	keyValues []interface{}
+}
+
+// capture returns a Logger recording the emitted log lines, dropping the
+// latency value as it varies between runs.
+func capture(lines *[]line) telemetry.Logger {
+	return function.NewLogger(func(level telemetry.Level, msg string, _ error, values function.Values, _ int) {
+		kvs := append(append([]interface{}(nil), values.FromContext...), values.FromMethod...)
+		for i := 0; i < len(kvs); i += 2 {
+			if _, ok := kvs[i+1].(time.Duration); ok && kvs[i] == LatencyKey {
+				kvs[i+1] = nil
+			}
+		}
+		*lines = append(*lines, line{level, msg, kvs})
+	}, 0)
+}
+
+type mockMetric struct {
+	telemetry.Metric
+	contexts [][]interface{}
+}
+
+func (m *mockMetric) RecordContext(ctx context.Context, _ float64) {
+	m.contexts = append(m.contexts, telemetry.KeyValuesFromContext(ctx))
+}
+
+func TestMiddleware(t *testing.T) {
+	var (
+		lines   []line
+		inner   []line
+		metric  mockMetric
+		logger  = capture(&lines)
+		handler = capture(&inner)
+	)
+	h := Middleware(logger, WithLatencyMetric(&metric),
+		WithFilter(func(r *http.Request) bool { return r.URL.Path != "/health" }))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.Context(r.Context()).Debug("inner")
+			switch r.URL.Path {
+			case "/missing":
+				http.NotFound(w, r)
+			case "/failure":
+				w.WriteHeader(http.StatusBadGateway)
+			default:
+				_, _ = w.Write([]byte("hello"))
+			}
+		}))
+	handler.SetLevel(telemetry.LevelDebug)
+
+	for _, path := range []string{"/hello", "/missing", "/failure", "/health"} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("User-Agent", "test")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	kvs := func(path string, status int, bytes int64) []interface{} {
+		return []interface{}{MethodKey, "GET", PathKey, path, StatusKey, status, BytesKey, bytes,
+			LatencyKey, nil, RemoteAddrKey, "192.0.2.1:1234", UserAgentKey, "test"}
+	}
+	want := []line{
+		{telemetry.LevelInfo, DefaultMessage, kvs("/hello", 200, 5)},
+		{telemetry.LevelInfo, DefaultMessage, kvs("/missing", 404, 19)},
+		{telemetry.LevelError, DefaultMessage, kvs("/failure", 502, 0)},
+	}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+	if want, have := []interface{}{MethodKey, "GET", PathKey, "/hello"}, inner[0].keyValues; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+	if len(inner) != 4 {
+		t.Fatalf("expected 4 inner log lines, have %d", len(inner))
+	}
+	wantMetric := [][]interface{}{
+		{MethodKey, "GET", PathKey, "/hello", StatusKey, 200},
+		{MethodKey, "GET", PathKey, "/missing", StatusKey, 404},
+		{MethodKey, "GET", PathKey, "/failure", StatusKey, 502},
+	}
+	if !reflect.DeepEqual(wantMetric, metric.contexts) {
+		t.Fatalf("want: %v\nhave: %v", wantMetric, metric.contexts)
+	}
+}
+
+func TestMiddlewarePanic(t *testing.T) {
+	var lines []line
+	h := Middleware(capture(&lines), WithLevels(telemetry.LevelNone, telemetry.LevelNone, telemetry.LevelWarn))(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+	func() {
+		defer func() {
+			if p := recover(); p != http.ErrAbortHandler {
+				t.Fatalf("unexpected panic: %v", p)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	}()
+
+	if len(lines) != 1 || lines[0].level != telemetry.LevelWarn || lines[0].keyValues[5] != 500 {
+		t.Fatalf("unexpected log lines: %v", lines)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var lines []line
+	h := Recover(capture(&lines))(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/abort" {
+			panic(http.ErrAbortHandler)
+		}
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("want: 500\nhave: %d", w.Code)
+	}
+	if len(lines) != 1 || lines[0].msg != telemetry.RecoveredMessage || lines[0].keyValues[1] != "boom" {
+		t.Fatalf("unexpected log lines: %v", lines)
+	}
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Fatalf("unexpected panic: %v", p)
+		}
+		if len(lines) != 1 {
+			t.Fatalf("unexpected log lines: %v", lines)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+}
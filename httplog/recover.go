@@ -0,0 +1,52 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplog
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// Recover returns HTTP middleware recovering from panics in the next Handler.
+// The panic is logged through the provided Logger using
+// telemetry.ReportPanic, including the key-value pairs found in the request
+// Context, after which a 500 response is written if no response was started.
+// The http.ErrAbortHandler sentinel panic used to abort a response is passed
+// on as is.
+//
+// Install it inside the access log Middleware to log recovered requests with
+// their 500 status.
+func Recover(l telemetry.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					if recovered == http.ErrAbortHandler {
+						panic(recovered)
+					}
+					telemetry.ReportPanic(r.Context(), l, recovered, debug.Stack())
+					if rw.status == 0 {
+						http.Error(rw, http.StatusText(http.StatusInternalServerError),
+							http.StatusInternalServerError)
+					}
+				}
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
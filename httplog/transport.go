@@ -0,0 +1,228 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplog
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/correlation"
+)
+
+// TraceParentHeader is the W3C Trace Context header injected by the Transport.
+const TraceParentHeader = "traceparent"
+
+// WithRetry makes the Transport retry idempotent requests failing without
+// response or with a 502, 503 or 504 status, up to the provided number of
+// attempts in total. The backoff between attempts doubles after each failed
+// attempt. Requests with a body are only retried if their GetBody function is
+// set, as done by http.NewRequest for common body types.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.attempts = attempts
+		o.backoff = backoff
+	}
+}
+
+// WithCorrelationHeader sets the header in which the Transport propagates the
+// correlation identifier found in the request Context. It defaults to
+// correlation.DefaultHeader, an empty header disables propagation.
+func WithCorrelationHeader(header string) Option {
+	return func(o *options) {
+		o.correlationHeader = header
+	}
+}
+
+// WithTraceContext sets whether the Transport propagates the telemetry.Span
+// found in the request Context using the W3C traceparent header. It is
+// enabled by default. Disable it if the trace context is propagated by other
+// means, like the OpenTelemetry instrumentation.
+func WithTraceContext(enabled bool) Option {
+	return func(o *options) {
+		o.traceContext = enabled
+	}
+}
+
+// Transport returns an http.RoundTripper logging outbound calls through the
+// provided Logger. The log line holds the method, host, path, response
+// status, latency and number of retries of the call, and the error of calls
+// failing without response. The key-value pairs found in the request Context
+// are included.
+//
+// Unless already present, the correlation identifier and the trace context
+// found in the request Context are injected as request headers, see
+// WithCorrelationHeader and WithTraceContext. If base is nil,
+// http.DefaultTransport is used.
+func Transport(base http.RoundTripper, l telemetry.Logger, opts ...Option) http.RoundTripper {
+	o := options{
+		message:           DefaultClientMessage,
+		successLevel:      telemetry.LevelDebug,
+		clientLevel:       telemetry.LevelInfo,
+		serverLevel:       telemetry.LevelError,
+		attempts:          1,
+		correlationHeader: correlation.DefaultHeader,
+		traceContext:      true,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.attempts < 1 {
+		o.attempts = 1
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, logger: l, opts: o}
+}
+
+// transport logs and instruments outbound calls.
+type transport struct {
+	base   http.RoundTripper
+	logger telemetry.Logger
+	opts   options
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if t.opts.filter != nil && !t.opts.filter(r) {
+		return t.base.RoundTrip(r)
+	}
+
+	ctx := r.Context()
+	r = t.inject(r)
+
+	var (
+		start   = time.Now()
+		backoff = t.opts.backoff
+		res     *http.Response
+		err     error
+		retries int
+	)
+	for attempt := 1; ; attempt++ {
+		res, err = t.base.RoundTrip(r)
+		if attempt >= t.opts.attempts || !retryable(r, res, err) {
+			break
+		}
+		if r.Body != nil && r.Body != http.NoBody {
+			body, bodyErr := r.GetBody()
+			if bodyErr != nil {
+				break
+			}
+			r = r.Clone(ctx)
+			r.Body = body
+		}
+		if res != nil {
+			// drain the discarded response, allowing its connection to be
+			// reused.
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		retries++
+	}
+
+	latency := time.Since(start)
+	status := 0
+	if res != nil {
+		status = res.StatusCode
+	}
+	if t.opts.latencyMetric != nil {
+		t.opts.latencyMetric.RecordContext(telemetry.KeyValuesToContext(ctx,
+			MethodKey, r.Method, HostKey, r.URL.Host, StatusKey, status), latency.Seconds())
+	}
+
+	l := t.logger.Context(ctx)
+	keyValues := []interface{}{
+		MethodKey, r.Method,
+		HostKey, r.URL.Host,
+		PathKey, r.URL.Path,
+		StatusKey, status,
+		LatencyKey, latency,
+		RetriesKey, retries,
+	}
+	if err != nil {
+		if level := t.opts.serverLevel; level == telemetry.LevelError {
+			l.Error(t.opts.message, err, keyValues...)
+		} else {
+			logAt(l, level, t.opts.message, append(keyValues, "error", err.Error())...)
+		}
+		return nil, err
+	}
+	logAt(l, t.opts.level(status), t.opts.message, keyValues...)
+	return res, nil
+}
+
+// inject returns the request with the correlation and trace context headers
+// added, if not already present.
+func (t *transport) inject(r *http.Request) *http.Request {
+	var headers [][2]string
+	if h := t.opts.correlationHeader; h != "" && r.Header.Get(h) == "" {
+		if id := correlation.FromContext(r.Context()); id != "" {
+			headers = append(headers, [2]string{h, id})
+		}
+	}
+	if t.opts.traceContext && r.Header.Get(TraceParentHeader) == "" {
+		if sc := telemetry.SpanFromContext(r.Context()).SpanContext(); sc.IsValid() {
+			flags := "00"
+			if sc.Sampled {
+				flags = "01"
+			}
+			headers = append(headers, [2]string{TraceParentHeader,
+				"00-" + sc.TraceID + "-" + sc.SpanID + "-" + flags})
+		}
+	}
+	if len(headers) == 0 {
+		return r
+	}
+
+	// a RoundTripper must not modify the provided request.
+	r = r.Clone(r.Context())
+	for _, h := range headers {
+		r.Header.Set(h[0], h[1])
+	}
+	return r
+}
+
+// retryable reports whether the outcome of the request allows a retry.
+func retryable(r *http.Request, res *http.Response, err error) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	if r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return r.Context().Err() == nil
+	}
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
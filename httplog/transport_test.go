@@ -0,0 +1,126 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplog
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/correlation"
+)
+
+type span struct {
+	telemetry.Span
+}
+
+func (span) SpanContext() telemetry.SpanContext {
+	return telemetry.SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}
+}
+
+func TestTransport(t *testing.T) {
+	var (
+		calls   int32
+		headers []http.Header
+		bodies  []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		var body strings.Builder
+		buf := make([]byte, 16)
+		n, _ := r.Body.Read(buf)
+		body.Write(buf[:n])
+		bodies = append(bodies, body.String())
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	var (
+		lines  []line
+		metric mockMetric
+	)
+	client := &http.Client{Transport: Transport(nil, capture(&lines), WithRetry(3, 0), WithLatencyMetric(&metric))}
+
+	ctx := correlation.ToContext(context.Background(), "abc")
+	ctx = telemetry.ContextWithSpan(ctx, span{Span: telemetry.SpanFromContext(ctx)})
+	r, err := http.NewRequestWithContext(ctx, http.MethodPut, srv.URL+"/items", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err := client.Do(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode != http.StatusNotFound || len(headers) != 2 {
+		t.Fatalf("expected 2 calls ending in 404, have %d ending in %d", len(headers), res.StatusCode)
+	}
+	if want := []string{"payload", "payload"}; !reflect.DeepEqual(want, bodies) {
+		t.Fatalf("want: %v\nhave: %v", want, bodies)
+	}
+	if id := headers[1].Get(correlation.DefaultHeader); id != "abc" {
+		t.Fatalf("want: abc\nhave: %s", id)
+	}
+	if tp, want := headers[1].Get(TraceParentHeader), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; tp != want {
+		t.Fatalf("want: %s\nhave: %s", want, tp)
+	}
+	if r.Header.Get(TraceParentHeader) != "" {
+		t.Fatal("expected request not to be modified")
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	want := []line{{telemetry.LevelInfo, DefaultClientMessage, []interface{}{
+		correlation.DefaultKey, "abc", MethodKey, "PUT", HostKey, host, PathKey, "/items",
+		StatusKey, 404, LatencyKey, nil, RetriesKey, 1,
+	}}}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+	wantMetric := [][]interface{}{{correlation.DefaultKey, "abc", MethodKey, "PUT", HostKey, host, StatusKey, 404}}
+	if !reflect.DeepEqual(wantMetric, metric.contexts) {
+		t.Fatalf("want: %v\nhave: %v", wantMetric, metric.contexts)
+	}
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestTransportError(t *testing.T) {
+	var lines []line
+	client := &http.Client{Transport: Transport(failingTransport{}, capture(&lines),
+		WithRetry(2, 0), WithCorrelationHeader(""), WithTraceContext(false))}
+
+	if _, err := client.Post("http://example.com/", "text/plain", strings.NewReader("x")); err == nil {
+		t.Fatal("expected error")
+	}
+	// POST requests are not retried.
+	if len(lines) != 1 || lines[0].level != telemetry.LevelError || lines[0].keyValues[11] != 0 {
+		t.Fatalf("unexpected log lines: %v", lines)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import "context"
+
+// Counter is a Metric holding a cumulative, monotonically increasing value,
+// e.g. the number of handled requests. Negative values are ignored, also for
+// the Decrement, Record and RecordContext methods inherited from Metric.
+type Counter interface {
+	Metric
+
+	// Add increases the Counter by the provided value.
+	Add(value float64)
+
+	// AddContext increases the Counter by the provided value. LabelValues
+	// found in Context are handled as with Metric.RecordContext.
+	AddContext(ctx context.Context, value float64)
+}
+
+// Gauge is a Metric holding the last recorded value, e.g. the current number
+// of open connections.
+type Gauge interface {
+	Metric
+
+	// Set sets the Gauge to the provided value.
+	Set(value float64)
+
+	// SetContext sets the Gauge to the provided value. LabelValues found in
+	// Context are handled as with Metric.RecordContext.
+	SetContext(ctx context.Context, value float64)
+}
+
+// Histogram is a Metric collecting the distribution of recorded values over
+// buckets, e.g. request latencies. Observations are made using the Record and
+// RecordContext methods inherited from Metric.
+type Histogram interface {
+	Metric
+
+	// Bounds returns the upper bounds of the Histogram buckets.
+	Bounds() []float64
+}
+
+// InstrumentSink creates typed Metric instruments. As each instrument is also
+// a Metric, they can be attached to a Logger using Logger.Metric.
+type InstrumentSink interface {
+	// Counter creates a new Counter.
+	Counter(name, description string, opts ...MetricOption) Counter
+
+	// Gauge creates a new Gauge.
+	Gauge(name, description string, opts ...MetricOption) Gauge
+
+	// Histogram creates a new Histogram with the specified bucket bounds.
+	Histogram(name, description string, bounds []float64, opts ...MetricOption) Histogram
+}
+
+// Instruments returns an InstrumentSink creating its instruments on the
+// provided MetricSink, backing Counters by Sums, Gauges by Gauges and
+// Histograms by Distributions. If the MetricSink implements InstrumentSink
+// itself, it is returned as is.
+func Instruments(ms MetricSink) InstrumentSink {
+	if is, ok := ms.(InstrumentSink); ok {
+		return is
+	}
+	return instruments{ms: ms}
+}
+
+type instruments struct {
+	ms MetricSink
+}
+
+// Counter implements InstrumentSink.
+func (i instruments) Counter(name, description string, opts ...MetricOption) Counter {
+	return AsCounter(i.ms.NewSum(name, description, opts...))
+}
+
+// Gauge implements InstrumentSink.
+func (i instruments) Gauge(name, description string, opts ...MetricOption) Gauge {
+	return AsGauge(i.ms.NewGauge(name, description, opts...))
+}
+
+// Histogram implements InstrumentSink.
+func (i instruments) Histogram(name, description string, bounds []float64, opts ...MetricOption) Histogram {
+	return AsHistogram(i.ms.NewDistribution(name, description, bounds, opts...), bounds)
+}
+
+// AsCounter returns the provided Metric as a Counter. The Metric is expected to
+// have an aggregation type of Sum. If it implements Counter, it is returned as
+// is.
+func AsCounter(m Metric) Counter {
+	if c, ok := m.(Counter); ok {
+		return c
+	}
+	return counter{Metric: m}
+}
+
+// AsGauge returns the provided Metric as a Gauge. The Metric is expected to
+// have an aggregation type of LastValue. If it implements Gauge, it is
+// returned as is.
+func AsGauge(m Metric) Gauge {
+	if g, ok := m.(Gauge); ok {
+		return g
+	}
+	return gauge{Metric: m}
+}
+
+// AsHistogram returns the provided Metric as a Histogram with the provided
+// bucket bounds. The Metric is expected to have an aggregation type of
+// Distribution. If it implements Histogram, it is returned as is.
+func AsHistogram(m Metric, bounds []float64) Histogram {
+	if h, ok := m.(Histogram); ok {
+		return h
+	}
+	return histogram{Metric: m, bounds: append([]float64(nil), bounds...)}
+}
+
+// recordLeveled passes the level to the Metric if it implements LeveledMetric.
+func recordLeveled(ctx context.Context, m Metric, value float64, level Level) {
+	if lm, ok := m.(LeveledMetric); ok {
+		lm.RecordLeveled(ctx, value, level)
+		return
+	}
+	m.RecordContext(ctx, value)
+}
+
+type counter struct {
+	Metric
+}
+
+func (c counter) Add(value float64) {
+	if value >= 0 {
+		c.Metric.Record(value)
+	}
+}
+
+func (c counter) AddContext(ctx context.Context, value float64) {
+	if value >= 0 {
+		c.Metric.RecordContext(ctx, value)
+	}
+}
+
+func (c counter) Decrement() {}
+
+func (c counter) Record(value float64) { c.Add(value) }
+
+func (c counter) RecordContext(ctx context.Context, value float64) { c.AddContext(ctx, value) }
+
+func (c counter) RecordLeveled(ctx context.Context, value float64, level Level) {
+	if value >= 0 {
+		recordLeveled(ctx, c.Metric, value, level)
+	}
+}
+
+func (c counter) With(labelValues ...LabelValue) Metric {
+	return counter{Metric: c.Metric.With(labelValues...)}
+}
+
+type gauge struct {
+	Metric
+}
+
+func (g gauge) Set(value float64) { g.Metric.Record(value) }
+
+func (g gauge) SetContext(ctx context.Context, value float64) { g.Metric.RecordContext(ctx, value) }
+
+func (g gauge) RecordLeveled(ctx context.Context, value float64, level Level) {
+	recordLeveled(ctx, g.Metric, value, level)
+}
+
+func (g gauge) With(labelValues ...LabelValue) Metric {
+	return gauge{Metric: g.Metric.With(labelValues...)}
+}
+
+type histogram struct {
+	Metric
+	bounds []float64
+}
+
+func (h histogram) Bounds() []float64 { return h.bounds }
+
+func (h histogram) RecordLeveled(ctx context.Context, value float64, level Level) {
+	recordLeveled(ctx, h.Metric, value, level)
+}
+
+func (h histogram) With(labelValues ...LabelValue) Metric {
+	return histogram{Metric: h.Metric.With(labelValues...), bounds: h.bounds}
+}
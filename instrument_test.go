@@ -0,0 +1,128 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type recordingMetric struct {
+	telemetry.Metric
+	kind   string
+	values []float64
+	levels []telemetry.Level
+}
+
+func (m *recordingMetric) Increment()                                     { m.Record(1) }
+func (m *recordingMetric) Decrement()                                     { m.Record(-1) }
+func (m *recordingMetric) Record(value float64)                           { m.values = append(m.values, value) }
+func (m *recordingMetric) RecordContext(_ context.Context, value float64) { m.Record(value) }
+func (m *recordingMetric) With(...telemetry.LabelValue) telemetry.Metric  { return m }
+
+func (m *recordingMetric) RecordLeveled(_ context.Context, value float64, level telemetry.Level) {
+	m.Record(value)
+	m.levels = append(m.levels, level)
+}
+
+type recordingSink struct {
+	telemetry.MetricSink
+	metrics map[string]*recordingMetric
+}
+
+func (s *recordingSink) metric(name, kind string) telemetry.Metric {
+	m := &recordingMetric{kind: kind}
+	s.metrics[name] = m
+	return m
+}
+
+func (s *recordingSink) NewSum(name, _ string, _ ...telemetry.MetricOption) telemetry.Metric {
+	return s.metric(name, "sum")
+}
+
+func (s *recordingSink) NewGauge(name, _ string, _ ...telemetry.MetricOption) telemetry.Metric {
+	return s.metric(name, "gauge")
+}
+
+func (s *recordingSink) NewDistribution(name, _ string, _ []float64, _ ...telemetry.MetricOption) telemetry.Metric {
+	return s.metric(name, "distribution")
+}
+
+func TestInstruments(t *testing.T) {
+	var (
+		sink = &recordingSink{metrics: make(map[string]*recordingMetric)}
+		is   = telemetry.Instruments(sink)
+		ctx  = context.Background()
+	)
+
+	c := is.Counter("requests", "handled requests")
+	c.Add(2)
+	c.AddContext(ctx, 3)
+	c.Add(-1)
+	c.Decrement()
+	c.Increment()
+	if want, have := []float64{2, 3, 1}, sink.metrics["requests"].values; !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %v\nhave: %v", want, have)
+	}
+
+	g := is.Gauge("connections", "open connections")
+	g.Set(5)
+	g.SetContext(ctx, 4)
+	g.Decrement()
+	if want, have := []float64{5, 4, -1}, sink.metrics["connections"].values; !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %v\nhave: %v", want, have)
+	}
+
+	h := is.Histogram("latency", "request latency", []float64{0.1, 1})
+	h.Record(0.5)
+	if want, have := []float64{0.1, 1}, h.Bounds(); !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %v\nhave: %v", want, have)
+	}
+	if want, have := []float64{0.5}, sink.metrics["latency"].values; !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %v\nhave: %v", want, have)
+	}
+
+	for name, kind := range map[string]string{"requests": "sum", "connections": "gauge", "latency": "distribution"} {
+		if have := sink.metrics[name].kind; have != kind {
+			t.Errorf("expected %s to be a %s, have %s", name, kind, have)
+		}
+	}
+
+	if _, ok := c.With().(telemetry.Counter); !ok {
+		t.Errorf("expected With to return a Counter")
+	}
+	if have := telemetry.AsCounter(c); have != c {
+		t.Errorf("expected AsCounter to return the Counter as is")
+	}
+}
+
+func TestInstrumentLogger(t *testing.T) {
+	var (
+		m = &recordingMetric{}
+		c = telemetry.AsCounter(m)
+	)
+	logger := function.NewLogger(func(telemetry.Level, string, error, function.Values, int) {}, 0).Metric(c)
+
+	logger.Info("info")
+	logger.Error("error", nil)
+
+	if want, have := []telemetry.Level{telemetry.LevelInfo, telemetry.LevelError}, m.levels; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filter provides the plumbing shared by the telemetry.Logger
+// decorators dropping log lines based on their level and message.
+package filter
+
+import (
+	"context"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// compile time check for compatibility with the telemetry.Logger interface.
+var _ telemetry.Logger = (*Logger)(nil)
+
+type (
+	// Policy decides which log lines are forwarded to the decorated Logger.
+	Policy interface {
+		// Allow reports if the log line with the provided level and message is
+		// forwarded. The provided Logger is the decorated Logger, which the
+		// Policy can use to log about the log lines it drops.
+		Allow(l telemetry.Logger, level telemetry.Level, msg string) bool
+		// Clone returns a Policy with the same configuration and its own state.
+		Clone() Policy
+	}
+
+	// Logger decorates a telemetry.Logger with a Policy.
+	Logger struct {
+		logger telemetry.Logger
+		policy Policy
+	}
+
+	// callerSkip matches telemetry.Logger implementations supporting caller
+	// skip adjustments, like scope.CallerSkip.
+	callerSkip interface {
+		CSIncrease()
+		CSDecrease()
+	}
+
+	// callerSkipper matches telemetry.Logger implementations returning a
+	// Logger with an adjusted caller skip, like the function Logger.
+	callerSkipper interface {
+		WithCallerSkip(skip int) telemetry.Logger
+	}
+)
+
+// New returns a telemetry.Logger forwarding the log lines allowed by the
+// Policy to the provided Logger. The Policy is only consulted for log lines
+// enabled on the decorated Logger. Disabled Info, Warn and Error log lines are
+// still forwarded for Metric recording, so dropped log lines do not record an
+// attached Metric. Fatal log lines are never dropped.
+//
+// Loggers derived through With, Context and Metric share the Policy with the
+// Logger they were derived from, while Clone returns a Logger with a clone of
+// the Policy.
+//
+// The stack frame added by the returned Logger is accounted for in the caller
+// skip of the decorated Logger. If supported, the decorated Logger is derived
+// using WithCallerSkip, leaving the provided Logger untouched. Otherwise
+// CSIncrease is called on the provided Logger.
+func New(l telemetry.Logger, p Policy) *Logger {
+	if cs, ok := l.(callerSkipper); ok {
+		return &Logger{logger: cs.WithCallerSkip(1), policy: p}
+	}
+	fl := &Logger{logger: l, policy: p}
+	fl.CSIncrease()
+	return fl
+}
+
+// enabled checks if the decorated Logger outputs log lines for the level.
+func (l *Logger) enabled(level telemetry.Level) bool {
+	return level <= l.logger.Level()
+}
+
+// allow reports if the log line can be forwarded.
+func (l *Logger) allow(level telemetry.Level, msg string) bool {
+	return l.policy.Allow(l.logger, level, msg)
+}
+
+// Trace implements telemetry.Logger.
+func (l *Logger) Trace(msg string, keyValuePairs ...interface{}) {
+	if !l.enabled(telemetry.LevelTrace) || !l.allow(telemetry.LevelTrace, msg) {
+		return
+	}
+	l.logger.Trace(msg, keyValuePairs...)
+}
+
+// Debug implements telemetry.Logger.
+func (l *Logger) Debug(msg string, keyValuePairs ...interface{}) {
+	if !l.enabled(telemetry.LevelDebug) || !l.allow(telemetry.LevelDebug, msg) {
+		return
+	}
+	l.logger.Debug(msg, keyValuePairs...)
+}
+
+// Info implements telemetry.Logger.
+func (l *Logger) Info(msg string, keyValuePairs ...interface{}) {
+	// disabled log lines are still forwarded for Metric recording.
+	if l.enabled(telemetry.LevelInfo) && !l.allow(telemetry.LevelInfo, msg) {
+		return
+	}
+	l.logger.Info(msg, keyValuePairs...)
+}
+
+// Warn implements telemetry.Logger.
+func (l *Logger) Warn(msg string, keyValuePairs ...interface{}) {
+	// disabled log lines are still forwarded for Metric recording.
+	if l.enabled(telemetry.LevelWarn) && !l.allow(telemetry.LevelWarn, msg) {
+		return
+	}
+	l.logger.Warn(msg, keyValuePairs...)
+}
+
+// Error implements telemetry.Logger.
+func (l *Logger) Error(msg string, err error, keyValuePairs ...interface{}) {
+	// disabled log lines are still forwarded for Metric recording.
+	if l.enabled(telemetry.LevelError) && !l.allow(telemetry.LevelError, msg) {
+		return
+	}
+	l.logger.Error(msg, err, keyValuePairs...)
+}
+
+// Fatal implements telemetry.Logger. Fatal log lines are never dropped.
+func (l *Logger) Fatal(msg string, err error, keyValuePairs ...interface{}) {
+	l.logger.Fatal(msg, err, keyValuePairs...)
+}
+
+// SetLevel implements telemetry.Logger.
+func (l *Logger) SetLevel(lvl telemetry.Level) { l.logger.SetLevel(lvl) }
+
+// Level implements telemetry.Logger.
+func (l *Logger) Level() telemetry.Level { return l.logger.Level() }
+
+// With implements telemetry.Logger.
+func (l *Logger) With(keyValuePairs ...interface{}) telemetry.Logger {
+	return &Logger{logger: l.logger.With(keyValuePairs...), policy: l.policy}
+}
+
+// Context implements telemetry.Logger.
+func (l *Logger) Context(ctx context.Context) telemetry.Logger {
+	return &Logger{logger: l.logger.Context(ctx), policy: l.policy}
+}
+
+// Metric implements telemetry.Logger.
+func (l *Logger) Metric(m telemetry.Metric) telemetry.Logger {
+	return &Logger{logger: l.logger.Metric(m), policy: l.policy}
+}
+
+// Clone implements telemetry.Logger.
+func (l *Logger) Clone() telemetry.Logger {
+	return &Logger{logger: l.logger.Clone(), policy: l.policy.Clone()}
+}
+
+// CSIncrease forwards the caller skip adjustment to the decorated Logger.
+func (l *Logger) CSIncrease() {
+	if cs, ok := l.logger.(callerSkip); ok {
+		cs.CSIncrease()
+	}
+}
+
+// CSDecrease forwards the caller skip adjustment to the decorated Logger.
+func (l *Logger) CSDecrease() {
+	if cs, ok := l.logger.(callerSkip); ok {
+		cs.CSDecrease()
+	}
+}
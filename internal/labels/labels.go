@@ -0,0 +1,229 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package labels provides the label operations shared by the
+// telemetry.MetricSink implementations.
+package labels
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// compile time check for compatibility with the telemetry interfaces.
+var (
+	_ telemetry.Label      = Label("")
+	_ telemetry.LabelValue = Op{}
+)
+
+// Kind enumerates the operations on a label value.
+type Kind int
+
+// Supported operations.
+const (
+	Insert Kind = iota
+	Update
+	Upsert
+	Delete
+)
+
+// Op holds an operation on a label value.
+type Op struct {
+	Name  string
+	Kind  Kind
+	Value string
+}
+
+// Label implements telemetry.Label, creating Ops.
+type Label string
+
+// Insert implements telemetry.Label.
+func (l Label) Insert(value string) telemetry.LabelValue {
+	return Op{Name: string(l), Kind: Insert, Value: value}
+}
+
+// Update implements telemetry.Label.
+func (l Label) Update(value string) telemetry.LabelValue {
+	return Op{Name: string(l), Kind: Update, Value: value}
+}
+
+// Upsert implements telemetry.Label.
+func (l Label) Upsert(value string) telemetry.LabelValue {
+	return Op{Name: string(l), Kind: Upsert, Value: value}
+}
+
+// Delete implements telemetry.Label.
+func (l Label) Delete() telemetry.LabelValue {
+	return Op{Name: string(l), Kind: Delete}
+}
+
+// Names returns the names of the Labels created by this package. The name is
+// taken from a Delete operation, so decorated Labels forwarding it are
+// supported.
+func Names(labels []telemetry.Label) []string {
+	var names []string
+	for _, l := range labels {
+		if op, ok := l.Delete().(Op); ok {
+			names = append(names, op.Name)
+		}
+	}
+	return names
+}
+
+// With returns a copy of ops extended with the label values created by this
+// package. Other label values are ignored.
+func With(ops []Op, values []telemetry.LabelValue) []Op {
+	out := make([]Op, 0, len(ops)+len(values))
+	out = append(out, ops...)
+	for _, v := range values {
+		if op, ok := v.(Op); ok {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+// ContextKey identifies the Ops stored in a Context by a MetricSink, keeping
+// the Ops of different sinks apart.
+type ContextKey string
+
+// Ops returns the Ops stored in the Context.
+func (k ContextKey) Ops(ctx context.Context) []Op {
+	if ops, ok := ctx.Value(k).([]Op); ok {
+		return ops
+	}
+	return nil
+}
+
+// WithValues returns a copy of the Context with the label values appended to
+// the Ops stored in it. It returns an error if a value was not created by this
+// package or its label name is rejected by valid.
+func (k ContextKey) WithValues(ctx context.Context, values []telemetry.LabelValue, valid func(name string) bool) (context.Context, error) {
+	existing := k.Ops(ctx)
+	ops := make([]Op, 0, len(existing)+len(values))
+	ops = append(ops, existing...)
+	for _, v := range values {
+		op, ok := v.(Op)
+		if !ok {
+			return ctx, fmt.Errorf("unsupported label value %T", v)
+		}
+		if !valid(op.Name) {
+			return ctx, fmt.Errorf("invalid label name %q", op.Name)
+		}
+		ops = append(ops, op)
+	}
+	return context.WithValue(ctx, k, ops), nil
+}
+
+// Resolve returns the label values of a measurement on a Metric with the
+// provided label names. The Ops are processed in order: the level set on the
+// level label if both are not empty, the key-value pairs found in Context as
+// upserts, the Ops found in Context and the with Ops. A nil Context is
+// skipped.
+func (k ContextKey) Resolve(ctx context.Context, names []string, levelLabel, level string, with []Op) *Set {
+	s := NewSet(names)
+	if levelLabel != "" && level != "" {
+		s.Apply(Op{Name: levelLabel, Kind: Upsert, Value: level})
+	}
+	if ctx != nil {
+		kvs := telemetry.ResolveKeyValuesFromContext(ctx)
+		for i := 0; i+1 < len(kvs); i += 2 {
+			if name, ok := kvs[i].(string); ok {
+				s.Apply(Op{Name: name, Kind: Upsert, Value: fmt.Sprint(kvs[i+1])})
+			}
+		}
+		for _, op := range k.Ops(ctx) {
+			s.Apply(op)
+		}
+	}
+	for _, op := range with {
+		s.Apply(op)
+	}
+	return s
+}
+
+// Set holds the values of the labels of a Metric while processing Ops.
+type Set struct {
+	open   bool
+	names  []string
+	values []string
+	set    []bool
+}
+
+// NewSet returns a Set for the provided label names. Without names, the Set
+// accepts any label, in order of first insertion.
+func NewSet(names []string) *Set {
+	if len(names) == 0 {
+		// never append to the slice of the caller.
+		names = nil
+	}
+	return &Set{
+		open:   len(names) == 0,
+		names:  names,
+		values: make([]string, len(names)),
+		set:    make([]bool, len(names)),
+	}
+}
+
+func (s *Set) index(name string) int {
+	for i, n := range s.names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Apply processes the Op. Ops on labels unknown to the Set are ignored.
+func (s *Set) Apply(op Op) {
+	i := s.index(op.Name)
+	if i < 0 {
+		if !s.open || op.Kind == Update || op.Kind == Delete {
+			return
+		}
+		s.names = append(s.names, op.Name)
+		s.values = append(s.values, "")
+		s.set = append(s.set, false)
+		i = len(s.names) - 1
+	}
+	switch op.Kind {
+	case Insert:
+		if s.set[i] {
+			return
+		}
+	case Update:
+		if !s.set[i] {
+			return
+		}
+	case Delete:
+		s.values[i], s.set[i] = "", false
+		return
+	}
+	s.values[i], s.set[i] = op.Value, true
+}
+
+// Values returns the label values in order of the label names, holding an
+// empty string for labels without value.
+func (s *Set) Values() []string { return s.values }
+
+// Each calls fn for each label holding a value, in order of the label names.
+func (s *Set) Each(fn func(name, value string)) {
+	for i, name := range s.names {
+		if s.set[i] {
+			fn(name, s.values[i])
+		}
+	}
+}
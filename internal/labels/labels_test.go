@@ -0,0 +1,90 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+const testKey = ContextKey("labels.test")
+
+func values(s *Set) map[string]string {
+	m := make(map[string]string)
+	s.Each(func(name, value string) { m[name] = value })
+	return m
+}
+
+func TestResolve(t *testing.T) {
+	ctx := telemetry.KeyValuesToContext(context.Background(), "tenant", "acme", "region", "us")
+	ctx, err := testKey.WithValues(ctx, []telemetry.LabelValue{
+		Label("region").Update("eu"), Label("code").Insert("200"),
+	}, func(string) bool { return true })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	with := With(nil, []telemetry.LabelValue{Label("code").Insert("500"), Label("tenant").Delete(), "foreign"})
+
+	tests := []struct {
+		name  string
+		names []string
+		want  map[string]string
+	}{
+		{"registered", []string{"level", "tenant", "region", "code"},
+			map[string]string{"level": "warn", "region": "eu", "code": "200"}},
+		{"subset", []string{"code"}, map[string]string{"code": "200"}},
+		{"open", nil, map[string]string{"level": "warn", "region": "eu", "code": "200"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			have := values(testKey.Resolve(ctx, tt.names, "level", "warn", with))
+			if !reflect.DeepEqual(tt.want, have) {
+				t.Fatalf("want: %v\nhave: %v", tt.want, have)
+			}
+		})
+	}
+
+	if have := values(testKey.Resolve(nil, []string{"code"}, "", "", with)); !reflect.DeepEqual(have, map[string]string{"code": "500"}) {
+		t.Fatalf("expected Context to be skipped, have %v", have)
+	}
+}
+
+func TestWithValues(t *testing.T) {
+	valid := func(name string) bool { return name != "" }
+	base, _ := testKey.WithValues(context.Background(), []telemetry.LabelValue{Label("a").Upsert("1")}, valid)
+
+	// deriving two Contexts from the same parent must not share Ops.
+	ctx1, _ := testKey.WithValues(base, []telemetry.LabelValue{Label("b").Upsert("2")}, valid)
+	ctx2, _ := testKey.WithValues(base, []telemetry.LabelValue{Label("c").Upsert("3")}, valid)
+	if have := testKey.Ops(ctx1); len(have) != 2 || have[1].Name != "b" {
+		t.Fatalf("unexpected Ops: %v", have)
+	}
+	if have := testKey.Ops(ctx2); len(have) != 2 || have[1].Name != "c" {
+		t.Fatalf("unexpected Ops: %v", have)
+	}
+
+	if _, err := testKey.WithValues(base, []telemetry.LabelValue{Label("").Upsert("x")}, valid); err == nil {
+		t.Fatal("expected error for invalid label name")
+	}
+	if _, err := testKey.WithValues(base, []telemetry.LabelValue{"foreign"}, valid); err == nil {
+		t.Fatal("expected error for foreign label value")
+	}
+	if have := ContextKey("other").Ops(ctx1); have != nil {
+		t.Fatalf("expected Ops of other keys to be kept apart, have %v", have)
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/basvanbeek/telemetry/kafkalog
+
+go 1.21
+
+require (
+	github.com/basvanbeek/telemetry v0.2.0
+	github.com/segmentio/kafka-go v0.4.47
+)
+
+require (
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+)
+
+// Work around for maintaining multiple go modules in the same repository
+// until go has better support for this. https://github.com/golang/go/issues/45713
+replace github.com/basvanbeek/telemetry => ../
@@ -0,0 +1,158 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kafkalog provides a sink producing log lines to Kafka, with the
+// message key derived from a chosen key-value so log lines of the same
+// tenant, trace or entity end up on the same partition.
+package kafkalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/batch"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Writer produces messages to Kafka. It is implemented by *kafka.Writer.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type (
+	// Option implements a functional option type for the Kafka sink.
+	Option func(*options)
+
+	// options holds the configuration of the Kafka sink.
+	options struct {
+		topic         string
+		key           string
+		encoder       batch.Encoder
+		failureMetric telemetry.Metric
+		batchOpts     []batch.Option
+	}
+)
+
+// WithTopic sets the topic of the produced messages. Leave it empty if the
+// topic is configured on the Writer.
+func WithTopic(topic string) Option {
+	return func(o *options) {
+		o.topic = topic
+	}
+}
+
+// WithKey sets the key of the key-value pair whose value is used as message
+// key, e.g. "tenant" or "trace_id". Values passed to the logging method take
+// precedence over those added to the Logger or found in Context. Messages
+// lacking the key-value pair are produced without key. Configure the Writer
+// with a hashing Balancer, such as kafka.Hash, to partition by key.
+func WithKey(key string) Option {
+	return func(o *options) {
+		o.key = key
+	}
+}
+
+// WithEncoder sets the encoder of the message values. The default encodes
+// log lines as JSON using batch.JSONEncoder.
+func WithEncoder(enc batch.Encoder) Option {
+	return func(o *options) {
+		o.encoder = enc
+	}
+}
+
+// WithFailureMetric sets a Metric recording the number of messages the Writer
+// failed to deliver, for each failed attempt.
+func WithFailureMetric(m telemetry.Metric) Option {
+	return func(o *options) {
+		o.failureMetric = m
+	}
+}
+
+// WithBatchOptions sets the options of the underlying batch.Batcher, e.g. to
+// configure batch size, flush interval and retries. As kafka.Writer retries
+// failed deliveries itself, consider limiting the batch.Batcher attempts.
+func WithBatchOptions(opts ...batch.Option) Option {
+	return func(o *options) {
+		o.batchOpts = append(o.batchOpts, opts...)
+	}
+}
+
+// New returns a batch.Batcher producing log lines to Kafka using the provided
+// Writer. Use its Emit method with function.NewLoggerErr, its Flush method
+// with function.WithFlush and Close it on shutdown, before closing the
+// Writer. Failed batches are retried as a whole, so log lines may be
+// delivered more than once.
+func New(w Writer, opts ...Option) *batch.Batcher {
+	o := options{encoder: batch.JSONEncoder()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return batch.New(handler(w, o), o.batchOpts...)
+}
+
+func handler(w Writer, o options) batch.Handler {
+	return func(ctx context.Context, records []batch.Record) error {
+		msgs := make([]kafka.Message, 0, len(records))
+		for _, r := range records {
+			value, err := o.encoder(r)
+			if err != nil {
+				return batch.Permanent(err)
+			}
+			msgs = append(msgs, kafka.Message{
+				Topic: o.topic,
+				Key:   messageKey(o.key, r.Values),
+				Value: value,
+				Time:  r.Time,
+			})
+		}
+
+		err := w.WriteMessages(ctx, msgs...)
+		if err != nil && o.failureMetric != nil {
+			o.failureMetric.RecordContext(ctx, float64(failed(err, len(msgs))))
+		}
+		return err
+	}
+}
+
+// failed returns the number of messages not delivered.
+func failed(err error, n int) int {
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		return writeErrs.Count()
+	}
+	return n
+}
+
+// messageKey returns the value of the key-value pair with the provided key,
+// looking at the method, Logger and Context key-value pairs in that order.
+func messageKey(key string, values function.Values) []byte {
+	if key == "" {
+		return nil
+	}
+	for _, kvs := range [][]interface{}{values.FromMethod, values.FromLogger, values.FromContext} {
+		for i := len(kvs) - 2; i >= 0; i -= 2 {
+			if k, ok := kvs[i].(string); ok && k == key {
+				if s, ok := kvs[i+1].(string); ok {
+					return []byte(s)
+				}
+				return []byte(fmt.Sprint(kvs[i+1]))
+			}
+		}
+	}
+	return nil
+}
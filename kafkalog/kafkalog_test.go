@@ -0,0 +1,122 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkalog
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/batch"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type mockWriter struct {
+	mtx  sync.Mutex
+	msgs []kafka.Message
+	err  error
+}
+
+func (w *mockWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+type mockMetric struct {
+	telemetry.Metric
+	mtx   sync.Mutex
+	count float64
+}
+
+func (m *mockMetric) RecordContext(_ context.Context, value float64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.count += value
+}
+
+func TestKafka(t *testing.T) {
+	var (
+		w = &mockWriter{}
+		b = New(w, WithTopic("logs"), WithKey("tenant"))
+		// retain duplicate keys to verify the precedence of the key lookup.
+		logger = function.NewLoggerErr(b.Emit, 0, function.WithDuplicateKeys(function.KeepAll)).With("tenant", "acme")
+	)
+
+	logger.Info("hello", "count", 1)
+	logger.Error("failed", errors.New("boom"), "tenant", 42)
+	logger.Context(telemetry.KeyValuesToContext(context.Background(), "tenant", "ctx")).Info("from context")
+	function.NewLoggerErr(b.Emit, 0).Info("no key")
+	if err := b.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		key   string
+		value string
+	}{
+		{"acme", `{"time":"TS","level":"info","msg":"hello","tenant":"acme","count":1}`},
+		{"42", `{"time":"TS","level":"error","msg":"failed","error":"boom","tenant":"acme","tenant":42}`},
+		{"acme", `{"time":"TS","level":"info","msg":"from context","tenant":"ctx","tenant":"acme"}`},
+		{"", `{"time":"TS","level":"info","msg":"no key"}`},
+	}
+	if len(w.msgs) != len(expected) {
+		t.Fatalf("expected %d messages, got %d", len(expected), len(w.msgs))
+	}
+	for i, msg := range w.msgs {
+		if msg.Topic != "logs" {
+			t.Errorf("expected %q to match %q", msg.Topic, "logs")
+		}
+		if string(msg.Key) != expected[i].key {
+			t.Errorf("expected %q to match %q", msg.Key, expected[i].key)
+		}
+		ts := msg.Time.Format(time.RFC3339Nano)
+		if want := strings.Replace(expected[i].value, `"TS"`, `"`+ts+`"`, 1); string(msg.Value) != want {
+			t.Errorf("expected %s to match %s", msg.Value, want)
+		}
+	}
+}
+
+func TestKafkaFailureMetric(t *testing.T) {
+	var (
+		m = &mockMetric{}
+		w = &mockWriter{err: kafka.WriteErrors{nil, errors.New("failed"), kafka.LeaderNotAvailable}}
+		b = New(w, WithFailureMetric(m), WithBatchOptions(
+			batch.WithRetry(2, time.Millisecond, time.Millisecond),
+			batch.WithErrorHandler(func(error) {}),
+		))
+		logger = function.NewLoggerErr(b.Emit, 0)
+	)
+
+	logger.Info("1")
+	logger.Info("2")
+	logger.Info("3")
+	_ = b.Close()
+
+	// two of three messages failed on both attempts.
+	if m.count != 4 {
+		t.Fatalf("expected 4 failed deliveries, have %v", m.count)
+	}
+}
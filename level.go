@@ -15,6 +15,12 @@
 
 package telemetry
 
+import (
+	"encoding"
+	"fmt"
+	"strings"
+)
+
 // Level is an enumeration of the available log levels.
 type Level int32
 
@@ -22,31 +28,104 @@ type Level int32
 const (
 	LevelNone  Level = 0
 	LevelError Level = 1
+	LevelWarn  Level = 3
 	LevelInfo  Level = 5
 	LevelDebug Level = 10
+	LevelTrace Level = 15
+)
+
+// compile time check for compatibility with the encoding interfaces.
+var (
+	_ encoding.TextMarshaler   = LevelNone
+	_ encoding.TextUnmarshaler = (*Level)(nil)
 )
 
 // levelToString maps each logging level to its string representation.
 var levelToString = map[Level]string{
 	LevelNone:  "none",
 	LevelError: "error",
+	LevelWarn:  "warn",
 	LevelInfo:  "info",
 	LevelDebug: "debug",
+	LevelTrace: "trace",
 }
 
 // levelToString maps string representations to the corresponding level
 var stringToLevel = map[string]Level{
 	"none":  LevelNone,
 	"error": LevelError,
+	"warn":  LevelWarn,
 	"info":  LevelInfo,
 	"debug": LevelDebug,
+	"trace": LevelTrace,
 }
 
 // String returns the string representation of the logging level.
 func (v Level) String() string { return levelToString[v] }
 
+// Enabled reports whether the Logger emits log lines at the provided level.
+// Loggers able to tell more than their level, e.g. a Logger without output,
+// implement an Enabled(Level) bool method which is used instead. Use it to guard log lines on hot paths when calling through the Logger
+// interface: the key-value pairs passed to an interface method are allocated
+// by the caller even if the level is disabled, as the compiler can't tell
+// whether the implementation retains them. The same goes for boxing values
+// which are not constants, pointers or small integers into interface{}.
+//
+//	if telemetry.Enabled(logger, telemetry.LevelDebug) {
+//		logger.Debug("received packet", "size", len(pkt))
+//	}
+func Enabled(l Logger, level Level) bool {
+	if e, ok := l.(interface{ Enabled(Level) bool }); ok {
+		return e.Enabled(level)
+	}
+	return level <= l.Level()
+}
+
+// VerbosityToLevel maps a klog/logr style numeric verbosity onto a logging
+// level. Verbosity 0 and below map to LevelInfo, 1 through 4 map to LevelDebug
+// and 5 and up map to LevelTrace.
+func VerbosityToLevel(verbosity int) Level {
+	switch {
+	case verbosity <= 0:
+		return LevelInfo
+	case verbosity < 5:
+		return LevelDebug
+	default:
+		return LevelTrace
+	}
+}
+
 // FromLevel returns the logging level corresponding to the given string representation.
 func FromLevel(level string) (Level, bool) {
 	l, ok := stringToLevel[level]
 	return l, ok
 }
+
+// ParseLevel returns the logging level corresponding to the given string
+// representation. Surrounding whitespace and casing are ignored.
+func ParseLevel(level string) (Level, error) {
+	l, ok := stringToLevel[strings.ToLower(strings.TrimSpace(level))]
+	if !ok {
+		return LevelNone, fmt.Errorf("%q is not a valid log level", level)
+	}
+	return l, nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v Level) MarshalText() ([]byte, error) {
+	s, ok := levelToString[v]
+	if !ok {
+		return nil, fmt.Errorf("%d is not a valid log level", int32(v))
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *Level) UnmarshalText(text []byte) error {
+	l, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*v = l
+	return nil
+}
@@ -16,9 +16,47 @@
 package telemetry
 
 import (
+	"encoding/json"
 	"testing"
 )
 
+func TestVerbosityToLevel(t *testing.T) {
+	tests := []struct {
+		verbosity int
+		want      Level
+	}{
+		{-1, LevelInfo},
+		{0, LevelInfo},
+		{1, LevelDebug},
+		{4, LevelDebug},
+		{5, LevelTrace},
+		{10, LevelTrace},
+	}
+
+	for _, tt := range tests {
+		if have := VerbosityToLevel(tt.verbosity); have != tt.want {
+			t.Errorf("VerbosityToLevel(%d)=%s, want: %s", tt.verbosity, have, tt.want)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	l := NoopLogger()
+	l.SetLevel(LevelInfo)
+
+	for level, want := range map[Level]bool{
+		LevelError: true,
+		LevelWarn:  true,
+		LevelInfo:  true,
+		LevelDebug: false,
+		LevelTrace: false,
+	} {
+		if have := Enabled(l, level); have != want {
+			t.Errorf("Enabled(%s) want: %t\nhave: %t", level, want, have)
+		}
+	}
+}
+
 func TestFromLevel(t *testing.T) {
 	tests := []struct {
 		level string
@@ -27,8 +65,10 @@ func TestFromLevel(t *testing.T) {
 	}{
 		{"none", LevelNone, true},
 		{"error", LevelError, true},
+		{"warn", LevelWarn, true},
 		{"info", LevelInfo, true},
 		{"debug", LevelDebug, true},
+		{"trace", LevelTrace, true},
 		{"invalid", LevelNone, false},
 	}
 
@@ -45,3 +85,59 @@ func TestFromLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    Level
+		wantErr bool
+	}{
+		{"none", LevelNone, false},
+		{"error", LevelError, false},
+		{" Warn\n", LevelWarn, false},
+		{"INFO", LevelInfo, false},
+		{"debug", LevelDebug, false},
+		{"trace", LevelTrace, false},
+		{"invalid", LevelNone, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			level, err := ParseLevel(tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevel(%q) err=%v, want error: %t", tt.level, err, tt.wantErr)
+			}
+			if level != tt.want {
+				t.Fatalf("ParseLevel(%q)=%s, want: %s", tt.level, level, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevelText(t *testing.T) {
+	type config struct {
+		Level Level `json:"level"`
+	}
+
+	for _, want := range []Level{LevelNone, LevelError, LevelWarn, LevelInfo, LevelDebug, LevelTrace} {
+		b, err := json.Marshal(config{Level: want})
+		if err != nil {
+			t.Fatalf("json.Marshal(%s) err=%v", want, err)
+		}
+		var have config
+		if err = json.Unmarshal(b, &have); err != nil {
+			t.Fatalf("json.Unmarshal(%s) err=%v", b, err)
+		}
+		if have.Level != want {
+			t.Fatalf("round trip of %s=%s", want, have.Level)
+		}
+	}
+
+	if _, err := Level(7).MarshalText(); err == nil {
+		t.Fatal("expected error marshaling invalid level")
+	}
+	var l Level
+	if err := json.Unmarshal([]byte(`"verbose"`), &l); err == nil {
+		t.Fatal("expected error unmarshaling invalid level")
+	}
+}
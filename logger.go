@@ -19,6 +19,10 @@ import "context"
 
 // Logger provides a simple yet powerful logging abstraction.
 type Logger interface {
+	// Trace logging with key-value pairs. This is for very chatty logs, like
+	// per-packet or per-iteration details, which would drown out Debug logging.
+	Trace(msg string, keyValuePairs ...interface{})
+
 	// Debug logging with key-value pairs. Don't be shy, use it.
 	Debug(msg string, keyValuePairs ...interface{})
 
@@ -30,6 +34,13 @@ type Logger interface {
 	// situations, you make this easy through histograms, thresholds, etc.
 	Info(msg string, keyValuePairs ...interface{})
 
+	// Warn logging with key-value pairs. This is for conditions which are not
+	// yet impacting application stability but are likely to require attention
+	// if they persist, like degraded dependencies or retried operations. As
+	// with Info, it is highly recommended you attach a Metric to these types of
+	// messages.
+	Warn(msg string, keyValuePairs ...interface{})
+
 	// Error logging with key-value pairs. Use this when application state and
 	// stability are at risk. These types of conditions are actionable and often
 	// alerted on. It is very strongly encouraged to add a Metric to each of
@@ -38,6 +49,13 @@ type Logger interface {
 	// metrics backend.
 	Error(msg string, err error, keyValuePairs ...interface{})
 
+	// Fatal logging with key-value pairs at Error level, after which the
+	// process is terminated. Implementations must flush their sinks and then
+	// call Exit(1), which runs the hooks registered with OnFatal before calling
+	// the configured exit function. Only use this in application entry points
+	// for conditions the application cannot possibly continue from.
+	Fatal(msg string, err error, keyValuePairs ...interface{})
+
 	// SetLevel provides the ability to set the desired logging level.
 	// This function can be used at runtime and must be safe for concurrent use.
 	//
@@ -59,10 +77,12 @@ type Logger interface {
 	Context(ctx context.Context) Logger
 
 	// Metric returns a new Logger which will emit a measurement for the
-	// provided Metric when the Log level is either Info or Error.
+	// provided Metric when the Log level is either Info, Warn or Error.
 	// **Note** that in the event the Logger is set to only output Error level
-	// messages, Info messages even though silenced from a logging perspective,
-	// will still emit their Metric measurements.
+	// messages, Info and Warn messages even though silenced from a logging
+	// perspective, will still emit their Metric measurements.
+	// If the Metric implements LeveledMetric, the level of the log line is
+	// passed along with the measurement.
 	Metric(m Metric) Logger
 
 	// Clone returns a new Logger based on the original implementation.
@@ -70,35 +90,239 @@ type Logger interface {
 }
 
 // KeyValuesToContext takes provided Context, retrieves the already stored
-// key-value pairs from it, appends the in this function provided key-value
+// key-value pairs from it, merges the in this function provided key-value
 // pairs and stores the result in the returned Context.
+// Key-value pairs are merged using last-wins semantics as implemented by
+// MergeKeyValues: if a middleware adds a key already stored by an earlier
+// middleware, the later value replaces the earlier one in place, so the
+// Context never holds duplicate keys.
+// The stored key-value pairs are never modified once added to a Context, so
+// slices returned by KeyValuesFromContext remain a stable snapshot, even when
+// deriving new Contexts from the same parent later on.
 func KeyValuesToContext(ctx context.Context, keyValuePairs ...interface{}) context.Context {
 	if len(keyValuePairs) == 0 {
 		return ctx
 	}
-	if len(keyValuePairs)%2 != 0 {
-		keyValuePairs = append(keyValuePairs, "(MISSING)")
-	}
-	args := KeyValuesFromContext(ctx)
-	args = append(args, keyValuePairs...)
+	args := MergeKeyValues(KeyValuesFromContext(ctx), keyValuePairs...)
 	return context.WithValue(ctx, ctxKVP, args)
 }
 
+// MergeKeyValues returns a new slice holding the base key-value pairs merged
+// with the provided key-value pairs using last-wins semantics. If a provided
+// key equals a key found in base, the value in base is replaced at its original
+// position. Other key-value pairs are appended in order. Only string keys are
+// matched, key-value pairs with other key types are always appended. An odd
+// number of provided key-value pairs is completed with "(MISSING)".
+// The base slice is never modified.
+func MergeKeyValues(base []interface{}, keyValuePairs ...interface{}) []interface{} {
+	args := make([]interface{}, 0, len(base)+len(keyValuePairs)+len(keyValuePairs)%2)
+	args = append(args, base...)
+	for i := 0; i < len(keyValuePairs); i += 2 {
+		var value interface{} = "(MISSING)"
+		if i+1 < len(keyValuePairs) {
+			value = keyValuePairs[i+1]
+		}
+		if k, ok := keyValuePairs[i].(string); ok {
+			replaced := false
+			for j := 0; j < len(args); j += 2 {
+				if ak, ok := args[j].(string); ok && ak == k && j+1 < len(args) {
+					args[j+1] = value
+					replaced = true
+				}
+			}
+			if replaced {
+				continue
+			}
+		}
+		args = append(args, keyValuePairs[i], value)
+	}
+	return args
+}
+
 // KeyValuesFromContext retrieves key-value pairs that might be stored in the
 // provided Context. Logging implementations must use this function to retrieve
 // the key-value pairs they need to include if a Context object was attached to
-// them.
+// them. The returned slice must not be modified.
 func KeyValuesFromContext(ctx context.Context) (keyValuePairs []interface{}) {
 	keyValuePairs, _ = ctx.Value(ctxKVP).([]interface{})
 	return
 }
 
+// ClearKeyValues returns a Context that copies the provided Context but
+// removes the stored key-value pairs and providers, e.g. to prevent a
+// subsystem from inheriting the attributes of the caller.
+func ClearKeyValues(ctx context.Context) context.Context {
+	if KeyValuesFromContext(ctx) == nil && KeyValuesProvidersFromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(context.WithValue(ctx, ctxKVP, nil), ctxProviders, nil)
+}
+
+// RemoveKeys returns a Context that copies the provided Context but removes
+// the key-value pairs with the provided keys, e.g. to drop a verbose payload
+// before passing the Context to a subsystem. Key-value pairs with these keys
+// returned by the stored providers are removed as well.
+func RemoveKeys(ctx context.Context, keys ...string) context.Context {
+	if len(keys) == 0 {
+		return ctx
+	}
+	remove := func(keyValuePairs []interface{}) []interface{} {
+		args := make([]interface{}, 0, len(keyValuePairs))
+		for i := 0; i < len(keyValuePairs); i += 2 {
+			if k, ok := keyValuePairs[i].(string); ok && hasKey(keys, k) {
+				continue
+			}
+			args = append(args, keyValuePairs[i])
+			if i+1 < len(keyValuePairs) {
+				args = append(args, keyValuePairs[i+1])
+			}
+		}
+		return args
+	}
+
+	if kvs := KeyValuesFromContext(ctx); len(kvs) > 0 {
+		ctx = context.WithValue(ctx, ctxKVP, remove(kvs))
+	}
+	if providers := KeyValuesProvidersFromContext(ctx); len(providers) > 0 {
+		ctx = context.WithValue(ctx, ctxProviders, []KeyValuesProvider{func(ctx context.Context) []interface{} {
+			var kvs []interface{}
+			for _, provider := range providers {
+				kvs = MergeKeyValues(kvs, provider(ctx)...)
+			}
+			return remove(kvs)
+		}})
+	}
+	return ctx
+}
+
+// hasKey reports whether keys holds the key.
+func hasKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyValuesProvider returns key-value pairs derived from the provided Context.
+// Providers allow expensive lookups, like resolving the name of a tenant, to
+// only run for log lines actually emitted.
+type KeyValuesProvider func(ctx context.Context) []interface{}
+
+// KeyValuesProviderToContext returns a Context holding the provider in
+// addition to the providers already stored. Providers are not invoked until a
+// Logger emits a log line, using ResolveKeyValuesFromContext.
+func KeyValuesProviderToContext(ctx context.Context, provider KeyValuesProvider) context.Context {
+	if provider == nil {
+		return ctx
+	}
+	existing := KeyValuesProvidersFromContext(ctx)
+	providers := make([]KeyValuesProvider, 0, len(existing)+1)
+	providers = append(providers, existing...)
+	return context.WithValue(ctx, ctxProviders, append(providers, provider))
+}
+
+// KeyValuesProvidersFromContext returns the providers stored in the Context.
+// The returned slice must not be modified.
+func KeyValuesProvidersFromContext(ctx context.Context) []KeyValuesProvider {
+	providers, _ := ctx.Value(ctxProviders).([]KeyValuesProvider)
+	return providers
+}
+
+// ResolveKeyValuesFromContext returns the key-value pairs stored in the
+// Context merged with the key-value pairs returned by its providers, invoked
+// in the order they were added, using the last-wins semantics of
+// MergeKeyValues. Logging implementations supporting providers must only call
+// it when emitting a log line. Without providers, it returns the result of
+// KeyValuesFromContext, which must not be modified.
+func ResolveKeyValuesFromContext(ctx context.Context) []interface{} {
+	keyValuePairs := KeyValuesFromContext(ctx)
+	for _, provider := range KeyValuesProvidersFromContext(ctx) {
+		if kvs := provider(ctx); len(kvs) > 0 {
+			keyValuePairs = MergeKeyValues(keyValuePairs, kvs...)
+		}
+	}
+	return keyValuePairs
+}
+
 // RemoveKeyValuesFromContext returns a Context that copies the provided Context
-// but removes the key-value pairs that were stored.
+// but removes the key-value pairs that were stored. Use ClearKeyValues to also
+// remove the stored providers.
 func RemoveKeyValuesFromContext(ctx context.Context) context.Context {
 	return context.WithValue(ctx, ctxKVP, nil)
 }
 
+// WithLogger returns a Context holding the provided Logger, e.g. a request
+// scoped Logger decorated with a request ID, allowing it to travel through the
+// call stack without explicit parameters. Retrieve it using LoggerFromContext.
+func WithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, ctxLogger, l)
+}
+
+// LoggerFromContext returns the Logger stored in the Context using WithLogger.
+// If none is found, the global Logger is returned, which is a NoopLogger
+// unless set using SetGlobalLogger. The key-value pairs found in the Context
+// are not added, use the Context method of the returned Logger for that.
+func LoggerFromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxLogger).(Logger); ok && l != nil {
+			return l
+		}
+	}
+	return GlobalLogger()
+}
+
+// NoMetric is a sentinel key which can be added to the key-value pairs of an
+// individual Info, Warn or Error call to skip the measurement of an attached Metric
+// for that call only, e.g.:
+//
+//	logger.Info("cache hit", telemetry.NoMetric, true, "key", key)
+//
+// The value belonging to the key is ignored. Logger implementations must remove
+// the sentinel key and its value from the key-value pairs before emitting, so
+// it never shows up in log output. See ExtractNoMetric.
+var NoMetric tNoMetric
+
+// ExtractNoMetric reports whether the provided key-value pairs hold the
+// NoMetric sentinel key. If found, a copy of the key-value pairs without the
+// sentinel key and its value is returned, otherwise the key-value pairs are
+// returned as is.
+func ExtractNoMetric(keyValuePairs []interface{}) ([]interface{}, bool) {
+	idx := -1
+	for i := 0; i < len(keyValuePairs); i += 2 {
+		if _, ok := keyValuePairs[i].(tNoMetric); ok {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return keyValuePairs, false
+	}
+
+	kvs := make([]interface{}, 0, len(keyValuePairs))
+	for i := 0; i < len(keyValuePairs); i += 2 {
+		if _, ok := keyValuePairs[i].(tNoMetric); ok {
+			continue
+		}
+		kvs = append(kvs, keyValuePairs[i])
+		if i+1 < len(keyValuePairs) {
+			kvs = append(kvs, keyValuePairs[i+1])
+		}
+	}
+	return kvs, true
+}
+
+type tNoMetric struct{}
+
 type tCtxKVP string
 
 var ctxKVP tCtxKVP
+
+type tCtxProviders struct{}
+
+var ctxProviders tCtxProviders
+
+type tCtxLogger struct{}
+
+var ctxLogger tCtxLogger
@@ -46,3 +46,158 @@ func TestRemoveFromContext(t *testing.T) {
 		t.Errorf("want: %+v\nhave: %+v\n", want, have)
 	}
 }
+
+func TestContextSnapshot(t *testing.T) {
+	ctx := KeyValuesToContext(context.Background(), "key1", "val1", "key2", "val2", "key3")
+	ctx = KeyValuesToContext(ctx, "key4", "val4")
+
+	want := []interface{}{"key1", "val1", "key2", "val2", "key3", "(MISSING)", "key4", "val4", "key5", "val5"}
+	ctx1 := KeyValuesToContext(ctx, "key5", "val5")
+	have := KeyValuesFromContext(ctx1)
+
+	// deriving a new Context from the same parent must not alter the
+	// key-value pairs already handed out.
+	_ = KeyValuesToContext(ctx, "key6", "val6")
+
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %+v\nhave: %+v\n", want, have)
+	}
+}
+
+func TestExtractNoMetric(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []interface{}
+		want []interface{}
+		skip bool
+	}{
+		{"empty", nil, nil, false},
+		{"absent", []interface{}{"key1", "val1"}, []interface{}{"key1", "val1"}, false},
+		{"first", []interface{}{NoMetric, true, "key1", "val1"}, []interface{}{"key1", "val1"}, true},
+		{"last", []interface{}{"key1", "val1", NoMetric, true}, []interface{}{"key1", "val1"}, true},
+		{"dangling", []interface{}{"key1", "val1", NoMetric}, []interface{}{"key1", "val1"}, true},
+		{"as-value", []interface{}{"key1", NoMetric}, []interface{}{"key1", NoMetric}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			have, skip := ExtractNoMetric(tt.in)
+			if skip != tt.skip {
+				t.Fatalf("ExtractNoMetric()=%t, want: %t", skip, tt.skip)
+			}
+			if !reflect.DeepEqual(tt.want, have) {
+				t.Errorf("want: %+v\nhave: %+v\n", tt.want, have)
+			}
+		})
+	}
+}
+
+func TestContextOverride(t *testing.T) {
+	// outer middleware
+	ctx := KeyValuesToContext(context.Background(), "request_id", "outer", "tenant", "a")
+	// inner middleware overriding the request_id and adding a user
+	ctx = KeyValuesToContext(ctx, "user", "bob", "request_id", "inner")
+
+	want := []interface{}{"request_id", "inner", "tenant", "a", "user", "bob"}
+	have := KeyValuesFromContext(ctx)
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %+v\nhave: %+v\n", want, have)
+	}
+
+	// a sibling Context derived from the outer one is unaffected
+	outer := KeyValuesFromContext(KeyValuesToContext(context.Background(), "request_id", "outer"))
+	if !reflect.DeepEqual([]interface{}{"request_id", "outer"}, outer) {
+		t.Errorf("unexpected outer key-value pairs: %+v", outer)
+	}
+}
+
+func TestMergeKeyValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      []interface{}
+		overrides []interface{}
+		want      []interface{}
+	}{
+		{"empty", nil, nil, []interface{}{}},
+		{"append", []interface{}{"key1", "val1"}, []interface{}{"key2", "val2"}, []interface{}{"key1", "val1", "key2", "val2"}},
+		{"override", []interface{}{"key1", "val1", "key2", "val2"}, []interface{}{"key1", "new"}, []interface{}{"key1", "new", "key2", "val2"}},
+		{"repeated", nil, []interface{}{"key1", "val1", "key1", "val2"}, []interface{}{"key1", "val2"}},
+		{"missing", []interface{}{"key1", "val1"}, []interface{}{"key1"}, []interface{}{"key1", "(MISSING)"}},
+		{"non-string", []interface{}{1, "val1"}, []interface{}{1, "val2"}, []interface{}{1, "val1", 1, "val2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := append([]interface{}(nil), tt.base...)
+			have := MergeKeyValues(base, tt.overrides...)
+			if !reflect.DeepEqual(tt.want, have) {
+				t.Errorf("want: %+v\nhave: %+v\n", tt.want, have)
+			}
+			if !reflect.DeepEqual(tt.base, base) {
+				t.Errorf("base modified: %+v", base)
+			}
+		})
+	}
+}
+
+func TestResolveKeyValuesFromContext(t *testing.T) {
+	var calls int
+	provider := func(ctx context.Context) []interface{} {
+		calls++
+		return []interface{}{"tenant", "acme", "key1", "provided"}
+	}
+
+	ctx := KeyValuesToContext(context.Background(), "key1", "val1")
+	if have := ResolveKeyValuesFromContext(ctx); !reflect.DeepEqual([]interface{}{"key1", "val1"}, have) {
+		t.Fatalf("want: %v\nhave: %v", []interface{}{"key1", "val1"}, have)
+	}
+
+	ctx = KeyValuesProviderToContext(ctx, provider)
+	ctx = KeyValuesProviderToContext(ctx, nil)
+	ctx = KeyValuesProviderToContext(ctx, func(context.Context) []interface{} { return nil })
+	if calls != 0 {
+		t.Fatalf("expected provider not to be invoked, have %d calls", calls)
+	}
+	if n := len(KeyValuesProvidersFromContext(ctx)); n != 2 {
+		t.Fatalf("expected 2 providers, have %d", n)
+	}
+
+	want := []interface{}{"key1", "provided", "tenant", "acme"}
+	if have := ResolveKeyValuesFromContext(ctx); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+	if have := KeyValuesFromContext(ctx); !reflect.DeepEqual([]interface{}{"key1", "val1"}, have) {
+		t.Fatalf("expected stored key-value pairs to be unmodified, have %v", have)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call, have %d", calls)
+	}
+}
+
+func TestRemoveKeys(t *testing.T) {
+	ctx := KeyValuesToContext(context.Background(), "key1", "val1", "payload", "large", "key2", "val2")
+	ctx = KeyValuesProviderToContext(ctx, func(context.Context) []interface{} {
+		return []interface{}{"tenant", "acme", "payload", "provided"}
+	})
+
+	removed := RemoveKeys(ctx, "payload", "unknown")
+	want := []interface{}{"key1", "val1", "key2", "val2", "tenant", "acme"}
+	if have := ResolveKeyValuesFromContext(removed); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+	want = []interface{}{"key1", "val1", "payload", "provided", "key2", "val2", "tenant", "acme"}
+	if have := ResolveKeyValuesFromContext(ctx); !reflect.DeepEqual(want, have) {
+		t.Fatalf("expected the parent Context to be unmodified, have %v", have)
+	}
+	if RemoveKeys(ctx) != ctx {
+		t.Fatal("expected the Context to be returned without keys")
+	}
+
+	cleared := ClearKeyValues(ctx)
+	if have := ResolveKeyValuesFromContext(cleared); len(have) != 0 {
+		t.Fatalf("expected no key-value pairs, have %v", have)
+	}
+	if ClearKeyValues(cleared) != cleared {
+		t.Fatal("expected a cleared Context to be returned as is")
+	}
+}
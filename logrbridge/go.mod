@@ -0,0 +1,11 @@
+module github.com/basvanbeek/telemetry/logrbridge
+
+go 1.21
+
+require github.com/basvanbeek/telemetry v0.2.0
+
+require github.com/go-logr/logr v1.4.2
+
+// Work around for maintaining multiple go modules in the same repository
+// until go has better support for this. https://github.com/golang/go/issues/45713
+replace github.com/basvanbeek/telemetry => ../
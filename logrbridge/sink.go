@@ -0,0 +1,105 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logrbridge provides a logr.LogSink forwarding into a
+// telemetry.Logger, allowing libraries using logr, like client-go and
+// controller-runtime, to log through this package.
+package logrbridge
+
+import (
+	"github.com/go-logr/logr"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// NameKey is the key used for the logr logger name.
+const NameKey = "logger"
+
+// compile time check for compatibility with the logr.LogSink interface.
+var _ logr.LogSink = (*sink)(nil)
+
+type sink struct {
+	logger telemetry.Logger
+	name   string
+}
+
+// NewLogger returns a logr.Logger forwarding into the provided
+// telemetry.Logger. It is shorthand for logr.New(NewLogSink(l)).
+func NewLogger(l telemetry.Logger) logr.Logger {
+	return logr.New(NewLogSink(l))
+}
+
+// NewLogSink returns a logr.LogSink forwarding into the provided
+// telemetry.Logger.
+//
+// Info log lines are emitted at the level matching their verbosity, as
+// defined by telemetry.VerbosityToLevel: V(0) logs at Info, V(1) through V(4)
+// at Debug and V(5) and up at Trace. Names given to WithName are joined by a
+// slash and added as the NameKey key-value pair. Key-value pairs given to
+// WithValues are added to the telemetry.Logger using With.
+//
+// The caller tracked by logr is not forwarded, telemetry.Logger
+// implementations capturing caller information will report the LogSink.
+func NewLogSink(l telemetry.Logger) logr.LogSink {
+	return &sink{logger: l}
+}
+
+// Init implements logr.LogSink.
+func (s *sink) Init(logr.RuntimeInfo) {}
+
+// Enabled implements logr.LogSink.
+func (s *sink) Enabled(level int) bool {
+	return telemetry.VerbosityToLevel(level) <= s.logger.Level()
+}
+
+// Info implements logr.LogSink.
+func (s *sink) Info(level int, msg string, keysAndValues ...interface{}) {
+	keysAndValues = s.withName(keysAndValues)
+	switch telemetry.VerbosityToLevel(level) {
+	case telemetry.LevelInfo:
+		s.logger.Info(msg, keysAndValues...)
+	case telemetry.LevelDebug:
+		s.logger.Debug(msg, keysAndValues...)
+	default:
+		s.logger.Trace(msg, keysAndValues...)
+	}
+}
+
+// Error implements logr.LogSink.
+func (s *sink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.logger.Error(msg, err, s.withName(keysAndValues)...)
+}
+
+// WithValues implements logr.LogSink.
+func (s *sink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &sink{logger: s.logger.With(keysAndValues...), name: s.name}
+}
+
+// WithName implements logr.LogSink.
+func (s *sink) WithName(name string) logr.LogSink {
+	if s.name != "" {
+		name = s.name + "/" + name
+	}
+	return &sink{logger: s.logger, name: name}
+}
+
+// withName prepends the logger name, if set, to the key-value pairs.
+func (s *sink) withName(keysAndValues []interface{}) []interface{} {
+	if s.name == "" {
+		return keysAndValues
+	}
+	kvs := make([]interface{}, 0, len(keysAndValues)+2)
+	kvs = append(kvs, NameKey, s.name)
+	return append(kvs, keysAndValues...)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrbridge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestLogSink(t *testing.T) {
+	var out bytes.Buffer
+	logger := function.NewLogger(func(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+		_, _ = fmt.Fprintf(&out, "level=%v msg=%q", level, msg)
+		if err != nil {
+			_, _ = fmt.Fprintf(&out, " err=%v", err)
+		}
+		all := append(values.FromLogger, values.FromMethod...)
+		_, _ = fmt.Fprintf(&out, " %v", all)
+	}, 0)
+	logger.SetLevel(telemetry.LevelDebug)
+
+	l := NewLogger(logger).WithValues("component", "lib")
+
+	tests := []struct {
+		name     string
+		logfunc  func()
+		expected string
+	}{
+		{"info", func() { l.Info("text", "id", 1) },
+			`level=info msg="text" [component lib id 1]`},
+		{"v1", func() { l.V(1).Info("text") },
+			`level=debug msg="text" [component lib]`},
+		{"v5", func() { l.V(5).Info("text") }, ""},
+		{"named", func() { l.WithName("a").WithName("b").Info("text") },
+			`level=info msg="text" [component lib logger a/b]`},
+		{"error", func() { l.V(5).Error(errors.New("failed"), "text") },
+			`level=error msg="text" err=failed [component lib]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			tt.logfunc()
+			if out.String() != tt.expected {
+				t.Fatalf("expected %s to match %s", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	logger := function.NewLogger(func(telemetry.Level, string, error, function.Values, int) {}, 0)
+	s := NewLogSink(logger)
+
+	tests := []struct {
+		level     telemetry.Level
+		verbosity int
+		want      bool
+	}{
+		{telemetry.LevelInfo, 0, true},
+		{telemetry.LevelInfo, 1, false},
+		{telemetry.LevelDebug, 4, true},
+		{telemetry.LevelDebug, 5, false},
+		{telemetry.LevelTrace, 10, true},
+		{telemetry.LevelWarn, 0, false},
+	}
+
+	for _, tt := range tests {
+		logger.SetLevel(tt.level)
+		if have := s.Enabled(tt.verbosity); have != tt.want {
+			t.Errorf("Enabled(%d) at %s=%v, want %v", tt.verbosity, tt.level, have, tt.want)
+		}
+	}
+}
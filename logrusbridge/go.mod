@@ -0,0 +1,14 @@
+module github.com/basvanbeek/telemetry/logrusbridge
+
+go 1.21
+
+require (
+	github.com/basvanbeek/telemetry v0.2.0
+	github.com/sirupsen/logrus v1.9.3
+)
+
+require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+
+// Work around for maintaining multiple go modules in the same repository
+// until go has better support for this. https://github.com/golang/go/issues/45713
+replace github.com/basvanbeek/telemetry => ../
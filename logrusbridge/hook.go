@@ -0,0 +1,115 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrusbridge
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// compile time check for compatibility with the logrus.Hook interface.
+var _ logrus.Hook = (*hook)(nil)
+
+type hook struct {
+	logger telemetry.Logger
+}
+
+// NewHook returns a logrus.Hook forwarding entries into the provided
+// telemetry.Logger, allowing code bases using logrus to feed into the
+// configured sinks and scopes. To prevent entries from also being written by
+// logrus itself, set the output of the logrus Logger to io.Discard.
+//
+// Entry data is passed as key-value pairs sorted by key. For entries at error
+// level and above, an error found under logrus.ErrorKey is passed as the error
+// argument of Error. The Panic and Fatal levels are logged at Error level, as
+// logrus itself takes care of panicking or exiting after firing the hooks.
+//
+// Debug and Trace entries are only forwarded if enabled by the level of the
+// telemetry.Logger. The caller recorded by logrus is not forwarded, telemetry.Logger
+// implementations capturing caller information will report the Hook.
+func NewHook(l telemetry.Logger) logrus.Hook {
+	return &hook{logger: l}
+}
+
+// Levels implements logrus.Hook.
+func (h *hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (h *hook) Fire(e *logrus.Entry) error {
+	level := FromLogrusLevel(e.Level)
+	if level > h.logger.Level() && level > telemetry.LevelInfo {
+		// Info, Warn and Error are always forwarded as the telemetry.Logger
+		// records its Metric regardless of the log level.
+		return nil
+	}
+
+	logger := h.logger
+	if e.Context != nil && (len(telemetry.KeyValuesFromContext(e.Context)) > 0 ||
+		len(telemetry.KeyValuesProvidersFromContext(e.Context)) > 0) {
+		logger = logger.Context(e.Context)
+	}
+
+	var err error
+	keys := make([]string, 0, len(e.Data))
+	for k, v := range e.Data {
+		if k == logrus.ErrorKey && level == telemetry.LevelError {
+			if err, _ = v.(error); err != nil {
+				continue
+			}
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	keyValues := make([]interface{}, 0, 2*len(keys))
+	for _, k := range keys {
+		keyValues = append(keyValues, k, e.Data[k])
+	}
+
+	switch level {
+	case telemetry.LevelError:
+		logger.Error(e.Message, err, keyValues...)
+	case telemetry.LevelWarn:
+		logger.Warn(e.Message, keyValues...)
+	case telemetry.LevelInfo:
+		logger.Info(e.Message, keyValues...)
+	case telemetry.LevelDebug:
+		logger.Debug(e.Message, keyValues...)
+	default:
+		logger.Trace(e.Message, keyValues...)
+	}
+	return nil
+}
+
+// FromLogrusLevel maps a logrus.Level onto the corresponding telemetry.Level.
+// Levels above logrus.ErrorLevel map onto telemetry.LevelError.
+func FromLogrusLevel(level logrus.Level) telemetry.Level {
+	switch {
+	case level <= logrus.ErrorLevel:
+		return telemetry.LevelError
+	case level == logrus.WarnLevel:
+		return telemetry.LevelWarn
+	case level == logrus.InfoLevel:
+		return telemetry.LevelInfo
+	case level == logrus.DebugLevel:
+		return telemetry.LevelDebug
+	default:
+		return telemetry.LevelTrace
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrusbridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestHook(t *testing.T) {
+	var out bytes.Buffer
+	logger := function.NewLogger(func(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+		_, _ = fmt.Fprintf(&out, "level=%v msg=%q", level, msg)
+		if err != nil {
+			_, _ = fmt.Fprintf(&out, " err=%v", err)
+		}
+		all := append(values.FromContext, values.FromMethod...)
+		_, _ = fmt.Fprintf(&out, " %v", all)
+	}, 0)
+	logger.SetLevel(telemetry.LevelDebug)
+
+	lr := logrus.New()
+	lr.SetOutput(io.Discard)
+	lr.SetLevel(logrus.TraceLevel)
+	lr.AddHook(NewHook(logger))
+	e := lr.WithField("component", "lib")
+	ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value")
+
+	tests := []struct {
+		name     string
+		logfunc  func()
+		expected string
+	}{
+		{"trace", func() { e.Trace("text") }, ""},
+		{"debug", func() { e.WithContext(ctx).WithField("id", 1).Debug("text") },
+			`level=debug msg="text" [ctx value component lib id 1]`},
+		{"info", func() { e.Info("text") },
+			`level=info msg="text" [component lib]`},
+		{"warn", func() { e.Warn("text") },
+			`level=warn msg="text" [component lib]`},
+		{"error", func() { e.WithError(errors.New("failed")).Error("text") },
+			`level=error msg="text" err=failed [component lib]`},
+		{"error-non-error", func() { e.WithField(logrus.ErrorKey, "failed").Error("text") },
+			`level=error msg="text" [component lib error failed]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			tt.logfunc()
+			if out.String() != tt.expected {
+				t.Fatalf("expected %s to match %s", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestFromLogrusLevel(t *testing.T) {
+	for _, want := range []telemetry.Level{
+		telemetry.LevelError, telemetry.LevelWarn, telemetry.LevelInfo, telemetry.LevelDebug, telemetry.LevelTrace,
+	} {
+		if have := FromLogrusLevel(ToLogrusLevel(want)); have != want {
+			t.Errorf("FromLogrusLevel(ToLogrusLevel(%s))=%s", want, have)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logrusbridge provides a telemetry.Logger implementation emitting
+// through a logrus Entry, and a logrus Hook forwarding into a telemetry.Logger.
+package logrusbridge
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// NewLogger returns a telemetry.Logger emitting through the provided logrus
+// Entry. Use logrus.NewEntry to wrap a *logrus.Logger. The key-value pairs found
+// in Context, added to the Logger and passed to the logging method are added as
+// fields on top of the Entry data, with later pairs overriding earlier ones
+// using the same key. The error passed to Error and Fatal is added using
+// logrus.ErrorKey.
+//
+// The returned Logger is a function Logger and supports the same options.
+// Its level is initialized at telemetry.LevelInfo. Log lines enabled by the
+// Logger level are only written if also enabled by the logrus Logger.
+func NewLogger(e *logrus.Entry, opts ...function.Option) telemetry.Logger {
+	return function.NewLogger(Emit(e), 0, opts...)
+}
+
+// Emit returns a function.Emit writing log lines to the provided logrus Entry.
+// Caller information is determined by logrus itself if ReportCaller is enabled
+// on the logrus Logger.
+func Emit(e *logrus.Entry) function.Emit {
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+		lvl := ToLogrusLevel(level)
+		if !e.Logger.IsLevelEnabled(lvl) {
+			return
+		}
+
+		n := len(values.FromContext) + len(values.FromLogger) + len(values.FromMethod)
+		fields := make(logrus.Fields, (n+1)/2+1)
+		addFields(fields, values.FromContext)
+		addFields(fields, values.FromLogger)
+		addFields(fields, values.FromMethod)
+		if err != nil {
+			fields[logrus.ErrorKey] = err
+		}
+
+		e.WithFields(fields).Log(lvl, msg)
+	}
+}
+
+// ToLogrusLevel maps a telemetry.Level onto the corresponding logrus.Level.
+func ToLogrusLevel(level telemetry.Level) logrus.Level {
+	switch {
+	case level <= telemetry.LevelError:
+		return logrus.ErrorLevel
+	case level <= telemetry.LevelWarn:
+		return logrus.WarnLevel
+	case level <= telemetry.LevelInfo:
+		return logrus.InfoLevel
+	case level <= telemetry.LevelDebug:
+		return logrus.DebugLevel
+	default:
+		return logrus.TraceLevel
+	}
+}
+
+// addFields adds key-value pairs to the logrus fields. Non-string keys are
+// formatted using fmt.Sprint and a missing value is set to "(MISSING)".
+func addFields(fields logrus.Fields, keyValues []interface{}) {
+	for i := 0; i < len(keyValues); i += 2 {
+		k, ok := keyValues[i].(string)
+		if !ok {
+			k = fmt.Sprint(keyValues[i])
+		}
+		var v interface{} = "(MISSING)"
+		if i+1 < len(keyValues) {
+			v = keyValues[i+1]
+		}
+		fields[k] = v
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrusbridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    telemetry.Level
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"disabled", telemetry.LevelInfo, func(l telemetry.Logger) { l.Debug("text") }, ""},
+		{"trace", telemetry.LevelTrace, func(l telemetry.Logger) { l.Trace("text", "where", "there") },
+			`level=trace msg=text component=lib ctx=value lvl=info missing="(MISSING)" where=there` + "\n"},
+		{"debug", telemetry.LevelDebug, func(l telemetry.Logger) { l.Debug("text", 1, "1") },
+			`level=debug msg=text 1=1 component=lib ctx=value lvl=info missing="(MISSING)"` + "\n"},
+		{"info", telemetry.LevelInfo, func(l telemetry.Logger) { l.Info("text", "lvl", "override") },
+			`level=info msg=text component=lib ctx=value lvl=override missing="(MISSING)"` + "\n"},
+		{"warn", telemetry.LevelInfo, func(l telemetry.Logger) { l.Warn("text", "dangling") },
+			`level=warning msg=text component=lib ctx=value dangling="(MISSING)" lvl=info missing="(MISSING)"` + "\n"},
+		{"error", telemetry.LevelInfo, func(l telemetry.Logger) { l.Error("text", errors.New("failed")) },
+			`level=error msg=text component=lib ctx=value error=failed lvl=info missing="(MISSING)"` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			lr := logrus.New()
+			lr.SetOutput(&out)
+			lr.SetLevel(logrus.TraceLevel)
+			lr.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+			logger := NewLogger(lr.WithField("component", "lib"))
+			logger.SetLevel(tt.level)
+
+			ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value")
+			l := logger.Context(ctx).With("lvl", telemetry.LevelInfo).With("missing")
+
+			tt.logfunc(l)
+
+			if out.String() != tt.expected {
+				t.Fatalf("expected %q to match %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestLogrusLevel(t *testing.T) {
+	var out bytes.Buffer
+	lr := logrus.New()
+	lr.SetOutput(&out)
+	lr.SetLevel(logrus.WarnLevel)
+
+	NewLogger(logrus.NewEntry(lr)).Info("text")
+	if out.Len() != 0 {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
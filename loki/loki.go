@@ -0,0 +1,306 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package loki provides a sink pushing log lines to Grafana Loki using its
+// HTTP push API, grouping log lines into streams by labels derived from
+// key-value pairs.
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/basvanbeek/telemetry/batch"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// PushPath is the path of the Loki push API.
+const PushPath = "/loki/api/v1/push"
+
+type (
+	// Option implements a functional option type for the Loki sink.
+	Option func(*options)
+
+	// options holds the configuration of the Loki sink.
+	options struct {
+		labels     map[string]string
+		labelKeys  map[string]bool
+		levelLabel string
+		tenant     string
+		headers    map[string]string
+		gzip       bool
+		httpClient *http.Client
+		encoder    batch.Encoder
+		batchOpts  []batch.Option
+	}
+)
+
+// WithLabels sets static labels added to all streams, e.g. "job" or "env".
+func WithLabels(labels map[string]string) Option {
+	return func(o *options) {
+		for k, v := range labels {
+			o.labels[labelName(k)] = v
+		}
+	}
+}
+
+// WithLabelKeys sets the keys of the key-value pairs used as stream labels
+// instead of being part of the log line. Keep the number of distinct values
+// low, as each combination of label values creates a new stream.
+func WithLabelKeys(keys ...string) Option {
+	return func(o *options) {
+		for _, key := range keys {
+			o.labelKeys[key] = true
+		}
+	}
+}
+
+// WithLevelLabel sets the name of the label holding the log line level. By
+// default the level is only part of the log line.
+func WithLevelLabel(name string) Option {
+	return func(o *options) {
+		o.levelLabel = labelName(name)
+	}
+}
+
+// WithTenant sets the tenant ID sent in the X-Scope-OrgID header, as required
+// by multi-tenant Loki deployments.
+func WithTenant(tenant string) Option {
+	return func(o *options) {
+		o.tenant = tenant
+	}
+}
+
+// WithHeaders sets additional headers sent with each push request, e.g. to
+// authenticate with the Loki gateway.
+func WithHeaders(headers map[string]string) Option {
+	return func(o *options) {
+		o.headers = headers
+	}
+}
+
+// WithGzip enables gzip compression of push requests.
+func WithGzip() Option {
+	return func(o *options) {
+		o.gzip = true
+	}
+}
+
+// WithHTTPClient sets the http.Client used to push log lines.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// WithEncoder sets the encoder of the log lines. The default encodes log lines
+// as logfmt using batch.LogfmtEncoder.
+func WithEncoder(enc batch.Encoder) Option {
+	return func(o *options) {
+		o.encoder = enc
+	}
+}
+
+// WithBatchOptions sets the options of the underlying batch.Batcher, e.g. to
+// configure batch size, flush interval and retries.
+func WithBatchOptions(opts ...batch.Option) Option {
+	return func(o *options) {
+		o.batchOpts = append(o.batchOpts, opts...)
+	}
+}
+
+// New returns a batch.Batcher pushing log lines to the Loki instance at the
+// provided URL, e.g. "http://localhost:3100". Use its Emit method with
+// function.NewLoggerErr, its Flush method with function.WithFlush and Close it
+// on shutdown.
+//
+// Responses with status 429 and 5xx are retried by the batch.Batcher, other
+// failed responses drop the batch.
+func New(url string, opts ...Option) *batch.Batcher {
+	o := options{
+		labels:     make(map[string]string),
+		labelKeys:  make(map[string]bool),
+		httpClient: http.DefaultClient,
+		encoder:    batch.LogfmtEncoder(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	p := &pusher{url: strings.TrimSuffix(url, "/") + PushPath, opts: o}
+	return batch.New(p.push, o.batchOpts...)
+}
+
+// stream holds the log lines of a unique label set, in the push API format.
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// pusher sends batches to the push API.
+type pusher struct {
+	url  string
+	opts options
+}
+
+// push implements batch.Handler.
+func (p *pusher) push(ctx context.Context, records []batch.Record) error {
+	var (
+		streams []*stream
+		index   = make(map[string]*stream)
+	)
+	for _, r := range records {
+		labels, values := p.labels(r)
+		r.Values = values
+		line, err := p.opts.encoder(r)
+		if err != nil {
+			return batch.Permanent(err)
+		}
+
+		key := streamKey(labels)
+		s, ok := index[key]
+		if !ok {
+			s = &stream{Stream: labels}
+			index[key] = s
+			streams = append(streams, s)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(r.Time.UnixNano(), 10), string(line)})
+	}
+
+	body, err := json.Marshal(struct {
+		Streams []*stream `json:"streams"`
+	}{streams})
+	if err != nil {
+		return batch.Permanent(err)
+	}
+	return p.send(ctx, body)
+}
+
+// labels returns the stream labels of the record and the key-value pairs not
+// used as labels.
+func (p *pusher) labels(r batch.Record) (map[string]string, function.Values) {
+	labels := make(map[string]string, len(p.opts.labels)+1)
+	for k, v := range p.opts.labels {
+		labels[k] = v
+	}
+	if p.opts.levelLabel != "" {
+		labels[p.opts.levelLabel] = r.Level.String()
+	}
+	if len(p.opts.labelKeys) == 0 {
+		return labels, r.Values
+	}
+
+	filter := func(kvs []interface{}) []interface{} {
+		var rest []interface{}
+		for i := 0; i < len(kvs); i += 2 {
+			k, ok := kvs[i].(string)
+			if ok && p.opts.labelKeys[k] && i+1 < len(kvs) {
+				labels[labelName(k)] = fmt.Sprint(kvs[i+1])
+				continue
+			}
+			rest = append(rest, kvs[i])
+			if i+1 < len(kvs) {
+				rest = append(rest, kvs[i+1])
+			}
+		}
+		return rest
+	}
+	values := r.Values
+	values.FromContext = filter(values.FromContext)
+	values.FromLogger = filter(values.FromLogger)
+	values.FromMethod = filter(values.FromMethod)
+	return labels, values
+}
+
+func (p *pusher) send(ctx context.Context, body []byte) error {
+	if p.opts.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return batch.Permanent(err)
+		}
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return batch.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.opts.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if p.opts.tenant != "" {
+		req.Header.Set("X-Scope-OrgID", p.opts.tenant)
+	}
+	for k, v := range p.opts.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := p.opts.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case res.StatusCode == http.StatusTooManyRequests, res.StatusCode >= 500:
+		return fmt.Errorf("loki push failed: %s: %s", res.Status, bytes.TrimSpace(msg))
+	default:
+		return batch.Permanent(fmt.Errorf("loki push failed: %s: %s", res.Status, bytes.TrimSpace(msg)))
+	}
+}
+
+// streamKey returns a key uniquely identifying the label set.
+func streamKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte(0)
+		sb.WriteString(labels[k])
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+// labelName returns the name with characters not allowed in Loki label names
+// replaced by underscores.
+func labelName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || i > 0 && c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
@@ -0,0 +1,176 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loki
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/batch"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type pushRequest struct {
+	Streams []stream `json:"streams"`
+}
+
+type server struct {
+	*httptest.Server
+	mtx      sync.Mutex
+	status   int
+	requests []pushRequest
+	headers  []http.Header
+}
+
+func newServer() *server {
+	s := &server{status: http.StatusNoContent}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = zr
+		}
+		var req pushRequest
+		if r.URL.Path != PushPath || json.NewDecoder(body).Decode(&req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.requests = append(s.requests, req)
+		s.headers = append(s.headers, r.Header)
+		w.WriteHeader(s.status)
+	}))
+	return s
+}
+
+func TestLoki(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+
+	b := New(s.URL,
+		WithLabels(map[string]string{"job": "app"}),
+		WithLabelKeys("tenant"),
+		WithLevelLabel("level"),
+		WithTenant("team-a"),
+		WithGzip(),
+		WithEncoder(batch.LogfmtEncoder(emitter.WithTimeKey(""))),
+	)
+	logger := function.NewLoggerErr(b.Emit, 0)
+	logger.With("tenant", "acme").Info("first", "n", 1)
+	logger.With("tenant", "acme").Info("second")
+	logger.With("tenant", "other").Warn("third")
+	if err := b.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(s.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(s.requests))
+	}
+	if have := s.headers[0].Get("X-Scope-OrgID"); have != "team-a" {
+		t.Fatalf("expected %q to match %q", have, "team-a")
+	}
+
+	streams := s.requests[0].Streams
+	expected := []struct {
+		labels map[string]string
+		lines  []string
+	}{
+		{map[string]string{"job": "app", "level": "info", "tenant": "acme"}, []string{"level=info msg=first n=1", "level=info msg=second"}},
+		{map[string]string{"job": "app", "level": "warn", "tenant": "other"}, []string{"level=warn msg=third"}},
+	}
+	if len(streams) != len(expected) {
+		t.Fatalf("expected %d streams, got %d", len(expected), len(streams))
+	}
+	for i, e := range expected {
+		if !reflect.DeepEqual(streams[i].Stream, e.labels) {
+			t.Errorf("expected %v to match %v", streams[i].Stream, e.labels)
+		}
+		var lines []string
+		for _, v := range streams[i].Values {
+			if ns, err := strconv.ParseInt(v[0], 10, 64); err != nil || ns <= 0 {
+				t.Errorf("unexpected timestamp %q", v[0])
+			}
+			lines = append(lines, v[1])
+		}
+		if !reflect.DeepEqual(lines, e.lines) {
+			t.Errorf("expected %v to match %v", lines, e.lines)
+		}
+	}
+}
+
+func TestLokiStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		fails     bool
+		permanent bool
+	}{
+		{http.StatusNoContent, false, false},
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusServiceUnavailable, true, false},
+		{http.StatusBadRequest, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			s := newServer()
+			defer s.Close()
+			s.status = tt.status
+
+			p := &pusher{url: s.URL + PushPath, opts: options{httpClient: http.DefaultClient, encoder: batch.LogfmtEncoder()}}
+			err := p.push(context.Background(), []batch.Record{{Time: time.Now(), Level: telemetry.LevelInfo, Message: "hello"}})
+			if (err != nil) != tt.fails {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if batch.IsPermanent(err) != tt.permanent {
+				t.Fatalf("expected permanent to be %t for %v", tt.permanent, err)
+			}
+		})
+	}
+}
+
+func TestLabelName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"tenant", "tenant"},
+		{"k8s.namespace", "k8s_namespace"},
+		{"1st", "_st"},
+		{"", "_"},
+	}
+
+	for _, tt := range tests {
+		if have := labelName(tt.name); have != tt.expected {
+			t.Errorf("expected %s to match %s", have, tt.expected)
+		}
+	}
+}
@@ -29,7 +29,9 @@ const (
 	Milliseconds Unit = "ms"
 )
 
-// Metric collects numerical observations.
+// Metric collects numerical observations. It is the common interface of the
+// Counter, Gauge and Histogram instruments, and remains the type accepted by
+// Logger.Metric.
 type Metric interface {
 	// Increment records a value of 1 for the current Metric.
 	// For Sums, this is equivalent to adding 1 to the current value.
@@ -64,6 +66,18 @@ type Metric interface {
 	With(labelValues ...LabelValue) Metric
 }
 
+// LeveledMetric is an optional interface a Metric can implement to receive the
+// logging level of the log line that triggered a measurement. Logger
+// implementations recording a Metric on Info, Warn and Error calls use it when
+// available, allowing label-aware metrics to split counts by level. This makes
+// it possible to compute and alert on the error-log rate specifically.
+type LeveledMetric interface {
+	// RecordLeveled makes an observation of the provided value for the given
+	// Metric, annotated with the logging level of the log line that triggered
+	// it. Context is handled as with RecordContext.
+	RecordLeveled(ctx context.Context, value float64, level Level)
+}
+
 // DerivedMetric can be used to supply values that dynamically derive from internal
 // state, but are not updated based on any specific event. Their value will be calculated
 // based on a value func that executes when the metrics are exported.
@@ -151,8 +165,32 @@ type MetricOptions struct {
 	Unit Unit
 	// Labels holds the registered dimensions for the Metric.
 	Labels []Label
+	// ExponentialBuckets holds the exponential bucket configuration of a
+	// Distribution, if set.
+	ExponentialBuckets *ExponentialBuckets
+}
+
+// ExponentialBuckets configures a Distribution to use exponential buckets,
+// known as native histograms in Prometheus and exponential histograms in
+// OpenTelemetry. Bucket boundaries are powers of a base of 2^(2^-scale),
+// with the scale reduced automatically to cover the observed range with at
+// most MaxSize buckets, resulting in high resolution histograms without
+// configuring explicit bounds.
+type ExponentialBuckets struct {
+	// MaxSize holds the maximum number of buckets.
+	MaxSize int
+	// MaxScale holds the maximum scale, and thereby resolution, of the
+	// buckets.
+	MaxScale int
 }
 
+// Default exponential bucket configuration, matching the OpenTelemetry
+// defaults.
+const (
+	DefaultExponentialMaxSize  = 160
+	DefaultExponentialMaxScale = 20
+)
+
 // WithLabels provides a configuration MetricOption for a new Metric, providing
 // the required dimensions for data collection of that Metric.
 func WithLabels(labels ...Label) MetricOption {
@@ -177,3 +215,48 @@ func WithEnabled(enabled func() bool) MetricOption {
 		opts.EnabledCondition = enabled
 	}
 }
+
+// WithExponentialBuckets provides a configuration MetricOption for a new
+// Distribution, using exponential buckets with the provided maximum number of
+// buckets and maximum scale. Values of zero or less select
+// DefaultExponentialMaxSize and DefaultExponentialMaxScale. If explicit bounds
+// are also provided, sinks supporting both maintain both.
+func WithExponentialBuckets(maxSize, maxScale int) MetricOption {
+	if maxSize <= 0 {
+		maxSize = DefaultExponentialMaxSize
+	}
+	if maxScale <= 0 {
+		maxScale = DefaultExponentialMaxScale
+	}
+	return func(opts *MetricOptions) {
+		opts.ExponentialBuckets = &ExponentialBuckets{MaxSize: maxSize, MaxScale: maxScale}
+	}
+}
+
+// LinearBounds returns count explicit Distribution bounds, the lowest being
+// start and each next one being width higher.
+func LinearBounds(start, width float64, count int) []float64 {
+	if count < 1 {
+		return nil
+	}
+	bounds := make([]float64, count)
+	for i := range bounds {
+		bounds[i] = start + float64(i)*width
+	}
+	return bounds
+}
+
+// ExponentialBounds returns count explicit Distribution bounds, the lowest
+// being start and each next one being factor times the previous one. It
+// returns nil if start is not positive or factor is not greater than 1.
+func ExponentialBounds(start, factor float64, count int) []float64 {
+	if count < 1 || start <= 0 || factor <= 1 {
+		return nil
+	}
+	bounds := make([]float64, count)
+	for i := range bounds {
+		bounds[i] = start
+		start *= factor
+	}
+	return bounds
+}
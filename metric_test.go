@@ -16,6 +16,7 @@
 package telemetry_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/basvanbeek/telemetry"
@@ -70,3 +71,32 @@ func TestMetricOptions(t *testing.T) {
 		t.Errorf("[1] unexpected label value: want: %s, have: %s", label2, ms.options.Labels[1].(label))
 	}
 }
+
+func TestBounds(t *testing.T) {
+	tests := []struct {
+		name string
+		have []float64
+		want []float64
+	}{
+		{"linear", telemetry.LinearBounds(1, 2, 3), []float64{1, 3, 5}},
+		{"linear empty", telemetry.LinearBounds(1, 2, 0), nil},
+		{"exponential", telemetry.ExponentialBounds(0.5, 2, 4), []float64{0.5, 1, 2, 4}},
+		{"exponential invalid factor", telemetry.ExponentialBounds(1, 1, 4), nil},
+		{"exponential invalid start", telemetry.ExponentialBounds(0, 2, 4), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.want, tt.have) {
+				t.Fatalf("want: %v\nhave: %v", tt.want, tt.have)
+			}
+		})
+	}
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	ms := newMetricSink(telemetry.WithExponentialBuckets(0, 8))
+	want := &telemetry.ExponentialBuckets{MaxSize: telemetry.DefaultExponentialMaxSize, MaxScale: 8}
+	if have := ms.options.ExponentialBuckets; !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
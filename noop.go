@@ -15,23 +15,36 @@
 
 package telemetry
 
-import "context"
+import (
+	"context"
+	"sync/atomic"
+)
 
-// NoopLogger returns a no-op logger.
+// compile time check for compatibility with the Logger interface.
+var _ Logger = (*noopLogger)(nil)
+
+// NoopLogger returns a no-op logger, discarding all log lines. It is meant for
+// tests and optional dependencies. Its logging methods and With, Context and
+// Metric return immediately without allocating, with the derived Loggers
+// sharing the level of the NoopLogger. Fatal still terminates the process
+// through Exit.
 func NoopLogger() Logger {
-	return &noopLogger{level: LevelNone}
+	return &noopLogger{level: int32(LevelNone)}
 }
 
 type noopLogger struct {
-	level Level
+	level int32
 }
 
+func (*noopLogger) Trace(string, ...interface{})        {}
 func (*noopLogger) Debug(string, ...interface{})        {}
 func (*noopLogger) Info(string, ...interface{})         {}
+func (*noopLogger) Warn(string, ...interface{})         {}
 func (*noopLogger) Error(string, error, ...interface{}) {}
-func (n *noopLogger) SetLevel(l Level)                  { n.level = l }
-func (n *noopLogger) Level() Level                      { return n.level }
+func (*noopLogger) Fatal(string, error, ...interface{}) { Exit(1) }
+func (n *noopLogger) SetLevel(l Level)                  { atomic.StoreInt32(&n.level, int32(l)) }
+func (n *noopLogger) Level() Level                      { return Level(atomic.LoadInt32(&n.level)) }
 func (n *noopLogger) With(...interface{}) Logger        { return n }
 func (n *noopLogger) Context(context.Context) Logger    { return n }
 func (n *noopLogger) Metric(Metric) Logger              { return n }
-func (*noopLogger) Clone() Logger                       { return NoopLogger() }
+func (n *noopLogger) Clone() Logger                     { return &noopLogger{level: atomic.LoadInt32(&n.level)} }
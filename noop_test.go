@@ -28,8 +28,10 @@ func TestNoopLogger(t *testing.T) {
 		metricCount float64
 	}{
 		{"info-", func(l Logger) { l.Info("text", "where", "there") }, 1},
+		{"warn-", func(l Logger) { l.Warn("text", "where", "there") }, 1},
 		{"error", func(l Logger) { l.Error("text", errors.New("error"), "where", "there") }, 1},
 		{"debug", func(l Logger) { l.Debug("text", "where", "there") }, 0},
+		{"trace", func(l Logger) { l.Trace("text", "where", "there") }, 0},
 	}
 
 	for _, tt := range tests {
@@ -52,6 +54,31 @@ func TestNoopLogger(t *testing.T) {
 	}
 }
 
+func TestNoopLoggerAllocs(t *testing.T) {
+	var (
+		l   = NoopLogger()
+		ctx = context.Background()
+		m   = &mockMetric{}
+		err = errors.New("error")
+	)
+	allocs := testing.AllocsPerRun(100, func() {
+		nl := l.With("key", "value").Context(ctx).Metric(m)
+		nl.Info("text", "where", "there")
+		nl.Error("text", err, "where", "there")
+		nl.Debug("text")
+	})
+	if allocs != 0 {
+		t.Fatalf("allocs=%v, want 0", allocs)
+	}
+
+	l.SetLevel(LevelWarn)
+	c := l.Clone()
+	c.SetLevel(LevelTrace)
+	if l.Level() != LevelWarn || c.Level() != LevelTrace {
+		t.Fatalf("l.Level()=%v, c.Level()=%v, want LevelWarn, LevelTrace", l.Level(), c.Level())
+	}
+}
+
 type mockMetric struct {
 	Metric
 	count float64
@@ -0,0 +1,107 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelbridge
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// BaggageKeyValues returns a telemetry.KeyValuesProvider returning the W3C
+// Baggage members found in Context as key-value pairs, using the member keys
+// as keys. Only members with keys in the provided allowlist are included, in
+// allowlist order, so arbitrary baggage set by upstream services can not
+// introduce unbounded keys. Members absent from the baggage are skipped.
+func BaggageKeyValues(keys ...string) telemetry.KeyValuesProvider {
+	keys = append([]string(nil), keys...)
+	return func(ctx context.Context) []interface{} {
+		b := baggage.FromContext(ctx)
+		if b.Len() == 0 {
+			return nil
+		}
+		var keyValues []interface{}
+		for _, key := range keys {
+			if m := b.Member(key); m.Key() != "" {
+				keyValues = append(keyValues, key, m.Value())
+			}
+		}
+		return keyValues
+	}
+}
+
+// WithBaggage returns a function.Option adding the allowlisted W3C Baggage
+// members of the Logger Context to Values.FromContext of each emitted log
+// line. See BaggageKeyValues.
+func WithBaggage(keys ...string) function.Option {
+	return function.WithKeyValuesProvider(BaggageKeyValues(keys...))
+}
+
+// BaggageLabels maps allowlisted W3C Baggage members onto metric Labels.
+type BaggageLabels struct {
+	sink   telemetry.MetricSink
+	keys   []string
+	labels []telemetry.Label
+}
+
+// NewBaggageLabels returns BaggageLabels creating a Label on the provided
+// MetricSink for each key in the allowlist, using the member key as Label name.
+func NewBaggageLabels(sink telemetry.MetricSink, keys ...string) *BaggageLabels {
+	b := &BaggageLabels{
+		sink:   sink,
+		keys:   append([]string(nil), keys...),
+		labels: make([]telemetry.Label, 0, len(keys)),
+	}
+	for _, key := range keys {
+		b.labels = append(b.labels, sink.NewLabel(key))
+	}
+	return b
+}
+
+// Labels returns the Labels in allowlist order, to be registered on a Metric
+// using telemetry.WithLabels.
+func (b *BaggageLabels) Labels() []telemetry.Label {
+	return b.labels
+}
+
+// LabelValues returns Upsert operations for the allowlisted W3C Baggage
+// members found in Context. Members absent from the baggage are skipped.
+func (b *BaggageLabels) LabelValues(ctx context.Context) []telemetry.LabelValue {
+	bag := baggage.FromContext(ctx)
+	if bag.Len() == 0 {
+		return nil
+	}
+	var values []telemetry.LabelValue
+	for i, key := range b.keys {
+		if m := bag.Member(key); m.Key() != "" {
+			values = append(values, b.labels[i].Upsert(m.Value()))
+		}
+	}
+	return values
+}
+
+// ContextWithLabels returns a Context holding the LabelValues of the
+// allowlisted W3C Baggage members found in Context, so they are picked up by
+// Metric.RecordContext.
+func (b *BaggageLabels) ContextWithLabels(ctx context.Context) (context.Context, error) {
+	values := b.LabelValues(ctx)
+	if len(values) == 0 {
+		return ctx, nil
+	}
+	return b.sink.ContextWithLabels(ctx, values...)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelbridge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type labelOp struct {
+	name, value string
+}
+
+type mockLabel string
+
+func (l mockLabel) Insert(value string) telemetry.LabelValue { return labelOp{string(l), value} }
+func (l mockLabel) Update(value string) telemetry.LabelValue { return labelOp{string(l), value} }
+func (l mockLabel) Upsert(value string) telemetry.LabelValue { return labelOp{string(l), value} }
+func (l mockLabel) Delete() telemetry.LabelValue             { return labelOp{name: string(l)} }
+
+type ctxLabels struct{}
+
+type mockSink struct {
+	telemetry.MetricSink
+}
+
+func (mockSink) NewLabel(name string) telemetry.Label { return mockLabel(name) }
+
+func (mockSink) ContextWithLabels(ctx context.Context, values ...telemetry.LabelValue) (context.Context, error) {
+	return context.WithValue(ctx, ctxLabels{}, values), nil
+}
+
+func baggageContext(t *testing.T) context.Context {
+	t.Helper()
+	b, err := baggage.Parse("tenant=acme,region=eu-west,user=1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return baggage.ContextWithBaggage(context.Background(), b)
+}
+
+func TestWithBaggage(t *testing.T) {
+	var have [][]interface{}
+	logger := function.NewLogger(func(_ telemetry.Level, _ string, _ error, values function.Values, _ int) {
+		have = append(have, values.FromContext)
+	}, 0, WithBaggage("region", "tenant", "plan"))
+
+	logger.Context(baggageContext(t)).Info("with baggage")
+	logger.Info("without baggage")
+
+	want := [][]interface{}{{"region", "eu-west", "tenant", "acme"}, nil}
+	if !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestBaggageLabels(t *testing.T) {
+	b := NewBaggageLabels(mockSink{}, "tenant", "plan")
+
+	if want, have := []telemetry.Label{mockLabel("tenant"), mockLabel("plan")}, b.Labels(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+
+	ctx, err := b.ContextWithLabels(baggageContext(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []telemetry.LabelValue{labelOp{"tenant", "acme"}}
+	if have := ctx.Value(ctxLabels{}); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+
+	ctx, err = b.ContextWithLabels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if have := ctx.Value(ctxLabels{}); have != nil {
+		t.Fatalf("expected no label values, have %v", have)
+	}
+}
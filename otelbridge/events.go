@@ -0,0 +1,118 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelbridge
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Keys of the span event attributes not taken from the log line key-values.
+const (
+	SeverityKey = "log.severity"
+	ErrorKey    = "error"
+)
+
+// WithSpanEvents returns a function.Option mirroring log lines as events on the
+// span found in the Logger Context, if that span is recording. Only log lines
+// at the provided level or more severe are mirrored, e.g. telemetry.LevelError
+// for errors only or telemetry.LevelInfo to include informational messages:
+//
+//	logger := function.NewLogger(emit, 0,
+//		otelbridge.WithSpanEvents(telemetry.LevelError))
+//	logger.Context(ctx).Error("query failed", err, "table", "users")
+//
+// The log message is used as event name. The key-value pairs of the log line
+// are added as event attributes together with the log level and error, if
+// any. This makes log lines readable in the trace view without instrumenting
+// the code twice.
+func WithSpanEvents(level telemetry.Level) function.Option {
+	return function.WithHook(func(ctx context.Context, l telemetry.Level, msg string, err error, values function.Values) {
+		if l > level {
+			return
+		}
+		span := trace.SpanFromContext(ctx)
+		if !span.IsRecording() {
+			return
+		}
+
+		attrs := make([]attribute.KeyValue, 0, 2+
+			(len(values.FromContext)+len(values.FromLogger)+len(values.FromMethod)+1)/2)
+		attrs = append(attrs, attribute.String(SeverityKey, l.String()))
+		if err != nil {
+			attrs = append(attrs, attribute.String(ErrorKey, err.Error()))
+		}
+		attrs = appendAttributes(attrs, values.FromContext)
+		attrs = appendAttributes(attrs, values.FromLogger)
+		attrs = appendAttributes(attrs, values.FromMethod)
+
+		span.AddEvent(msg, trace.WithAttributes(attrs...))
+	})
+}
+
+// appendAttributes appends the key-value pairs as span attributes, keeping the
+// type of common value types. A dangling key is added with an empty value.
+func appendAttributes(attrs []attribute.KeyValue, keyValues []interface{}) []attribute.KeyValue {
+	for i := 0; i < len(keyValues); i += 2 {
+		key, ok := keyValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyValues[i])
+		}
+		if i+1 == len(keyValues) {
+			attrs = append(attrs, attribute.String(key, ""))
+			break
+		}
+		attrs = append(attrs, toAttribute(key, keyValues[i+1]))
+	}
+	return attrs
+}
+
+// toAttribute converts a log line value into a span attribute.
+func toAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int32:
+		return attribute.Int64(key, int64(v))
+	case int64:
+		return attribute.Int64(key, v)
+	case uint32:
+		return attribute.Int64(key, int64(v))
+	case float32:
+		return attribute.Float64(key, float64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	case error:
+		return attribute.String(key, v.Error())
+	case fmt.Stringer:
+		return attribute.String(key, v.String())
+	case nil:
+		return attribute.String(key, "<nil>")
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
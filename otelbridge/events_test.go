@@ -0,0 +1,95 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelbridge
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type event struct {
+	name  string
+	attrs []attribute.KeyValue
+}
+
+// recordingSpan captures the events added to it.
+type recordingSpan struct {
+	trace.Span
+	recording bool
+	events    []event
+}
+
+func (s *recordingSpan) IsRecording() bool { return s.recording }
+
+func (s *recordingSpan) AddEvent(name string, opts ...trace.EventOption) {
+	cfg := trace.NewEventConfig(opts...)
+	s.events = append(s.events, event{name: name, attrs: cfg.Attributes()})
+}
+
+func TestWithSpanEvents(t *testing.T) {
+	var (
+		emitted int
+		span    = &recordingSpan{Span: trace.SpanFromContext(spanContext(t)), recording: true}
+		ctx     = trace.ContextWithSpan(telemetry.KeyValuesToContext(spanContext(t), "request_id", 42), span)
+	)
+	logger := function.NewLogger(func(telemetry.Level, string, error, function.Values, int) {
+		emitted++
+	}, 0, WithSpanEvents(telemetry.LevelWarn))
+	logger.SetLevel(telemetry.LevelInfo)
+
+	l := logger.Context(ctx).With("table", "users")
+	l.Error("query failed", errors.New("timeout"), "rows", int64(3), "ratio", 0.5, "retry", true, "dangling")
+	l.Warn("slow query", 7)
+	l.Info("query done")
+	logger.Warn("no span")
+
+	want := []event{
+		{"query failed", []attribute.KeyValue{
+			attribute.String(SeverityKey, "error"),
+			attribute.String(ErrorKey, "timeout"),
+			attribute.Int("request_id", 42),
+			attribute.String("table", "users"),
+			attribute.Int64("rows", 3),
+			attribute.Float64("ratio", 0.5),
+			attribute.Bool("retry", true),
+			attribute.String("dangling", ""),
+		}},
+		{"slow query", []attribute.KeyValue{
+			attribute.String(SeverityKey, "warn"),
+			attribute.Int("request_id", 42),
+			attribute.String("table", "users"),
+			attribute.String("7", ""),
+		}},
+	}
+	if !reflect.DeepEqual(want, span.events) {
+		t.Fatalf("want: %v\nhave: %v", want, span.events)
+	}
+	if emitted != 4 {
+		t.Fatalf("expected 4 emitted log lines, have %d", emitted)
+	}
+
+	span.events, span.recording = nil, false
+	l.Error("not recording", nil)
+	if len(span.events) != 0 {
+		t.Fatalf("unexpected events: %v", span.events)
+	}
+}
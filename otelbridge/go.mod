@@ -0,0 +1,13 @@
+module github.com/basvanbeek/telemetry/otelbridge
+
+go 1.21
+
+require (
+	github.com/basvanbeek/telemetry v0.2.0
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+// Work around for maintaining multiple go modules in the same repository
+// until go has better support for this. https://github.com/golang/go/issues/45713
+replace github.com/basvanbeek/telemetry => ../
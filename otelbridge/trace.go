@@ -0,0 +1,77 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otelbridge correlates telemetry log lines and metrics with
+// OpenTelemetry traces and baggage found in Context.
+package otelbridge
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Keys of the trace correlation key-value pairs.
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// TraceKeyValues returns the trace_id, span_id and trace_flags key-value pairs
+// of the span found in Context, or nil if it holds no valid span context. The
+// identifiers are hex encoded as in the W3C Trace Context traceparent header.
+// It implements telemetry.KeyValuesProvider, so it can be stored in Context
+// using telemetry.KeyValuesProviderToContext.
+func TraceKeyValues(ctx context.Context) []interface{} {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []interface{}{
+		TraceIDKey, sc.TraceID().String(),
+		SpanIDKey, sc.SpanID().String(),
+		TraceFlagsKey, sc.TraceFlags().String(),
+	}
+}
+
+// ExemplarKeyValues returns the trace_id and span_id key-value pairs of the
+// span found in Context, or nil if it holds no sampled span context. It is
+// meant for attaching exemplars to histogram observations, linking them to
+// traces which are actually recorded by the tracing backend.
+func ExemplarKeyValues(ctx context.Context) []interface{} {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsSampled() {
+		return nil
+	}
+	return []interface{}{
+		TraceIDKey, sc.TraceID().String(),
+		SpanIDKey, sc.SpanID().String(),
+	}
+}
+
+// WithTraceContext returns a function.Option adding the TraceKeyValues of the
+// Logger Context to Values.FromContext of each emitted log line, so log lines
+// correlate with traces without changes at the call sites:
+//
+//	logger := function.NewLogger(emit, 0, otelbridge.WithTraceContext())
+//	logger.Context(ctx).Info("handled request")
+//
+// The span context is only looked up for log lines enabled by the Logger
+// level.
+func WithTraceContext() function.Option {
+	return function.WithKeyValuesProvider(TraceKeyValues)
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelbridge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func spanContext(t *testing.T) context.Context {
+	t.Helper()
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+func TestWithTraceContext(t *testing.T) {
+	var have [][]interface{}
+	logger := function.NewLogger(func(_ telemetry.Level, _ string, _ error, values function.Values, _ int) {
+		have = append(have, values.FromContext)
+	}, 0, WithTraceContext())
+
+	ctx := telemetry.KeyValuesToContext(spanContext(t), "request_id", 42)
+	logger.Context(ctx).Info("traced")
+	logger.Info("untraced")
+
+	want := [][]interface{}{
+		{"request_id", 42, TraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736",
+			SpanIDKey, "00f067aa0ba902b7", TraceFlagsKey, "01"},
+		nil,
+	}
+	if !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestTraceKeyValuesProvider(t *testing.T) {
+	ctx := telemetry.KeyValuesProviderToContext(spanContext(t), TraceKeyValues)
+	want := []interface{}{TraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736", SpanIDKey, "00f067aa0ba902b7",
+		TraceFlagsKey, "01"}
+	if have := telemetry.ResolveKeyValuesFromContext(ctx); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestExemplarKeyValues(t *testing.T) {
+	ctx := spanContext(t)
+	want := []interface{}{TraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736", SpanIDKey, "00f067aa0ba902b7"}
+	if have := ExemplarKeyValues(ctx); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+
+	sc := trace.SpanContextFromContext(ctx).WithTraceFlags(0)
+	if have := ExemplarKeyValues(trace.ContextWithSpanContext(ctx, sc)); have != nil {
+		t.Fatalf("expected no key-value pairs for unsampled span, have %v", have)
+	}
+}
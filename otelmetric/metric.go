@@ -0,0 +1,123 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelmetric
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/internal/labels"
+)
+
+// ctxLabels is the Context key holding the label operations.
+const ctxLabels = labels.ContextKey("otelmetric.labels")
+
+// compile time check for compatibility with the telemetry interfaces.
+var (
+	_ telemetry.LeveledMetric = (*otelMetric)(nil)
+)
+
+// LevelLabel is the label name receiving the logging level of the log line
+// triggering a measurement, if registered on a Metric attached to a Logger.
+const LevelLabel = "level"
+
+// otelMetric implements telemetry.Metric on top of an OpenTelemetry instrument.
+type otelMetric struct {
+	name    string
+	unit    string
+	labels  []string
+	enabled func() bool
+	observe func(ctx context.Context, value float64, o metric.MeasurementOption)
+	with    []labels.Op
+}
+
+// metricOptions returns the MetricOptions configured by the provided options.
+func metricOptions(opts []telemetry.MetricOption) telemetry.MetricOptions {
+	var o telemetry.MetricOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func newMetric(name string, opts []telemetry.MetricOption) *otelMetric {
+	o := metricOptions(opts)
+	m := &otelMetric{
+		name:    name,
+		unit:    string(o.Unit),
+		enabled: o.EnabledCondition,
+		observe: func(context.Context, float64, metric.MeasurementOption) {},
+	}
+	m.labels = labels.Names(o.Labels)
+	return m
+}
+
+// Increment implements telemetry.Metric.
+func (m *otelMetric) Increment() { m.Record(1) }
+
+// Decrement implements telemetry.Metric.
+func (m *otelMetric) Decrement() { m.Record(-1) }
+
+// Name implements telemetry.Metric.
+func (m *otelMetric) Name() string { return m.name }
+
+// Record implements telemetry.Metric.
+func (m *otelMetric) Record(value float64) {
+	m.record(context.Background(), value, "", false)
+}
+
+// RecordContext implements telemetry.Metric.
+func (m *otelMetric) RecordContext(ctx context.Context, value float64) {
+	m.record(ctx, value, "", true)
+}
+
+// RecordLeveled implements telemetry.LeveledMetric. The level is set on the
+// LevelLabel, if registered, before the label operations found in Context
+// and added through With are processed.
+func (m *otelMetric) RecordLeveled(ctx context.Context, value float64, level telemetry.Level) {
+	m.record(ctx, value, level.String(), true)
+}
+
+// With implements telemetry.Metric. LabelValues not created by a Sink are
+// ignored.
+func (m *otelMetric) With(labelValues ...telemetry.LabelValue) telemetry.Metric {
+	mc := *m
+	mc.with = labels.With(m.with, labelValues)
+	return &mc
+}
+
+func (m *otelMetric) record(ctx context.Context, value float64, level string, fromContext bool) {
+	if m.enabled != nil && !m.enabled() {
+		return
+	}
+	if len(m.labels) == 0 {
+		m.observe(ctx, value, metric.WithAttributeSet(*attribute.EmptySet()))
+		return
+	}
+
+	labelCtx := ctx
+	if !fromContext {
+		// skip the key-value pairs and label operations found in Context.
+		labelCtx = nil
+	}
+	attrs := make([]attribute.KeyValue, 0, len(m.labels))
+	ctxLabels.Resolve(labelCtx, m.labels, LevelLabel, level, m.with).Each(func(name, value string) {
+		attrs = append(attrs, attribute.String(name, value))
+	})
+	m.observe(ctx, value, metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,219 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otelmetric provides a telemetry.MetricSink implementation backed by
+// the OpenTelemetry metrics API, so Metrics are exported through the configured
+// OpenTelemetry MeterProvider, e.g. using OTLP.
+package otelmetric
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/internal/labels"
+)
+
+// DefaultMeterName is the name of the Meter used by a Sink if not configured
+// otherwise.
+const DefaultMeterName = "github.com/basvanbeek/telemetry"
+
+// compile time check for compatibility with the telemetry.MetricSink interface.
+var _ telemetry.MetricSink = (*Sink)(nil)
+
+type (
+	// Option implements a functional option type for the Sink.
+	Option func(*options)
+
+	// options holds the configuration of a Sink.
+	options struct {
+		provider     metric.MeterProvider
+		meterName    string
+		meterOpts    []metric.MeterOption
+		errorHandler func(error)
+	}
+)
+
+// WithMeterProvider sets the MeterProvider used to create instruments. By
+// default, the global MeterProvider as returned by otel.GetMeterProvider is
+// used.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *options) {
+		o.provider = mp
+	}
+}
+
+// WithMeterName sets the name and options of the Meter used to create
+// instruments. By default, DefaultMeterName is used.
+func WithMeterName(name string, opts ...metric.MeterOption) Option {
+	return func(o *options) {
+		o.meterName = name
+		o.meterOpts = opts
+	}
+}
+
+// WithErrorHandler sets the function receiving errors creating instruments.
+// By default, these errors are written to os.Stderr.
+func WithErrorHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = fn
+	}
+}
+
+// Sink creates Metrics backed by OpenTelemetry instruments.
+//
+// Sums are backed by Float64Counters, Gauges by Float64Gauges and
+// Distributions by Float64Histograms using the provided bounds as explicit
+// bucket boundaries. As counters can only increase, negative values recorded
+// on Sums are ignored. Instruments are cached by name, so Metrics created with
+// the same name and type share their instrument.
+//
+// The Labels registered on a Metric using telemetry.WithLabels become its
+// measurement attributes, Labels not created by a Sink are ignored. When
+// recording, label values are taken in sequence from the key-value pairs found
+// in Context with a key matching a label name, the LabelValues found in
+// Context and the LabelValues added through With. Labels without a value are
+// omitted.
+//
+// The Context passed to RecordContext is handed to the instruments, so a
+// MeterProvider collecting exemplars links measurements made within a sampled
+// span to its trace. With the OpenTelemetry SDK v1.28, exemplar collection is
+// enabled by setting the OTEL_GO_X_EXEMPLAR environment variable to true.
+type Sink struct {
+	opts  options
+	meter metric.Meter
+
+	mtx         sync.Mutex
+	instruments map[string]instrument
+}
+
+// instrument holds a cached instrument and its kind.
+type instrument struct {
+	kind       string
+	instrument interface{}
+}
+
+// New returns a new Sink.
+func New(opts ...Option) *Sink {
+	o := options{
+		meterName: DefaultMeterName,
+		errorHandler: func(err error) {
+			_, _ = fmt.Fprintf(os.Stderr, "telemetry: %v\n", err)
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.provider == nil {
+		o.provider = otel.GetMeterProvider()
+	}
+	return &Sink{
+		opts:        o,
+		meter:       o.provider.Meter(o.meterName, o.meterOpts...),
+		instruments: make(map[string]instrument),
+	}
+}
+
+// NewSum implements telemetry.MetricSink.
+func (s *Sink) NewSum(name, description string, opts ...telemetry.MetricOption) telemetry.Metric {
+	m := newMetric(name, opts)
+	c, ok := s.instrument(name, "sum", func() (interface{}, error) {
+		return s.meter.Float64Counter(name, metric.WithDescription(description), metric.WithUnit(m.unit))
+	}).(metric.Float64Counter)
+	if ok {
+		m.observe = func(ctx context.Context, value float64, o metric.MeasurementOption) {
+			if value >= 0 {
+				c.Add(ctx, value, o)
+			}
+		}
+	}
+	return m
+}
+
+// NewGauge implements telemetry.MetricSink.
+func (s *Sink) NewGauge(name, description string, opts ...telemetry.MetricOption) telemetry.Metric {
+	m := newMetric(name, opts)
+	g, ok := s.instrument(name, "gauge", func() (interface{}, error) {
+		return s.meter.Float64Gauge(name, metric.WithDescription(description), metric.WithUnit(m.unit))
+	}).(metric.Float64Gauge)
+	if ok {
+		m.observe = func(ctx context.Context, value float64, o metric.MeasurementOption) {
+			g.Record(ctx, value, o)
+		}
+	}
+	return m
+}
+
+// NewDistribution implements telemetry.MetricSink. If no bounds are provided,
+// the default bucket boundaries of the MeterProvider are used. The exponential
+// buckets configured using telemetry.WithExponentialBuckets are applied by the
+// View, taking precedence over the bounds.
+func (s *Sink) NewDistribution(name, description string, bounds []float64, opts ...telemetry.MetricOption) telemetry.Metric {
+	m := newMetric(name, opts)
+	if exp := metricOptions(opts).ExponentialBuckets; exp != nil {
+		exponential.Store(viewKey(s.opts.meterName, name), *exp)
+	}
+	h, ok := s.instrument(name, "distribution", func() (interface{}, error) {
+		hOpts := []metric.Float64HistogramOption{metric.WithDescription(description), metric.WithUnit(m.unit)}
+		if len(bounds) > 0 {
+			hOpts = append(hOpts, metric.WithExplicitBucketBoundaries(bounds...))
+		}
+		return s.meter.Float64Histogram(name, hOpts...)
+	}).(metric.Float64Histogram)
+	if ok {
+		m.observe = func(ctx context.Context, value float64, o metric.MeasurementOption) {
+			h.Record(ctx, value, o)
+		}
+	}
+	return m
+}
+
+// NewLabel implements telemetry.MetricSink.
+func (s *Sink) NewLabel(name string) telemetry.Label {
+	return labels.Label(name)
+}
+
+// ContextWithLabels implements telemetry.MetricSink. It returns an error if the
+// provided values hold empty label names or were not created by a Sink.
+func (s *Sink) ContextWithLabels(ctx context.Context, values ...telemetry.LabelValue) (context.Context, error) {
+	return ctxLabels.WithValues(ctx, values, func(name string) bool { return name != "" })
+}
+
+// instrument returns the cached instrument with the provided name, creating it
+// if not found. It returns nil if creation failed or an instrument of another
+// kind was created with the same name.
+func (s *Sink) instrument(name, kind string, create func() (interface{}, error)) interface{} {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if inst, ok := s.instruments[name]; ok {
+		if inst.kind != kind {
+			s.opts.errorHandler(fmt.Errorf("metric %q already created as %s", name, inst.kind))
+			return nil
+		}
+		return inst.instrument
+	}
+	inst, err := create()
+	if err != nil {
+		s.opts.errorHandler(err)
+		return nil
+	}
+	s.instruments[name] = instrument{kind: kind, instrument: inst}
+	return inst
+}
@@ -0,0 +1,205 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelmetric
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func newSink(t *testing.T, opts ...Option) (*Sink, func() map[string]metricdata.Aggregation) {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithView(View()))
+	s := New(append([]Option{WithMeterProvider(mp)}, opts...)...)
+	return s, func() map[string]metricdata.Aggregation {
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data := make(map[string]metricdata.Aggregation)
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				data[m.Name] = m.Data
+			}
+		}
+		return data
+	}
+}
+
+// points returns the data point values of a sum or gauge by attribute set.
+func points(t *testing.T, data metricdata.Aggregation) map[string]float64 {
+	t.Helper()
+	var dps []metricdata.DataPoint[float64]
+	switch d := data.(type) {
+	case metricdata.Sum[float64]:
+		dps = d.DataPoints
+	case metricdata.Gauge[float64]:
+		dps = d.DataPoints
+	default:
+		t.Fatalf("unexpected aggregation %T", data)
+	}
+	have := make(map[string]float64)
+	for _, dp := range dps {
+		have[dp.Attributes.Encoded(attribute.DefaultEncoder())] = dp.Value
+	}
+	return have
+}
+
+func TestSink(t *testing.T) {
+	s, collect := newSink(t)
+
+	sum := s.NewSum("requests", "handled requests")
+	sum.Increment()
+	sum.Record(2)
+	sum.Decrement()
+	s.NewSum("requests", "handled requests").Increment()
+
+	s.NewGauge("connections", "open connections").Record(3)
+
+	dist := s.NewDistribution("latency", "request latency", []float64{0.1, 1}, telemetry.WithUnit(telemetry.Seconds))
+	dist.Record(0.5)
+
+	data := collect()
+	if want, have := map[string]float64{"": 4}, points(t, data["requests"]); !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %v\nhave: %v", want, have)
+	}
+	if want, have := map[string]float64{"": 3}, points(t, data["connections"]); !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %v\nhave: %v", want, have)
+	}
+	h, ok := data["latency"].(metricdata.Histogram[float64])
+	if !ok || len(h.DataPoints) != 1 {
+		t.Fatalf("unexpected histogram: %v", data["latency"])
+	}
+	if want, have := []float64{0.1, 1}, h.DataPoints[0].Bounds; !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %v\nhave: %v", want, have)
+	}
+	if want, have := []uint64{0, 1, 0}, h.DataPoints[0].BucketCounts; !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	var (
+		s, collect = newSink(t)
+		tenant     = s.NewLabel("tenant")
+		region     = s.NewLabel("region")
+		m          = s.NewSum("hits", "hits", telemetry.WithLabels(tenant, region))
+	)
+
+	ctx := telemetry.KeyValuesToContext(context.Background(), "tenant", "acme", "ignored", 1)
+	m.RecordContext(ctx, 1)
+
+	ctx, err := s.ContextWithLabels(ctx, region.Upsert("eu"), tenant.Update("globex"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.RecordContext(ctx, 1)
+	m.With(region.Delete()).RecordContext(ctx, 1)
+	m.With(tenant.Insert("initech")).Record(1)
+
+	want := map[string]float64{
+		"tenant=acme":             1,
+		"region=eu,tenant=globex": 1,
+		"tenant=globex":           1,
+		"tenant=initech":          1,
+	}
+	if have := points(t, collect()["hits"]); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+
+	if _, err := s.ContextWithLabels(ctx, "foreign"); err == nil {
+		t.Errorf("expected error for foreign label value")
+	}
+}
+
+func TestLoggerMetric(t *testing.T) {
+	var (
+		s, collect = newSink(t)
+		m          = s.NewSum("log_messages", "log messages", telemetry.WithLabels(s.NewLabel(LevelLabel)))
+	)
+	logger := function.NewLogger(func(telemetry.Level, string, error, function.Values, int) {}, 0).Metric(m)
+
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Warn("warn")
+
+	want := map[string]float64{"level=info": 1, "level=warn": 2}
+	if have := points(t, collect()["log_messages"]); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestInstrumentKindMismatch(t *testing.T) {
+	var errs []error
+	s, _ := newSink(t, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+
+	s.NewSum("dup", "dup")
+	s.NewGauge("dup", "dup").Record(1)
+
+	if len(errs) != 1 || errs[0].Error() != `metric "dup" already created as sum` {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+func TestExemplars(t *testing.T) {
+	t.Setenv("OTEL_GO_X_EXEMPLAR", "true")
+	s, collect := newSink(t)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	s.NewDistribution("latency", "request latency", []float64{0.1, 1}).RecordContext(ctx, 0.5)
+
+	h, ok := collect()["latency"].(metricdata.Histogram[float64])
+	if !ok || len(h.DataPoints) != 1 || len(h.DataPoints[0].Exemplars) != 1 {
+		t.Fatalf("expected a single exemplar, have %v", h)
+	}
+	if e := h.DataPoints[0].Exemplars[0]; trace.TraceID(e.TraceID) != traceID || e.Value != 0.5 {
+		t.Fatalf("unexpected exemplar: %v", e)
+	}
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	s, collect := newSink(t)
+
+	s.NewDistribution("exponential", "exponential", []float64{1}, telemetry.WithExponentialBuckets(100, 3)).Record(0.5)
+	s.NewDistribution("explicit", "explicit", []float64{1}).Record(0.5)
+
+	data := collect()
+	e, ok := data["exponential"].(metricdata.ExponentialHistogram[float64])
+	if !ok || len(e.DataPoints) != 1 {
+		t.Fatalf("unexpected exponential histogram: %v", data["exponential"])
+	}
+	if have := e.DataPoints[0].Scale; have != 3 {
+		t.Errorf("expected scale 3, have %d", have)
+	}
+	if _, ok := data["explicit"].(metricdata.Histogram[float64]); !ok {
+		t.Errorf("unexpected explicit histogram: %v", data["explicit"])
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelmetric
+
+import (
+	"sync"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// exponential holds the exponential bucket configuration of Distributions by
+// meter and instrument name.
+var exponential sync.Map
+
+func viewKey(meterName, name string) string {
+	return meterName + "\x00" + name
+}
+
+// View returns an OpenTelemetry SDK View selecting the base2 exponential
+// histogram aggregation for Distributions created by a Sink with the
+// telemetry.WithExponentialBuckets option. The OpenTelemetry metrics API does
+// not allow selecting an aggregation when creating an instrument, so register
+// the View with the MeterProvider passed to the Sink:
+//
+//	mp := sdkmetric.NewMeterProvider(sdkmetric.WithView(otelmetric.View()), ...)
+//	sink := otelmetric.New(otelmetric.WithMeterProvider(mp))
+//
+// As instruments are created lazily by the SDK, the View applies to
+// Distributions created after the MeterProvider.
+func View() sdkmetric.View {
+	return func(i sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+		v, ok := exponential.Load(viewKey(i.Scope.Name, i.Name))
+		if !ok || i.Kind != sdkmetric.InstrumentKindHistogram {
+			return sdkmetric.Stream{}, false
+		}
+		exp := v.(telemetry.ExponentialBuckets)
+		return sdkmetric.Stream{
+			Name:        i.Name,
+			Description: i.Description,
+			Unit:        i.Unit,
+			Aggregation: sdkmetric.AggregationBase2ExponentialHistogram{
+				MaxSize:  int32(exp.MaxSize),
+				MaxScale: int32(exp.MaxScale),
+			},
+		}, true
+	}
+}
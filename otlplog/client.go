@@ -0,0 +1,192 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlplog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/basvanbeek/telemetry/batch"
+)
+
+type (
+	// ClientOption implements a functional option type for the OTLP Clients.
+	ClientOption func(*clientOptions)
+
+	// clientOptions holds the configuration of an OTLP Client.
+	clientOptions struct {
+		headers    map[string]string
+		httpClient *http.Client
+		gzip       bool
+	}
+)
+
+// WithHeaders sets headers sent with each export request, e.g. to
+// authenticate with the backend. They are sent as gRPC metadata by the gRPC
+// Client.
+func WithHeaders(headers map[string]string) ClientOption {
+	return func(o *clientOptions) {
+		o.headers = headers
+	}
+}
+
+// WithHTTPClient sets the http.Client used by the HTTP Client.
+func WithHTTPClient(c *http.Client) ClientOption {
+	return func(o *clientOptions) {
+		o.httpClient = c
+	}
+}
+
+// WithGzip enables gzip compression of export requests sent by the HTTP
+// Client. For the gRPC Client use the grpc.UseCompressor call option on the
+// connection instead.
+func WithGzip() ClientOption {
+	return func(o *clientOptions) {
+		o.gzip = true
+	}
+}
+
+func newClientOptions(opts []ClientOption) clientOptions {
+	o := clientOptions{httpClient: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+type grpcClient struct {
+	client collogspb.LogsServiceClient
+	md     metadata.MD
+}
+
+// NewGRPCClient returns a Client exporting log records over OTLP/gRPC using
+// the provided connection. Errors with a status code the OTLP specification
+// considers transient are retried by the batch.Batcher.
+func NewGRPCClient(conn grpc.ClientConnInterface, opts ...ClientOption) Client {
+	o := newClientOptions(opts)
+	return &grpcClient{
+		client: collogspb.NewLogsServiceClient(conn),
+		md:     metadata.New(o.headers),
+	}
+}
+
+// Export implements Client.
+func (c *grpcClient) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	if len(c.md) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, c.md)
+	}
+	res, err := c.client.Export(ctx, req)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted,
+			codes.Aborted, codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+			return err
+		default:
+			return batch.Permanent(err)
+		}
+	}
+	return partialSuccess(res)
+}
+
+type httpClient struct {
+	endpoint string
+	opts     clientOptions
+}
+
+// NewHTTPClient returns a Client exporting log records over OTLP/HTTP using
+// binary protobuf encoding. The endpoint holds the full URL to post to, e.g.
+// "http://localhost:4318/v1/logs". Responses with status 429, 502, 503 and
+// 504 are retried by the batch.Batcher.
+func NewHTTPClient(endpoint string, opts ...ClientOption) Client {
+	return &httpClient{endpoint: endpoint, opts: newClientOptions(opts)}
+}
+
+// Export implements Client.
+func (c *httpClient) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return batch.Permanent(err)
+	}
+	if c.opts.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err = zw.Write(body); err == nil {
+			err = zw.Close()
+		}
+		if err != nil {
+			return batch.Permanent(err)
+		}
+		body = buf.Bytes()
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return batch.Permanent(err)
+	}
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	if c.opts.gzip {
+		r.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range c.opts.headers {
+		r.Header.Set(k, v)
+	}
+
+	res, err := c.opts.httpClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	data, _ := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		var resp collogspb.ExportLogsServiceResponse
+		if len(data) > 0 && proto.Unmarshal(data, &resp) == nil {
+			return partialSuccess(&resp)
+		}
+		return nil
+	case res.StatusCode == http.StatusTooManyRequests, res.StatusCode == http.StatusBadGateway,
+		res.StatusCode == http.StatusServiceUnavailable, res.StatusCode == http.StatusGatewayTimeout:
+		return fmt.Errorf("otlp export failed: %s", res.Status)
+	default:
+		return batch.Permanent(fmt.Errorf("otlp export failed: %s", res.Status))
+	}
+}
+
+// partialSuccess returns a permanent error if the backend reports rejected
+// log records.
+func partialSuccess(res *collogspb.ExportLogsServiceResponse) error {
+	ps := res.GetPartialSuccess()
+	if ps.GetRejectedLogRecords() == 0 {
+		return nil
+	}
+	msg := ps.GetErrorMessage()
+	if msg == "" {
+		msg = "no reason given"
+	}
+	return batch.Permanent(fmt.Errorf("otlp export rejected %d log records: %s",
+		ps.GetRejectedLogRecords(), msg))
+}
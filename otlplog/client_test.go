@@ -0,0 +1,169 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlplog
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/batch"
+)
+
+func TestHTTPClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		response  *collogspb.ExportLogsServiceResponse
+		fails     bool
+		permanent bool
+	}{
+		{"ok", http.StatusOK, nil, false, false},
+		{"partial", http.StatusOK, &collogspb.ExportLogsServiceResponse{
+			PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1, ErrorMessage: "too big"},
+		}, true, true},
+		{"throttled", http.StatusTooManyRequests, nil, true, false},
+		{"unavailable", http.StatusServiceUnavailable, nil, true, false},
+		{"bad request", http.StatusBadRequest, nil, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received collogspb.ExportLogsServiceRequest
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") != "application/x-protobuf" || r.Header.Get("Authorization") != "token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				body, _ := io.ReadAll(r.Body)
+				if err := proto.Unmarshal(body, &received); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(tt.status)
+				if tt.response != nil {
+					data, _ := proto.Marshal(tt.response)
+					_, _ = w.Write(data)
+				}
+			}))
+			defer srv.Close()
+
+			c := NewHTTPClient(srv.URL+"/v1/logs", WithHeaders(map[string]string{"Authorization": "token"}))
+			err := c.Export(context.Background(), request("hello"))
+			if (err != nil) != tt.fails {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil && batch.IsPermanent(err) != tt.permanent {
+				t.Fatalf("expected permanent to be %t for %v", tt.permanent, err)
+			}
+			if have := received.GetResourceLogs()[0].GetScopeLogs()[0].GetLogRecords()[0].GetBody().GetStringValue(); have != "hello" {
+				t.Fatalf("expected %q to match %q", have, "hello")
+			}
+		})
+	}
+}
+
+type logsServer struct {
+	collogspb.UnimplementedLogsServiceServer
+	mtx   sync.Mutex
+	code  codes.Code
+	token string
+}
+
+func (s *logsServer) set(code codes.Code) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.code = code
+}
+
+func (s *logsServer) Export(ctx context.Context, _ *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+		s.token = md.Get("authorization")[0]
+	}
+	if s.code != codes.OK {
+		return nil, status.Error(s.code, "failed")
+	}
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func TestGRPCClient(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var (
+		ls  = &logsServer{}
+		srv = grpc.NewServer()
+	)
+	collogspb.RegisterLogsServiceServer(srv, ls)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	c := NewGRPCClient(conn, WithHeaders(map[string]string{"Authorization": "token"}))
+	if err = c.Export(context.Background(), request("hello")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ls.mtx.Lock()
+	token := ls.token
+	ls.mtx.Unlock()
+	if token != "token" {
+		t.Fatalf("expected %q to match %q", token, "token")
+	}
+
+	ls.set(codes.Unavailable)
+	if err = c.Export(context.Background(), request("hello")); err == nil || batch.IsPermanent(err) {
+		t.Fatalf("expected transient error, got %v", err)
+	}
+
+	ls.set(codes.InvalidArgument)
+	if err = c.Export(context.Background(), request("hello")); err == nil || !batch.IsPermanent(err) {
+		t.Fatalf("expected permanent error, got %v", err)
+	}
+}
+
+func request(msg string) *collogspb.ExportLogsServiceRequest {
+	return &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			ScopeLogs: []*logspb.ScopeLogs{{
+				LogRecords: []*logspb.LogRecord{
+					LogRecord(batch.Record{Time: time.Now(), Level: telemetry.LevelInfo, Message: msg}),
+				},
+			}},
+		}},
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlplog
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/batch"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type (
+	// DelimitedOption implements a functional option type for Delimited.
+	DelimitedOption func(*delimitedOptions)
+
+	// delimitedOptions holds the configuration of Delimited.
+	delimitedOptions struct {
+		now func() time.Time
+	}
+)
+
+// WithClock sets the function providing the timestamp of the written log
+// records, e.g. to produce deterministic output in tests. Defaults to
+// time.Now.
+func WithClock(now func() time.Time) DelimitedOption {
+	return func(o *delimitedOptions) {
+		o.now = now
+	}
+}
+
+// Delimited returns an EmitErr function writing each log line to w as an OTLP
+// LogRecord protobuf message, prefixed with its size as a varint. The records
+// are converted using LogRecord and can be read back with
+// protodelim.UnmarshalFrom, e.g. to capture log lines to a file and replay
+// them into a collector. Each record is handed to w in a single Write call.
+// Create the function Logger with the function.WithCaller option to populate
+// the code attributes of the written log records.
+func Delimited(w io.Writer, opts ...DelimitedOption) function.EmitErr {
+	o := delimitedOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var (
+		mtx sync.Mutex
+		buf []byte
+	)
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) error {
+		lr := LogRecord(batch.Record{Time: o.now(), Level: level, Message: msg, Error: err, Values: values})
+
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		buf = protowire.AppendVarint(buf[:0], uint64(proto.Size(lr)))
+		buf, err = proto.MarshalOptions{}.MarshalAppend(buf, lr)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf)
+		return err
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlplog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestDelimited(t *testing.T) {
+	var (
+		out   bytes.Buffer
+		clock = time.Unix(1700000000, 0)
+	)
+	logger := function.NewLoggerErr(Delimited(&out, WithClock(func() time.Time { return clock })), 0,
+		function.WithCaller())
+	logger.With("component", "store").Info("first", "count", 3)
+	logger.Error("second", errors.New("failed"))
+
+	var (
+		r       = bufio.NewReader(&out)
+		records []*logspb.LogRecord
+	)
+	for {
+		lr := &logspb.LogRecord{}
+		if err := protodelim.UnmarshalFrom(r, lr); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("unexpected error decoding record %d: %v", len(records), err)
+		}
+		records = append(records, lr)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, have %d", len(records))
+	}
+	tests := []struct {
+		body     string
+		severity logspb.SeverityNumber
+		attrs    map[string]interface{}
+	}{
+		{"first", logspb.SeverityNumber_SEVERITY_NUMBER_INFO, map[string]interface{}{
+			"component": "store", "count": int64(3),
+		}},
+		{"second", logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, map[string]interface{}{
+			"exception.message": "failed",
+		}},
+	}
+	for i, tt := range tests {
+		lr := records[i]
+		if have := lr.GetBody().GetStringValue(); have != tt.body {
+			t.Errorf("record %d: want: %v\nhave: %v", i, tt.body, have)
+		}
+		if lr.GetSeverityNumber() != tt.severity {
+			t.Errorf("record %d: want: %v\nhave: %v", i, tt.severity, lr.GetSeverityNumber())
+		}
+		if want := uint64(clock.UnixNano()); lr.GetTimeUnixNano() != want {
+			t.Errorf("record %d: want: %v\nhave: %v", i, want, lr.GetTimeUnixNano())
+		}
+		attrs := attributes(lr.GetAttributes())
+		if fn, _ := attrs["code.function"].(string); fn != "otlplog.TestDelimited" {
+			t.Errorf("record %d: expected code.function to match otlplog.TestDelimited, got %v", i, attrs["code.function"])
+		}
+		for k, v := range tt.attrs {
+			if attrs[k] != v {
+				t.Errorf("record %d: attribute %s want: %v\nhave: %v", i, k, v, attrs[k])
+			}
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestDelimitedWriteError(t *testing.T) {
+	emit := Delimited(failingWriter{})
+	if err := emit(telemetry.LevelInfo, "text", nil, function.Values{}, 0); err == nil || err.Error() != "disk full" {
+		t.Fatalf("expected write error, have %v", err)
+	}
+}
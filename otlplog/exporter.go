@@ -0,0 +1,260 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otlplog provides a sink exporting log lines to an OpenTelemetry
+// collector or backend using the OTLP logs protocol over gRPC or HTTP, or
+// writing them to a file as length-delimited OTLP LogRecord messages.
+package otlplog
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/batch"
+)
+
+// DefaultScopeName is the instrumentation scope name used if not set by
+// WithScope.
+const DefaultScopeName = "github.com/basvanbeek/telemetry"
+
+// Client sends an export request to an OTLP endpoint.
+type Client interface {
+	Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error
+}
+
+type (
+	// Option implements a functional option type for the exporter.
+	Option func(*options)
+
+	// options holds the configuration of the exporter.
+	options struct {
+		resource     []*commonpb.KeyValue
+		scopeName    string
+		scopeVersion string
+		batchOpts    []batch.Option
+	}
+)
+
+// WithResource adds the provided key-value pairs as attributes of the
+// resource producing the log lines, e.g. "service.name" and
+// "service.version".
+func WithResource(keyValues ...interface{}) Option {
+	return func(o *options) {
+		o.resource = appendAttributes(o.resource, keyValues)
+	}
+}
+
+// WithScope sets the name and version of the instrumentation scope reported
+// with the log lines.
+func WithScope(name, version string) Option {
+	return func(o *options) {
+		o.scopeName = name
+		o.scopeVersion = version
+	}
+}
+
+// WithBatchOptions sets the options of the underlying batch.Batcher, e.g. to
+// configure batch size, flush interval and retries.
+func WithBatchOptions(opts ...batch.Option) Option {
+	return func(o *options) {
+		o.batchOpts = append(o.batchOpts, opts...)
+	}
+}
+
+// New returns a batch.Batcher exporting log lines through the provided
+// Client. Use its Emit method with function.NewLoggerErr, its Flush method
+// with function.WithFlush and Close it on shutdown. Create the function Logger
+// with the function.WithCaller option to populate the code attributes of the
+// exported log records.
+func New(c Client, opts ...Option) *batch.Batcher {
+	o := newOptions(opts)
+	return batch.New(handler(c, o), o.batchOpts...)
+}
+
+// Handler returns a batch.Handler exporting batches of records through the
+// provided Client. Batch options set through WithBatchOptions are ignored.
+func Handler(c Client, opts ...Option) batch.Handler {
+	return handler(c, newOptions(opts))
+}
+
+func newOptions(opts []Option) options {
+	o := options{scopeName: DefaultScopeName}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func handler(c Client, o options) batch.Handler {
+	resource := &resourcepb.Resource{Attributes: o.resource}
+	scope := &commonpb.InstrumentationScope{Name: o.scopeName, Version: o.scopeVersion}
+	return func(ctx context.Context, records []batch.Record) error {
+		logRecords := make([]*logspb.LogRecord, 0, len(records))
+		for _, r := range records {
+			logRecords = append(logRecords, LogRecord(r))
+		}
+		return c.Export(ctx, &collogspb.ExportLogsServiceRequest{
+			ResourceLogs: []*logspb.ResourceLogs{{
+				Resource: resource,
+				ScopeLogs: []*logspb.ScopeLogs{{
+					Scope:      scope,
+					LogRecords: logRecords,
+				}},
+			}},
+		})
+	}
+}
+
+// LogRecord maps a batch.Record onto the OpenTelemetry log data model. The
+// message is set as the body and the key-value pairs as attributes. The error
+// is added as the "exception.message" attribute and the caller, if resolved,
+// as the "code.filepath", "code.lineno" and "code.function" attributes.
+func LogRecord(r batch.Record) *logspb.LogRecord {
+	ts := uint64(r.Time.UnixNano())
+	lr := &logspb.LogRecord{
+		TimeUnixNano:         ts,
+		ObservedTimeUnixNano: ts,
+		SeverityNumber:       ToSeverityNumber(r.Level),
+		SeverityText:         r.Level.String(),
+		Body:                 stringValue(r.Message),
+	}
+
+	n := len(r.Values.FromContext) + len(r.Values.FromLogger) + len(r.Values.FromMethod)
+	attrs := make([]*commonpb.KeyValue, 0, 4+(n+1)/2)
+	if r.Error != nil {
+		attrs = append(attrs, &commonpb.KeyValue{Key: "exception.message", Value: stringValue(r.Error.Error())})
+	}
+	if frame, ok := r.Values.Caller.Resolve(); ok {
+		attrs = append(attrs,
+			&commonpb.KeyValue{Key: "code.filepath", Value: stringValue(frame.File)},
+			&commonpb.KeyValue{Key: "code.lineno", Value: intValue(int64(frame.Line))},
+		)
+		if frame.Function != "" {
+			attrs = append(attrs, &commonpb.KeyValue{Key: "code.function", Value: stringValue(path.Base(frame.Function))})
+		}
+	}
+	attrs = appendAttributes(attrs, r.Values.FromContext)
+	attrs = appendAttributes(attrs, r.Values.FromLogger)
+	lr.Attributes = appendAttributes(attrs, r.Values.FromMethod)
+
+	return lr
+}
+
+// ToSeverityNumber maps a telemetry.Level onto the corresponding OpenTelemetry
+// severity number.
+func ToSeverityNumber(level telemetry.Level) logspb.SeverityNumber {
+	switch {
+	case level <= telemetry.LevelNone:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+	case level <= telemetry.LevelError:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case level <= telemetry.LevelWarn:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case level <= telemetry.LevelInfo:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case level <= telemetry.LevelDebug:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	default:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_TRACE
+	}
+}
+
+// appendAttributes converts key-value pairs into OTLP attributes. Non-string
+// keys are formatted using fmt.Sprint and a missing value is set to
+// "(MISSING)".
+func appendAttributes(attrs []*commonpb.KeyValue, keyValues []interface{}) []*commonpb.KeyValue {
+	for i := 0; i < len(keyValues); i += 2 {
+		k, ok := keyValues[i].(string)
+		if !ok {
+			k = fmt.Sprint(keyValues[i])
+		}
+		var v interface{} = "(MISSING)"
+		if i+1 < len(keyValues) {
+			v = keyValues[i+1]
+		}
+		attrs = append(attrs, &commonpb.KeyValue{Key: k, Value: anyValue(v)})
+	}
+	return attrs
+}
+
+// anyValue converts a value into an OTLP AnyValue, preserving its type where
+// the data model allows.
+func anyValue(v interface{}) *commonpb.AnyValue {
+	switch t := v.(type) {
+	case nil:
+		return &commonpb.AnyValue{}
+	case string:
+		return stringValue(t)
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: t}}
+	case int:
+		return intValue(int64(t))
+	case int8:
+		return intValue(int64(t))
+	case int16:
+		return intValue(int64(t))
+	case int32:
+		return intValue(int64(t))
+	case int64:
+		return intValue(t)
+	case uint:
+		return uintValue(uint64(t))
+	case uint8:
+		return intValue(int64(t))
+	case uint16:
+		return intValue(int64(t))
+	case uint32:
+		return intValue(int64(t))
+	case uint64:
+		return uintValue(t)
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(t)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: t}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: t}}
+	case error:
+		return stringValue(t.Error())
+	case fmt.Stringer:
+		return stringValue(t.String())
+	default:
+		return stringValue(fmt.Sprint(t))
+	}
+}
+
+func stringValue(s string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+}
+
+func intValue(i int64) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: i}}
+}
+
+// uintValue converts an unsigned integer, which the data model lacks, into an
+// int value or a string value if it overflows.
+func uintValue(u uint64) *commonpb.AnyValue {
+	if u > math.MaxInt64 {
+		return stringValue(strconv.FormatUint(u, 10))
+	}
+	return intValue(int64(u))
+}
@@ -0,0 +1,151 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlplog
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type recordingClient struct {
+	mtx  sync.Mutex
+	reqs []*collogspb.ExportLogsServiceRequest
+}
+
+func (c *recordingClient) Export(_ context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.reqs = append(c.reqs, req)
+	return nil
+}
+
+func attributes(kvs []*commonpb.KeyValue) map[string]interface{} {
+	m := make(map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		switch v := kv.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			m[kv.GetKey()] = v.StringValue
+		case *commonpb.AnyValue_IntValue:
+			m[kv.GetKey()] = v.IntValue
+		case *commonpb.AnyValue_BoolValue:
+			m[kv.GetKey()] = v.BoolValue
+		case *commonpb.AnyValue_DoubleValue:
+			m[kv.GetKey()] = v.DoubleValue
+		default:
+			m[kv.GetKey()] = nil
+		}
+	}
+	return m
+}
+
+func TestExport(t *testing.T) {
+	var (
+		c = &recordingClient{}
+		b = New(c, WithResource("service.name", "test"), WithScope("scope", "v1.0.0"))
+
+		logger = function.NewLoggerErr(b.Emit, 0, function.WithCaller())
+	)
+	logger.SetLevel(telemetry.LevelDebug)
+
+	logger.With("component", "otlp").Error("failed", errors.New("boom"), "attempt", 3, "ok", false, "ratio", 0.5, "dangling")
+	logger.Debug("details")
+	if err := b.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(c.reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(c.reqs))
+	}
+	rl := c.reqs[0].GetResourceLogs()[0]
+	if have := attributes(rl.GetResource().GetAttributes())["service.name"]; have != "test" {
+		t.Fatalf("expected service.name to match test, got %v", have)
+	}
+	sl := rl.GetScopeLogs()[0]
+	if sl.GetScope().GetName() != "scope" || sl.GetScope().GetVersion() != "v1.0.0" {
+		t.Fatalf("unexpected scope: %v", sl.GetScope())
+	}
+
+	records := sl.GetLogRecords()
+	if len(records) != 2 {
+		t.Fatalf("expected 2 log records, got %d", len(records))
+	}
+
+	lr := records[0]
+	if lr.GetSeverityNumber() != logspb.SeverityNumber_SEVERITY_NUMBER_ERROR || lr.GetSeverityText() != "error" {
+		t.Fatalf("unexpected severity: %v %q", lr.GetSeverityNumber(), lr.GetSeverityText())
+	}
+	if have := lr.GetBody().GetStringValue(); have != "failed" {
+		t.Fatalf("expected %q to match %q", have, "failed")
+	}
+	if lr.GetTimeUnixNano() == 0 {
+		t.Fatal("expected timestamp to be set")
+	}
+	attrs := attributes(lr.GetAttributes())
+	expected := map[string]interface{}{
+		"exception.message": "boom",
+		"component":         "otlp",
+		"attempt":           int64(3),
+		"ok":                false,
+		"ratio":             0.5,
+		"dangling":          "(MISSING)",
+	}
+	for k, v := range expected {
+		if attrs[k] != v {
+			t.Fatalf("expected attribute %s to match %v, got %v", k, v, attrs[k])
+		}
+	}
+	if file, _ := attrs["code.filepath"].(string); !strings.HasSuffix(file, "exporter_test.go") {
+		t.Fatalf("expected code.filepath to reference exporter_test.go, got %v", attrs["code.filepath"])
+	}
+	if fn, _ := attrs["code.function"].(string); fn != "otlplog.TestExport" {
+		t.Fatalf("expected code.function to match otlplog.TestExport, got %v", attrs["code.function"])
+	}
+
+	if records[1].GetSeverityNumber() != logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG {
+		t.Fatalf("unexpected severity: %v", records[1].GetSeverityNumber())
+	}
+}
+
+func TestToSeverityNumber(t *testing.T) {
+	tests := []struct {
+		level    telemetry.Level
+		expected logspb.SeverityNumber
+	}{
+		{telemetry.LevelNone, logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED},
+		{telemetry.LevelError, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR},
+		{telemetry.LevelWarn, logspb.SeverityNumber_SEVERITY_NUMBER_WARN},
+		{telemetry.LevelInfo, logspb.SeverityNumber_SEVERITY_NUMBER_INFO},
+		{telemetry.LevelDebug, logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG},
+		{telemetry.LevelTrace, logspb.SeverityNumber_SEVERITY_NUMBER_TRACE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			if have := ToSeverityNumber(tt.level); have != tt.expected {
+				t.Fatalf("expected %s to match %s", have, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+module github.com/basvanbeek/telemetry/otlplog
+
+go 1.21
+
+require (
+	github.com/basvanbeek/telemetry v0.2.0
+	go.opentelemetry.io/proto/otlp v1.3.1
+	google.golang.org/grpc v1.64.1
+	google.golang.org/protobuf v1.34.1
+)
+
+require (
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.20.0 // indirect
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20240513163218-0867130af1f8 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240513163218-0867130af1f8 // indirect
+)
+
+// Work around for maintaining multiple go modules in the same repository
+// until go has better support for this. https://github.com/golang/go/issues/45713
+replace github.com/basvanbeek/telemetry => ../
@@ -0,0 +1,103 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetric
+
+import (
+	"context"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/internal/labels"
+)
+
+// ctxLabels is the Context key holding the label operations.
+const ctxLabels = labels.ContextKey("prommetric.labels")
+
+// compile time check for compatibility with the telemetry interfaces.
+var (
+	_ telemetry.LeveledMetric = (*metric)(nil)
+)
+
+// LevelLabel is the label name receiving the logging level of the log line
+// triggering a measurement, if registered on a Metric attached to a Logger.
+const LevelLabel = "level"
+
+// validLabelName reports whether name is a valid Prometheus label name.
+func validLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= '0' && c <= '9' && i > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// metric implements telemetry.Metric on top of a Prometheus collector.
+type metric struct {
+	name    string
+	labels  []string
+	enabled func() bool
+	observe func(ctx context.Context, labelValues []string, value float64)
+	with    []labels.Op
+}
+
+// Increment implements telemetry.Metric.
+func (m *metric) Increment() { m.Record(1) }
+
+// Decrement implements telemetry.Metric.
+func (m *metric) Decrement() { m.Record(-1) }
+
+// Name implements telemetry.Metric.
+func (m *metric) Name() string { return m.name }
+
+// Record implements telemetry.Metric.
+func (m *metric) Record(value float64) {
+	m.record(nil, value, "")
+}
+
+// RecordContext implements telemetry.Metric.
+func (m *metric) RecordContext(ctx context.Context, value float64) {
+	m.record(ctx, value, "")
+}
+
+// RecordLeveled implements telemetry.LeveledMetric. The level is set on the
+// LevelLabel, if registered, before the label operations found in Context
+// and added through With are processed.
+func (m *metric) RecordLeveled(ctx context.Context, value float64, level telemetry.Level) {
+	m.record(ctx, value, level.String())
+}
+
+// With implements telemetry.Metric. LabelValues not created by a Sink are
+// ignored.
+func (m *metric) With(labelValues ...telemetry.LabelValue) telemetry.Metric {
+	mc := *m
+	mc.with = labels.With(m.with, labelValues)
+	return &mc
+}
+
+func (m *metric) record(ctx context.Context, value float64, level string) {
+	if m.enabled != nil && !m.enabled() {
+		return
+	}
+	if len(m.labels) == 0 {
+		m.observe(ctx, nil, value)
+		return
+	}
+
+	ls := ctxLabels.Resolve(ctx, m.labels, LevelLabel, level, m.with)
+	m.observe(ctx, ls.Values(), value)
+}
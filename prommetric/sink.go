@@ -0,0 +1,286 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prommetric provides a telemetry.MetricSink implementation backed by
+// the Prometheus client library, exposing its metrics for scraping.
+package prommetric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/internal/labels"
+)
+
+// compile time check for compatibility with the telemetry.MetricSink interface.
+var _ telemetry.MetricSink = (*Sink)(nil)
+
+type (
+	// Option implements a functional option type for the Sink.
+	Option func(*options)
+
+	// options holds the configuration of a Sink.
+	options struct {
+		registry     *prometheus.Registry
+		namespace    string
+		errorHandler func(error)
+		exemplars    telemetry.KeyValuesProvider
+	}
+)
+
+// WithRegistry sets the Prometheus registry the Sink registers its metrics
+// with and gathers them from. By default, a new registry is created.
+func WithRegistry(r *prometheus.Registry) Option {
+	return func(o *options) {
+		o.registry = r
+	}
+}
+
+// WithNamespace sets the namespace prefixed to the name of each metric,
+// separated by an underscore.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithErrorHandler sets the function receiving errors registering metrics. By
+// default, these errors are written to os.Stderr.
+func WithErrorHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = fn
+	}
+}
+
+// WithExemplars enables exemplars on Distributions. When recording with a
+// Context, the key-value pairs returned by the provider become the labels of
+// the exemplar attached to the observation, e.g. using
+// otelbridge.ExemplarKeyValues to link histogram buckets to sampled traces.
+// No exemplar is attached if the provider returns no key-value pairs or their
+// total length exceeds prometheus.ExemplarMaxRunes. Exemplars are exposed by
+// the Handler if the scraper negotiates the OpenMetrics format.
+func WithExemplars(provider telemetry.KeyValuesProvider) Option {
+	return func(o *options) {
+		o.exemplars = provider
+	}
+}
+
+// Sink creates Metrics backed by Prometheus collectors.
+//
+// Sums are backed by counters, Gauges by gauges and Distributions by
+// histograms. As Prometheus counters can only increase, negative values
+// recorded on Sums are ignored. The name of a Metric with a Unit other than
+// telemetry.None is suffixed with the unit, e.g. "_seconds", unless already
+// present.
+//
+// The Labels registered on a Metric using telemetry.WithLabels become its
+// Prometheus labels, Labels not created by a Sink are ignored. When recording, label values are taken in sequence from
+// the key-value pairs found in Context with a key matching a label name, the
+// LabelValues found in Context and the LabelValues added through With.
+// Labels without a value are exported as empty.
+type Sink struct {
+	opts options
+}
+
+// New returns a new Sink.
+func New(opts ...Option) *Sink {
+	o := options{
+		errorHandler: func(err error) {
+			_, _ = fmt.Fprintf(os.Stderr, "telemetry: %v\n", err)
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.registry == nil {
+		o.registry = prometheus.NewRegistry()
+	}
+	return &Sink{opts: o}
+}
+
+// Registry returns the Prometheus registry holding the metrics of the Sink.
+func (s *Sink) Registry() *prometheus.Registry {
+	return s.opts.registry
+}
+
+// Handler returns a http.Handler exposing the metrics of the Sink in the
+// Prometheus exposition format, to be served on e.g. /metrics.
+func (s *Sink) Handler() http.Handler {
+	return promhttp.HandlerFor(s.opts.registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// NewSum implements telemetry.MetricSink.
+func (s *Sink) NewSum(name, description string, opts ...telemetry.MetricOption) telemetry.Metric {
+	m := s.newMetric(name, opts)
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: m.name, Help: description}, m.labels)
+	if vec, ok := s.register(m.name, vec).(*prometheus.CounterVec); ok {
+		m.observe = func(_ context.Context, lvs []string, value float64) {
+			if value >= 0 {
+				vec.WithLabelValues(lvs...).Add(value)
+			}
+		}
+	}
+	return m
+}
+
+// NewGauge implements telemetry.MetricSink.
+func (s *Sink) NewGauge(name, description string, opts ...telemetry.MetricOption) telemetry.Metric {
+	m := s.newMetric(name, opts)
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: m.name, Help: description}, m.labels)
+	if vec, ok := s.register(m.name, vec).(*prometheus.GaugeVec); ok {
+		m.observe = func(_ context.Context, lvs []string, value float64) {
+			vec.WithLabelValues(lvs...).Set(value)
+		}
+	}
+	return m
+}
+
+// NewDistribution implements telemetry.MetricSink. If configured using
+// telemetry.WithExponentialBuckets, the histogram is maintained as a native
+// histogram, next to the classic histogram with the provided bounds, if any.
+// Otherwise, if no bounds are provided, prometheus.DefBuckets is used.
+func (s *Sink) NewDistribution(name, description string, bounds []float64, opts ...telemetry.MetricOption) telemetry.Metric {
+	m := s.newMetric(name, opts)
+	hOpts := prometheus.HistogramOpts{Name: m.name, Help: description, Buckets: bounds}
+	if exp := metricOptions(opts).ExponentialBuckets; exp != nil {
+		hOpts.NativeHistogramBucketFactor = math.Pow(2, math.Pow(2, -float64(exp.MaxScale)))
+		hOpts.NativeHistogramMaxBucketNumber = uint32(exp.MaxSize)
+	} else if len(bounds) == 0 {
+		hOpts.Buckets = prometheus.DefBuckets
+	}
+	vec := prometheus.NewHistogramVec(hOpts, m.labels)
+	if vec, ok := s.register(m.name, vec).(*prometheus.HistogramVec); ok {
+		m.observe = func(ctx context.Context, lvs []string, value float64) {
+			o := vec.WithLabelValues(lvs...)
+			if eo, ok := o.(prometheus.ExemplarObserver); ok {
+				if labels := s.exemplar(ctx); labels != nil {
+					eo.ObserveWithExemplar(value, labels)
+					return
+				}
+			}
+			o.Observe(value)
+		}
+	}
+	return m
+}
+
+// NewLabel implements telemetry.MetricSink. Label names must be valid
+// Prometheus label names.
+func (s *Sink) NewLabel(name string) telemetry.Label {
+	return labels.Label(name)
+}
+
+// ContextWithLabels implements telemetry.MetricSink. It returns an error if the
+// provided values hold invalid label names or were not created by a Sink.
+func (s *Sink) ContextWithLabels(ctx context.Context, values ...telemetry.LabelValue) (context.Context, error) {
+	return ctxLabels.WithValues(ctx, values, validLabelName)
+}
+
+// metricOptions returns the MetricOptions configured by the provided options.
+func metricOptions(opts []telemetry.MetricOption) telemetry.MetricOptions {
+	var o telemetry.MetricOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// newMetric returns a Metric not yet backed by a collector.
+func (s *Sink) newMetric(name string, opts []telemetry.MetricOption) *metric {
+	o := metricOptions(opts)
+	if s.opts.namespace != "" {
+		name = s.opts.namespace + "_" + name
+	}
+	if suffix := unitSuffix(o.Unit); suffix != "" && !strings.HasSuffix(name, suffix) {
+		name += suffix
+	}
+	return &metric{
+		name:    name,
+		labels:  labels.Names(o.Labels),
+		enabled: o.EnabledCondition,
+		observe: func(context.Context, []string, float64) {},
+	}
+}
+
+// register registers the collector, returning the already registered collector
+// if an identical one exists, or nil if registration failed.
+func (s *Sink) register(name string, c prometheus.Collector) prometheus.Collector {
+	err := s.opts.registry.Register(c)
+	if err == nil {
+		return c
+	}
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if reflect.TypeOf(are.ExistingCollector) == reflect.TypeOf(c) {
+			return are.ExistingCollector
+		}
+		err = fmt.Errorf("metric %q already registered with a different type", name)
+	}
+	s.opts.errorHandler(err)
+	return nil
+}
+
+// exemplar returns the exemplar labels provided for the Context, or nil if
+// none.
+func (s *Sink) exemplar(ctx context.Context) prometheus.Labels {
+	if s.opts.exemplars == nil || ctx == nil {
+		return nil
+	}
+	kvs := s.opts.exemplars(ctx)
+	if len(kvs) < 2 {
+		return nil
+	}
+	var (
+		labels = make(prometheus.Labels, len(kvs)/2)
+		runes  int
+	)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		k, ok := kvs[i].(string)
+		if !ok || !validLabelName(k) {
+			continue
+		}
+		v := fmt.Sprint(kvs[i+1])
+		labels[k] = v
+		runes += utf8.RuneCountInString(k) + utf8.RuneCountInString(v)
+	}
+	if len(labels) == 0 || runes > prometheus.ExemplarMaxRunes {
+		return nil
+	}
+	return labels
+}
+
+// unitSuffix returns the metric name suffix for the provided Unit.
+func unitSuffix(unit telemetry.Unit) string {
+	switch unit {
+	case telemetry.Seconds:
+		return "_seconds"
+	case telemetry.Milliseconds:
+		return "_milliseconds"
+	case telemetry.Bytes:
+		return "_bytes"
+	default:
+		return ""
+	}
+}
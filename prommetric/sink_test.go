@@ -0,0 +1,192 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetric
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func scrape(t *testing.T, s *Sink) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	b, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(b)
+}
+
+func expectLines(t *testing.T, have string, want ...string) {
+	t.Helper()
+	for _, line := range want {
+		if !strings.Contains(have, line+"\n") {
+			t.Errorf("expected %q in:\n%s", line, have)
+		}
+	}
+}
+
+func TestSink(t *testing.T) {
+	s := New(WithNamespace("app"))
+
+	sum := s.NewSum("requests_total", "handled requests")
+	sum.Increment()
+	sum.Record(2)
+	sum.Decrement()
+
+	gauge := s.NewGauge("connections", "open connections")
+	gauge.Record(3)
+	gauge.Decrement()
+
+	dist := s.NewDistribution("latency", "request latency", []float64{0.1, 1}, telemetry.WithUnit(telemetry.Seconds))
+	dist.Record(0.5)
+
+	expectLines(t, scrape(t, s),
+		"# HELP app_requests_total handled requests",
+		"# TYPE app_requests_total counter",
+		"app_requests_total 3",
+		"# TYPE app_connections gauge",
+		"app_connections -1",
+		"# TYPE app_latency_seconds histogram",
+		`app_latency_seconds_bucket{le="0.1"} 0`,
+		`app_latency_seconds_bucket{le="1"} 1`,
+		"app_latency_seconds_sum 0.5",
+	)
+}
+
+func TestLabels(t *testing.T) {
+	var (
+		s      = New()
+		tenant = s.NewLabel("tenant")
+		region = s.NewLabel("region")
+		code   = s.NewLabel("code")
+		m      = s.NewSum("hits_total", "hits", telemetry.WithLabels(tenant, region, code))
+	)
+
+	ctx := telemetry.KeyValuesToContext(context.Background(), "tenant", "acme", "ignored", 1)
+	m.RecordContext(ctx, 1)
+
+	ctx, err := s.ContextWithLabels(ctx, region.Upsert("eu"), tenant.Update("globex"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.RecordContext(ctx, 1)
+	m.With(region.Delete(), code.Insert("200")).RecordContext(ctx, 1)
+	m.With(code.Upsert("500")).RecordContext(ctx, 1)
+	m.With(tenant.Insert("initech")).Record(1)
+
+	expectLines(t, scrape(t, s),
+		`hits_total{code="",region="",tenant="acme"} 1`,
+		`hits_total{code="",region="eu",tenant="globex"} 1`,
+		`hits_total{code="200",region="",tenant="globex"} 1`,
+		`hits_total{code="500",region="eu",tenant="globex"} 1`,
+		`hits_total{code="",region="",tenant="initech"} 1`,
+	)
+
+	if _, err := s.ContextWithLabels(ctx, s.NewLabel("in-valid").Upsert("x")); err == nil {
+		t.Errorf("expected error for invalid label name")
+	}
+	if _, err := s.ContextWithLabels(ctx, "foreign"); err == nil {
+		t.Errorf("expected error for foreign label value")
+	}
+}
+
+func TestLoggerMetric(t *testing.T) {
+	var (
+		s = New()
+		m = s.NewSum("log_messages_total", "log messages", telemetry.WithLabels(s.NewLabel(LevelLabel)))
+	)
+	logger := function.NewLogger(func(telemetry.Level, string, error, function.Values, int) {}, 0).Metric(m)
+
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Warn("warn")
+	logger.Debug("debug")
+
+	expectLines(t, scrape(t, s),
+		`log_messages_total{level="info"} 1`,
+		`log_messages_total{level="warn"} 2`,
+	)
+}
+
+func TestDuplicateRegistration(t *testing.T) {
+	var errs []error
+	s := New(WithErrorHandler(func(err error) { errs = append(errs, err) }))
+
+	s.NewSum("dup_total", "dup").Increment()
+	s.NewSum("dup_total", "dup").Increment()
+	s.NewGauge("dup_total", "dup").Record(5)
+
+	expectLines(t, scrape(t, s), "dup_total 2")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 registration error, have %v", errs)
+	}
+}
+
+type ctxTrace struct{}
+
+func TestExemplars(t *testing.T) {
+	s := New(WithExemplars(func(ctx context.Context) []interface{} {
+		if id, ok := ctx.Value(ctxTrace{}).(string); ok {
+			return []interface{}{"trace_id", id}
+		}
+		return nil
+	}))
+	m := s.NewDistribution("latency", "request latency", []float64{0.1, 1})
+	m.RecordContext(context.WithValue(context.Background(), ctxTrace{}, "4bf92f3577b34da6"), 0.5)
+	m.RecordContext(context.Background(), 0.05)
+	m.RecordContext(context.WithValue(context.Background(), ctxTrace{}, strings.Repeat("x", 128)), 2)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	s.Handler().ServeHTTP(rec, req)
+	have := rec.Body.String()
+
+	if !strings.Contains(have, `latency_bucket{le="1.0"} 2 # {trace_id="4bf92f3577b34da6"} 0.5`) {
+		t.Errorf("expected exemplar in:\n%s", have)
+	}
+	if strings.Count(have, "# {") != 1 {
+		t.Errorf("expected a single exemplar in:\n%s", have)
+	}
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	s := New()
+	s.NewDistribution("native", "native only", nil, telemetry.WithExponentialBuckets(100, 3)).Record(0.5)
+	s.NewDistribution("both", "native and classic", []float64{1}, telemetry.WithExponentialBuckets(0, 0)).Record(0.5)
+
+	mfs, err := s.Registry().Gather()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, mf := range mfs {
+		h := mf.GetMetric()[0].GetHistogram()
+		if have := h.GetSchema(); h.Schema == nil || (mf.GetName() == "native" && have != 3) {
+			t.Errorf("%s: unexpected native histogram schema %v", mf.GetName(), h.Schema)
+		}
+		want := map[string]int{"native": 0, "both": 1}[mf.GetName()]
+		if have := len(h.GetBucket()); have != want {
+			t.Errorf("%s: expected %d classic buckets, have %d", mf.GetName(), want, have)
+		}
+	}
+}
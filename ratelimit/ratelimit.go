@@ -0,0 +1,323 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit provides a telemetry.Logger decorator capping the rate of
+// log lines per message, protecting sinks during error storms.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/internal/filter"
+)
+
+// SuppressedMessage is the message of the log line reporting suppressed log
+// lines.
+const SuppressedMessage = "suppressed similar log messages"
+
+// SummaryMessage is the message of the log line reporting the lifetime totals
+// on Close.
+const SummaryMessage = "rate limiter closed"
+
+// DefaultReportInterval is the default interval at which suppressed log lines
+// are reported.
+const DefaultReportInterval = time.Second
+
+// maxIdleBuckets is the number of buckets above which idle buckets are
+// removed.
+const maxIdleBuckets = 1024
+
+// compile time checks for compatibility with the filter.Policy and
+// telemetry.Logger interfaces.
+var (
+	_ filter.Policy    = (*tracker)(nil)
+	_ telemetry.Logger = (*Logger)(nil)
+)
+
+type (
+	// Option implements a functional option type for the rate limited Logger.
+	Option func(*options)
+
+	// options holds the configuration of a rate limited Logger.
+	options struct {
+		reportInterval time.Duration
+		summary        bool
+	}
+
+	// key identifies the log lines sharing a token bucket.
+	key struct {
+		level telemetry.Level
+		msg   string
+	}
+
+	// bucket holds the tokens available to a key.
+	bucket struct {
+		tokens     float64
+		last       time.Time
+		suppressed int
+		// logger holds the Logger which suppressed the most recent log line,
+		// used to report suppressed log lines once the storm stops.
+		logger telemetry.Logger
+	}
+
+	// tracker holds the token buckets of the seen log messages.
+	tracker struct {
+		family   *family
+		mtx      sync.Mutex
+		rate     float64
+		burst    int
+		interval time.Duration
+		now      func() time.Time
+		buckets  map[key]*bucket
+		timer    *time.Timer
+		// emitted and suppressed hold the lifetime totals of allowed and
+		// suppressed log lines.
+		emitted    uint64
+		suppressed uint64
+	}
+
+	// family holds the tracker of a Logger and the trackers of its clones,
+	// so Close reports the suppressed log lines of all of them.
+	family struct {
+		mtx      sync.Mutex
+		trackers []*tracker
+	}
+)
+
+// WithReportInterval sets the interval at which log lines reporting the
+// number of suppressed log lines are emitted. The default is
+// DefaultReportInterval.
+func WithReportInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.reportInterval = d
+	}
+}
+
+// WithSummary sets whether Close logs the lifetime totals of emitted and
+// suppressed log lines. It is enabled by default.
+func WithSummary(enabled bool) Option {
+	return func(o *options) {
+		o.summary = enabled
+	}
+}
+
+// Logger is a rate limited telemetry.Logger. Close it on shutdown to report
+// the pending and lifetime totals of suppressed log lines.
+type Logger struct {
+	*filter.Logger
+
+	logger    telemetry.Logger
+	family    *family
+	summary   bool
+	closeOnce sync.Once
+}
+
+// New returns a Logger which forwards at most rate log lines per
+// second for each distinct (level, message) combination, allowing bursts of up
+// to burst log lines. Log lines exceeding the rate are dropped. Once a
+// message is allowed again, a log line at the same level reporting the number
+// of suppressed log lines precedes it. Suppressed log lines not reported that
+// way are reported at the report interval, so the count of a storm that stops
+// is not lost. The reporting log lines do not record an attached Metric.
+//
+// Loggers derived through With, Context and Metric share the rate limits with
+// the Logger they were derived from, while Clone returns a Logger with its own
+// rate limits, which are reported on by Close as well. Dropped Info, Warn and
+// Error log lines do not record an attached Metric. Fatal log lines are never
+// dropped. The stack frame added by the decorator is accounted for in the
+// caller skip of the decorated Logger.
+func New(l telemetry.Logger, rate float64, burst int, opts ...Option) *Logger {
+	o := options{reportInterval: DefaultReportInterval, summary: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	t := newTracker(rate, burst, o.reportInterval, time.Now)
+	return &Logger{
+		Logger:  filter.New(l, t),
+		logger:  l,
+		family:  t.family,
+		summary: o.summary,
+	}
+}
+
+// Close reports the suppressed log lines not reported yet, including those of
+// the Loggers returned by Clone, and, unless disabled using WithSummary, logs
+// their combined lifetime totals at Info level through the decorated Logger,
+// without recording its Metric. Subsequent calls do nothing.
+func (l *Logger) Close() error {
+	l.closeOnce.Do(func() {
+		l.family.mtx.Lock()
+		trackers := l.family.trackers
+		l.family.mtx.Unlock()
+
+		var emitted, suppressed uint64
+		for _, t := range trackers {
+			t.mtx.Lock()
+			if t.timer != nil {
+				t.timer.Stop()
+			}
+			t.mtx.Unlock()
+			t.flush()
+
+			t.mtx.Lock()
+			emitted += t.emitted
+			suppressed += t.suppressed
+			t.mtx.Unlock()
+		}
+
+		if !l.summary {
+			return
+		}
+		l.logger.Info(SummaryMessage, "emitted", emitted, "suppressed", suppressed, telemetry.NoMetric, true)
+	})
+	return nil
+}
+
+func newTracker(rate float64, burst int, interval time.Duration, now func() time.Time) *tracker {
+	if burst < 1 {
+		burst = 1
+	}
+	t := &tracker{
+		family:   &family{},
+		rate:     rate,
+		burst:    burst,
+		interval: interval,
+		now:      now,
+		buckets:  make(map[key]*bucket),
+	}
+	t.family.trackers = append(t.family.trackers, t)
+	return t
+}
+
+// allow reports if a log line with the provided level and message can be
+// forwarded. If allowed, it also returns the number of log lines suppressed
+// since the previous allowed one. The provided Logger is used to report
+// suppressed log lines at the report interval.
+func (t *tracker) allow(l telemetry.Logger, level telemetry.Level, msg string) (allowed bool, suppressed int) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	now := t.now()
+	k := key{level: level, msg: msg}
+	b, ok := t.buckets[k]
+	if !ok {
+		if len(t.buckets) >= maxIdleBuckets {
+			t.prune(now)
+		}
+		b = &bucket{tokens: float64(t.burst), last: now}
+		t.buckets[k] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * t.rate
+	if b.tokens > float64(t.burst) {
+		b.tokens = float64(t.burst)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		t.suppressed++
+		b.suppressed++
+		b.logger = l
+		if t.timer == nil {
+			t.timer = time.AfterFunc(t.interval, t.flush)
+		}
+		return false, 0
+	}
+	b.tokens--
+	t.emitted++
+	suppressed, b.suppressed = b.suppressed, 0
+	b.logger = nil
+	return true, suppressed
+}
+
+// flush reports the suppressed log lines of all buckets.
+func (t *tracker) flush() {
+	type pending struct {
+		logger     telemetry.Logger
+		key        key
+		suppressed int
+	}
+	var reports []pending
+
+	t.mtx.Lock()
+	t.timer = nil
+	for k, b := range t.buckets {
+		if b.suppressed > 0 {
+			reports = append(reports, pending{logger: b.logger, key: k, suppressed: b.suppressed})
+			b.suppressed, b.logger = 0, nil
+		}
+	}
+	t.mtx.Unlock()
+
+	for _, r := range reports {
+		report(r.logger, r.key.level, r.key.msg, r.suppressed)
+	}
+}
+
+// report logs the number of suppressed log lines of a message at the level of
+// the message, without recording the attached Metric.
+func report(l telemetry.Logger, level telemetry.Level, msg string, suppressed int) {
+	kvs := []interface{}{"suppressed_msg", msg, "count", suppressed, telemetry.NoMetric, true}
+	switch level {
+	case telemetry.LevelError:
+		l.Error(SuppressedMessage, nil, kvs...)
+	case telemetry.LevelWarn:
+		l.Warn(SuppressedMessage, kvs...)
+	case telemetry.LevelInfo:
+		l.Info(SuppressedMessage, kvs...)
+	case telemetry.LevelDebug:
+		l.Debug(SuppressedMessage, kvs...)
+	default:
+		l.Trace(SuppressedMessage, kvs...)
+	}
+}
+
+// prune removes the buckets which are full and have no pending suppressed
+// log lines, as they behave identical to new buckets.
+func (t *tracker) prune(now time.Time) {
+	for k, b := range t.buckets {
+		if b.suppressed == 0 && b.tokens+now.Sub(b.last).Seconds()*t.rate >= float64(t.burst) {
+			delete(t.buckets, k)
+		}
+	}
+}
+
+// Allow implements filter.Policy. It reports the log lines suppressed before
+// an allowed log line.
+func (t *tracker) Allow(l telemetry.Logger, level telemetry.Level, msg string) bool {
+	allowed, suppressed := t.allow(l, level, msg)
+	if suppressed > 0 {
+		report(l, level, msg, suppressed)
+	}
+	return allowed
+}
+
+// Clone implements filter.Policy. The clone joins the family of the tracker,
+// so it is flushed on Close.
+func (t *tracker) Clone() filter.Policy {
+	c := &tracker{
+		family:   t.family,
+		rate:     t.rate,
+		burst:    t.burst,
+		interval: t.interval,
+		now:      t.now,
+		buckets:  make(map[key]*bucket),
+	}
+	t.family.mtx.Lock()
+	t.family.trackers = append(t.family.trackers, c)
+	t.family.mtx.Unlock()
+	return c
+}
@@ -0,0 +1,257 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+	"github.com/basvanbeek/telemetry/internal/filter"
+)
+
+func TestRateLimit(t *testing.T) {
+	var msgs []string
+	inner := function.NewLogger(func(level telemetry.Level, msg string, _ error, values function.Values, _ int) {
+		msgs = append(msgs, fmt.Sprintf("%s %s %v", level, msg, values.FromMethod))
+	}, 0)
+	inner.SetLevel(telemetry.LevelDebug)
+
+	now := time.Unix(0, 0)
+	l := filter.New(inner, newTracker(1, 2, time.Hour, func() time.Time { return now }))
+
+	// a burst of two log lines is allowed per message.
+	for i := 0; i < 5; i++ {
+		l.Error("failed", nil, "i", i)
+		l.With("key", "value").Info("other")
+	}
+	l.Debug("failed")
+
+	want := []string{
+		"error failed [i 0]",
+		"info other []",
+		"error failed [i 1]",
+		"info other []",
+		"debug failed []",
+	}
+	if !reflect.DeepEqual(want, msgs) {
+		t.Fatalf("want: %v\nhave: %v", want, msgs)
+	}
+
+	// tokens are refilled at the configured rate and suppressed log lines are
+	// reported.
+	msgs = nil
+	now = now.Add(time.Second)
+	l.Error("failed", nil, "i", 5)
+	l.Error("failed", nil, "i", 6)
+	want = []string{
+		"error " + SuppressedMessage + " [suppressed_msg failed count 3]",
+		"error failed [i 5]",
+	}
+	if !reflect.DeepEqual(want, msgs) {
+		t.Fatalf("want: %v\nhave: %v", want, msgs)
+	}
+
+	// a clone has its own rate limits.
+	msgs = nil
+	c := l.Clone()
+	c.SetLevel(telemetry.LevelInfo)
+	c.Error("failed", nil)
+	if want = []string{"error failed []"}; !reflect.DeepEqual(want, msgs) {
+		t.Fatalf("want: %v\nhave: %v", want, msgs)
+	}
+}
+
+func TestRateLimitPrune(t *testing.T) {
+	now := time.Unix(0, 0)
+	tr := newTracker(1, 1, time.Hour, func() time.Time { return now })
+	l := telemetry.NoopLogger()
+
+	for i := 0; i < maxIdleBuckets; i++ {
+		tr.allow(l, telemetry.LevelInfo, fmt.Sprint(i))
+	}
+	tr.allow(l, telemetry.LevelInfo, "0")
+
+	// after refilling, idle buckets are removed once the limit is reached,
+	// while buckets with suppressed log lines are kept.
+	now = now.Add(time.Second)
+	tr.allow(l, telemetry.LevelInfo, "new")
+	if len(tr.buckets) != 2 {
+		t.Fatalf("expected 2 buckets, have %d", len(tr.buckets))
+	}
+}
+
+func TestRateLimitStormStops(t *testing.T) {
+	var (
+		mtx    sync.Mutex
+		msgs   []string
+		metric = &mockMetric{}
+	)
+	inner := function.NewLogger(func(level telemetry.Level, msg string, _ error, values function.Values, _ int) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		msgs = append(msgs, fmt.Sprintf("%s %s %v", level, msg, values.FromMethod))
+	}, 0)
+	inner.SetLevel(telemetry.LevelInfo)
+
+	l := New(inner, 0.001, 1, WithReportInterval(10*time.Millisecond)).Metric(metric)
+	for i := 0; i < 4; i++ {
+		l.Warn("storm", "i", i)
+	}
+
+	// the storm stops, its suppressed log lines are reported at the report
+	// interval without recording the Metric.
+	want := []string{
+		"warn storm [i 0]",
+		"warn " + SuppressedMessage + " [suppressed_msg storm count 3]",
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mtx.Lock()
+		have := append([]string(nil), msgs...)
+		mtx.Unlock()
+		if reflect.DeepEqual(want, have) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("want: %v\nhave: %v", want, have)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	metric.mtx.Lock()
+	if metric.count != 1 {
+		t.Fatalf("expected metric count 1, have %v", metric.count)
+	}
+	metric.mtx.Unlock()
+
+	// once reported, the count is not reported again.
+	time.Sleep(50 * time.Millisecond)
+	mtx.Lock()
+	defer mtx.Unlock()
+	if !reflect.DeepEqual(want, msgs) {
+		t.Fatalf("want: %v\nhave: %v", want, msgs)
+	}
+}
+
+func TestRateLimitClose(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{"summary", nil, []string{
+			"warn storm [i 0]",
+			"warn " + SuppressedMessage + " [suppressed_msg storm count 2]",
+			"info " + SummaryMessage + " [emitted 1 suppressed 2]",
+		}},
+		{"no summary", []Option{WithSummary(false)}, []string{
+			"warn storm [i 0]",
+			"warn " + SuppressedMessage + " [suppressed_msg storm count 2]",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msgs []string
+			inner := function.NewLogger(func(level telemetry.Level, msg string, _ error, values function.Values, _ int) {
+				msgs = append(msgs, fmt.Sprintf("%s %s %v", level, msg, values.FromMethod))
+			}, 0)
+			inner.SetLevel(telemetry.LevelInfo)
+
+			l := New(inner, 0.001, 1, append(tt.opts, WithReportInterval(time.Hour))...)
+			for i := 0; i < 3; i++ {
+				l.Warn("storm", "i", i)
+			}
+			if err := l.Close(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_ = l.Close()
+
+			if !reflect.DeepEqual(tt.want, msgs) {
+				t.Fatalf("want: %v\nhave: %v", tt.want, msgs)
+			}
+		})
+	}
+}
+
+func TestRateLimitCaller(t *testing.T) {
+	var lines []string
+	inner := function.NewLogger(func(_ telemetry.Level, msg string, _ error, values function.Values, _ int) {
+		frame, _ := values.Caller.Resolve()
+		lines = append(lines, fmt.Sprintf("%s %s:%d", msg, filepath.Base(frame.File), frame.Line))
+	}, 0, function.WithCaller())
+
+	l := New(inner, 1, 1, WithSummary(false))
+	defer func() { _ = l.Close() }()
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Info("decorated")
+	l.With("key", "value").Info("derived")
+	inner.Info("inner")
+
+	want := []string{
+		fmt.Sprintf("decorated ratelimit_test.go:%d", line+1),
+		fmt.Sprintf("derived ratelimit_test.go:%d", line+2),
+		fmt.Sprintf("inner ratelimit_test.go:%d", line+3),
+	}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+}
+
+func TestRateLimitCloseClones(t *testing.T) {
+	var msgs []string
+	inner := function.NewLogger(func(level telemetry.Level, msg string, _ error, values function.Values, _ int) {
+		msgs = append(msgs, fmt.Sprintf("%s %s %v", level, msg, values.FromMethod))
+	}, 0)
+
+	l := New(inner, 0.001, 1, WithReportInterval(time.Hour))
+	c := l.Clone()
+	for i := 0; i < 3; i++ {
+		c.Warn("storm", "i", i)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the suppressed log lines of the clone are reported and counted.
+	want := []string{
+		"warn storm [i 0]",
+		"warn " + SuppressedMessage + " [suppressed_msg storm count 2]",
+		"info " + SummaryMessage + " [emitted 1 suppressed 2]",
+	}
+	if !reflect.DeepEqual(want, msgs) {
+		t.Fatalf("want: %v\nhave: %v", want, msgs)
+	}
+}
+
+type mockMetric struct {
+	telemetry.Metric
+	mtx   sync.Mutex
+	count float64
+}
+
+func (m *mockMetric) RecordContext(_ context.Context, value float64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.count += value
+}
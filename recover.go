@@ -0,0 +1,122 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// Keys of the key-value pairs describing a recovered panic.
+const (
+	PanicKey = "panic"
+	StackKey = "stack"
+)
+
+// RecoveredMessage is the message of the log lines reporting recovered
+// panics.
+const RecoveredMessage = "recovered from panic"
+
+type (
+	// RecoverOption implements a functional option type for Recover and
+	// RecoverContext.
+	RecoverOption func(*recoverOptions)
+
+	// recoverOptions holds the configuration of Recover and RecoverContext.
+	recoverOptions struct {
+		repanic bool
+		handler func(recovered interface{})
+	}
+)
+
+// Repanic makes Recover and RecoverContext panic again with the recovered
+// value after logging it, e.g. to let the process crash while making sure the
+// panic is reported through the configured sinks first.
+func Repanic() RecoverOption {
+	return func(o *recoverOptions) {
+		o.repanic = true
+	}
+}
+
+// WithRecoverHandler sets a function called with the recovered value after it
+// has been logged, e.g. to write an error response or to set a named error
+// result.
+func WithRecoverHandler(fn func(recovered interface{})) RecoverOption {
+	return func(o *recoverOptions) {
+		o.handler = fn
+	}
+}
+
+// Recover recovers from a panic and logs the recovered value and the stack of
+// the panicking goroutine at Error level through the provided Logger. It must
+// be called directly as a deferred function:
+//
+//	defer telemetry.Recover(logger)
+//
+// If the recovered value is an error, it is passed as the error of the log
+// line. Use RecoverContext to include the key-values found in a Context.
+func Recover(l Logger, opts ...RecoverOption) {
+	if recovered := recover(); recovered != nil {
+		handlePanic(context.Background(), l, recovered, opts)
+	}
+}
+
+// RecoverContext acts as Recover, including the key-value pairs found in the
+// provided Context in the log line. It must be called directly as a deferred
+// function:
+//
+//	defer telemetry.RecoverContext(ctx, logger)
+func RecoverContext(ctx context.Context, l Logger, opts ...RecoverOption) {
+	if recovered := recover(); recovered != nil {
+		handlePanic(ctx, l, recovered, opts)
+	}
+}
+
+// handlePanic logs the recovered value and applies the options.
+func handlePanic(ctx context.Context, l Logger, recovered interface{}, opts []RecoverOption) {
+	var o recoverOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	ReportPanic(ctx, l, recovered, debug.Stack())
+
+	if o.handler != nil {
+		o.handler(recovered)
+	}
+	if o.repanic {
+		panic(recovered)
+	}
+}
+
+// ReportPanic logs a value recovered from a panic at Error level through the
+// provided Logger, or the global Logger if nil, including the provided stack
+// trace and the key-value pairs found in Context. It is meant for code needing
+// to inspect the recovered value before deciding to report it, like
+// middleware. Otherwise, use Recover or RecoverContext.
+func ReportPanic(ctx context.Context, l Logger, recovered interface{}, stack []byte) {
+	err, ok := recovered.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", recovered)
+	}
+	if l == nil {
+		l = GlobalLogger()
+	}
+	l.Context(ctx).Error(RecoveredMessage, err,
+		PanicKey, fmt.Sprint(recovered),
+		StackKey, string(stack),
+	)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestRecover(t *testing.T) {
+	type line struct {
+		msg       string
+		err       string
+		keyValues []interface{}
+	}
+	var lines []line
+	logger := function.NewLogger(func(_ telemetry.Level, msg string, err error, values function.Values, _ int) {
+		kvs := append(append([]interface{}(nil), values.FromContext...), values.FromMethod...)
+		if stack := kvs[len(kvs)-1].(string); !strings.Contains(stack, "recover_test.go") {
+			t.Errorf("unexpected stack: %s", stack)
+		}
+		lines = append(lines, line{msg, err.Error(), kvs[:len(kvs)-1]})
+	}, 0)
+
+	var handled interface{}
+	func() {
+		defer telemetry.Recover(logger, telemetry.WithRecoverHandler(func(v interface{}) { handled = v }))
+		panic("boom")
+	}()
+	func() {
+		ctx := telemetry.KeyValuesToContext(context.Background(), "request_id", 1)
+		defer telemetry.RecoverContext(ctx, logger)
+		panic(errors.New("failed"))
+	}()
+	func() {
+		defer telemetry.Recover(logger)
+	}()
+
+	want := []line{
+		{telemetry.RecoveredMessage, "panic: boom", []interface{}{telemetry.PanicKey, "boom", telemetry.StackKey}},
+		{telemetry.RecoveredMessage, "failed", []interface{}{"request_id", 1, telemetry.PanicKey, "failed", telemetry.StackKey}},
+	}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+	if handled != "boom" {
+		t.Fatalf("want: boom\nhave: %v", handled)
+	}
+
+	defer func() {
+		if v := recover(); v != "again" {
+			t.Fatalf("want: again\nhave: %v", v)
+		}
+	}()
+	defer telemetry.Recover(logger, telemetry.Repanic())
+	panic("again")
+}
@@ -0,0 +1,176 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact provides a rule based function.Redactor scrubbing sensitive
+// data, like credentials, payment card numbers and personal data, from log
+// lines before they reach any sink:
+//
+//	redactor := redact.New(
+//		redact.Keys("password", "authorization"),
+//		redact.CardNumbers(),
+//		redact.EmailAddresses(),
+//	)
+//	function.SetGlobalRedactor(redactor)
+//
+// Use function.WithRedactor to apply a Redactor to a single Logger and
+// function.RedactEmit to apply it to a single destination.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Redacted replaces values redacted by the Keys rule.
+const Redacted = telemetry.RedactedText
+
+// Rule inspects a key/value pair and returns the value to log in its place. It
+// returns false if it leaves the value unchanged. Custom functions can be used
+// as Rule directly.
+type Rule func(key string, value interface{}) (interface{}, bool)
+
+// New returns a function.Redactor applying the rules to each key/value pair,
+// in order, each rule receiving the value returned by the previous one.
+// Non-string keys are passed to the rules in their fmt representation. Slices
+// holding redacted values are copied, the original key/value pairs are never
+// modified.
+func New(rules ...Rule) function.Redactor {
+	return func(values function.Values) function.Values {
+		values.FromContext = redact(rules, values.FromContext)
+		values.FromLogger = redact(rules, values.FromLogger)
+		values.FromMethod = redact(rules, values.FromMethod)
+		return values
+	}
+}
+
+// redact applies the rules to the key/value pairs, copying the slice on the
+// first change.
+func redact(rules []Rule, keyValues []interface{}) []interface{} {
+	copied := false
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		key, ok := keyValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyValues[i])
+		}
+		value, changed := keyValues[i+1], false
+		for _, rule := range rules {
+			if v, ok := rule(key, value); ok {
+				value, changed = v, true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if !copied {
+			keyValues = append([]interface{}(nil), keyValues...)
+			copied = true
+		}
+		keyValues[i+1] = value
+	}
+	return keyValues
+}
+
+// Keys returns a Rule replacing the values of the provided keys by Redacted.
+// Keys are matched case-insensitively.
+func Keys(keys ...string) Rule {
+	deny := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		deny[strings.ToLower(key)] = struct{}{}
+	}
+	return func(key string, _ interface{}) (interface{}, bool) {
+		if _, ok := deny[strings.ToLower(key)]; ok {
+			return Redacted, true
+		}
+		return nil, false
+	}
+}
+
+// Pattern returns a Rule replacing the matches of the regular expression in
+// string values by the replacement, which can refer to submatches as in
+// regexp.Regexp.ReplaceAllString. Values implementing error or fmt.Stringer
+// are scrubbed in their string representation, which then replaces the
+// value if it holds a match.
+func Pattern(re *regexp.Regexp, replacement string) Rule {
+	return func(_ string, value interface{}) (interface{}, bool) {
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case error:
+			s = v.Error()
+		case fmt.Stringer:
+			s = v.String()
+		default:
+			return nil, false
+		}
+		if !re.MatchString(s) {
+			return nil, false
+		}
+		return re.ReplaceAllString(s, replacement), true
+	}
+}
+
+// cardPattern matches sequences of 13 to 19 digits, optionally separated by
+// spaces or dashes.
+var cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// CardNumbers returns a Rule masking payment card numbers, as required by PCI
+// DSS, keeping only the last four digits. Only digit sequences passing the
+// Luhn checksum are masked, reducing false positives on other numbers.
+func CardNumbers() Rule {
+	return func(_ string, value interface{}) (interface{}, bool) {
+		s, ok := value.(string)
+		if !ok || !cardPattern.MatchString(s) {
+			return nil, false
+		}
+		changed := false
+		s = cardPattern.ReplaceAllStringFunc(s, func(match string) string {
+			digits := strings.NewReplacer(" ", "", "-", "").Replace(match)
+			if !luhn(digits) {
+				return match
+			}
+			changed = true
+			return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+		})
+		return s, changed
+	}
+}
+
+// luhn reports whether the digits pass the Luhn checksum.
+func luhn(digits string) bool {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// emailPattern matches e-mail addresses.
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// EmailAddresses returns a Rule replacing e-mail addresses in string values by
+// Redacted, as personal data under GDPR.
+func EmailAddresses() Rule {
+	return Pattern(emailPattern, Redacted)
+}
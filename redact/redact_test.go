@@ -0,0 +1,81 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  Rule
+		key   string
+		value interface{}
+		want  interface{}
+	}{
+		{"key", Keys("Password"), "password", "hunter2", Redacted},
+		{"other key", Keys("password"), "user", "alice", "alice"},
+		{"card", CardNumbers(), "msg", "paid with 4111 1111 1111 1111 today", "paid with ************1111 today"},
+		{"card dashes", CardNumbers(), "card", "5500-0000-0000-0004", "************0004"},
+		{"not luhn", CardNumbers(), "order", "1234567890123", "1234567890123"},
+		{"email", EmailAddresses(), "to", "mail alice@example.com now", "mail " + Redacted + " now"},
+		{"pattern error", Pattern(regexp.MustCompile(`token=\w+`), "token=***"), "err",
+			errors.New("invalid token=abc123"), "invalid token=***"},
+		{"pattern other type", Pattern(regexp.MustCompile(`1`), "*"), "n", 1, 1},
+		{"custom", func(key string, value interface{}) (interface{}, bool) {
+			if key == "ip" {
+				return "x.x.x.x", true
+			}
+			return nil, false
+		}, "ip", "10.0.0.1", "x.x.x.x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := New(tt.rule)(function.Values{FromMethod: []interface{}{tt.key, tt.value}})
+			if have := values.FromMethod[1]; !reflect.DeepEqual(tt.want, have) {
+				t.Fatalf("want: %v\nhave: %v", tt.want, have)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	var have function.Values
+	logger := function.NewLogger(func(_ telemetry.Level, _ string, _ error, values function.Values, _ int) {
+		have = values
+	}, 0, function.WithRedactor(New(Keys("token"), EmailAddresses())))
+
+	args := []interface{}{"user", "bob@example.com", "id", 1}
+	logger.With("token", "abc").Info("text", args...)
+
+	want := function.Values{
+		FromLogger: []interface{}{"token", Redacted},
+		FromMethod: []interface{}{"user", Redacted, "id", 1},
+	}
+	if !reflect.DeepEqual(want.FromLogger, have.FromLogger) || !reflect.DeepEqual(want.FromMethod, have.FromMethod) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+	if args[1] != "bob@example.com" {
+		t.Fatal("expected key/value pairs not to be modified")
+	}
+}
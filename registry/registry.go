@@ -0,0 +1,322 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package registry provides a telemetry.MetricSink decorator keeping a catalog
+// of the created Metrics. It namespaces metric names, rejects registrations
+// conflicting with an earlier registration of the same name and can write the
+// catalog for documentation purposes.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// Metric kinds as recorded in a Descriptor.
+const (
+	KindSum          = "sum"
+	KindGauge        = "gauge"
+	KindDistribution = "distribution"
+)
+
+// DefaultSeparator joins namespaces and metric names.
+const DefaultSeparator = "_"
+
+// ErrIncompatible is reported if a Metric is registered with the name of an
+// earlier registered Metric, but with a different kind, unit, label keys or
+// bucket bounds.
+var ErrIncompatible = errors.New("incompatible metric registration")
+
+// compile time check for compatibility with the telemetry.MetricSink interface.
+var _ telemetry.MetricSink = (*Registry)(nil)
+
+// Descriptor describes a registered Metric.
+type Descriptor struct {
+	Name        string         `json:"name"`
+	Kind        string         `json:"kind"`
+	Unit        telemetry.Unit `json:"unit,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Labels      []string       `json:"labels,omitempty"`
+	Bounds      []float64      `json:"bounds,omitempty"`
+}
+
+// compatible reports whether the Descriptors describe the same time series.
+// Descriptions may differ.
+func (d Descriptor) compatible(o Descriptor) bool {
+	return d.Kind == o.Kind && d.Unit == o.Unit && reflect.DeepEqual(d.Labels, o.Labels) &&
+		reflect.DeepEqual(d.Bounds, o.Bounds)
+}
+
+// schema returns a short representation of the Descriptor for error messages.
+func (d Descriptor) schema() string {
+	s := d.Kind
+	if d.Unit != "" {
+		s += " in " + string(d.Unit)
+	}
+	if len(d.Labels) > 0 {
+		s += " {" + strings.Join(d.Labels, ",") + "}"
+	}
+	if len(d.Bounds) > 0 {
+		s += fmt.Sprintf(" %v", d.Bounds)
+	}
+	return s
+}
+
+type (
+	// Option implements a functional option type for the Registry.
+	Option func(*options)
+
+	// options holds the configuration of a Registry.
+	options struct {
+		namespace    string
+		separator    string
+		errorHandler func(error)
+	}
+)
+
+// WithNamespace sets the namespace prefixed to the metric names.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithSeparator sets the separator joining namespaces and metric names, e.g.
+// "." for StatsD. By default, DefaultSeparator is used.
+func WithSeparator(separator string) Option {
+	return func(o *options) {
+		o.separator = separator
+	}
+}
+
+// WithErrorHandler sets the function receiving errors of rejected
+// registrations. By default, these errors are written to os.Stderr.
+func WithErrorHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = fn
+	}
+}
+
+// Registry is a telemetry.MetricSink creating Metrics on an underlying
+// MetricSink while recording their Descriptors.
+//
+// Registering a Metric with the name of an earlier registered Metric returns
+// the earlier Metric if their Descriptors are compatible. Otherwise, an error
+// wrapping ErrIncompatible is reported and a Metric discarding all
+// measurements is returned.
+//
+// Label keys are only known for Labels created through the Registry. Others
+// are recorded using their fmt.Sprint representation.
+type Registry struct {
+	sink      telemetry.MetricSink
+	namespace string
+	catalog   *catalog
+}
+
+// catalog holds the state shared by a Registry and its namespaces.
+type catalog struct {
+	opts options
+
+	mtx     sync.Mutex
+	entries map[string]entry
+	labels  map[telemetry.LabelValue]string
+}
+
+// entry holds a registered Metric and its Descriptor.
+type entry struct {
+	descriptor Descriptor
+	metric     telemetry.Metric
+}
+
+// New returns a Registry creating Metrics on the provided MetricSink.
+func New(sink telemetry.MetricSink, opts ...Option) *Registry {
+	o := options{
+		separator: DefaultSeparator,
+		errorHandler: func(err error) {
+			_, _ = fmt.Fprintf(os.Stderr, "telemetry: %v\n", err)
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Registry{
+		sink:      sink,
+		namespace: o.namespace,
+		catalog: &catalog{
+			opts:    o,
+			entries: make(map[string]entry),
+			labels:  make(map[telemetry.LabelValue]string),
+		},
+	}
+}
+
+// Namespace returns a Registry sharing the catalog of the Registry, with the
+// provided namespace appended to its namespace.
+func (r *Registry) Namespace(namespace string) *Registry {
+	return &Registry{
+		sink:      r.sink,
+		namespace: r.name(namespace),
+		catalog:   r.catalog,
+	}
+}
+
+// NewSum implements telemetry.MetricSink.
+func (r *Registry) NewSum(name, description string, opts ...telemetry.MetricOption) telemetry.Metric {
+	name = r.name(name)
+	return r.register(r.descriptor(name, KindSum, description, nil, opts), func() telemetry.Metric {
+		return r.sink.NewSum(name, description, opts...)
+	})
+}
+
+// NewGauge implements telemetry.MetricSink.
+func (r *Registry) NewGauge(name, description string, opts ...telemetry.MetricOption) telemetry.Metric {
+	name = r.name(name)
+	return r.register(r.descriptor(name, KindGauge, description, nil, opts), func() telemetry.Metric {
+		return r.sink.NewGauge(name, description, opts...)
+	})
+}
+
+// NewDistribution implements telemetry.MetricSink.
+func (r *Registry) NewDistribution(name, description string, bounds []float64, opts ...telemetry.MetricOption) telemetry.Metric {
+	name = r.name(name)
+	return r.register(r.descriptor(name, KindDistribution, description, bounds, opts), func() telemetry.Metric {
+		return r.sink.NewDistribution(name, description, bounds, opts...)
+	})
+}
+
+// NewLabel implements telemetry.MetricSink.
+func (r *Registry) NewLabel(name string) telemetry.Label {
+	l := r.sink.NewLabel(name)
+	// labels are identified by their Delete operation, so their key is also
+	// known if decorated.
+	if op := l.Delete(); op != nil && reflect.TypeOf(op).Comparable() {
+		r.catalog.mtx.Lock()
+		r.catalog.labels[op] = name
+		r.catalog.mtx.Unlock()
+	}
+	return l
+}
+
+// ContextWithLabels implements telemetry.MetricSink.
+func (r *Registry) ContextWithLabels(ctx context.Context, values ...telemetry.LabelValue) (context.Context, error) {
+	return r.sink.ContextWithLabels(ctx, values...)
+}
+
+// Catalog returns the Descriptors of the registered Metrics, sorted by name.
+func (r *Registry) Catalog() []Descriptor {
+	r.catalog.mtx.Lock()
+	defer r.catalog.mtx.Unlock()
+
+	descriptors := make([]Descriptor, 0, len(r.catalog.entries))
+	for _, e := range r.catalog.entries {
+		descriptors = append(descriptors, e.descriptor)
+	}
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].Name < descriptors[j].Name
+	})
+	return descriptors
+}
+
+// WriteCatalog writes the Catalog as a Markdown table.
+func (r *Registry) WriteCatalog(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("| Name | Kind | Unit | Labels | Description |\n")
+	sb.WriteString("|------|------|------|--------|-------------|\n")
+	for _, d := range r.Catalog() {
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n", d.Name, d.Kind, d.Unit,
+			strings.Join(d.Labels, ", "), strings.ReplaceAll(d.Description, "|", "\\|"))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// name returns the metric name prefixed with the namespace.
+func (r *Registry) name(name string) string {
+	if r.namespace == "" {
+		return name
+	}
+	return r.namespace + r.catalog.opts.separator + name
+}
+
+// descriptor returns the Descriptor of a Metric to be registered.
+func (r *Registry) descriptor(name, kind, description string, bounds []float64,
+	opts []telemetry.MetricOption) Descriptor {
+	var o telemetry.MetricOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	d := Descriptor{
+		Name:        name,
+		Kind:        kind,
+		Unit:        o.Unit,
+		Description: description,
+		Bounds:      append([]float64(nil), bounds...),
+	}
+	if len(d.Bounds) == 0 {
+		d.Bounds = nil
+	}
+
+	r.catalog.mtx.Lock()
+	defer r.catalog.mtx.Unlock()
+	for _, l := range o.Labels {
+		key := fmt.Sprint(l)
+		if op := l.Delete(); op != nil && reflect.TypeOf(op).Comparable() {
+			if k, ok := r.catalog.labels[op]; ok {
+				key = k
+			}
+		}
+		d.Labels = append(d.Labels, key)
+	}
+	return d
+}
+
+// register returns the registered Metric with the name of the Descriptor,
+// creating it if not found.
+func (r *Registry) register(d Descriptor, create func() telemetry.Metric) telemetry.Metric {
+	r.catalog.mtx.Lock()
+	defer r.catalog.mtx.Unlock()
+
+	if e, ok := r.catalog.entries[d.Name]; ok {
+		if e.descriptor.compatible(d) {
+			return e.metric
+		}
+		r.catalog.opts.errorHandler(fmt.Errorf("%w: %q registered as %s, have %s",
+			ErrIncompatible, d.Name, e.descriptor.schema(), d.schema()))
+		return discard{name: d.Name}
+	}
+	m := create()
+	r.catalog.entries[d.Name] = entry{descriptor: d, metric: m}
+	return m
+}
+
+// discard is a Metric discarding all measurements.
+type discard struct {
+	name string
+}
+
+func (d discard) Increment()                                    {}
+func (d discard) Decrement()                                    {}
+func (d discard) Name() string                                  { return d.name }
+func (d discard) Record(float64)                                {}
+func (d discard) RecordContext(context.Context, float64)        {}
+func (d discard) With(...telemetry.LabelValue) telemetry.Metric { return d }
@@ -0,0 +1,133 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+type labelOp struct {
+	name string
+}
+
+type mockLabel string
+
+func (l mockLabel) Insert(string) telemetry.LabelValue { return labelOp{string(l)} }
+func (l mockLabel) Update(string) telemetry.LabelValue { return labelOp{string(l)} }
+func (l mockLabel) Upsert(string) telemetry.LabelValue { return labelOp{string(l)} }
+func (l mockLabel) Delete() telemetry.LabelValue       { return labelOp{string(l)} }
+
+type mockMetric struct {
+	telemetry.Metric
+	name string
+}
+
+func (m *mockMetric) Name() string { return m.name }
+
+type mockSink struct {
+	created []string
+}
+
+func (s *mockSink) metric(name string) telemetry.Metric {
+	s.created = append(s.created, name)
+	return &mockMetric{name: name}
+}
+
+func (s *mockSink) NewSum(name, _ string, _ ...telemetry.MetricOption) telemetry.Metric {
+	return s.metric(name)
+}
+
+func (s *mockSink) NewGauge(name, _ string, _ ...telemetry.MetricOption) telemetry.Metric {
+	return s.metric(name)
+}
+
+func (s *mockSink) NewDistribution(name, _ string, _ []float64, _ ...telemetry.MetricOption) telemetry.Metric {
+	return s.metric(name)
+}
+
+func (s *mockSink) NewLabel(name string) telemetry.Label { return mockLabel(name) }
+
+func (s *mockSink) ContextWithLabels(ctx context.Context, _ ...telemetry.LabelValue) (context.Context, error) {
+	return ctx, nil
+}
+
+func TestRegistry(t *testing.T) {
+	var (
+		errs []error
+		sink mockSink
+		r    = New(&sink, WithNamespace("app"), WithErrorHandler(func(err error) { errs = append(errs, err) }))
+		http = r.Namespace("http")
+		code = http.NewLabel("code")
+	)
+
+	requests := http.NewSum("requests_total", "handled requests", telemetry.WithLabels(code))
+	if have := http.NewSum("requests_total", "other description", telemetry.WithLabels(code)); have != requests {
+		t.Errorf("expected compatible registration to return the registered Metric")
+	}
+	latency := r.NewDistribution("latency", "request latency", []float64{0.1, 1}, telemetry.WithUnit(telemetry.Seconds))
+	if have := latency.Name(); have != "app_latency" {
+		t.Errorf("expected app_latency, have %s", have)
+	}
+
+	rejected := http.NewGauge("requests_total", "handled requests")
+	rejected.Increment()
+	if _, ok := rejected.(discard); !ok {
+		t.Errorf("expected incompatible registration to be rejected, have %T", rejected)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrIncompatible) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if want, have := `incompatible metric registration: "app_http_requests_total" registered as sum {code}, have gauge`,
+		errs[0].Error(); want != have {
+		t.Errorf("want: %s\nhave: %s", want, have)
+	}
+
+	if want, have := []string{"app_http_requests_total", "app_latency"}, sink.created; !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %v\nhave: %v", want, have)
+	}
+
+	want := []Descriptor{
+		{Name: "app_http_requests_total", Kind: KindSum, Description: "handled requests", Labels: []string{"code"}},
+		{Name: "app_latency", Kind: KindDistribution, Unit: telemetry.Seconds, Description: "request latency",
+			Bounds: []float64{0.1, 1}},
+	}
+	if have := r.Catalog(); !reflect.DeepEqual(want, have) {
+		t.Errorf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestWriteCatalog(t *testing.T) {
+	r := New(&mockSink{}, WithSeparator("."), WithNamespace("app"))
+	r.NewGauge("connections", "open | active connections", telemetry.WithLabels(r.NewLabel("listener")))
+	r.NewSum("requests", "handled requests")
+
+	var sb strings.Builder
+	if err := r.WriteCatalog(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "| Name | Kind | Unit | Labels | Description |\n" +
+		"|------|------|------|--------|-------------|\n" +
+		"| app.connections | gauge |  | listener | open \\| active connections |\n" +
+		"| app.requests | sum |  |  | handled requests |\n"
+	if have := sb.String(); want != have {
+		t.Fatalf("want: %s\nhave: %s", want, have)
+	}
+}
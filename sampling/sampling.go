@@ -0,0 +1,311 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sampling provides head sampling of log lines, emitting only every
+// Nth occurrence or a random fraction of the log lines sharing the same
+// scope, level and message.
+package sampling
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+	"github.com/basvanbeek/telemetry/scope"
+)
+
+// DefaultResetInterval is the default interval at which the occurrence counts
+// are reset.
+const DefaultResetInterval = time.Minute
+
+// DefaultSampleRateKey is the default key of the sample rate added to the log
+// lines emitted by a Sampler.
+const DefaultSampleRateKey = "sample_rate"
+
+// SummaryMessage is the message of the log line reporting the lifetime totals
+// on Close.
+const SummaryMessage = "sampler closed"
+
+type (
+	// Option implements a functional option type for the Sampler.
+	Option func(*options)
+
+	// options holds the configuration of a Sampler.
+	options struct {
+		everyN        uint64
+		probability   float64
+		resetInterval time.Duration
+		level         telemetry.Level
+		droppedMetric telemetry.Metric
+		summary       bool
+		sampleRateKey string
+		now           func() time.Time
+	}
+)
+
+// WithEveryN emits the first occurrence of each key and every Nth occurrence
+// after that, within each reset interval.
+func WithEveryN(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.everyN = uint64(n)
+		}
+	}
+}
+
+// WithProbability emits each occurrence with the provided probability
+// between 0 and 1. Combined with WithEveryN, occurrences selected by the
+// every Nth rule are subject to the probability.
+func WithProbability(p float64) Option {
+	return func(o *options) {
+		o.probability = p
+	}
+}
+
+// WithResetInterval sets the interval at which the occurrence counts are
+// reset, bounding the memory used for distinct keys. The default is
+// DefaultResetInterval.
+func WithResetInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.resetInterval = d
+	}
+}
+
+// WithLevel only samples log lines at the provided level or more verbose
+// ones. Less verbose log lines, like errors when set to telemetry.LevelWarn,
+// are always emitted. By default all levels are sampled.
+func WithLevel(level telemetry.Level) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// WithDroppedMetric sets the Metric on which each log line sampled away is
+// recorded.
+func WithDroppedMetric(m telemetry.Metric) Option {
+	return func(o *options) {
+		o.droppedMetric = m
+	}
+}
+
+// WithSummary sets whether Close emits a log line with the lifetime totals of
+// emitted and sampled away log lines. It is enabled by default.
+func WithSummary(enabled bool) Option {
+	return func(o *options) {
+		o.summary = enabled
+	}
+}
+
+// WithSampleRateKey sets the key of the sample rate added to the log lines
+// emitted through the Sampler, allowing downstream systems to extrapolate
+// counts. The sample rate is the number of log lines each emitted log line
+// represents: one divided by the probability of WithProbability for the first
+// occurrence of a key in each reset interval, and the N of WithEveryN divided
+// by the probability for the occurrences after that. It is omitted if the
+// sample rate is 1, like for log lines not subject to sampling. An empty key
+// disables the field. The default is DefaultSampleRateKey.
+func WithSampleRateKey(key string) Option {
+	return func(o *options) {
+		o.sampleRateKey = key
+	}
+}
+
+// Key identifies log lines sampled together.
+type Key struct {
+	Scope   string
+	Level   telemetry.Level
+	Message string
+}
+
+// Sampler decides which log lines are emitted. Use Emit or EmitErr to wrap
+// the function emitting the log lines and Close it on shutdown.
+type Sampler struct {
+	// emitted and dropped hold the lifetime totals of log lines, kept first
+	// for 64-bit alignment of atomic operations.
+	emitted uint64
+	dropped uint64
+
+	opts options
+
+	mtx    sync.Mutex
+	rnd    *rand.Rand
+	start  time.Time
+	counts map[Key]uint64
+	// emits holds the wrapped functions, receiving the summary on Close.
+	emits  []function.EmitErr
+	closed bool
+}
+
+// New returns a Sampler. Without WithEveryN or WithProbability options, all
+// log lines are emitted.
+func New(opts ...Option) *Sampler {
+	o := options{
+		everyN:        1,
+		probability:   1,
+		resetInterval: DefaultResetInterval,
+		summary:       true,
+		sampleRateKey: DefaultSampleRateKey,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Sampler{
+		opts:   o,
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		start:  o.now(),
+		counts: make(map[Key]uint64),
+	}
+}
+
+// Sample reports whether the log line is to be emitted. The scope is taken
+// from the scope.Key key-value pair added to the Logger.
+func (s *Sampler) Sample(level telemetry.Level, msg string, values function.Values) bool {
+	keep, _ := s.selectLine(level, msg, values)
+	return keep
+}
+
+// selectLine reports whether the log line is to be emitted and the number of
+// log lines it represents if so.
+func (s *Sampler) selectLine(level telemetry.Level, msg string, values function.Values) (bool, float64) {
+	if level < s.opts.level {
+		atomic.AddUint64(&s.emitted, 1)
+		return true, 1
+	}
+	return s.sample(Key{Scope: scopeName(values), Level: level, Message: msg})
+}
+
+// annotate adds the sample rate to the key-value pairs of an emitted log line.
+func (s *Sampler) annotate(values function.Values, rate float64) function.Values {
+	if s.opts.sampleRateKey == "" || rate <= 1 {
+		return values
+	}
+	// the key-value pairs passed to the logging method are owned by the
+	// caller.
+	values.FromMethod = append(append(make([]interface{}, 0, len(values.FromMethod)+2),
+		values.FromMethod...), s.opts.sampleRateKey, rate)
+	return values
+}
+
+func (s *Sampler) sample(k Key) (bool, float64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.opts.resetInterval > 0 {
+		if now := s.opts.now(); now.Sub(s.start) >= s.opts.resetInterval {
+			s.start = now
+			s.counts = make(map[Key]uint64)
+		}
+	}
+
+	n := s.counts[k]
+	s.counts[k] = n + 1
+	keep := n%s.opts.everyN == 0
+	// the first occurrence represents only itself, the ones after that the
+	// occurrences since the previous one selected by every N.
+	rate := float64(s.opts.everyN)
+	if n == 0 {
+		rate = 1
+	}
+	if keep && s.opts.probability < 1 {
+		keep = s.rnd.Float64() < s.opts.probability
+		rate /= s.opts.probability
+	}
+	if !keep {
+		atomic.AddUint64(&s.dropped, 1)
+		if s.opts.droppedMetric != nil {
+			s.opts.droppedMetric.Record(1)
+		}
+	} else {
+		atomic.AddUint64(&s.emitted, 1)
+	}
+	return keep, rate
+}
+
+// Dropped returns the total number of log lines sampled away.
+func (s *Sampler) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close emits a log line at Info level with the lifetime totals through each
+// of the functions wrapped using Emit and EmitErr, unless disabled using
+// WithSummary. It returns the first error returned by these functions.
+// Subsequent calls do nothing.
+func (s *Sampler) Close() error {
+	s.mtx.Lock()
+	closing, emits := !s.closed, s.emits
+	s.closed = true
+	s.mtx.Unlock()
+
+	if !closing || !s.opts.summary {
+		return nil
+	}
+	var firstErr error
+	for _, emit := range emits {
+		err := emit(telemetry.LevelInfo, SummaryMessage, nil, function.Values{
+			FromMethod: []interface{}{"emitted", atomic.LoadUint64(&s.emitted), "dropped", s.Dropped()},
+		}, 0)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// wrap registers the wrapped function for the summary emitted on Close.
+func (s *Sampler) wrap(emit function.EmitErr) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.emits = append(s.emits, emit)
+}
+
+// Emit returns a function.Emit emitting the log lines selected by the Sampler
+// through the provided function.
+func (s *Sampler) Emit(emit function.Emit) function.Emit {
+	s.wrap(function.AsEmitErr(emit))
+	return func(level telemetry.Level, msg string, err error, values function.Values, callerSkip int) {
+		if keep, rate := s.selectLine(level, msg, values); keep {
+			emit(level, msg, err, s.annotate(values, rate), callerSkip+1)
+		}
+	}
+}
+
+// EmitErr returns a function.EmitErr emitting the log lines selected by the
+// Sampler through the provided function.
+func (s *Sampler) EmitErr(emit function.EmitErr) function.EmitErr {
+	s.wrap(emit)
+	return func(level telemetry.Level, msg string, err error, values function.Values, callerSkip int) error {
+		if keep, rate := s.selectLine(level, msg, values); keep {
+			return emit(level, msg, err, s.annotate(values, rate), callerSkip+1)
+		}
+		return nil
+	}
+}
+
+// scopeName returns the value of the last scope.Key key-value pair.
+func scopeName(values function.Values) string {
+	for _, kvs := range [][]interface{}{values.FromMethod, values.FromLogger, values.FromContext} {
+		for i := len(kvs) - 2; i >= 0; i -= 2 {
+			if k, ok := kvs[i].(string); ok && k == scope.Key {
+				if name, ok := kvs[i+1].(string); ok {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
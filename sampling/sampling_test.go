@@ -0,0 +1,239 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sampling
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+	"github.com/basvanbeek/telemetry/scope"
+)
+
+type mockMetric struct {
+	telemetry.Metric
+	mtx   sync.Mutex
+	count float64
+}
+
+func (m *mockMetric) Record(value float64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.count += value
+}
+
+type recorder struct {
+	msgs []string
+}
+
+func (r *recorder) emit(_ telemetry.Level, msg string, _ error, _ function.Values, _ int) {
+	r.msgs = append(r.msgs, msg)
+}
+
+func TestEveryN(t *testing.T) {
+	var (
+		m      = &mockMetric{}
+		r      = &recorder{}
+		s      = New(WithEveryN(3), WithDroppedMetric(m))
+		logger = function.NewLogger(s.Emit(r.emit), 0)
+		a      = logger.With(scope.Key, "a")
+		b      = logger.With(scope.Key, "b")
+	)
+
+	for i := 0; i < 7; i++ {
+		a.Info("msg")
+		b.Info("msg")
+		a.Warn("msg")
+	}
+
+	// each key emits occurrences 1, 4 and 7.
+	if have := len(r.msgs); have != 9 {
+		t.Fatalf("expected 9 log lines, have %d", have)
+	}
+	if s.Dropped() != 12 || m.count != 12 {
+		t.Fatalf("expected 12 dropped, have %d and %v", s.Dropped(), m.count)
+	}
+}
+
+func TestClose(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{"summary", nil, []string{
+			"msg []",
+			"msg [sample_rate 3]",
+			SummaryMessage + " [emitted 2 dropped 3]",
+		}},
+		{"no summary", []Option{WithSummary(false)}, []string{"msg []", "msg [sample_rate 3]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				msgs []string
+				s    = New(append(tt.opts, WithEveryN(3))...)
+				emit = s.EmitErr(func(_ telemetry.Level, msg string, _ error, values function.Values, _ int) error {
+					msgs = append(msgs, fmt.Sprintf("%s %v", msg, values.FromMethod))
+					return nil
+				})
+				logger = function.NewLoggerErr(emit, 0)
+			)
+
+			for i := 0; i < 5; i++ {
+				logger.Info("msg")
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_ = s.Close()
+
+			if !reflect.DeepEqual(tt.want, msgs) {
+				t.Fatalf("want: %v\nhave: %v", tt.want, msgs)
+			}
+		})
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	var (
+		plain = []interface{}{"id", 1}
+		rate  = func(key string, rate float64) []interface{} { return []interface{}{"id", 1, key, rate} }
+	)
+	tests := []struct {
+		name string
+		opts []Option
+		// want holds the key-value pairs of the first two emitted log lines.
+		want [][]interface{}
+	}{
+		{"every n", []Option{WithEveryN(3)}, [][]interface{}{plain, rate("sample_rate", 3)}},
+		{"probability", []Option{WithProbability(0.5)}, [][]interface{}{rate("sample_rate", 2), rate("sample_rate", 2)}},
+		{"custom key", []Option{WithEveryN(2), WithSampleRateKey("rate")}, [][]interface{}{plain, rate("rate", 2)}},
+		{"disabled", []Option{WithEveryN(2), WithSampleRateKey("")}, [][]interface{}{plain, plain}},
+		{"no sampling", nil, [][]interface{}{plain, plain}},
+		{"level not sampled", []Option{WithEveryN(2), WithLevel(telemetry.LevelDebug)}, [][]interface{}{plain, plain}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				have [][]interface{}
+				s    = New(tt.opts...)
+				emit = s.Emit(func(_ telemetry.Level, _ string, _ error, values function.Values, _ int) {
+					have = append(have, values.FromMethod)
+				})
+			)
+			// a probability of 0.5 may take a few attempts.
+			for i := 0; len(have) < 2 && i < 100; i++ {
+				emit(telemetry.LevelInfo, "msg", nil, function.Values{FromMethod: []interface{}{"id", 1}}, 0)
+			}
+
+			if !reflect.DeepEqual(tt.want, have) {
+				t.Fatalf("want: %v\nhave: %v", tt.want, have)
+			}
+		})
+	}
+}
+
+func TestProbability(t *testing.T) {
+	tests := []struct {
+		name        string
+		probability float64
+		expected    int
+	}{
+		{"none", 0, 0},
+		{"all", 1, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				r      = &recorder{}
+				s      = New(WithProbability(tt.probability))
+				logger = function.NewLogger(s.Emit(r.emit), 0)
+			)
+			for i := 0; i < 100; i++ {
+				logger.Info("msg")
+			}
+			if have := len(r.msgs); have != tt.expected {
+				t.Fatalf("expected %d log lines, have %d", tt.expected, have)
+			}
+		})
+	}
+
+	var (
+		r      = &recorder{}
+		s      = New(WithProbability(0.5))
+		logger = function.NewLogger(s.Emit(r.emit), 0)
+	)
+	for i := 0; i < 10000; i++ {
+		logger.Info("msg")
+	}
+	if have := len(r.msgs); have < 4000 || have > 6000 {
+		t.Fatalf("expected about 5000 log lines, have %d", have)
+	}
+}
+
+func TestLevelAndReset(t *testing.T) {
+	var (
+		now    = time.Now()
+		r      = &recorder{}
+		s      = New(WithEveryN(100), WithLevel(telemetry.LevelInfo), WithResetInterval(time.Minute))
+		logger = function.NewLoggerErr(s.EmitErr(function.AsEmitErr(r.emit)), 0)
+	)
+	s.opts.now = func() time.Time { return now }
+	s.start = now
+
+	logger.Info("info")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Warn("warn")
+	now = now.Add(time.Minute)
+	logger.Info("info")
+
+	if want := []string{"info", "warn", "warn", "info"}; !reflect.DeepEqual(want, r.msgs) {
+		t.Fatalf("want: %v\nhave: %v", want, r.msgs)
+	}
+}
+
+func TestSampleRateSingleOccurrence(t *testing.T) {
+	var (
+		now  = time.Now()
+		have [][]interface{}
+		s    = New(WithEveryN(10), WithResetInterval(time.Minute))
+		emit = s.Emit(func(_ telemetry.Level, _ string, _ error, values function.Values, _ int) {
+			have = append(have, values.FromMethod)
+		})
+	)
+	s.opts.now = func() time.Time { return now }
+	s.start = now
+
+	// a key seen once per reset interval is emitted each time, representing
+	// only itself.
+	for i := 0; i < 3; i++ {
+		emit(telemetry.LevelInfo, "rare", nil, function.Values{FromMethod: []interface{}{"id", i}}, 0)
+		now = now.Add(time.Minute)
+	}
+
+	want := [][]interface{}{{"id", 0}, {"id", 1}, {"id", 2}}
+	if !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
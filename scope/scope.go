@@ -19,7 +19,9 @@ package scope
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path"
 	"sort"
 	"strings"
 	"sync"
@@ -35,6 +37,7 @@ var (
 	scopes        = make(map[string]*scope)
 	uninitialized = make(map[string][]*scope)
 	defaultLogger telemetry.Logger
+	levelRules    []levelRule
 
 	// PanicOnUninitialized can be used when testing for sequencing issues
 	// between creating log lines and initializing the actual logger
@@ -42,6 +45,9 @@ var (
 	PanicOnUninitialized bool
 )
 
+// ErrUnknownScope is returned when referencing a scope that is not registered.
+var ErrUnknownScope = errors.New("unknown logging scope")
+
 const (
 	// Key used to store the name of scope in the logger key/value pairs.
 	Key = "scope"
@@ -55,6 +61,9 @@ type Scope interface {
 	Name() string
 	// Description of the logging scope
 	Description() string
+	// WithName returns the child scope named by appending the provided name
+	// to the name of the scope, separated by a dot.
+	WithName(name string) Scope
 }
 
 type CallerSkip interface {
@@ -85,6 +94,37 @@ func (s *scope) Description() string {
 	return s.description
 }
 
+// WithName returns the child scope named by appending the provided name to
+// the name of the scope, separated by a dot, e.g. "server.http". The name may
+// hold dots itself, like "http.router". The child scope is registered on first
+// use and logs its full name as the value of Key. It takes the level of its
+// parent, unless matched by a pattern set using SetLevels, e.g. "server.*".
+// Invalid names, holding colons, commas or empty elements, return the scope
+// itself.
+func (s *scope) WithName(name string) Scope {
+	name = strings.ToLower(strings.Trim(name, "\r\n\t "))
+	if name == "" || strings.ContainsAny(name, ":,") {
+		return s
+	}
+	for _, elem := range strings.Split(name, ".") {
+		if elem == "" {
+			return s
+		}
+	}
+	return register(s.name+"."+name, s.description, s)
+}
+
+// Trace implements telemetry.Logger.
+func (s *scope) Trace(msg string, keyValuePairs ...interface{}) {
+	if s.logger != nil {
+		s.logger.Trace(msg, keyValuePairs...)
+		return
+	}
+	if PanicOnUninitialized {
+		panic("calling Trace on uninitialized logger")
+	}
+}
+
 // Debug implements telemetry.Logger.
 func (s *scope) Debug(msg string, keyValuePairs ...interface{}) {
 	if s.logger != nil {
@@ -107,6 +147,17 @@ func (s *scope) Info(msg string, keyValuePairs ...interface{}) {
 	}
 }
 
+// Warn implements telemetry.Logger.
+func (s *scope) Warn(msg string, keyValuePairs ...interface{}) {
+	if s.logger != nil {
+		s.logger.Warn(msg, keyValuePairs...)
+		return
+	}
+	if PanicOnUninitialized {
+		panic("calling Warn on uninitialized logger")
+	}
+}
+
 // Error implements telemetry.Logger.
 func (s *scope) Error(msg string, err error, keyValuePairs ...interface{}) {
 	if s.logger != nil {
@@ -118,6 +169,18 @@ func (s *scope) Error(msg string, err error, keyValuePairs ...interface{}) {
 	}
 }
 
+// Fatal implements telemetry.Logger.
+func (s *scope) Fatal(msg string, err error, keyValuePairs ...interface{}) {
+	if s.logger != nil {
+		s.logger.Fatal(msg, err, keyValuePairs...)
+		return
+	}
+	if PanicOnUninitialized {
+		panic("calling Fatal on uninitialized logger")
+	}
+	telemetry.Exit(1)
+}
+
 // With implements telemetry.Logger.
 func (s *scope) With(keyValuePairs ...interface{}) telemetry.Logger {
 	if len(keyValuePairs) == 0 {
@@ -222,12 +285,16 @@ func (s *scope) SetLevel(lvl telemetry.Level) {
 	switch {
 	case lvl < telemetry.LevelError:
 		lvl = telemetry.LevelNone
-	case lvl < telemetry.LevelInfo:
+	case lvl < telemetry.LevelWarn:
 		lvl = telemetry.LevelError
+	case lvl < telemetry.LevelInfo:
+		lvl = telemetry.LevelWarn
 	case lvl < telemetry.LevelDebug:
 		lvl = telemetry.LevelInfo
-	default:
+	case lvl < telemetry.LevelTrace:
 		lvl = telemetry.LevelDebug
+	default:
+		lvl = telemetry.LevelTrace
 	}
 
 	atomic.StoreInt32(s.level, int32(lvl))
@@ -246,7 +313,12 @@ func Register(name, description string) Scope {
 	if strings.ContainsAny(name, ":,.") {
 		return nil
 	}
+	return register(name, description, nil)
+}
 
+// register returns the scope by the provided name, registering it if needed.
+// New scopes take the level of the parent scope, if provided.
+func register(name, description string, parent *scope) Scope {
 	lock.Lock()
 	defer lock.Unlock()
 
@@ -264,6 +336,12 @@ func Register(name, description string) Scope {
 		if defaultLogger != nil {
 			sc.logger = defaultLogger.Clone().With(Key, name)
 		}
+		if parent != nil {
+			sc.SetLevel(parent.Level())
+		}
+		if lvl, ok := matchLevel(name); ok {
+			sc.SetLevel(lvl)
+		}
 
 		scopes[name] = sc
 	}
@@ -309,6 +387,105 @@ func Names() []string {
 	return s
 }
 
+// Levels returns the logging level of all registered scopes by name.
+func Levels() map[string]telemetry.Level {
+	lock.Lock()
+	defer lock.Unlock()
+
+	levels := make(map[string]telemetry.Level, len(scopes))
+	for k, v := range scopes {
+		levels[k] = v.Level()
+	}
+
+	return levels
+}
+
+// SetLevel sets the logging level of the scope registered by the provided
+// name. It returns ErrUnknownScope if no such scope exists.
+func SetLevel(name string, lvl telemetry.Level) error {
+	sc, ok := Find(name)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownScope, name)
+	}
+	sc.SetLevel(lvl)
+	return nil
+}
+
+// levelRule holds a scope name pattern and the logging level to apply to the
+// matching scopes.
+type levelRule struct {
+	pattern string
+	level   telemetry.Level
+}
+
+// SetLevels applies a comma separated list of scope level settings in the
+// form "pattern=level" or "pattern:level", e.g. "grpc*=debug,storage=error".
+// Patterns use the path.Match syntax, so "*" matches all scopes. An entry
+// without pattern, e.g. "info", applies to all scopes. Entries are applied in
+// order, so later entries override earlier ones for the scopes they match.
+//
+// The settings are applied to the existing scopes and retained for scopes
+// registered afterwards, replacing the settings of previous calls. Nothing is
+// applied if the list holds an invalid entry.
+func SetLevels(expr string) error {
+	rules, err := parseLevels(expr)
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	levelRules = rules
+	for name, sc := range scopes {
+		if lvl, ok := matchLevel(name); ok {
+			sc.SetLevel(lvl)
+		}
+	}
+
+	return nil
+}
+
+// parseLevels parses a comma separated list of scope level settings.
+func parseLevels(expr string) ([]levelRule, error) {
+	var rules []levelRule
+	for _, entry := range strings.Split(expr, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, level := "*", entry
+		if i := strings.IndexAny(entry, "=:"); i >= 0 {
+			pattern = strings.ToLower(strings.TrimSpace(entry[:i]))
+			level = entry[i+1:]
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid scope pattern in %q: %w", entry, err)
+		}
+		lvl, err := telemetry.ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scope level in %q: %w", entry, err)
+		}
+		rules = append(rules, levelRule{pattern: pattern, level: lvl})
+	}
+	return rules, nil
+}
+
+// matchLevel returns the logging level of the last level rule matching the
+// scope name. The caller must hold the lock.
+func matchLevel(name string) (telemetry.Level, bool) {
+	var (
+		lvl   telemetry.Level
+		found bool
+	)
+	for _, r := range levelRules {
+		if ok, _ := path.Match(r.pattern, name); ok {
+			lvl, found = r.level, true
+		}
+	}
+	return lvl, found
+}
+
 // PrintRegistered outputs a list of registered scopes with their log level on
 // stdout.
 func PrintRegistered() {
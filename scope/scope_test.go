@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strconv"
 	"sync"
 	"testing"
@@ -84,12 +85,16 @@ func TestLogger(t *testing.T) {
 	}{
 		{"none", telemetry.LevelNone, func(l telemetry.Logger) { l.Error("text", errors.New("error")) }, "", 1},
 		{"disabled-info", telemetry.LevelNone, func(l telemetry.Logger) { l.Info("text") }, "", 1},
+		{"disabled-warn", telemetry.LevelNone, func(l telemetry.Logger) { l.Warn("text") }, "", 1},
 		{"disabled-debug", telemetry.LevelNone, func(l telemetry.Logger) { l.Debug("text") }, "", 0},
+		{"disabled-trace", telemetry.LevelDebug, func(l telemetry.Logger) { l.Trace("text") }, "", 0},
 		{"disabled-error", telemetry.LevelNone, func(l telemetry.Logger) { l.Error("text", errors.New("error")) }, "", 1},
 		{"info", telemetry.LevelInfo, func(l telemetry.Logger) { l.Info("text") },
 			`level=info msg="text" [ctx value scope info lvl info missing (MISSING)]`, 1},
 		{"info-with-values", telemetry.LevelInfo, func(l telemetry.Logger) { l.Info("text", "where", "there", 1, "1") },
 			`level=info msg="text" [ctx value scope info-with-values lvl info missing (MISSING) where there 1 1]`, 1},
+		{"warn", telemetry.LevelWarn, func(l telemetry.Logger) { l.Warn("text") },
+			`level=warn msg="text" [ctx value scope warn lvl info missing (MISSING)]`, 1},
 		{"error", telemetry.LevelInfo, func(l telemetry.Logger) { l.Error("text", errors.New("error")) },
 			`level=error msg="text" err=error [ctx value scope error lvl info missing (MISSING)]`, 1},
 		{"error-with-values", telemetry.LevelInfo, func(l telemetry.Logger) { l.Error("text", errors.New("error"), "where", "there", 1, "1") },
@@ -98,6 +103,8 @@ func TestLogger(t *testing.T) {
 			`level=debug msg="text" [ctx value scope debug lvl info missing (MISSING)]`, 0},
 		{"debug-with-values", telemetry.LevelDebug, func(l telemetry.Logger) { l.Debug("text", "where", "there", 1, "1") },
 			`level=debug msg="text" [ctx value scope debug-with-values lvl info missing (MISSING) where there 1 1]`, 0},
+		{"trace", telemetry.LevelTrace, func(l telemetry.Logger) { l.Trace("text", "where", "there") },
+			`level=trace msg="text" [ctx value scope trace lvl info missing (MISSING) where there]`, 0},
 	}
 
 	for _, tt := range tests {
@@ -138,11 +145,110 @@ func TestSetLevel(t *testing.T) {
 	logger := Register("test-set-level", "test logger")
 
 	withvalues := logger.With("key", "value")
-	logger.SetLevel(telemetry.LevelInfo - 1)
+	logger.SetLevel(telemetry.LevelWarn - 1)
 
 	if withvalues.Level() != telemetry.LevelError {
 		t.Fatalf("logger.Level()=%v, want: %v", withvalues.Level(), telemetry.LevelError)
 	}
+
+	logger.SetLevel(telemetry.LevelInfo - 1)
+
+	if withvalues.Level() != telemetry.LevelWarn {
+		t.Fatalf("logger.Level()=%v, want: %v", withvalues.Level(), telemetry.LevelWarn)
+	}
+}
+
+func TestSetLevelByName(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	logger := Register("by-name", "test logger")
+	_ = Register("other", "test logger")
+
+	if err := SetLevel(" By-Name ", telemetry.LevelDebug); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if logger.Level() != telemetry.LevelDebug {
+		t.Fatalf("logger.Level()=%v, want: %v", logger.Level(), telemetry.LevelDebug)
+	}
+	want := map[string]telemetry.Level{"by-name": telemetry.LevelDebug, "other": telemetry.LevelNone}
+	if have := Levels(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+	if err := SetLevel("unknown", telemetry.LevelDebug); !errors.Is(err, ErrUnknownScope) {
+		t.Fatalf("expected ErrUnknownScope, have %v", err)
+	}
+}
+
+func TestSetLevels(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	_ = Register("grpc-server", "test logger")
+	_ = Register("storage", "test logger")
+
+	if err := SetLevels("warn, grpc*=debug,storage:error"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = Register("grpc-client", "test logger")
+	_ = Register("http", "test logger")
+
+	want := map[string]telemetry.Level{
+		"grpc-server": telemetry.LevelDebug,
+		"grpc-client": telemetry.LevelDebug,
+		"storage":     telemetry.LevelError,
+		"http":        telemetry.LevelWarn,
+	}
+	if have := Levels(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+
+	for _, expr := range []string{"grpc*=verbose", "[=debug"} {
+		if err := SetLevels(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+	if have := Levels(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestWithName(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	var out bytes.Buffer
+	UseLogger(function.NewLogger(func(_ telemetry.Level, msg string, _ error, values function.Values, _ int) {
+		_, _ = fmt.Fprintf(&out, "%s %v\n", msg, values.FromLogger)
+	}, 0))
+
+	server := Register("server", "server scope")
+	server.SetLevel(telemetry.LevelDebug)
+	if err := SetLevels("server.*.router=trace"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	http := server.WithName("HTTP")
+	router := http.WithName("router")
+	if router != server.WithName("http.router") {
+		t.Fatal("expected the registered child scope to be returned")
+	}
+	for _, name := range []string{"", "a,b", "a..b"} {
+		if server.WithName(name) != server {
+			t.Errorf("expected invalid name %q to return the scope", name)
+		}
+	}
+
+	want := map[string]telemetry.Level{
+		"server":             telemetry.LevelDebug,
+		"server.http":        telemetry.LevelDebug,
+		"server.http.router": telemetry.LevelTrace,
+	}
+	if have := Levels(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+
+	router.Trace("routed")
+	if want := "routed [scope server.http.router]\n"; out.String() != want {
+		t.Fatalf("expected %s to match %s", out.String(), want)
+	}
 }
 
 func TestTwoScopes(t *testing.T) {
@@ -167,6 +273,32 @@ func TestTwoScopes(t *testing.T) {
 	}
 }
 
+func TestFatal(t *testing.T) {
+	cleanup()
+	t.Cleanup(cleanup)
+	t.Cleanup(func() { telemetry.SetExitFunc(nil) })
+
+	var exits int
+	telemetry.SetExitFunc(func(int) { exits++ })
+
+	logger := Register("fatal", "test logger")
+	logger.Fatal("uninitialized", nil)
+
+	var out bytes.Buffer
+	UseLogger(function.NewLogger(func(level telemetry.Level, msg string, _ error, _ function.Values, _ int) {
+		_, _ = fmt.Fprintf(&out, "level=%v msg=%q", level, msg)
+	}, 0))
+	logger.SetLevel(telemetry.LevelError)
+	logger.Fatal("initialized", nil)
+
+	if exits != 2 {
+		t.Fatalf("exits=%d, want 2", exits)
+	}
+	if want := `level=error msg="initialized"`; out.String() != want {
+		t.Fatalf("expected %s to match %s", out.String(), want)
+	}
+}
+
 func TestFind(t *testing.T) {
 	s, ok := Find("unexisting")
 	if ok {
@@ -178,6 +310,7 @@ func cleanup() {
 	scopes = make(map[string]*scope)
 	uninitialized = make(map[string][]*scope)
 	defaultLogger = nil
+	levelRules = nil
 }
 
 type mockMetric struct {
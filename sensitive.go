@@ -0,0 +1,129 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Renderings of values holding sensitive data.
+const (
+	// MaskedText is the rendering of values wrapped using Masked.
+	MaskedText = "********"
+	// RedactedText is the rendering of Secret values.
+	RedactedText = "[REDACTED]"
+)
+
+// Secret holds a credential, like a password, token or API key, which must
+// never be rendered. It implements fmt.Stringer, fmt.Formatter,
+// fmt.GoStringer, encoding.TextMarshaler and json.Marshaler, all yielding
+// RedactedText, so a Secret accidentally passed as key-value pair or
+// formatted into a message can not leak into any sink. Convert it to a string
+// to access the credential:
+//
+//	req.SetBasicAuth(user, string(password))
+type Secret string
+
+// String implements fmt.Stringer.
+func (Secret) String() string { return RedactedText }
+
+// GoString implements fmt.GoStringer.
+func (Secret) GoString() string { return fmt.Sprintf("%q", RedactedText) }
+
+// Format implements fmt.Formatter, rendering RedactedText for all verbs.
+func (Secret) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, RedactedText)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (Secret) MarshalText() ([]byte, error) { return []byte(RedactedText), nil }
+
+// MarshalJSON implements json.Marshaler.
+func (Secret) MarshalJSON() ([]byte, error) { return json.Marshal(RedactedText) }
+
+// SensitiveValue wraps a key-value pair value holding personal or otherwise
+// sensitive data, rendering it in a redacted form. It implements fmt.Stringer,
+// fmt.Formatter, fmt.GoStringer, encoding.TextMarshaler and json.Marshaler, so
+// the original value never shows up in the output of the built-in emitters,
+// nor in output formatted using the fmt package. Tests can inspect the
+// original value using Value.
+type SensitiveValue struct {
+	value    interface{}
+	redacted string
+}
+
+// Masked wraps the value, rendering it as MaskedText.
+func Masked(value interface{}) SensitiveValue {
+	return SensitiveValue{value: value, redacted: MaskedText}
+}
+
+// HashedSHA256 wraps the value, rendering it as "sha256:" followed by the hex
+// encoded SHA-256 hash of its fmt representation. Equal values render equally,
+// allowing correlation of log lines without revealing the value. Beware that
+// values from a small domain, like phone numbers, can be recovered from their
+// hash by brute force.
+func HashedSHA256(value interface{}) SensitiveValue {
+	sum := sha256.Sum256([]byte(fmt.Sprint(value)))
+	return SensitiveValue{value: value, redacted: "sha256:" + hex.EncodeToString(sum[:])}
+}
+
+// Last4 wraps the value, rendering only the last four characters of its fmt
+// representation, with the preceding characters replaced by asterisks, e.g.
+// "************1111" for a card number. Values of up to four characters are
+// fully masked.
+func Last4(value interface{}) SensitiveValue {
+	r := []rune(fmt.Sprint(value))
+	n := len(r) - 4
+	if n <= 0 {
+		n = len(r)
+	}
+	for i := 0; i < n; i++ {
+		r[i] = '*'
+	}
+	return SensitiveValue{value: value, redacted: string(r)}
+}
+
+// Value returns the original value.
+func (s SensitiveValue) Value() interface{} { return s.value }
+
+// String implements fmt.Stringer.
+func (s SensitiveValue) String() string { return s.redacted }
+
+// GoString implements fmt.GoStringer.
+func (s SensitiveValue) GoString() string { return fmt.Sprintf("%q", s.redacted) }
+
+// Format implements fmt.Formatter, rendering the redacted form for all verbs.
+func (s SensitiveValue) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, s.redacted)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s SensitiveValue) MarshalText() ([]byte, error) { return []byte(s.redacted), nil }
+
+// MarshalJSON implements json.Marshaler.
+func (s SensitiveValue) MarshalJSON() ([]byte, error) { return json.Marshal(s.redacted) }
+
+// formatRedacted writes the redacted rendering of a value, quoted for the %q
+// and %#v verbs.
+func formatRedacted(f fmt.State, verb rune, redacted string) {
+	if verb == 'q' || (verb == 'v' && f.Flag('#')) {
+		_, _ = fmt.Fprintf(f, "%q", redacted)
+		return
+	}
+	_, _ = f.Write([]byte(redacted))
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestSensitiveValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value telemetry.SensitiveValue
+		want  string
+	}{
+		{"masked", telemetry.Masked("alice"), telemetry.MaskedText},
+		{"hashed", telemetry.HashedSHA256("alice"),
+			"sha256:2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90"},
+		{"last4", telemetry.Last4("4111111111111111"), "************1111"},
+		{"last4 short", telemetry.Last4(1234), "****"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, have := range []string{
+				tt.value.String(),
+				fmt.Sprint(tt.value),
+				fmt.Sprintf("%+v|%s|%d", tt.value, tt.value, tt.value),
+				fmt.Sprintf("%#v", tt.value),
+			} {
+				if !strings.Contains(have, tt.want) || strings.Contains(have, fmt.Sprint(tt.value.Value())) {
+					t.Errorf("unexpected rendering: %s", have)
+				}
+			}
+			if b, err := json.Marshal(tt.value); err != nil || string(b) != `"`+tt.want+`"` {
+				t.Errorf("unexpected JSON: %s (%v)", b, err)
+			}
+		})
+	}
+}
+
+func TestSensitiveValueEmitters(t *testing.T) {
+	for name, emit := range map[string]func(*bytes.Buffer) function.EmitErr{
+		"json":    func(b *bytes.Buffer) function.EmitErr { return emitter.JSON(b) },
+		"logfmt":  func(b *bytes.Buffer) function.EmitErr { return emitter.Logfmt(b) },
+		"console": func(b *bytes.Buffer) function.EmitErr { return emitter.Console(b) },
+	} {
+		var out bytes.Buffer
+		logger := function.NewLoggerErr(emit(&out), 0)
+		logger.Info("text", "email", telemetry.Masked("alice@example.com"))
+		if strings.Contains(out.String(), "alice") || !strings.Contains(out.String(), telemetry.MaskedText) {
+			t.Errorf("%s: unexpected output: %s", name, out.String())
+		}
+	}
+}
+
+func TestSecret(t *testing.T) {
+	secret := telemetry.Secret("hunter2")
+
+	var out bytes.Buffer
+	logger := function.NewLoggerErr(emitter.JSON(&out), 0)
+	logger.Info(fmt.Sprintf("login with %s %q %v %#v", secret, secret, secret, secret), "password", secret)
+
+	if strings.Contains(out.String(), "hunter2") || strings.Count(out.String(), telemetry.RedactedText) != 5 {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+	if b, err := json.Marshal(map[string]interface{}{"password": secret}); err != nil || string(b) != `{"password":"[REDACTED]"}` {
+		t.Fatalf("unexpected JSON: %s (%v)", b, err)
+	}
+	if string(secret) != "hunter2" {
+		t.Fatalf("unexpected credential: %s", string(secret))
+	}
+}
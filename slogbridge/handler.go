@@ -0,0 +1,152 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slogbridge
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// compile time check for compatibility with the slog.Handler interface.
+var _ slog.Handler = (*handler)(nil)
+
+type handler struct {
+	logger telemetry.Logger
+	prefix string
+}
+
+// NewHandler returns a slog.Handler forwarding records into the provided
+// telemetry.Logger, allowing libraries only accepting a *slog.Logger to feed
+// into the configured sinks and scopes.
+//
+// Attributes are passed as key-value pairs, with the keys of attributes inside
+// groups prefixed by the group names joined by dots, e.g. "request.method".
+// For records at error level, the first attribute holding an error value is
+// passed as the error argument of Error. Key-value pairs found in the Context
+// passed to the slog.Logger are included through Logger.Context.
+//
+// Records are only handled if enabled by the level of the telemetry.Logger.
+// The call site recorded by slog is not forwarded, telemetry.Logger
+// implementations capturing caller information will report the Handler.
+func NewHandler(l telemetry.Logger) slog.Handler {
+	return &handler{logger: l}
+}
+
+// Enabled implements slog.Handler.
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return FromSlogLevel(level) <= h.logger.Level()
+}
+
+// Handle implements slog.Handler.
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	logger := h.logger
+	if ctx != nil && (len(telemetry.KeyValuesFromContext(ctx)) > 0 ||
+		len(telemetry.KeyValuesProvidersFromContext(ctx)) > 0) {
+		logger = logger.Context(ctx)
+	}
+
+	var (
+		err       error
+		keyValues = make([]interface{}, 0, 2*r.NumAttrs())
+	)
+	r.Attrs(func(a slog.Attr) bool {
+		keyValues = appendAttr(keyValues, h.prefix, a)
+		return true
+	})
+
+	switch level := FromSlogLevel(r.Level); level {
+	case telemetry.LevelError:
+		keyValues, err = extractError(keyValues)
+		logger.Error(r.Message, err, keyValues...)
+	case telemetry.LevelWarn:
+		logger.Warn(r.Message, keyValues...)
+	case telemetry.LevelInfo:
+		logger.Info(r.Message, keyValues...)
+	case telemetry.LevelDebug:
+		logger.Debug(r.Message, keyValues...)
+	default:
+		logger.Trace(r.Message, keyValues...)
+	}
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	keyValues := make([]interface{}, 0, 2*len(attrs))
+	for _, a := range attrs {
+		keyValues = appendAttr(keyValues, h.prefix, a)
+	}
+	return &handler{logger: h.logger.With(keyValues...), prefix: h.prefix}
+}
+
+// WithGroup implements slog.Handler.
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &handler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+// FromSlogLevel maps a slog.Level onto the corresponding telemetry.Level.
+func FromSlogLevel(level slog.Level) telemetry.Level {
+	switch {
+	case level >= slog.LevelError:
+		return telemetry.LevelError
+	case level >= slog.LevelWarn:
+		return telemetry.LevelWarn
+	case level >= slog.LevelInfo:
+		return telemetry.LevelInfo
+	case level >= slog.LevelDebug:
+		return telemetry.LevelDebug
+	default:
+		return telemetry.LevelTrace
+	}
+}
+
+// appendAttr appends the attribute as key-value pairs, flattening groups.
+func appendAttr(keyValues []interface{}, prefix string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return keyValues
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			keyValues = appendAttr(keyValues, prefix, ga)
+		}
+		return keyValues
+	}
+	return append(keyValues, prefix+a.Key, a.Value.Any())
+}
+
+// extractError removes the first key-value pair holding an error value and
+// returns it.
+func extractError(keyValues []interface{}) ([]interface{}, error) {
+	for i := 1; i < len(keyValues); i += 2 {
+		if err, ok := keyValues[i].(error); ok {
+			kvs := make([]interface{}, 0, len(keyValues)-2)
+			kvs = append(kvs, keyValues[:i-1]...)
+			return append(kvs, keyValues[i+1:]...), err
+		}
+	}
+	return keyValues, nil
+}
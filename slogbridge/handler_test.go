@@ -0,0 +1,82 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slogbridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestHandler(t *testing.T) {
+	var out bytes.Buffer
+	logger := function.NewLogger(func(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+		_, _ = fmt.Fprintf(&out, "level=%v msg=%q", level, msg)
+		if err != nil {
+			_, _ = fmt.Fprintf(&out, " err=%v", err)
+		}
+		all := append(values.FromContext, values.FromLogger...)
+		all = append(all, values.FromMethod...)
+		_, _ = fmt.Fprintf(&out, " %v", all)
+	}, 0)
+	logger.SetLevel(telemetry.LevelDebug)
+
+	l := slog.New(NewHandler(logger)).With("component", "lib").WithGroup("req")
+	ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value")
+
+	tests := []struct {
+		name     string
+		logfunc  func()
+		expected string
+	}{
+		{"trace", func() { l.Log(ctx, slog.LevelDebug-4, "text") }, ""},
+		{"debug", func() { l.DebugContext(ctx, "text", "id", 1) },
+			`level=debug msg="text" [ctx value component lib req.id 1]`},
+		{"info", func() { l.Info("text", slog.Group("http", "method", "GET")) },
+			`level=info msg="text" [component lib req.http.method GET]`},
+		{"warn", func() { l.Warn("text", slog.Group("", "inline", true)) },
+			`level=warn msg="text" [component lib req.inline true]`},
+		{"error", func() { l.Error("text", "id", 1, "err", errors.New("failed")) },
+			`level=error msg="text" err=failed [component lib req.id 1]`},
+		{"error-without-error", func() { l.Error("text") },
+			`level=error msg="text" [component lib]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			tt.logfunc()
+			if out.String() != tt.expected {
+				t.Fatalf("expected %s to match %s", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestFromSlogLevel(t *testing.T) {
+	for _, want := range []telemetry.Level{
+		telemetry.LevelError, telemetry.LevelWarn, telemetry.LevelInfo, telemetry.LevelDebug, telemetry.LevelTrace,
+	} {
+		if have := FromSlogLevel(ToSlogLevel(want)); have != want {
+			t.Errorf("FromSlogLevel(ToSlogLevel(%s))=%s", want, have)
+		}
+	}
+}
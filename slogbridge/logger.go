@@ -0,0 +1,105 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slogbridge provides a telemetry.Logger implementation emitting
+// through a log/slog Handler.
+package slogbridge
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// LevelTrace is the slog level used for telemetry.LevelTrace log lines.
+const LevelTrace = slog.LevelDebug - 4
+
+// NewLogger returns a telemetry.Logger emitting through the provided
+// slog.Handler. The key-value pairs found in Context, added to the Logger and
+// passed to the logging method are added as attributes in that order. The
+// error passed to Error and Fatal is added as the "error" attribute. The call
+// site of the logging method is captured as the Record PC, so Handlers using
+// AddSource report the code calling the Logger.
+//
+// The returned Logger is a function Logger and supports the same options.
+// Its level is initialized at telemetry.LevelInfo. Log lines enabled by the
+// Logger level are only handled if also enabled by the Handler.
+func NewLogger(h slog.Handler, opts ...function.Option) telemetry.Logger {
+	opts = append([]function.Option{function.WithCaller()}, opts...)
+	return function.NewLogger(Emit(h), 0, opts...)
+}
+
+// Emit returns a function.Emit handing log lines to the provided slog.Handler.
+// The Record PC is taken from Values.Caller, which is only populated if the
+// function Logger is created with the function.WithCaller option.
+func Emit(h slog.Handler) function.Emit {
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+		lvl := ToSlogLevel(level)
+		ctx := context.Background()
+		if !h.Enabled(ctx, lvl) {
+			return
+		}
+
+		r := slog.NewRecord(time.Now(), lvl, msg, values.Caller.PC)
+		if err != nil {
+			r.AddAttrs(slog.Any("error", err))
+		}
+		r.AddAttrs(attrs(values.FromContext)...)
+		r.AddAttrs(attrs(values.FromLogger)...)
+		r.AddAttrs(attrs(values.FromMethod)...)
+
+		_ = h.Handle(ctx, r)
+	}
+}
+
+// ToSlogLevel maps a telemetry.Level onto the corresponding slog.Level.
+func ToSlogLevel(level telemetry.Level) slog.Level {
+	switch {
+	case level <= telemetry.LevelError:
+		return slog.LevelError
+	case level <= telemetry.LevelWarn:
+		return slog.LevelWarn
+	case level <= telemetry.LevelInfo:
+		return slog.LevelInfo
+	case level <= telemetry.LevelDebug:
+		return slog.LevelDebug
+	default:
+		return LevelTrace
+	}
+}
+
+// attrs converts key-value pairs into slog attributes. Non-string keys are
+// formatted using fmt.Sprint and a missing value is set to "(MISSING)".
+func attrs(keyValues []interface{}) []slog.Attr {
+	if len(keyValues) == 0 {
+		return nil
+	}
+	as := make([]slog.Attr, 0, (len(keyValues)+1)/2)
+	for i := 0; i < len(keyValues); i += 2 {
+		k, ok := keyValues[i].(string)
+		if !ok {
+			k = fmt.Sprint(keyValues[i])
+		}
+		var v interface{} = "(MISSING)"
+		if i+1 < len(keyValues) {
+			v = keyValues[i+1]
+		}
+		as = append(as, slog.Any(k, v))
+	}
+	return as
+}
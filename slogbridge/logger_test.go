@@ -0,0 +1,89 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slogbridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    telemetry.Level
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"disabled", telemetry.LevelInfo, func(l telemetry.Logger) { l.Debug("text") }, ""},
+		{"trace", telemetry.LevelTrace, func(l telemetry.Logger) { l.Trace("text", "where", "there") },
+			`level=DEBUG-4 source=logger_test.go msg=text ctx=value lvl=info missing=(MISSING) where=there` + "\n"},
+		{"debug", telemetry.LevelDebug, func(l telemetry.Logger) { l.Debug("text", 1, "1") },
+			`level=DEBUG source=logger_test.go msg=text ctx=value lvl=info missing=(MISSING) 1=1` + "\n"},
+		{"info", telemetry.LevelInfo, func(l telemetry.Logger) { l.Info("text") },
+			`level=INFO source=logger_test.go msg=text ctx=value lvl=info missing=(MISSING)` + "\n"},
+		{"warn", telemetry.LevelInfo, func(l telemetry.Logger) { l.Warn("text", "dangling") },
+			`level=WARN source=logger_test.go msg=text ctx=value lvl=info missing=(MISSING) dangling=(MISSING)` + "\n"},
+		{"error", telemetry.LevelInfo, func(l telemetry.Logger) { l.Error("text", errors.New("failed")) },
+			`level=ERROR source=logger_test.go msg=text error=failed ctx=value lvl=info missing=(MISSING)` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			h := slog.NewTextHandler(&out, &slog.HandlerOptions{
+				AddSource: true,
+				Level:     LevelTrace,
+				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+					switch a.Key {
+					case slog.TimeKey:
+						return slog.Attr{}
+					case slog.SourceKey:
+						src := a.Value.Any().(*slog.Source)
+						return slog.String(slog.SourceKey, filepath.Base(src.File))
+					}
+					return a
+				},
+			})
+
+			logger := NewLogger(h)
+			logger.SetLevel(tt.level)
+
+			ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value")
+			l := logger.Context(ctx).With("lvl", telemetry.LevelInfo).With("missing")
+
+			tt.logfunc(l)
+
+			if out.String() != tt.expected {
+				t.Fatalf("expected %q to match %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestHandlerLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewLogger(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	logger.Info("text")
+	if out.Len() != 0 {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqllog
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// compile time check for compatibility with the database/sql/driver
+// interfaces.
+var (
+	_ driver.Connector          = (*connector)(nil)
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.StmtExecContext    = (*stmt)(nil)
+	_ driver.StmtQueryContext   = (*stmt)(nil)
+	_ driver.NamedValueChecker  = (*stmt)(nil)
+)
+
+var (
+	errNamedParameters = errors.New("sqllog: driver does not support the use of named parameters")
+	errTxOptions       = errors.New("sqllog: driver does not support non-default transaction options")
+)
+
+// Wrap returns a driver.Connector logging the statements executed on the
+// connections of the provided Connector through the provided Logger. Use it
+// with sql.OpenDB.
+//
+// Successful statements are logged at the level configured for their Kind,
+// failed statements at telemetry.LevelError. Log lines hold the statement
+// kind, the operation (exec, query or prepare), the query, its duration and
+// its arguments as returned by the Redactor, together with the key-value pairs
+// found in the Context passed to the database/sql methods.
+func Wrap(c driver.Connector, l telemetry.Logger, opts ...Option) driver.Connector {
+	o := options{
+		message:  DefaultMessage,
+		levels:   make(map[Kind]telemetry.Level),
+		redactor: RedactAll,
+		latency:  make(map[Kind]telemetry.Metric),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &connector{Connector: c, logger: &logger{logger: l, opts: o}}
+}
+
+// DSNConnector returns a driver.Connector opening connections using the
+// provided Driver and data source name, for wrapping drivers which do not
+// provide a Connector themselves.
+func DSNConnector(d driver.Driver, dsn string) driver.Connector {
+	return dsnConnector{driver: d, dsn: dsn}
+}
+
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+// Connect implements driver.Connector.
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver implements driver.Connector.
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type connector struct {
+	driver.Connector
+	logger *logger
+}
+
+// Connect implements driver.Connector.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: dc, logger: c.logger}, nil
+}
+
+type conn struct {
+	driver.Conn
+	logger *logger
+}
+
+// Prepare implements driver.Conn.
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		start = time.Now()
+		ds    driver.Stmt
+		err   error
+	)
+	if cpc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		ds, err = cpc.PrepareContext(ctx, query)
+	} else {
+		ds, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		c.logger.observe(ctx, "prepare", query, nil, start, err)
+		return nil, err
+	}
+	return &stmt{Stmt: ds, query: query, logger: c.logger}, nil
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if cbt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return cbt.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errTxOptions
+	}
+	return c.Conn.Begin()
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var (
+		start = time.Now()
+		res   driver.Result
+		err   error
+	)
+	switch e := c.Conn.(type) {
+	case driver.ExecerContext:
+		res, err = e.ExecContext(ctx, query, args)
+	case driver.Execer:
+		var values []driver.Value
+		if values, err = namedValues(args); err != nil {
+			return nil, err
+		}
+		res, err = e.Exec(query, values)
+	default:
+		return nil, driver.ErrSkip
+	}
+	c.logger.observe(ctx, "exec", query, args, start, err)
+	return res, err
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	var (
+		start = time.Now()
+		rows  driver.Rows
+		err   error
+	)
+	switch q := c.Conn.(type) {
+	case driver.QueryerContext:
+		rows, err = q.QueryContext(ctx, query, args)
+	case driver.Queryer:
+		var values []driver.Value
+		if values, err = namedValues(args); err != nil {
+			return nil, err
+		}
+		rows, err = q.Query(query, values)
+	default:
+		return nil, driver.ErrSkip
+	}
+	c.logger.observe(ctx, "query", query, args, start, err)
+	return rows, err
+}
+
+// Ping implements driver.Pinger.
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.Conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator.
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	driver.Stmt
+	query  string
+	logger *logger
+}
+
+// Exec implements driver.Stmt.
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesNamed(args))
+}
+
+// Query implements driver.Stmt.
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesNamed(args))
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var (
+		start = time.Now()
+		res   driver.Result
+		err   error
+	)
+	if sec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = sec.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err != nil {
+			return nil, err
+		}
+		res, err = s.Stmt.Exec(values)
+	}
+	s.logger.observe(ctx, "exec", s.query, args, start, err)
+	return res, err
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var (
+		start = time.Now()
+		rows  driver.Rows
+		err   error
+	)
+	if sqc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = sqc.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err != nil {
+			return nil, err
+		}
+		rows, err = s.Stmt.Query(values)
+	}
+	s.logger.observe(ctx, "query", s.query, args, start, err)
+	return rows, err
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// namedValues converts the arguments for drivers not supporting named values.
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errNamedParameters
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+// valuesNamed converts positional arguments into named values.
+func valuesNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
@@ -0,0 +1,236 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqllog provides a database/sql driver wrapper logging queries and
+// recording their latency through the telemetry interfaces:
+//
+//	db := sql.OpenDB(sqllog.Wrap(connector, logger,
+//		sqllog.WithLatencyMetric(latency)))
+//
+// Drivers without driver.Connector support can be wrapped using
+// DSNConnector.
+package sqllog
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// Keys of the key-value pairs describing a query.
+const (
+	KindKey      = "statement"
+	QueryKey     = "query"
+	ArgsKey      = "args"
+	DurationKey  = "duration"
+	OperationKey = "operation"
+)
+
+// DefaultMessage is the message of the query log lines.
+const DefaultMessage = "sql query"
+
+// Redacted replaces argument values redacted by RedactAll.
+const Redacted = "[REDACTED]"
+
+// Kind classifies statements by their leading keyword.
+type Kind string
+
+// Available statement kinds.
+const (
+	KindSelect Kind = "select"
+	KindInsert Kind = "insert"
+	KindUpdate Kind = "update"
+	KindDelete Kind = "delete"
+	KindDDL    Kind = "ddl"
+	KindOther  Kind = "other"
+)
+
+// Redactor returns the value to log for the query argument at the provided
+// ordinal position, starting at 1, and with the provided name, if any.
+type Redactor func(ordinal int, name string, value interface{}) interface{}
+
+// RedactAll is the default Redactor, replacing all argument values by
+// Redacted.
+func RedactAll(int, string, interface{}) interface{} { return Redacted }
+
+// NoRedaction is a Redactor logging all argument values as is. Only use it if
+// queries never hold sensitive data.
+func NoRedaction(_ int, _ string, value interface{}) interface{} { return value }
+
+type (
+	// Option implements a functional option type for the Connector.
+	Option func(*options)
+
+	// options holds the configuration of the Connector.
+	options struct {
+		message       string
+		levels        map[Kind]telemetry.Level
+		redactor      Redactor
+		latency       map[Kind]telemetry.Metric
+		defaultMetric telemetry.Metric
+	}
+)
+
+// WithMessage sets the message of the query log lines. It defaults to
+// DefaultMessage.
+func WithMessage(msg string) Option {
+	return func(o *options) {
+		o.message = msg
+	}
+}
+
+// WithLevel sets the level at which successful statements of the provided
+// kinds are logged, telemetry.LevelNone disables logging them. It defaults
+// to telemetry.LevelDebug. Failed statements are always logged at
+// telemetry.LevelError.
+func WithLevel(level telemetry.Level, kinds ...Kind) Option {
+	return func(o *options) {
+		for _, kind := range kinds {
+			o.levels[kind] = level
+		}
+	}
+}
+
+// WithRedactor sets the Redactor deciding how query arguments are logged. It
+// defaults to RedactAll.
+func WithRedactor(r Redactor) Option {
+	return func(o *options) {
+		o.redactor = r
+	}
+}
+
+// WithLatencyMetric sets the Metric, typically a Distribution, on which the
+// duration of statements of the provided kinds is recorded in seconds. Without
+// kinds, it is used for all kinds not configured otherwise. The Metric is
+// recorded using the query Context holding the KindKey and OperationKey
+// key-value pairs, so labels with these names are set to the corresponding
+// values.
+func WithLatencyMetric(m telemetry.Metric, kinds ...Kind) Option {
+	return func(o *options) {
+		if len(kinds) == 0 {
+			o.defaultMetric = m
+		}
+		for _, kind := range kinds {
+			o.latency[kind] = m
+		}
+	}
+}
+
+// ParseKind returns the Kind of the query based on its leading keyword,
+// skipping white space, comments and opening parentheses.
+func ParseKind(query string) Kind {
+	for {
+		query = strings.TrimLeft(query, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(query, "--"):
+			idx := strings.IndexByte(query, '\n')
+			if idx < 0 {
+				return KindOther
+			}
+			query = query[idx+1:]
+		case strings.HasPrefix(query, "/*"):
+			idx := strings.Index(query, "*/")
+			if idx < 0 {
+				return KindOther
+			}
+			query = query[idx+2:]
+		default:
+			end := strings.IndexAny(query, " \t\r\n(;")
+			if end < 0 {
+				end = len(query)
+			}
+			switch strings.ToLower(query[:end]) {
+			case "select", "with", "show", "explain":
+				return KindSelect
+			case "insert", "replace":
+				return KindInsert
+			case "update":
+				return KindUpdate
+			case "delete":
+				return KindDelete
+			case "create", "alter", "drop", "truncate", "rename":
+				return KindDDL
+			default:
+				return KindOther
+			}
+		}
+	}
+}
+
+// logger logs and instruments statements.
+type logger struct {
+	logger telemetry.Logger
+	opts   options
+}
+
+// observe logs the statement and records its duration. Errors signalling
+// database/sql to use a fallback path are not reported.
+func (l *logger) observe(ctx context.Context, operation, query string, args []driver.NamedValue, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	duration := time.Since(start)
+	kind := ParseKind(query)
+
+	m, ok := l.opts.latency[kind]
+	if !ok {
+		m = l.opts.defaultMetric
+	}
+	if m != nil {
+		m.RecordContext(telemetry.KeyValuesToContext(ctx, KindKey, string(kind), OperationKey, operation),
+			duration.Seconds())
+	}
+
+	level, ok := l.opts.levels[kind]
+	if !ok {
+		level = telemetry.LevelDebug
+	}
+	if err == nil && (level == telemetry.LevelNone || level > l.logger.Level()) {
+		return
+	}
+
+	keyValues := []interface{}{
+		KindKey, string(kind),
+		OperationKey, operation,
+		QueryKey, query,
+		DurationKey, duration,
+	}
+	if len(args) > 0 {
+		values := make([]interface{}, 0, len(args))
+		for _, arg := range args {
+			values = append(values, l.opts.redactor(arg.Ordinal, arg.Name, arg.Value))
+		}
+		keyValues = append(keyValues, ArgsKey, values)
+	}
+
+	lg := l.logger.Context(ctx)
+	switch {
+	case err != nil:
+		lg.Error(l.opts.message, err, keyValues...)
+	case level == telemetry.LevelError:
+		lg.Error(l.opts.message, nil, keyValues...)
+	case level == telemetry.LevelWarn:
+		lg.Warn(l.opts.message, keyValues...)
+	case level == telemetry.LevelInfo:
+		lg.Info(l.opts.message, keyValues...)
+	case level == telemetry.LevelDebug:
+		lg.Debug(l.opts.message, keyValues...)
+	default:
+		lg.Trace(l.opts.message, keyValues...)
+	}
+}
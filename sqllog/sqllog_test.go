@@ -0,0 +1,175 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqllog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestParseKind(t *testing.T) {
+	tests := []struct {
+		query string
+		kind  Kind
+	}{
+		{"SELECT * FROM users", KindSelect},
+		{"  (select 1)", KindSelect},
+		{"-- comment\n/* block */ INSERT INTO users VALUES (?)", KindInsert},
+		{"update users set name = ?", KindUpdate},
+		{"DELETE FROM users", KindDelete},
+		{"create table users (id int)", KindDDL},
+		{"VACUUM", KindOther},
+		{"/* unterminated", KindOther},
+	}
+
+	for _, tt := range tests {
+		if have := ParseKind(tt.query); have != tt.kind {
+			t.Errorf("%q: want: %s\nhave: %s", tt.query, tt.kind, have)
+		}
+	}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+// fakeConn supports ExecerContext, while queries go through prepared
+// statements without context support.
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if query == "invalid" {
+		return nil, errors.New("syntax error")
+	}
+	return fakeStmt{}, nil
+}
+
+func (fakeConn) Close() error { return nil }
+
+func (fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("unsupported") }
+
+func (fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if query == "DELETE FROM users" {
+		return nil, errors.New("permission denied")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return fakeRows{}, nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return []string{"id"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+type line struct {
+	level     telemetry.Level
+	keyValues []interface{}
+	err       error
+}
+
+type mockMetric struct {
+	telemetry.Metric
+	contexts [][]interface{}
+}
+
+func (m *mockMetric) RecordContext(ctx context.Context, _ float64) {
+	m.contexts = append(m.contexts, telemetry.KeyValuesFromContext(ctx))
+}
+
+func TestWrap(t *testing.T) {
+	var (
+		lines   []line
+		selects mockMetric
+		others  mockMetric
+	)
+	l := function.NewLogger(func(level telemetry.Level, _ string, err error, values function.Values, _ int) {
+		kvs := append(append([]interface{}(nil), values.FromContext...), values.FromMethod...)
+		for i := 0; i < len(kvs); i += 2 {
+			if _, ok := kvs[i+1].(time.Duration); ok {
+				kvs[i+1] = nil
+			}
+		}
+		lines = append(lines, line{level, kvs, err})
+	}, 0)
+	l.SetLevel(telemetry.LevelInfo)
+
+	db := sql.OpenDB(Wrap(DSNConnector(fakeDriver{}, ""), l,
+		WithLevel(telemetry.LevelInfo, KindInsert, KindSelect),
+		WithLatencyMetric(&selects, KindSelect), WithLatencyMetric(&others),
+		WithRedactor(func(ordinal int, name string, value interface{}) interface{} {
+			if ordinal == 1 {
+				return value
+			}
+			return RedactAll(ordinal, name, value)
+		})))
+	defer func() { _ = db.Close() }()
+
+	ctx := telemetry.KeyValuesToContext(context.Background(), "request_id", 1)
+	if _, err := db.ExecContext(ctx, "INSERT INTO users VALUES (?, ?)", "alice", "secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "UPDATE users SET name = ?", "bob"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM users"); err == nil {
+		t.Fatal("expected error")
+	}
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = rows.Close()
+	if _, err = db.QueryContext(ctx, "invalid"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	want := []line{
+		{telemetry.LevelInfo, []interface{}{"request_id", 1, KindKey, "insert", OperationKey, "exec",
+			QueryKey, "INSERT INTO users VALUES (?, ?)", DurationKey, nil, ArgsKey, []interface{}{"alice", Redacted}}, nil},
+		{telemetry.LevelError, []interface{}{"request_id", 1, KindKey, "delete", OperationKey, "exec",
+			QueryKey, "DELETE FROM users", DurationKey, nil}, errors.New("permission denied")},
+		{telemetry.LevelInfo, []interface{}{"request_id", 1, KindKey, "select", OperationKey, "query",
+			QueryKey, "SELECT id FROM users", DurationKey, nil}, nil},
+		{telemetry.LevelError, []interface{}{"request_id", 1, KindKey, "other", OperationKey, "prepare",
+			QueryKey, "invalid", DurationKey, nil}, errors.New("syntax error")},
+	}
+	if !reflect.DeepEqual(want, lines) {
+		t.Fatalf("want: %v\nhave: %v", want, lines)
+	}
+
+	wantSelects := [][]interface{}{{"request_id", 1, KindKey, "select", OperationKey, "query"}}
+	if !reflect.DeepEqual(wantSelects, selects.contexts) {
+		t.Fatalf("want: %v\nhave: %v", wantSelects, selects.contexts)
+	}
+	if len(others.contexts) != 4 {
+		t.Fatalf("expected 4 observations, have %d", len(others.contexts))
+	}
+}
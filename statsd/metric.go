@@ -0,0 +1,137 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsd
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/internal/labels"
+)
+
+// tagValues replaces the characters of label values conflicting with the
+// DogStatsD protocol.
+var tagValues = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_")
+
+// ctxLabels is the Context key holding the label operations.
+const ctxLabels = labels.ContextKey("statsd.labels")
+
+// compile time check for compatibility with the telemetry interfaces.
+var (
+	_ telemetry.LeveledMetric = (*metric)(nil)
+)
+
+// LevelLabel is the label name receiving the logging level of the log line
+// triggering a measurement, if registered on a Metric attached to a Logger.
+const LevelLabel = "level"
+
+// Sampled returns the Metric with the provided sample rate, in the range
+// (0, 1], overriding the rate configured using WithSampleRate. Metrics not
+// created by a Sink are returned as is.
+func Sampled(m telemetry.Metric, rate float64) telemetry.Metric {
+	sm, ok := m.(*metric)
+	if !ok {
+		return m
+	}
+	mc := *sm
+	mc.rate = rate
+	return &mc
+}
+
+// metric implements telemetry.Metric on top of a Sink.
+type metric struct {
+	sink    *Sink
+	name    string
+	typ     string
+	rate    float64
+	labels  []string
+	enabled func() bool
+	with    []labels.Op
+}
+
+func (s *Sink) newMetric(name, typ string, rate float64, opts []telemetry.MetricOption) *metric {
+	var o telemetry.MetricOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	m := &metric{
+		sink:    s,
+		name:    name,
+		typ:     typ,
+		rate:    rate,
+		enabled: o.EnabledCondition,
+	}
+	m.labels = labels.Names(o.Labels)
+	return m
+}
+
+// Increment implements telemetry.Metric.
+func (m *metric) Increment() { m.Record(1) }
+
+// Decrement implements telemetry.Metric.
+func (m *metric) Decrement() { m.Record(-1) }
+
+// Name implements telemetry.Metric.
+func (m *metric) Name() string { return m.name }
+
+// Record implements telemetry.Metric.
+func (m *metric) Record(value float64) {
+	m.record(nil, value, "")
+}
+
+// RecordContext implements telemetry.Metric.
+func (m *metric) RecordContext(ctx context.Context, value float64) {
+	m.record(ctx, value, "")
+}
+
+// RecordLeveled implements telemetry.LeveledMetric. The level is set on the
+// LevelLabel, if registered, before the label operations found in Context
+// and added through With are processed.
+func (m *metric) RecordLeveled(ctx context.Context, value float64, level telemetry.Level) {
+	m.record(ctx, value, level.String())
+}
+
+// With implements telemetry.Metric. LabelValues not created by a Sink are
+// ignored.
+func (m *metric) With(labelValues ...telemetry.LabelValue) telemetry.Metric {
+	mc := *m
+	mc.with = labels.With(m.with, labelValues)
+	return &mc
+}
+
+func (m *metric) record(ctx context.Context, value float64, level string) {
+	if m.enabled != nil && !m.enabled() {
+		return
+	}
+	if !sample(m.rate) {
+		return
+	}
+
+	var tags []string
+	if len(m.labels) > 0 && m.sink.opts.flavor == DogStatsD {
+		ctxLabels.Resolve(ctx, m.labels, LevelLabel, level, m.with).Each(func(name, value string) {
+			tags = append(tags, name+":"+tagValues.Replace(value))
+		})
+	}
+
+	if m.typ == "g" && value < 0 {
+		// a signed gauge value is interpreted as a delta, so reset the gauge
+		// to zero first.
+		m.sink.write(m.name, "0", m.typ, 1, tags)
+	}
+	m.sink.write(m.name, strconv.FormatFloat(value, 'f', -1, 64), m.typ, m.rate, tags)
+}
@@ -0,0 +1,321 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statsd provides a telemetry.MetricSink implementation sending
+// measurements over UDP to a StatsD server or a Datadog agent using the
+// DogStatsD protocol.
+package statsd
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/internal/labels"
+)
+
+// Default configuration of a Sink.
+const (
+	DefaultAddress       = "127.0.0.1:8125"
+	DefaultFlushInterval = 100 * time.Millisecond
+	// DefaultMaxPacketSize fits a UDP datagram in a typical Ethernet MTU.
+	DefaultMaxPacketSize = 1432
+)
+
+// Flavor selects the line protocol used by the Sink.
+type Flavor int
+
+// Supported flavors.
+const (
+	// DogStatsD sends Distributions as histograms ("h") and label values as
+	// tags.
+	DogStatsD Flavor = iota
+	// StatsD sends Distributions as timers ("ms"). As tags are not supported
+	// by the protocol, label values are not sent.
+	StatsD
+)
+
+// compile time check for compatibility with the telemetry.MetricSink interface.
+var _ telemetry.MetricSink = (*Sink)(nil)
+
+type (
+	// Option implements a functional option type for the Sink.
+	Option func(*options)
+
+	// options holds the configuration of a Sink.
+	options struct {
+		flavor        Flavor
+		prefix        string
+		tags          []string
+		sampleRate    float64
+		flushInterval time.Duration
+		maxPacketSize int
+		errorHandler  func(error)
+	}
+)
+
+// WithFlavor sets the line protocol used by the Sink. By default, DogStatsD
+// is used.
+func WithFlavor(f Flavor) Option {
+	return func(o *options) {
+		o.flavor = f
+	}
+}
+
+// WithPrefix sets the prefix of each metric name, e.g. "myservice.".
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithTags sets constant tags added to each measurement, e.g. "env:prod".
+// Tags are only sent using the DogStatsD flavor.
+func WithTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithSampleRate sets the default sample rate of Sums and Distributions, in
+// the range (0, 1]. Only the given fraction of measurements is sent, with the
+// rate included so the server can scale them back up. Gauges are never
+// sampled. Use Sampled to override the rate of an individual Metric.
+func WithSampleRate(rate float64) Option {
+	return func(o *options) {
+		o.sampleRate = rate
+	}
+}
+
+// WithFlushInterval sets the maximum time measurements are buffered before
+// being sent.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.flushInterval = interval
+	}
+}
+
+// WithMaxPacketSize sets the maximum size of a UDP datagram. Measurements are
+// buffered until the next one would exceed it.
+func WithMaxPacketSize(size int) Option {
+	return func(o *options) {
+		o.maxPacketSize = size
+	}
+}
+
+// WithErrorHandler sets the function receiving errors sending measurements.
+// By default, these errors are written to os.Stderr.
+func WithErrorHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = fn
+	}
+}
+
+// Sink sends measurements to a StatsD server. Measurements are buffered
+// client side and sent once the buffer would exceed the maximum packet size
+// or the flush interval expires. Close the Sink on shutdown to send the
+// buffered measurements.
+//
+// Sums are sent as counters ("c"), Gauges as gauges ("g") and Distributions
+// as histograms or timers depending on the Flavor. The Labels registered on a
+// Metric using telemetry.WithLabels become its tags. When recording, label
+// values are taken in sequence from the key-value pairs found in Context with a
+// key matching a label name, the LabelValues found in Context and the
+// LabelValues added through With. Labels without a value are omitted.
+type Sink struct {
+	opts options
+	conn net.Conn
+
+	mtx    sync.Mutex
+	buf    []byte
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New returns a Sink sending measurements to the StatsD server listening on
+// the provided UDP address, or DefaultAddress if empty.
+func New(address string, opts ...Option) (*Sink, error) {
+	o := options{
+		sampleRate:    1,
+		flushInterval: DefaultFlushInterval,
+		maxPacketSize: DefaultMaxPacketSize,
+		errorHandler: func(err error) {
+			_, _ = fmt.Fprintf(os.Stderr, "telemetry: %v\n", err)
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if address == "" {
+		address = DefaultAddress
+	}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sink{
+		opts: o,
+		conn: conn,
+		buf:  make([]byte, 0, o.maxPacketSize),
+		done: make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s, nil
+}
+
+// NewSum implements telemetry.MetricSink.
+func (s *Sink) NewSum(name, _ string, opts ...telemetry.MetricOption) telemetry.Metric {
+	return s.newMetric(name, "c", s.opts.sampleRate, opts)
+}
+
+// NewGauge implements telemetry.MetricSink.
+func (s *Sink) NewGauge(name, _ string, opts ...telemetry.MetricOption) telemetry.Metric {
+	return s.newMetric(name, "g", 1, opts)
+}
+
+// NewDistribution implements telemetry.MetricSink. Bucket bounds are
+// determined by the server, the provided bounds are ignored.
+func (s *Sink) NewDistribution(name, _ string, _ []float64, opts ...telemetry.MetricOption) telemetry.Metric {
+	typ := "h"
+	if s.opts.flavor == StatsD {
+		typ = "ms"
+	}
+	return s.newMetric(name, typ, s.opts.sampleRate, opts)
+}
+
+// NewLabel implements telemetry.MetricSink.
+func (s *Sink) NewLabel(name string) telemetry.Label {
+	return labels.Label(name)
+}
+
+// ContextWithLabels implements telemetry.MetricSink. It returns an error if the
+// provided values hold invalid label names or were not created by a Sink.
+func (s *Sink) ContextWithLabels(ctx context.Context, values ...telemetry.LabelValue) (context.Context, error) {
+	return ctxLabels.WithValues(ctx, values, validTagName)
+}
+
+// validTagName reports whether name is a valid DogStatsD tag name.
+func validTagName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, ":|,#@\n")
+}
+
+// Flush sends the buffered measurements.
+func (s *Sink) Flush() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.flush()
+}
+
+// Close sends the buffered measurements and closes the connection.
+// Measurements recorded afterwards are dropped.
+func (s *Sink) Close() error {
+	s.mtx.Lock()
+	if s.closed {
+		s.mtx.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.flush()
+	s.mtx.Unlock()
+
+	close(s.done)
+	s.wg.Wait()
+	return s.conn.Close()
+}
+
+// run flushes the buffer on each interval until the Sink is closed.
+func (s *Sink) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.opts.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// write buffers a measurement, flushing first if it would not fit.
+func (s *Sink) write(name, value, typ string, rate float64, tags []string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return
+	}
+	n := len(s.buf)
+	if n > 0 {
+		s.buf = append(s.buf, '\n')
+	}
+	s.buf = append(s.buf, s.opts.prefix...)
+	s.buf = append(s.buf, name...)
+	s.buf = append(s.buf, ':')
+	s.buf = append(s.buf, value...)
+	s.buf = append(s.buf, '|')
+	s.buf = append(s.buf, typ...)
+	if rate < 1 {
+		s.buf = append(s.buf, "|@"...)
+		s.buf = strconv.AppendFloat(s.buf, rate, 'f', -1, 64)
+	}
+	if s.opts.flavor == DogStatsD && len(s.opts.tags)+len(tags) > 0 {
+		s.buf = append(s.buf, "|#"...)
+		for i, tag := range append(s.opts.tags[:len(s.opts.tags):len(s.opts.tags)], tags...) {
+			if i > 0 {
+				s.buf = append(s.buf, ',')
+			}
+			s.buf = append(s.buf, tag...)
+		}
+	}
+
+	if n > 0 && len(s.buf) > s.opts.maxPacketSize {
+		// send the previously buffered measurements and keep the new one.
+		line := append([]byte(nil), s.buf[n+1:]...)
+		s.buf = s.buf[:n]
+		s.flush()
+		s.buf = append(s.buf, line...)
+	}
+	if len(s.buf) >= s.opts.maxPacketSize {
+		s.flush()
+	}
+}
+
+// flush sends the buffer. The caller must hold the lock.
+func (s *Sink) flush() {
+	if len(s.buf) == 0 {
+		return
+	}
+	if _, err := s.conn.Write(s.buf); err != nil {
+		s.opts.errorHandler(fmt.Errorf("statsd: %w", err))
+	}
+	s.buf = s.buf[:0]
+}
+
+// sample reports whether a measurement with the provided rate is sent.
+func sample(rate float64) bool {
+	return rate >= 1 || rand.Float64() < rate
+}
@@ -0,0 +1,151 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsd
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// listen returns a UDP listener and a function returning the lines of the
+// next received packet.
+func listen(t *testing.T) (net.PacketConn, func() []string) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = pc.Close() })
+	return pc, func() []string {
+		t.Helper()
+		buf := make([]byte, 65536)
+		_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return strings.Split(string(buf[:n]), "\n")
+	}
+}
+
+func expectLines(t *testing.T, have []string, want ...string) {
+	t.Helper()
+	if strings.Join(have, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestSink(t *testing.T) {
+	pc, receive := listen(t)
+	s, err := New(pc.LocalAddr().String(), WithPrefix("app."), WithTags("env:test"), WithFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s.NewSum("requests", "").Increment()
+	s.NewGauge("connections", "").Record(-2)
+	s.NewDistribution("latency", "", nil).Record(0.25)
+	Sampled(s.NewSum("sampled", ""), 0.5).Record(1)
+	s.Flush()
+
+	have := receive()
+	// the sampled line is only sent half of the time.
+	if len(have) == 5 {
+		expectLines(t, have[4:], "app.sampled:1|c|@0.5|#env:test")
+		have = have[:4]
+	}
+	expectLines(t, have,
+		"app.requests:1|c|#env:test",
+		"app.connections:0|g|#env:test",
+		"app.connections:-2|g|#env:test",
+		"app.latency:0.25|h|#env:test",
+	)
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTags(t *testing.T) {
+	pc, receive := listen(t)
+	s, err := New(pc.LocalAddr().String(), WithFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	var (
+		tenant = s.NewLabel("tenant")
+		region = s.NewLabel("region")
+		m      = s.NewSum("hits", "", telemetry.WithLabels(tenant, region, s.NewLabel(LevelLabel)))
+	)
+	ctx := telemetry.KeyValuesToContext(context.Background(), "tenant", "acme,inc")
+	ctx, err = s.ContextWithLabels(ctx, region.Upsert("eu"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.RecordContext(ctx, 1)
+	m.With(region.Delete()).RecordContext(ctx, 2)
+
+	logger := function.NewLogger(func(telemetry.Level, string, error, function.Values, int) {}, 0).Metric(m)
+	logger.Warn("warn")
+	s.Flush()
+
+	expectLines(t, receive(),
+		"hits:1|c|#tenant:acme_inc,region:eu",
+		"hits:2|c|#tenant:acme_inc",
+		"hits:1|c|#level:warn",
+	)
+
+	if _, err := s.ContextWithLabels(ctx, s.NewLabel("a|b").Upsert("x")); err == nil {
+		t.Errorf("expected error for invalid label name")
+	}
+}
+
+func TestPacketSize(t *testing.T) {
+	pc, receive := listen(t)
+	s, err := New(pc.LocalAddr().String(), WithFlavor(StatsD), WithMaxPacketSize(26),
+		WithFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := s.NewDistribution("latency", "", nil, telemetry.WithLabels(s.NewLabel("ignored")))
+	m.Record(1)
+	m.Record(2)
+	m.Record(3)
+	_ = s.Close()
+
+	expectLines(t, receive(), "latency:1|ms", "latency:2|ms")
+	expectLines(t, receive(), "latency:3|ms")
+}
+
+func TestFlushInterval(t *testing.T) {
+	pc, receive := listen(t)
+	s, err := New(pc.LocalAddr().String(), WithFlushInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	s.NewGauge("up", "").Increment()
+	expectLines(t, receive(), "up:1|g")
+}
@@ -0,0 +1,119 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetrytest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// Replacements used by Normalize.
+const (
+	NormalizedTime = "TIME"
+	NormalizedLine = "LINE"
+)
+
+var (
+	update = flag.Bool("update", false, "update the golden files compared against by AssertGolden")
+
+	timestampRE = regexp.MustCompile(
+		`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	callerRE = regexp.MustCompile(`[\w.\-/\\]*?([\w.\-]+\.go):\d+`)
+)
+
+// Normalize replaces the parts of emitter output varying between runs and
+// machines. RFC 3339 style timestamps are replaced by NormalizedTime and
+// caller locations like "pkg/file.go:42" are reduced to their file name
+// followed by NormalizedLine, e.g. "file.go:LINE", so golden files survive
+// unrelated edits of the test file.
+func Normalize(out []byte) []byte {
+	out = timestampRE.ReplaceAll(out, []byte(NormalizedTime))
+	return callerRE.ReplaceAll(out, []byte("${1}:"+NormalizedLine))
+}
+
+// Capture returns the normalized output written by the emitter returned by
+// newEmit, while fn logs through a function Logger at telemetry.LevelTrace
+// level using it. The provided options are passed to the function Logger.
+//
+//	out := telemetrytest.Capture(func(w io.Writer) function.EmitErr {
+//		return emitter.JSON(w)
+//	}, func(l telemetry.Logger) {
+//		l.Info("started", "port", 8080)
+//	})
+func Capture(newEmit func(w io.Writer) function.EmitErr, fn func(l telemetry.Logger), opts ...function.Option) []byte {
+	var buf bytes.Buffer
+	l := function.NewLoggerErr(newEmit(&buf), 0, opts...)
+	l.SetLevel(telemetry.LevelTrace)
+	fn(l)
+	return Normalize(buf.Bytes())
+}
+
+// AssertGolden compares the output with the golden file testdata/<name>.golden
+// and fails the test on mismatch. Running the tests with the -update flag
+// writes the output to the golden file instead, so changes of the output
+// format show up in the diff of the golden files under review.
+func AssertGolden(t testing.TB, name string, out []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read golden file, run with -update to create it: %v", err)
+	}
+	if !bytes.Equal(golden, out) {
+		t.Errorf("output does not match %s, run with -update to accept the change:\n%s", path, diff(golden, out))
+	}
+}
+
+// diff returns the first line differing between want and have.
+func diff(want, have []byte) string {
+	var (
+		wl = strings.Split(string(want), "\n")
+		hl = strings.Split(string(have), "\n")
+	)
+	for i := 0; i < len(wl) || i < len(hl); i++ {
+		var w, h string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(hl) {
+			h = hl[i]
+		}
+		if w != h {
+			return fmt.Sprintf("line %d:\nwant: %s\nhave: %s", i+1, w, h)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`time=2024-03-01T12:00:00.123456Z msg=x`, `time=TIME msg=x`},
+		{`"time":"2024-03-01T12:00:00+01:00"`, `"time":"TIME"`},
+		{`2024-03-01 12:00:00 info`, `TIME info`},
+		{`caller=emitter/json_test.go:87`, `caller=json_test.go:LINE`},
+		{`caller=/home/ci/src/telemetry/logger.go:1`, `caller=logger.go:LINE`},
+		{`port=8080 file=go.mod`, `port=8080 file=go.mod`},
+	}
+
+	for _, tt := range tests {
+		if have := string(Normalize([]byte(tt.in))); have != tt.want {
+			t.Errorf("want: %s\nhave: %s", tt.want, have)
+		}
+	}
+}
+
+// logLines emits a representative set of log lines.
+func logLines(l telemetry.Logger) {
+	ctx := telemetry.KeyValuesToContext(context.Background(), "request", "r1")
+	l = l.Context(ctx).With("component", "store")
+
+	l.Trace("cache lookup", "key", "item/1")
+	l.Debug("fetching item", "id", 1, "fields", []string{"name", "price"})
+	l.Info("item fetched", "id", 1, "duration", 1500000, "quoted", `say "hi"`)
+	l.Warn("slow response", "empty", "")
+	l.Error("fetch failed", fmt.Errorf("query: %w", errors.New("connection refused")), "attempt", 3)
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		newEmit func(w io.Writer) function.EmitErr
+	}{
+		{"json", func(w io.Writer) function.EmitErr { return emitter.JSON(w) }},
+		{"logfmt", func(w io.Writer) function.EmitErr { return emitter.Logfmt(w) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			AssertGolden(t, tt.name, Capture(tt.newEmit, logLines, function.WithCaller()))
+		})
+	}
+}
+
+func TestGoldenMismatch(t *testing.T) {
+	if *update {
+		t.Skip("golden files are being updated")
+	}
+	var ft fakeT
+	AssertGolden(&ft, "logfmt", []byte("level=info msg=changed\n"))
+	if len(ft.errors) != 1 {
+		t.Fatalf("expected mismatch, have %v", ft.errors)
+	}
+}
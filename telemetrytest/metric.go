@@ -0,0 +1,249 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetrytest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/internal/labels"
+)
+
+// ctxLabels is the Context key holding the label operations.
+const ctxLabels = labels.ContextKey("telemetrytest.labels")
+
+// compile time check for compatibility with the telemetry interfaces.
+var (
+	_ telemetry.LeveledMetric = (*Metric)(nil)
+	_ telemetry.MetricSink    = (*MetricSink)(nil)
+)
+
+// NewLabel returns a Label for use with the Metrics of this package.
+func NewLabel(name string) telemetry.Label {
+	return labels.Label(name)
+}
+
+// Measurement holds a recorded observation.
+type Measurement struct {
+	// Value holds the recorded value.
+	Value float64
+	// Labels holds the label values in effect for the observation.
+	Labels map[string]string
+	// Level holds the logging level passed to RecordLeveled, or
+	// telemetry.LevelNone if recorded otherwise.
+	Level telemetry.Level
+}
+
+// matches reports whether the Measurement holds all provided label values.
+func (m Measurement) matches(labelValues []string) bool {
+	for i := 0; i+1 < len(labelValues); i += 2 {
+		if v, ok := m.Labels[labelValues[i]]; !ok || v != labelValues[i+1] {
+			return false
+		}
+	}
+	return true
+}
+
+// recorder holds the Measurements shared by a Metric and the Metrics derived
+// from it using With.
+type recorder struct {
+	mtx          sync.Mutex
+	measurements []Measurement
+}
+
+// Metric is a telemetry.Metric recording its observations for inspection by
+// tests. Metrics derived from it using With record into the same Metric. It is
+// safe for concurrent use.
+//
+// Label values are resolved like the metrics sinks of this repository do:
+// key-value pairs found in Context, followed by the label operations found in
+// Context and the ones added through With. If the Metric has registered
+// labels, values of other labels are ignored, otherwise all labels are kept.
+type Metric struct {
+	name    string
+	labels  []string
+	enabled func() bool
+	with    []labels.Op
+	rec     *recorder
+}
+
+// NewMetric returns a Metric with the provided name and registered label
+// names.
+func NewMetric(name string, labels ...string) *Metric {
+	return &Metric{name: name, labels: labels, rec: &recorder{}}
+}
+
+// Increment implements telemetry.Metric.
+func (m *Metric) Increment() { m.Record(1) }
+
+// Decrement implements telemetry.Metric.
+func (m *Metric) Decrement() { m.Record(-1) }
+
+// Name implements telemetry.Metric.
+func (m *Metric) Name() string { return m.name }
+
+// Record implements telemetry.Metric.
+func (m *Metric) Record(value float64) {
+	m.record(nil, value, telemetry.LevelNone)
+}
+
+// RecordContext implements telemetry.Metric.
+func (m *Metric) RecordContext(ctx context.Context, value float64) {
+	m.record(ctx, value, telemetry.LevelNone)
+}
+
+// RecordLeveled implements telemetry.LeveledMetric.
+func (m *Metric) RecordLeveled(ctx context.Context, value float64, level telemetry.Level) {
+	m.record(ctx, value, level)
+}
+
+// With implements telemetry.Metric. LabelValues not created by NewLabel or a
+// MetricSink of this package are ignored.
+func (m *Metric) With(labelValues ...telemetry.LabelValue) telemetry.Metric {
+	mc := *m
+	mc.with = labels.With(m.with, labelValues)
+	return &mc
+}
+
+func (m *Metric) record(ctx context.Context, value float64, level telemetry.Level) {
+	if m.enabled != nil && !m.enabled() {
+		return
+	}
+
+	values := make(map[string]string)
+	ctxLabels.Resolve(ctx, m.labels, "", "", m.with).Each(func(name, value string) {
+		values[name] = value
+	})
+
+	m.rec.mtx.Lock()
+	m.rec.measurements = append(m.rec.measurements, Measurement{Value: value, Labels: values, Level: level})
+	m.rec.mtx.Unlock()
+}
+
+// Measurements returns a copy of the recorded observations holding the
+// provided label name and value pairs, in order of recording.
+func (m *Metric) Measurements(labelValues ...string) []Measurement {
+	m.rec.mtx.Lock()
+	defer m.rec.mtx.Unlock()
+
+	var found []Measurement
+	for _, ms := range m.rec.measurements {
+		if ms.matches(labelValues) {
+			found = append(found, ms)
+		}
+	}
+	return found
+}
+
+// Count returns the number of recorded observations holding the provided label
+// name and value pairs, e.g. Count("method", "GET").
+func (m *Metric) Count(labelValues ...string) int {
+	return len(m.Measurements(labelValues...))
+}
+
+// Sum returns the sum of the recorded values holding the provided label name
+// and value pairs.
+func (m *Metric) Sum(labelValues ...string) float64 {
+	var sum float64
+	for _, ms := range m.Measurements(labelValues...) {
+		sum += ms.Value
+	}
+	return sum
+}
+
+// Last returns the last recorded observation holding the provided label name
+// and value pairs and whether one was found, e.g. to inspect a gauge.
+func (m *Metric) Last(labelValues ...string) (Measurement, bool) {
+	found := m.Measurements(labelValues...)
+	if len(found) == 0 {
+		return Measurement{}, false
+	}
+	return found[len(found)-1], true
+}
+
+// Reset removes the recorded observations.
+func (m *Metric) Reset() {
+	m.rec.mtx.Lock()
+	defer m.rec.mtx.Unlock()
+	m.rec.measurements = nil
+}
+
+// MetricSink is a telemetry.MetricSink creating Metrics of this package, so
+// code bootstrapping its metrics from a MetricSink can be asserted on. Metrics
+// created with a name already in use share their observations.
+type MetricSink struct {
+	mtx     sync.Mutex
+	metrics map[string]*Metric
+}
+
+// NewMetricSink returns a new MetricSink.
+func NewMetricSink() *MetricSink {
+	return &MetricSink{metrics: make(map[string]*Metric)}
+}
+
+// NewSum implements telemetry.MetricSink.
+func (s *MetricSink) NewSum(name, _ string, opts ...telemetry.MetricOption) telemetry.Metric {
+	return s.newMetric(name, opts)
+}
+
+// NewGauge implements telemetry.MetricSink.
+func (s *MetricSink) NewGauge(name, _ string, opts ...telemetry.MetricOption) telemetry.Metric {
+	return s.newMetric(name, opts)
+}
+
+// NewDistribution implements telemetry.MetricSink.
+func (s *MetricSink) NewDistribution(name, _ string, _ []float64, opts ...telemetry.MetricOption) telemetry.Metric {
+	return s.newMetric(name, opts)
+}
+
+// NewLabel implements telemetry.MetricSink.
+func (s *MetricSink) NewLabel(name string) telemetry.Label {
+	return labels.Label(name)
+}
+
+// ContextWithLabels implements telemetry.MetricSink. It returns an error if
+// the provided values were not created by NewLabel or a MetricSink of this
+// package.
+func (s *MetricSink) ContextWithLabels(ctx context.Context, values ...telemetry.LabelValue) (context.Context, error) {
+	return ctxLabels.WithValues(ctx, values, func(string) bool { return true })
+}
+
+// Metric returns the Metric created with the provided name and whether it
+// exists.
+func (s *MetricSink) Metric(name string) (*Metric, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	m, ok := s.metrics[name]
+	return m, ok
+}
+
+func (s *MetricSink) newMetric(name string, opts []telemetry.MetricOption) *Metric {
+	var o telemetry.MetricOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	rec := &recorder{}
+	if m, ok := s.metrics[name]; ok {
+		rec = m.rec
+	}
+	m := &Metric{name: name, labels: labels.Names(o.Labels), enabled: o.EnabledCondition, rec: rec}
+	s.metrics[name] = m
+	return m
+}
@@ -0,0 +1,116 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetrytest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestMetric(t *testing.T) {
+	var (
+		sink   = NewMetricSink()
+		method = sink.NewLabel("method")
+		code   = sink.NewLabel("code")
+		m      = sink.NewSum("requests", "", telemetry.WithLabels(method, code))
+	)
+	ctx := telemetry.KeyValuesToContext(context.Background(), "method", "GET", "user", "alice")
+
+	m.RecordContext(ctx, 1)
+	m.With(code.Insert("200")).RecordContext(ctx, 2)
+	ctx, err := sink.ContextWithLabels(ctx, method.Update("POST"), code.Upsert("500"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.With(code.Delete()).RecordContext(ctx, 3)
+	m.Increment()
+
+	rm, ok := sink.Metric("requests")
+	if !ok {
+		t.Fatal("expected metric to be found")
+	}
+	want := []Measurement{
+		{Value: 1, Labels: map[string]string{"method": "GET"}},
+		{Value: 2, Labels: map[string]string{"method": "GET", "code": "200"}},
+		{Value: 3, Labels: map[string]string{"method": "POST"}},
+		{Value: 1, Labels: map[string]string{}},
+	}
+	if have := rm.Measurements(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+
+	if have := rm.Count("method", "GET"); have != 2 {
+		t.Errorf("expected 2 GET measurements, have %d", have)
+	}
+	if have := rm.Sum("method", "GET"); have != 3 {
+		t.Errorf("expected sum of 3, have %v", have)
+	}
+	if last, ok := rm.Last("method"); !ok || last.Value != 1 {
+		t.Errorf("unexpected last measurement: %v", last)
+	}
+	if _, ok := rm.Last("method", "PUT"); ok {
+		t.Error("expected no PUT measurement")
+	}
+
+	// metrics created with the same name share their measurements.
+	sink.NewSum("requests", "").Record(1)
+	if have := rm.Count(); have != 5 {
+		t.Errorf("expected 5 measurements, have %d", have)
+	}
+	rm.Reset()
+	if have := rm.Count(); have != 0 {
+		t.Errorf("expected no measurements, have %d", have)
+	}
+	if _, err = sink.ContextWithLabels(ctx, "invalid"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestMetricLogger(t *testing.T) {
+	var (
+		m = NewMetric("log_lines")
+		l = New().Metric(m)
+	)
+	ctx := telemetry.KeyValuesToContext(context.Background(), "component", "store")
+
+	l.Context(ctx).Warn("slow response")
+	l.With("ignored", true).Error("failed", nil)
+	l.Debug("not recorded")
+
+	want := []Measurement{
+		{Value: 1, Labels: map[string]string{"component": "store"}, Level: telemetry.LevelWarn},
+		{Value: 1, Labels: map[string]string{}, Level: telemetry.LevelError},
+	}
+	if have := m.Measurements(); !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestMetricEnabled(t *testing.T) {
+	var (
+		enabled bool
+		m       = NewMetricSink().NewGauge("queue", "", telemetry.WithEnabled(func() bool { return enabled }))
+	)
+	m.Record(3)
+	enabled = true
+	m.Record(4)
+
+	if last, ok := m.(*Metric).Last(); !ok || last.Value != 4 || m.(*Metric).Count() != 1 {
+		t.Fatalf("unexpected measurements: %v", m.(*Metric).Measurements())
+	}
+}
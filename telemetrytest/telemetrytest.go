@@ -0,0 +1,244 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetrytest provides telemetry.Logger and telemetry.Metric
+// implementations for tests of instrumented code, capturing log lines and
+// measurements for inspection or writing log lines through testing.TB.
+package telemetrytest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+	"github.com/basvanbeek/telemetry/scope"
+)
+
+// Entry holds a captured log line.
+type Entry struct {
+	// Level holds the level of the log line.
+	Level telemetry.Level
+	// Message holds the log message.
+	Message string
+	// Error holds the error passed to Error or Fatal, if any.
+	Error error
+	// KeyValues holds the key-value pairs found in Context, added to the
+	// Logger and passed to the logging method, merged using last-wins
+	// semantics.
+	KeyValues []interface{}
+	// FromContext holds the key-value pairs found in Context.
+	FromContext []interface{}
+	// FromLogger holds the key-value pairs added to the Logger.
+	FromLogger []interface{}
+	// FromMethod holds the key-value pairs passed to the logging method.
+	FromMethod []interface{}
+	// Scope holds the name of the logging scope the log line was emitted
+	// through, if any.
+	Scope string
+}
+
+// Value returns the value of the provided key and whether it was found,
+// regardless of where the key-value pair originates from.
+func (e Entry) Value(key string) (interface{}, bool) {
+	return lookup(e.KeyValues, key)
+}
+
+// ContextValue returns the value of the provided key found in Context and
+// whether it was found.
+func (e Entry) ContextValue(key string) (interface{}, bool) {
+	return lookup(e.FromContext, key)
+}
+
+// LoggerValue returns the value of the provided key added to the Logger and
+// whether it was found.
+func (e Entry) LoggerValue(key string) (interface{}, bool) {
+	return lookup(e.FromLogger, key)
+}
+
+// MethodValue returns the value of the provided key passed to the logging
+// method and whether it was found.
+func (e Entry) MethodValue(key string) (interface{}, bool) {
+	return lookup(e.FromMethod, key)
+}
+
+// lookup returns the value of the last occurrence of the provided key in the
+// key-value pairs and whether it was found.
+func lookup(keyValues []interface{}, key string) (interface{}, bool) {
+	for i := len(keyValues) - len(keyValues)%2 - 2; i >= 0; i -= 2 {
+		if k, ok := keyValues[i].(string); ok && k == key {
+			return keyValues[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// String returns a single line representation of the Entry.
+func (e Entry) String() string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "%s %q", e.Level, e.Message)
+	if e.Error != nil {
+		_, _ = fmt.Fprintf(&sb, " error=%q", e.Error.Error())
+	}
+	for i := 0; i+1 < len(e.KeyValues); i += 2 {
+		_, _ = fmt.Fprintf(&sb, " %v=%v", e.KeyValues[i], e.KeyValues[i+1])
+	}
+	return sb.String()
+}
+
+// matches reports whether the Entry has the provided level, holds the message
+// substring and holds all provided key-value pairs.
+func (e Entry) matches(level telemetry.Level, msg string, keyValues []interface{}) bool {
+	if e.Level != level || !strings.Contains(e.Message, msg) {
+		return false
+	}
+	for i := 0; i < len(keyValues); i += 2 {
+		k, _ := keyValues[i].(string)
+		v, ok := e.Value(k)
+		if !ok {
+			return false
+		}
+		if i+1 < len(keyValues) && !reflect.DeepEqual(v, keyValues[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Logger is a telemetry.Logger capturing its log lines. Loggers derived from it
+// using With, Context, Metric and Clone capture into the same Logger. It is
+// safe for concurrent use.
+type Logger struct {
+	telemetry.Logger
+
+	mtx     sync.Mutex
+	entries []Entry
+}
+
+// New returns a Logger capturing log lines at all levels. The provided options
+// are passed to the underlying function Logger. Key-value pairs shadowed by
+// another source, e.g. a key found in Context also passed to the logging
+// method, are still captured with their source, unless overridden using
+// function.WithDuplicateKeys.
+func New(opts ...function.Option) *Logger {
+	l := &Logger{}
+	opts = append([]function.Option{function.WithDuplicateKeys(function.KeepAll)}, opts...)
+	l.Logger = function.NewLogger(l.emit, 0, opts...)
+	l.Logger.SetLevel(telemetry.LevelTrace)
+	return l
+}
+
+// emit implements function.Emit.
+func (l *Logger) emit(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+	kvs := telemetry.MergeKeyValues(nil, values.FromContext...)
+	kvs = telemetry.MergeKeyValues(kvs, values.FromLogger...)
+	kvs = telemetry.MergeKeyValues(kvs, values.FromMethod...)
+
+	e := Entry{
+		Level:       level,
+		Message:     msg,
+		Error:       err,
+		KeyValues:   kvs,
+		FromContext: append([]interface{}(nil), values.FromContext...),
+		FromLogger:  append([]interface{}(nil), values.FromLogger...),
+		FromMethod:  append([]interface{}(nil), values.FromMethod...),
+	}
+	if s, ok := e.Value(scope.Key); ok {
+		e.Scope = fmt.Sprint(s)
+	}
+
+	l.mtx.Lock()
+	l.entries = append(l.entries, e)
+	l.mtx.Unlock()
+}
+
+// Entries returns a copy of the captured log lines in order of emission.
+func (l *Logger) Entries() []Entry {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+// Entry returns the captured log line at the provided index, in order of
+// emission. It panics if the index is out of range, like indexing Entries.
+func (l *Logger) Entry(i int) Entry {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.entries[i]
+}
+
+// EntryCount returns the number of captured log lines.
+func (l *Logger) EntryCount() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return len(l.entries)
+}
+
+// Reset removes the captured log lines.
+func (l *Logger) Reset() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.entries = nil
+}
+
+// Find returns the captured log lines at the provided level, holding the
+// message substring and the provided key-value pairs. Values are compared
+// using reflect.DeepEqual, so their types must match as well. A trailing key
+// without value only requires the key to be present.
+func (l *Logger) Find(level telemetry.Level, msg string, keyValues ...interface{}) []Entry {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	var found []Entry
+	for _, e := range l.entries {
+		if e.matches(level, msg, keyValues) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// AssertLogged fails the test if no captured log line matches the provided
+// level, message substring and key-value pairs, as described by Find.
+func (l *Logger) AssertLogged(t testing.TB, level telemetry.Level, msg string, keyValues ...interface{}) {
+	t.Helper()
+	if len(l.Find(level, msg, keyValues...)) == 0 {
+		t.Errorf("expected %s log line holding %q with %v, have:\n%s", level, msg, keyValues, l.dump())
+	}
+}
+
+// AssertNotLogged fails the test if any captured log line matches the provided
+// level, message substring and key-value pairs, as described by Find.
+func (l *Logger) AssertNotLogged(t testing.TB, level telemetry.Level, msg string, keyValues ...interface{}) {
+	t.Helper()
+	if found := l.Find(level, msg, keyValues...); len(found) > 0 {
+		t.Errorf("unexpected %s log line holding %q with %v: %s", level, msg, keyValues, found[0])
+	}
+}
+
+// dump returns the captured log lines, one per line.
+func (l *Logger) dump() string {
+	entries := l.Entries()
+	if len(entries) == 0 {
+		return "(none)"
+	}
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, e.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,167 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/scope"
+)
+
+// fakeT records failures reported by the assertions.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestLogger(t *testing.T) {
+	l := New()
+	ctx := telemetry.KeyValuesToContext(context.Background(), "request", "r1", "user", "ctx")
+
+	l.Context(ctx).With("user", "logger").Debug("fetching item", "id", 1)
+	l.Error("fetch failed", errors.New("timeout"), "id", 1, "user", "method")
+
+	if l.EntryCount() != 2 {
+		t.Fatalf("expected 2 entries, have %d", l.EntryCount())
+	}
+	e := l.Entries()[0]
+	if e.Level != telemetry.LevelDebug || e.Message != "fetching item" || e.Error != nil {
+		t.Fatalf("unexpected entry: %s", e)
+	}
+	if want, have := `debug "fetching item" request=r1 user=logger id=1`, e.String(); want != have {
+		t.Fatalf("want: %s\nhave: %s", want, have)
+	}
+
+	tests := []struct {
+		name      string
+		level     telemetry.Level
+		msg       string
+		keyValues []interface{}
+		found     bool
+	}{
+		{"message", telemetry.LevelDebug, "fetching", nil, true},
+		{"key-values", telemetry.LevelDebug, "", []interface{}{"request", "r1", "id", 1}, true},
+		{"key only", telemetry.LevelError, "failed", []interface{}{"id"}, true},
+		{"last wins", telemetry.LevelError, "", []interface{}{"user", "method"}, true},
+		{"level", telemetry.LevelInfo, "fetching", nil, false},
+		{"message mismatch", telemetry.LevelDebug, "stored", nil, false},
+		{"value type", telemetry.LevelDebug, "", []interface{}{"id", int64(1)}, false},
+		{"missing key", telemetry.LevelError, "", []interface{}{"request"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ft fakeT
+			l.AssertLogged(&ft, tt.level, tt.msg, tt.keyValues...)
+			if found := len(ft.errors) == 0; found != tt.found {
+				t.Fatalf("expected found %t, have errors: %v", tt.found, ft.errors)
+			}
+			ft.errors = nil
+			l.AssertNotLogged(&ft, tt.level, tt.msg, tt.keyValues...)
+			if found := len(ft.errors) != 0; found != tt.found {
+				t.Fatalf("expected found %t, have errors: %v", tt.found, ft.errors)
+			}
+		})
+	}
+
+	l.Reset()
+	if l.EntryCount() != 0 {
+		t.Fatalf("expected no entries, have %d", l.EntryCount())
+	}
+}
+
+func TestEntrySources(t *testing.T) {
+	l := New()
+	ctx := telemetry.KeyValuesToContext(context.Background(), "request", "r1", "user", "ctx")
+	l.Context(ctx).With("user", "logger", "component", "store").Info("stored", "user", "method", "id", 1)
+
+	e := l.Entry(0)
+	tests := []struct {
+		name   string
+		lookup func(string) (interface{}, bool)
+		key    string
+		value  interface{}
+		found  bool
+	}{
+		{"context", e.ContextValue, "user", "ctx", true},
+		{"context only", e.ContextValue, "request", "r1", true},
+		{"not in context", e.ContextValue, "id", nil, false},
+		{"logger", e.LoggerValue, "user", "logger", true},
+		{"not in logger", e.LoggerValue, "request", nil, false},
+		{"method", e.MethodValue, "user", "method", true},
+		{"not in method", e.MethodValue, "component", nil, false},
+		{"flattened", e.Value, "user", "method", true},
+		{"flattened from context", e.Value, "request", "r1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, found := tt.lookup(tt.key)
+			if value != tt.value || found != tt.found {
+				t.Fatalf("want: %v %t\nhave: %v %t", tt.value, tt.found, value, found)
+			}
+		})
+	}
+}
+
+func TestLoggerScope(t *testing.T) {
+	l := New()
+	scope.UseLogger(l)
+	s := scope.Register("telemetrytest", "scope under test")
+	s.SetLevel(telemetry.LevelInfo)
+
+	s.Info("started")
+	s.Debug("silenced")
+
+	entries := l.Entries()
+	if len(entries) != 1 || entries[0].Scope != "telemetrytest" {
+		t.Fatalf("unexpected entries: %v", entries)
+	}
+}
+
+func TestLoggerConcurrent(t *testing.T) {
+	var (
+		l  = New()
+		wg sync.WaitGroup
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			logger := l.With("worker", i)
+			for j := 0; j < 100; j++ {
+				logger.Info("tick", "n", j)
+				_ = l.EntryCount()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if l.EntryCount() != 800 {
+		t.Fatalf("expected 800 entries, have %d", l.EntryCount())
+	}
+	l.AssertLogged(t, telemetry.LevelInfo, "tick", "worker", 7, "n", 99)
+}
@@ -0,0 +1,222 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetrytest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/emitter"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type (
+	// TOption implements a functional option type for the Logger returned by
+	// NewT.
+	TOption func(*tOptions)
+
+	// tOptions holds the configuration of the Logger returned by NewT.
+	tOptions struct {
+		failOnError bool
+	}
+)
+
+// FailOnError makes Error and Fatal log lines write through t.Error, failing
+// the test, instead of through t.Log.
+func FailOnError() TOption {
+	return func(o *tOptions) {
+		o.failOnError = true
+	}
+}
+
+// tState holds the state shared by a Logger returned by NewT and the Loggers
+// derived from it.
+type tState struct {
+	mtx  sync.RWMutex
+	done bool
+}
+
+// tLogger writes log lines through testing.TB.
+type tLogger struct {
+	t     testing.TB
+	opts  tOptions
+	state *tState
+	ctx   context.Context
+	args  []interface{}
+	// inner takes care of the level and Metric handling, it does not emit.
+	inner telemetry.Logger
+}
+
+// compile time check for compatibility with the telemetry.Logger interface.
+var _ telemetry.Logger = (*tLogger)(nil)
+
+// NewT returns a Logger writing its log lines in logfmt format through t.Log,
+// so they are only output if the test fails or when running with -v. The log
+// lines are attributed to the call site of the logging method in the code
+// under test rather than to the Logger. Loggers wrapping the returned Logger,
+// like scopes, are reported as call site instead.
+//
+// The Logger is configured at telemetry.LevelTrace level. Log lines emitted
+// after the test completed, e.g. by lingering goroutines, are written to
+// os.Stderr as the testing package does not allow for them. Fatal writes the
+// log line and calls telemetry.Exit(1) like any other Logger.
+func NewT(t testing.TB, opts ...TOption) telemetry.Logger {
+	l := &tLogger{
+		t:     t,
+		state: &tState{},
+		ctx:   context.Background(),
+		inner: function.NewLogger(nil, 0),
+	}
+	for _, opt := range opts {
+		opt(&l.opts)
+	}
+	l.inner.SetLevel(telemetry.LevelTrace)
+
+	t.Cleanup(func() {
+		l.state.mtx.Lock()
+		l.state.done = true
+		l.state.mtx.Unlock()
+	})
+	return l
+}
+
+// Trace implements telemetry.Logger.
+func (l *tLogger) Trace(msg string, keyValuePairs ...interface{}) {
+	l.t.Helper()
+	l.log(telemetry.LevelTrace, msg, nil, keyValuePairs)
+}
+
+// Debug implements telemetry.Logger.
+func (l *tLogger) Debug(msg string, keyValuePairs ...interface{}) {
+	l.t.Helper()
+	l.log(telemetry.LevelDebug, msg, nil, keyValuePairs)
+}
+
+// Info implements telemetry.Logger.
+func (l *tLogger) Info(msg string, keyValuePairs ...interface{}) {
+	l.t.Helper()
+	l.inner.Info(msg, keyValuePairs...)
+	l.log(telemetry.LevelInfo, msg, nil, keyValuePairs)
+}
+
+// Warn implements telemetry.Logger.
+func (l *tLogger) Warn(msg string, keyValuePairs ...interface{}) {
+	l.t.Helper()
+	l.inner.Warn(msg, keyValuePairs...)
+	l.log(telemetry.LevelWarn, msg, nil, keyValuePairs)
+}
+
+// Error implements telemetry.Logger.
+func (l *tLogger) Error(msg string, err error, keyValuePairs ...interface{}) {
+	l.t.Helper()
+	l.inner.Error(msg, err, keyValuePairs...)
+	l.log(telemetry.LevelError, msg, err, keyValuePairs)
+}
+
+// Fatal implements telemetry.Logger.
+func (l *tLogger) Fatal(msg string, err error, keyValuePairs ...interface{}) {
+	l.t.Helper()
+	l.log(telemetry.LevelError, msg, err, keyValuePairs)
+	// records the Metric and calls telemetry.Exit.
+	l.inner.Fatal(msg, err, keyValuePairs...)
+}
+
+// log writes the log line through the testing.TB if enabled. Like all logging
+// methods it marks itself as helper, so the testing package reports the call
+// site in the code under test.
+func (l *tLogger) log(level telemetry.Level, msg string, err error, keyValues []interface{}) {
+	l.t.Helper()
+	if level > l.inner.Level() {
+		return
+	}
+	keyValues, _ = telemetry.ExtractNoMetric(keyValues)
+
+	var buf bytes.Buffer
+	_ = emitter.Logfmt(&buf, emitter.WithTimeKey(""))(level, msg, err, function.Values{
+		FromContext: telemetry.ResolveKeyValuesFromContext(l.ctx),
+		FromLogger:  l.args,
+		FromMethod:  keyValues,
+	}, 0)
+	line := string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+
+	l.state.mtx.RLock()
+	defer l.state.mtx.RUnlock()
+
+	switch {
+	case l.state.done:
+		_, _ = fmt.Fprintf(os.Stderr, "telemetry: log line after %s completed: %s\n", l.t.Name(), line)
+	case level == telemetry.LevelError && l.opts.failOnError:
+		l.t.Error(line)
+	default:
+		l.t.Log(line)
+	}
+}
+
+// SetLevel implements telemetry.Logger.
+func (l *tLogger) SetLevel(lvl telemetry.Level) { l.inner.SetLevel(lvl) }
+
+// Level implements telemetry.Logger.
+func (l *tLogger) Level() telemetry.Level { return l.inner.Level() }
+
+// With implements telemetry.Logger.
+func (l *tLogger) With(keyValuePairs ...interface{}) telemetry.Logger {
+	if len(keyValuePairs) == 0 {
+		return l
+	}
+	if len(keyValuePairs)%2 != 0 {
+		keyValuePairs = append(keyValuePairs, "(MISSING)")
+	}
+	nl := l.derive(l.inner.With(keyValuePairs...))
+	for i := 0; i < len(keyValuePairs); i += 2 {
+		if k, ok := keyValuePairs[i].(string); ok {
+			nl.args = append(nl.args, k, keyValuePairs[i+1])
+		}
+	}
+	return nl
+}
+
+// Context implements telemetry.Logger.
+func (l *tLogger) Context(ctx context.Context) telemetry.Logger {
+	nl := l.derive(l.inner.Context(ctx))
+	nl.ctx = ctx
+	return nl
+}
+
+// Metric implements telemetry.Logger.
+func (l *tLogger) Metric(m telemetry.Metric) telemetry.Logger {
+	return l.derive(l.inner.Metric(m))
+}
+
+// Clone implements telemetry.Logger.
+func (l *tLogger) Clone() telemetry.Logger {
+	return l.derive(l.inner.Clone())
+}
+
+// derive returns a copy of the Logger using the provided inner Logger.
+func (l *tLogger) derive(inner telemetry.Logger) *tLogger {
+	return &tLogger{
+		t:     l.t,
+		opts:  l.opts,
+		state: l.state,
+		ctx:   l.ctx,
+		args:  append([]interface{}(nil), l.args...),
+		inner: inner,
+	}
+}
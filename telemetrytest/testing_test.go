@@ -0,0 +1,124 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// helperT mimics the call site attribution of the testing package, reporting
+// the first caller not marked as helper.
+type helperT struct {
+	testing.TB
+	helpers  map[string]bool
+	cleanups []func()
+	lines    []string
+	failed   bool
+}
+
+func (h *helperT) Helper() {
+	pc, _, _, _ := runtime.Caller(1)
+	h.helpers[runtime.FuncForPC(pc).Name()] = true
+}
+
+func (h *helperT) Log(args ...interface{}) {
+	var pcs [16]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		if !h.helpers[frame.Function] || !more {
+			h.lines = append(h.lines, fmt.Sprintf("%s:%d: %s", filepath.Base(frame.File), frame.Line, fmt.Sprint(args...)))
+			return
+		}
+	}
+}
+
+func (h *helperT) Error(args ...interface{}) {
+	h.failed = true
+	h.Log(args...)
+}
+
+func (h *helperT) Name() string { return "TestFake" }
+
+func (h *helperT) Cleanup(fn func()) { h.cleanups = append(h.cleanups, fn) }
+
+func TestNewT(t *testing.T) {
+	ft := &helperT{helpers: make(map[string]bool)}
+	ctx := telemetry.KeyValuesToContext(context.Background(), "request", "r1")
+	l := NewT(ft).Context(ctx).With("component", "store")
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Debug("fetching", "id", 1)
+	l.Error("fetch failed", errors.New("timeout"))
+	l.SetLevel(telemetry.LevelInfo)
+	l.Debug("silenced")
+
+	want := []string{
+		fmt.Sprintf(`testing_test.go:%d: level=debug msg=fetching request=r1 component=store id=1`, line+1),
+		fmt.Sprintf(`testing_test.go:%d: level=error msg="fetch failed" error=timeout request=r1 component=store`, line+2),
+	}
+	if fmt.Sprint(want) != fmt.Sprint(ft.lines) {
+		t.Fatalf("want: %q\nhave: %q", want, ft.lines)
+	}
+	if ft.failed {
+		t.Fatal("expected test not to fail")
+	}
+
+	// log lines after completion of the test are not written through the
+	// testing.TB.
+	for _, fn := range ft.cleanups {
+		fn()
+	}
+	l.Info("lingering")
+	if len(ft.lines) != 2 {
+		t.Fatalf("unexpected lines: %q", ft.lines)
+	}
+}
+
+func TestNewTFailOnError(t *testing.T) {
+	ft := &helperT{helpers: make(map[string]bool)}
+	l := NewT(ft, FailOnError())
+
+	l.Warn("degraded")
+	if ft.failed {
+		t.Fatal("expected test not to fail")
+	}
+	l.Error("failed", nil)
+	if !ft.failed {
+		t.Fatal("expected test to fail")
+	}
+}
+
+func TestNewTMetric(t *testing.T) {
+	var (
+		m  = NewMetric("requests")
+		ft = &helperT{helpers: make(map[string]bool)}
+		l  = NewT(ft).Metric(m)
+	)
+	l.SetLevel(telemetry.LevelError)
+
+	l.Info("counted")
+	l.Debug("not counted")
+	if m.Count() != 1 || len(ft.lines) != 0 {
+		t.Fatalf("expected 1 measurement and no lines, have %d and %q", m.Count(), ft.lines)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Keys of the span identifiers added to Loggers by SpanLogger.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// SpanContext identifies a Span and its trace.
+type SpanContext struct {
+	// TraceID holds the hex encoded identifier of the trace.
+	TraceID string
+	// SpanID holds the hex encoded identifier of the Span.
+	SpanID string
+	// Sampled indicates the trace is recorded by the tracing backend.
+	Sampled bool
+}
+
+// IsValid reports whether the SpanContext holds trace and span identifiers.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != "" && sc.SpanID != ""
+}
+
+// Span represents a unit of work within a trace.
+type Span interface {
+	// SpanContext returns the identifiers of the Span.
+	SpanContext() SpanContext
+
+	// IsRecording reports whether the Span records attributes, events and
+	// errors.
+	IsRecording() bool
+
+	// SetAttributes sets the provided key-value pairs as attributes of the
+	// Span.
+	SetAttributes(keyValues ...interface{})
+
+	// Attributes returns the attributes of the Span as key-value pairs.
+	Attributes() []interface{}
+
+	// AddEvent records an event with the provided name and key-value pairs as
+	// attributes.
+	AddEvent(name string, keyValues ...interface{})
+
+	// RecordError records the error as an event of the Span and marks the Span
+	// as failed.
+	RecordError(err error, keyValues ...interface{})
+
+	// End completes the Span.
+	End()
+}
+
+// Tracer creates Spans.
+type Tracer interface {
+	// Start creates a Span with the provided name and key-value pairs as
+	// attributes, as child of the Span found in Context, if any. The returned
+	// Context holds the new Span.
+	Start(ctx context.Context, name string, keyValues ...interface{}) (context.Context, Span)
+}
+
+// ContextWithSpan returns a Context holding the provided Span. Tracer
+// implementations use it to propagate their Spans.
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, ctxSpan, span)
+}
+
+// SpanFromContext returns the Span found in Context, or a no-op Span if none.
+func SpanFromContext(ctx context.Context) Span {
+	if ctx != nil {
+		if span, ok := ctx.Value(ctxSpan).(Span); ok && span != nil {
+			return span
+		}
+	}
+	return noopSpan{}
+}
+
+// SpanLogger returns the provided Logger with the Context attached and, if it
+// holds a valid Span, with the trace_id and span_id of the Span and its
+// attributes at the time of the call added as key-value pairs. Log lines
+// emitted through the returned Logger correlate with the Span.
+func SpanLogger(ctx context.Context, l Logger) Logger {
+	l = l.Context(ctx)
+	span := SpanFromContext(ctx)
+	sc := span.SpanContext()
+	if !sc.IsValid() {
+		return l
+	}
+	attrs := span.Attributes()
+	keyValues := make([]interface{}, 0, 4+len(attrs))
+	keyValues = append(keyValues, TraceIDKey, sc.TraceID, SpanIDKey, sc.SpanID)
+	return l.With(append(keyValues, attrs...)...)
+}
+
+// NoopTracer returns a Tracer creating no-op Spans. It propagates the Span
+// found in Context, so Spans started by another Tracer are kept.
+func NoopTracer() Tracer {
+	return noopTracer{}
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...interface{}) (context.Context, Span) {
+	return ctx, SpanFromContext(ctx)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SpanContext() SpanContext          { return SpanContext{} }
+func (noopSpan) IsRecording() bool                 { return false }
+func (noopSpan) SetAttributes(...interface{})      {}
+func (noopSpan) Attributes() []interface{}         { return nil }
+func (noopSpan) AddEvent(string, ...interface{})   {}
+func (noopSpan) RecordError(error, ...interface{}) {}
+func (noopSpan) End()                              {}
+
+var globalTracer atomic.Value
+
+// tracerHolder allows storing Tracers of different types in an atomic.Value.
+type tracerHolder struct {
+	tracer Tracer
+}
+
+// SetGlobalTracer atomically replaces the global Tracer used by StartSpan.
+// Passing nil restores the default NoopTracer.
+func SetGlobalTracer(t Tracer) {
+	if t == nil {
+		t = NoopTracer()
+	}
+	globalTracer.Store(tracerHolder{tracer: t})
+}
+
+// GlobalTracer returns the global Tracer, which is a NoopTracer unless set
+// using SetGlobalTracer.
+func GlobalTracer() Tracer {
+	if h, ok := globalTracer.Load().(tracerHolder); ok {
+		return h.tracer
+	}
+	return noopTracer{}
+}
+
+// StartSpan creates a Span using the global Tracer. See Tracer.Start.
+func StartSpan(ctx context.Context, name string, keyValues ...interface{}) (context.Context, Span) {
+	return GlobalTracer().Start(ctx, name, keyValues...)
+}
+
+type tCtxSpan struct{}
+
+var ctxSpan tCtxSpan
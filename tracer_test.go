@@ -0,0 +1,96 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+type fakeSpan struct {
+	sc    telemetry.SpanContext
+	attrs []interface{}
+}
+
+func (s *fakeSpan) SpanContext() telemetry.SpanContext { return s.sc }
+func (s *fakeSpan) IsRecording() bool                  { return true }
+func (s *fakeSpan) SetAttributes(kvs ...interface{})   { s.attrs = append(s.attrs, kvs...) }
+func (s *fakeSpan) Attributes() []interface{}          { return s.attrs }
+func (s *fakeSpan) AddEvent(string, ...interface{})    {}
+func (s *fakeSpan) RecordError(error, ...interface{})  {}
+func (s *fakeSpan) End()                               {}
+
+type fakeTracer struct {
+	spans int
+}
+
+func (t *fakeTracer) Start(ctx context.Context, _ string, kvs ...interface{}) (context.Context, telemetry.Span) {
+	t.spans++
+	sc := telemetry.SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: strconv.Itoa(t.spans), Sampled: true}
+	if parent := telemetry.SpanFromContext(ctx).SpanContext(); parent.IsValid() {
+		sc.TraceID = parent.TraceID
+	}
+	span := &fakeSpan{sc: sc, attrs: kvs}
+	return telemetry.ContextWithSpan(ctx, span), span
+}
+
+func TestSpanLogger(t *testing.T) {
+	var have []interface{}
+	logger := function.NewLogger(func(_ telemetry.Level, _ string, _ error, values function.Values, _ int) {
+		have = append(append(values.FromContext, values.FromLogger...), values.FromMethod...)
+	}, 0)
+
+	telemetry.SetGlobalTracer(&fakeTracer{})
+	defer telemetry.SetGlobalTracer(nil)
+
+	ctx := telemetry.KeyValuesToContext(context.Background(), "request_id", 42)
+	ctx, span := telemetry.StartSpan(ctx, "handle", "route", "/users")
+	span.SetAttributes("user", "bob")
+
+	telemetry.SpanLogger(ctx, logger).Info("handled", "status", 200)
+
+	want := []interface{}{"request_id", 42, telemetry.TraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736",
+		telemetry.SpanIDKey, "1", "route", "/users", "user", "bob", "status", 200}
+	if !reflect.DeepEqual(want, have) {
+		t.Fatalf("want: %v\nhave: %v", want, have)
+	}
+}
+
+func TestNoopTracer(t *testing.T) {
+	ctx, span := telemetry.StartSpan(context.Background(), "noop")
+	if span.IsRecording() || span.SpanContext().IsValid() {
+		t.Fatalf("expected no-op Span, have %v", span.SpanContext())
+	}
+
+	var have []interface{}
+	logger := function.NewLogger(func(_ telemetry.Level, _ string, _ error, values function.Values, _ int) {
+		have = values.FromLogger
+	}, 0)
+	telemetry.SpanLogger(ctx, logger).Info("untraced")
+	if len(have) != 0 {
+		t.Fatalf("expected no key-value pairs, have %v", have)
+	}
+
+	// the NoopTracer propagates Spans of other Tracers.
+	ctx, parent := (&fakeTracer{}).Start(context.Background(), "parent")
+	if _, span := telemetry.NoopTracer().Start(ctx, "child"); span != parent {
+		t.Fatalf("expected parent Span to be propagated")
+	}
+}
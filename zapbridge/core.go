@@ -0,0 +1,138 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapbridge
+
+import (
+	"go.uber.org/zap/zapcore"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// compile time check for compatibility with the zapcore.Core interface.
+var _ zapcore.Core = (*core)(nil)
+
+type core struct {
+	logger telemetry.Logger
+}
+
+// NewCore returns a zapcore.Core forwarding entries into the provided
+// telemetry.Logger, allowing code bases using zap to feed into the configured
+// sinks and scopes, e.g. by using zap.New(zapbridge.NewCore(logger)).
+//
+// Fields are passed as key-value pairs, with their values encoded by a
+// zapcore.MapObjectEncoder. For entries at error level and above, the first
+// field holding an error is passed as the error argument of Error. The DPanic,
+// Panic and Fatal levels are logged at Error level, as zap itself takes care
+// of panicking or exiting after writing the entry. A non-empty logger name is
+// added as the "logger" key.
+//
+// Entries are only written if enabled by the level of the telemetry.Logger.
+// The caller recorded by zap is not forwarded, telemetry.Logger
+// implementations capturing caller information will report the Core.
+func NewCore(l telemetry.Logger) zapcore.Core {
+	return &core{logger: l}
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (c *core) Enabled(level zapcore.Level) bool {
+	return FromZapLevel(level) <= c.logger.Level()
+}
+
+// With implements zapcore.Core.
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	if len(fields) == 0 {
+		return c
+	}
+	keyValues, _ := keyValues(fields, false)
+	return &core{logger: c.logger.With(keyValues...)}
+}
+
+// Check implements zapcore.Core.
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	level := FromZapLevel(ent.Level)
+	keyValues, err := keyValues(fields, level == telemetry.LevelError)
+	if ent.LoggerName != "" {
+		keyValues = append([]interface{}{"logger", ent.LoggerName}, keyValues...)
+	}
+
+	switch level {
+	case telemetry.LevelError:
+		c.logger.Error(ent.Message, err, keyValues...)
+	case telemetry.LevelWarn:
+		c.logger.Warn(ent.Message, keyValues...)
+	case telemetry.LevelInfo:
+		c.logger.Info(ent.Message, keyValues...)
+	case telemetry.LevelDebug:
+		c.logger.Debug(ent.Message, keyValues...)
+	default:
+		c.logger.Trace(ent.Message, keyValues...)
+	}
+	return nil
+}
+
+// Sync implements zapcore.Core. Flushing is left to the telemetry.Logger
+// implementation.
+func (c *core) Sync() error {
+	return nil
+}
+
+// FromZapLevel maps a zapcore.Level onto the corresponding telemetry.Level.
+// Levels above zapcore.ErrorLevel map onto telemetry.LevelError.
+func FromZapLevel(level zapcore.Level) telemetry.Level {
+	switch {
+	case level >= zapcore.ErrorLevel:
+		return telemetry.LevelError
+	case level >= zapcore.WarnLevel:
+		return telemetry.LevelWarn
+	case level >= zapcore.InfoLevel:
+		return telemetry.LevelInfo
+	case level >= zapcore.DebugLevel:
+		return telemetry.LevelDebug
+	default:
+		return telemetry.LevelTrace
+	}
+}
+
+// keyValues encodes the fields as key-value pairs, preserving their order. If
+// extractErr is set, the first field holding an error is returned separately.
+func keyValues(fields []zapcore.Field, extractErr bool) ([]interface{}, error) {
+	var (
+		err error
+		enc = zapcore.NewMapObjectEncoder()
+		kvs = make([]interface{}, 0, 2*len(fields))
+	)
+	for _, f := range fields {
+		if extractErr && err == nil && f.Type == zapcore.ErrorType {
+			if e, ok := f.Interface.(error); ok {
+				err = e
+				continue
+			}
+		}
+		f.AddTo(enc)
+		if v, ok := enc.Fields[f.Key]; ok {
+			kvs = append(kvs, f.Key, v)
+			delete(enc.Fields, f.Key)
+		}
+	}
+	return kvs, err
+}
@@ -0,0 +1,80 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapbridge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+func TestCore(t *testing.T) {
+	var out bytes.Buffer
+	logger := function.NewLogger(func(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+		_, _ = fmt.Fprintf(&out, "level=%v msg=%q", level, msg)
+		if err != nil {
+			_, _ = fmt.Fprintf(&out, " err=%v", err)
+		}
+		all := append(values.FromLogger, values.FromMethod...)
+		_, _ = fmt.Fprintf(&out, " %v", all)
+	}, 0)
+	logger.SetLevel(telemetry.LevelDebug)
+
+	z := zap.New(NewCore(logger)).With(zap.String("component", "lib"))
+
+	tests := []struct {
+		name     string
+		logfunc  func()
+		expected string
+	}{
+		{"trace", func() { z.Log(zap.DebugLevel-1, "text") }, ""},
+		{"debug", func() { z.Debug("text", zap.Int("id", 1)) },
+			`level=debug msg="text" [component lib id 1]`},
+		{"info", func() { z.Named("sub").Info("text", zap.Bool("ok", true)) },
+			`level=info msg="text" [component lib logger sub ok true]`},
+		{"warn", func() { z.Warn("text", zap.Strings("ids", []string{"a", "b"})) },
+			`level=warn msg="text" [component lib ids [a b]]`},
+		{"error", func() { z.Error("text", zap.Int("id", 1), zap.Error(errors.New("failed"))) },
+			`level=error msg="text" err=failed [component lib id 1]`},
+		{"dpanic", func() { z.DPanic("text") },
+			`level=error msg="text" [component lib]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			tt.logfunc()
+			if out.String() != tt.expected {
+				t.Fatalf("expected %s to match %s", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestFromZapLevel(t *testing.T) {
+	for _, want := range []telemetry.Level{
+		telemetry.LevelError, telemetry.LevelWarn, telemetry.LevelInfo, telemetry.LevelDebug,
+	} {
+		if have := FromZapLevel(ToZapLevel(want)); have != want {
+			t.Errorf("FromZapLevel(ToZapLevel(%s))=%s", want, have)
+		}
+	}
+}
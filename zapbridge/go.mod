@@ -0,0 +1,14 @@
+module github.com/basvanbeek/telemetry/zapbridge
+
+go 1.21
+
+require (
+	github.com/basvanbeek/telemetry v0.2.0
+	go.uber.org/zap v1.27.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+// Work around for maintaining multiple go modules in the same repository
+// until go has better support for this. https://github.com/golang/go/issues/45713
+replace github.com/basvanbeek/telemetry => ../
@@ -0,0 +1,111 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package zapbridge provides a telemetry.Logger implementation emitting
+// through a zap.Logger, and a zapcore.Core forwarding into a telemetry.Logger.
+package zapbridge
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// NewLogger returns a telemetry.Logger emitting through the provided
+// zap.Logger. The key-value pairs found in Context, added to the Logger and
+// passed to the logging method are added as fields in that order. The error
+// passed to Error and Fatal is added as the "error" field. The call site of the
+// logging method is captured as the Entry caller, so zap.Loggers configured to
+// add the caller report the code calling the Logger.
+//
+// The returned Logger is a function Logger and supports the same options.
+// Its level is initialized at telemetry.LevelInfo. Log lines enabled by the
+// Logger level are only written if also enabled by the zap.Logger.
+func NewLogger(z *zap.Logger, opts ...function.Option) telemetry.Logger {
+	opts = append([]function.Option{function.WithCaller()}, opts...)
+	return function.NewLogger(Emit(z), 0, opts...)
+}
+
+// Emit returns a function.Emit writing log lines to the Core of the provided
+// zap.Logger. The Entry caller is taken from Values.Caller, which is only
+// populated if the function Logger is created with the function.WithCaller
+// option. As the caller is resolved by the function Logger, which honors its
+// caller skip, the zap.AddCallerSkip option has no effect here.
+func Emit(z *zap.Logger) function.Emit {
+	core, name := z.Core(), z.Name()
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+		ent := zapcore.Entry{
+			LoggerName: name,
+			Time:       time.Now(),
+			Level:      ToZapLevel(level),
+			Message:    msg,
+		}
+		ce := core.Check(ent, nil)
+		if ce == nil {
+			return
+		}
+		if frame, ok := values.Caller.Resolve(); ok {
+			ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+			ce.Caller.Function = frame.Function
+		}
+
+		n := len(values.FromContext) + len(values.FromLogger) + len(values.FromMethod)
+		fs := make([]zapcore.Field, 0, (n+1)/2+1)
+		if err != nil {
+			fs = append(fs, zap.Error(err))
+		}
+		fs = appendFields(fs, values.FromContext)
+		fs = appendFields(fs, values.FromLogger)
+		fs = appendFields(fs, values.FromMethod)
+
+		ce.Write(fs...)
+	}
+}
+
+// ToZapLevel maps a telemetry.Level onto the corresponding zapcore.Level. As
+// zap has no trace level, telemetry.LevelTrace maps onto zapcore.DebugLevel.
+func ToZapLevel(level telemetry.Level) zapcore.Level {
+	switch {
+	case level <= telemetry.LevelError:
+		return zapcore.ErrorLevel
+	case level <= telemetry.LevelWarn:
+		return zapcore.WarnLevel
+	case level <= telemetry.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// appendFields converts key-value pairs into zap fields. Non-string keys are
+// formatted using fmt.Sprint and a missing value is set to "(MISSING)".
+func appendFields(fs []zapcore.Field, keyValues []interface{}) []zapcore.Field {
+	for i := 0; i < len(keyValues); i += 2 {
+		k, ok := keyValues[i].(string)
+		if !ok {
+			k = fmt.Sprint(keyValues[i])
+		}
+		var v interface{} = "(MISSING)"
+		if i+1 < len(keyValues) {
+			v = keyValues[i+1]
+		}
+		fs = append(fs, zap.Any(k, v))
+	}
+	return fs
+}
@@ -0,0 +1,89 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapbridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    telemetry.Level
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"disabled", telemetry.LevelInfo, func(l telemetry.Logger) { l.Debug("text") }, ""},
+		{"trace", telemetry.LevelTrace, func(l telemetry.Logger) { l.Trace("text", "where", "there") },
+			`debug	test	logger_test.go	text	{"ctx": "value", "lvl": "info", "missing": "(MISSING)", "where": "there"}` + "\n"},
+		{"debug", telemetry.LevelDebug, func(l telemetry.Logger) { l.Debug("text", 1, "1") },
+			`debug	test	logger_test.go	text	{"ctx": "value", "lvl": "info", "missing": "(MISSING)", "1": "1"}` + "\n"},
+		{"info", telemetry.LevelInfo, func(l telemetry.Logger) { l.Info("text") },
+			`info	test	logger_test.go	text	{"ctx": "value", "lvl": "info", "missing": "(MISSING)"}` + "\n"},
+		{"warn", telemetry.LevelInfo, func(l telemetry.Logger) { l.Warn("text", "dangling") },
+			`warn	test	logger_test.go	text	{"ctx": "value", "lvl": "info", "missing": "(MISSING)", "dangling": "(MISSING)"}` + "\n"},
+		{"error", telemetry.LevelInfo, func(l telemetry.Logger) { l.Error("text", errors.New("failed")) },
+			`error	test	logger_test.go	text	{"error": "failed", "ctx": "value", "lvl": "info", "missing": "(MISSING)"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cfg := zap.NewDevelopmentEncoderConfig()
+			cfg.TimeKey = ""
+			cfg.EncodeLevel = zapcore.LowercaseLevelEncoder
+			cfg.EncodeCaller = func(c zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+				enc.AppendString(filepath.Base(c.File))
+			}
+			z := zap.New(zapcore.NewCore(
+				zapcore.NewConsoleEncoder(cfg), zapcore.AddSync(&out), zapcore.DebugLevel,
+			)).Named("test")
+
+			logger := NewLogger(z)
+			logger.SetLevel(tt.level)
+
+			ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value")
+			l := logger.Context(ctx).With("lvl", telemetry.LevelInfo).With("missing")
+
+			tt.logfunc(l)
+
+			if out.String() != tt.expected {
+				t.Fatalf("expected %q to match %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestZapLevel(t *testing.T) {
+	var out bytes.Buffer
+	z := zap.New(zapcore.NewCore(
+		zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), zapcore.AddSync(&out), zapcore.WarnLevel,
+	))
+	logger := NewLogger(z)
+
+	logger.Info("text")
+	if out.Len() != 0 {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
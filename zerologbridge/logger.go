@@ -0,0 +1,119 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package zerologbridge provides a telemetry.Logger implementation emitting
+// through a zerolog.Logger.
+package zerologbridge
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"github.com/basvanbeek/telemetry"
+	"github.com/basvanbeek/telemetry/function"
+)
+
+// NewLogger returns a telemetry.Logger emitting through the provided
+// zerolog.Logger. The key-value pairs found in Context, added to the Logger and
+// passed to the logging method are added as fields in that order. The error
+// passed to Error and Fatal is added using zerolog.ErrorFieldName. The call
+// site of the logging method is added using zerolog.CallerFieldName.
+//
+// The returned Logger is a function Logger and supports the same options.
+// Its level is initialized at telemetry.LevelInfo. Log lines enabled by the
+// Logger level are only written if also enabled by the zerolog.Logger.
+func NewLogger(z zerolog.Logger, opts ...function.Option) telemetry.Logger {
+	opts = append([]function.Option{function.WithCaller()}, opts...)
+	return function.NewLogger(Emit(z), 0, opts...)
+}
+
+// Emit returns a function.Emit writing log lines to the provided
+// zerolog.Logger. Fields are encoded by zerolog directly, without converting
+// the key-value pairs into intermediate structures. The caller is taken from
+// Values.Caller, which is only populated if the function Logger is created
+// with the function.WithCaller option, and is formatted using
+// zerolog.CallerMarshalFunc.
+func Emit(z zerolog.Logger) function.Emit {
+	return func(level telemetry.Level, msg string, err error, values function.Values, _ int) {
+		e := z.WithLevel(ToZerologLevel(level))
+		if e == nil {
+			return
+		}
+		if err != nil {
+			e = e.Err(err)
+		}
+		e = fields(e, values.FromContext)
+		e = fields(e, values.FromLogger)
+		e = fields(e, values.FromMethod)
+		if frame, ok := values.Caller.Resolve(); ok {
+			e = e.Str(zerolog.CallerFieldName, zerolog.CallerMarshalFunc(frame.PC, frame.File, frame.Line))
+		}
+		e.Msg(msg)
+	}
+}
+
+// ToZerologLevel maps a telemetry.Level onto the corresponding zerolog.Level.
+func ToZerologLevel(level telemetry.Level) zerolog.Level {
+	switch {
+	case level <= telemetry.LevelError:
+		return zerolog.ErrorLevel
+	case level <= telemetry.LevelWarn:
+		return zerolog.WarnLevel
+	case level <= telemetry.LevelInfo:
+		return zerolog.InfoLevel
+	case level <= telemetry.LevelDebug:
+		return zerolog.DebugLevel
+	default:
+		return zerolog.TraceLevel
+	}
+}
+
+// fields adds the key-value pairs to the Event. Well-formed pairs are handed to
+// zerolog as is. Otherwise, non-string keys are formatted using fmt.Sprint and
+// a missing value is set to "(MISSING)".
+func fields(e *zerolog.Event, keyValues []interface{}) *zerolog.Event {
+	if len(keyValues) == 0 {
+		return e
+	}
+	if wellFormed(keyValues) {
+		return e.Fields(keyValues)
+	}
+	for i := 0; i < len(keyValues); i += 2 {
+		k, ok := keyValues[i].(string)
+		if !ok {
+			k = fmt.Sprint(keyValues[i])
+		}
+		var v interface{} = "(MISSING)"
+		if i+1 < len(keyValues) {
+			v = keyValues[i+1]
+		}
+		e = e.Fields([]interface{}{k, v})
+	}
+	return e
+}
+
+// wellFormed reports whether the key-value pairs hold string keys only and
+// have no missing value.
+func wellFormed(keyValues []interface{}) bool {
+	if len(keyValues)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(keyValues); i += 2 {
+		if _, ok := keyValues[i].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
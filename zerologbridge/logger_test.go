@@ -0,0 +1,80 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zerologbridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+func TestLogger(t *testing.T) {
+	defer func(fn func(uintptr, string, int) string) { zerolog.CallerMarshalFunc = fn }(zerolog.CallerMarshalFunc)
+	zerolog.CallerMarshalFunc = func(_ uintptr, file string, _ int) string {
+		return filepath.Base(file)
+	}
+
+	tests := []struct {
+		name     string
+		level    telemetry.Level
+		logfunc  func(telemetry.Logger)
+		expected string
+	}{
+		{"disabled", telemetry.LevelInfo, func(l telemetry.Logger) { l.Debug("text") }, ""},
+		{"trace", telemetry.LevelTrace, func(l telemetry.Logger) { l.Trace("text", "where", "there") },
+			`{"level":"trace","ctx":"value","lvl":"info","missing":"(MISSING)","where":"there","caller":"logger_test.go","message":"text"}` + "\n"},
+		{"debug", telemetry.LevelDebug, func(l telemetry.Logger) { l.Debug("text", 1, 1) },
+			`{"level":"debug","ctx":"value","lvl":"info","missing":"(MISSING)","1":1,"caller":"logger_test.go","message":"text"}` + "\n"},
+		{"info", telemetry.LevelInfo, func(l telemetry.Logger) { l.Info("text") },
+			`{"level":"info","ctx":"value","lvl":"info","missing":"(MISSING)","caller":"logger_test.go","message":"text"}` + "\n"},
+		{"warn", telemetry.LevelInfo, func(l telemetry.Logger) { l.Warn("text", "dangling") },
+			`{"level":"warn","ctx":"value","lvl":"info","missing":"(MISSING)","dangling":"(MISSING)","caller":"logger_test.go","message":"text"}` + "\n"},
+		{"error", telemetry.LevelInfo, func(l telemetry.Logger) { l.Error("text", errors.New("failed")) },
+			`{"level":"error","error":"failed","ctx":"value","lvl":"info","missing":"(MISSING)","caller":"logger_test.go","message":"text"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := NewLogger(zerolog.New(&out).Level(zerolog.TraceLevel))
+			logger.SetLevel(tt.level)
+
+			ctx := telemetry.KeyValuesToContext(context.Background(), "ctx", "value")
+			l := logger.Context(ctx).With("lvl", telemetry.LevelInfo).With("missing")
+
+			tt.logfunc(l)
+
+			if out.String() != tt.expected {
+				t.Fatalf("expected %q to match %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestZerologLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewLogger(zerolog.New(&out).Level(zerolog.WarnLevel))
+
+	logger.Info("text")
+	if out.Len() != 0 {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}